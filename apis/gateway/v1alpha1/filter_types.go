@@ -26,6 +26,20 @@ type PolicyFilterSpec struct {
 	// Policy rules in Pomerium Policy Language (PPL) syntax. May be expressed
 	// in either YAML or JSON format.
 	PPL string `json:"ppl,omitempty"`
+
+	// DownstreamMTLS sets the downstream mTLS requirements of the routes the filter is attached to.
+	// +optional
+	DownstreamMTLS *PolicyFilterDownstreamMTLS `json:"downstreamMtls,omitempty"`
+}
+
+// PolicyFilterDownstreamMTLS defines per-route downstream mTLS requirements.
+// Subject alt name matching and the max verify depth may only be set in the global settings.
+type PolicyFilterDownstreamMTLS struct {
+	// Enforcement overrides the global downstream mTLS enforcement mode for the routes.
+	// It may not be less strict than the global mode, and <code>reject_connection</code>
+	// may only be set globally.
+	// +kubebuilder:validation:Enum=policy_with_default_deny;policy;reject_connection
+	Enforcement string `json:"enforcement"`
 }
 
 // PolicyFilterStatus represents the state of a PolicyFilter.
@@ -14,7 +14,7 @@ func (in *PolicyFilter) DeepCopyInto(out *PolicyFilter) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -36,6 +36,21 @@ func (in *PolicyFilter) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyFilterDownstreamMTLS) DeepCopyInto(out *PolicyFilterDownstreamMTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyFilterDownstreamMTLS.
+func (in *PolicyFilterDownstreamMTLS) DeepCopy() *PolicyFilterDownstreamMTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyFilterDownstreamMTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PolicyFilterList) DeepCopyInto(out *PolicyFilterList) {
 	*out = *in
@@ -71,6 +86,11 @@ func (in *PolicyFilterList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PolicyFilterSpec) DeepCopyInto(out *PolicyFilterSpec) {
 	*out = *in
+	if in.DownstreamMTLS != nil {
+		in, out := &in.DownstreamMTLS, &out.DownstreamMTLS
+		*out = new(PolicyFilterDownstreamMTLS)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyFilterSpec.
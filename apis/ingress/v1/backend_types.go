@@ -0,0 +1,92 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackendKind is the kind an Ingress backend.resource should reference
+// in order to be handled by Pomerium rather than by a Service.
+const BackendKind = "Backend"
+
+// Backend is a Pomerium-handled Ingress backend. It may be referenced from an Ingress path
+// via <code>backend.resource</code> in place of a Service, and makes Pomerium respond directly,
+// redirect, or proxy to an upstream outside of the cluster.
+//
+// +kubebuilder:object:root=true
+type Backend struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines how matching requests are handled.
+	Spec BackendSpec `json:"spec,omitempty"`
+}
+
+// BackendSpec defines how requests routed to a Backend are handled.
+// Exactly one of <code>directResponse</code>, <code>redirect</code> or <code>externalURL</code> must be set.
+// +kubebuilder:validation:XValidation:rule="[has(self.directResponse), has(self.redirect), has(self.externalURL)].filter(x, x).size() == 1",message="exactly one of directResponse, redirect or externalURL must be set"
+type BackendSpec struct {
+	// DirectResponse makes Pomerium respond with a static response without contacting any upstream.
+	// +kubebuilder:validation:Optional
+	DirectResponse *BackendDirectResponse `json:"directResponse,omitempty"`
+	// Redirect makes Pomerium respond with an HTTP redirect.
+	// +kubebuilder:validation:Optional
+	Redirect *BackendRedirect `json:"redirect,omitempty"`
+	// ExternalURL makes Pomerium proxy requests to an upstream outside of the cluster,
+	// i.e. <code>https://example.com</code>.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Format=uri
+	// +kubebuilder:validation:Pattern=`^https?://`
+	ExternalURL *string `json:"externalURL,omitempty"`
+}
+
+// BackendDirectResponse is a static response.
+type BackendDirectResponse struct {
+	// Status is the HTTP status code of the response.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=200
+	// +kubebuilder:validation:Maximum=599
+	Status int32 `json:"status"`
+	// Body is the response body.
+	// +kubebuilder:validation:Optional
+	Body string `json:"body,omitempty"`
+}
+
+// BackendRedirect is an HTTP redirect. Components that are not set are preserved from the request.
+type BackendRedirect struct {
+	// Scheme replaces the request scheme.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=http;https
+	Scheme *string `json:"scheme,omitempty"`
+	// Host replaces the request host.
+	// +kubebuilder:validation:Optional
+	Host *string `json:"host,omitempty"`
+	// Port replaces the request port.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port *int32 `json:"port,omitempty"`
+	// Path replaces the request path.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^/`
+	Path *string `json:"path,omitempty"`
+	// StatusCode is the redirect HTTP status code, defaults to <code>301</code>.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=301;302;303;307;308
+	StatusCode *int32 `json:"statusCode,omitempty"`
+	// StripQuery removes the query string from the redirect location.
+	// +kubebuilder:validation:Optional
+	StripQuery bool `json:"stripQuery,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BackendList contains a list of Backends
+type BackendList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Backend `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Backend{}, &BackendList{})
+}
@@ -0,0 +1,51 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HostnamePolicy restricts which namespaces may publish Ingress and HTTPRoute hostnames
+// within a set of domains. Hostnames that are not covered by any policy may be used by any namespace.
+// When several policies cover a hostname, the ones with the most specific domain apply,
+// so that a subdomain may be delegated to a different set of namespaces.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+type HostnamePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines domains and namespaces that may use them.
+	Spec HostnamePolicySpec `json:"spec,omitempty"`
+}
+
+// HostnamePolicySpec maps domains to namespaces that may use them.
+// A namespace is allowed if it is either listed in <code>namespaces</code>
+// or matches the <code>namespaceSelector</code>.
+type HostnamePolicySpec struct {
+	// Domains this policy applies to. A domain covers itself and all of its subdomains,
+	// i.e. <code>example.com</code> covers <code>example.com</code> and <code>app.example.com</code>.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Pattern=`^(\*\.)?[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Domains []string `json:"domains"`
+	// Namespaces that may use the domains.
+	// +kubebuilder:validation:Optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	// NamespaceSelector selects namespaces that may use the domains.
+	// +kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// HostnamePolicyList contains a list of HostnamePolicies
+type HostnamePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []HostnamePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&HostnamePolicy{}, &HostnamePolicyList{})
+}
@@ -199,13 +199,45 @@ type MatchSubjectAltNames struct {
 }
 
 // DownstreamMTLS defines downstream MTLS configuration parameters.
+// +kubebuilder:validation:XValidation:rule="[has(self.ca), has(self.caSecret), has(self.caConfigMap)].filter(x, x).size() <= 1",message="at most one of ca, caSecret and caConfigMap may be set"
+// +kubebuilder:validation:XValidation:rule="[has(self.crl), has(self.crlSecret), has(self.crlConfigMap)].filter(x, x).size() <= 1",message="at most one of crl, crlSecret and crlConfigMap may be set"
 type DownstreamMTLS struct {
 	// CA is a bundle of PEM-encoded X.509 certificates that will be treated as trust anchors when verifying client certificates.
 	// +optional
 	CA []byte `json:"ca,omitempty"`
+	// CASecret should refer to a k8s secret with key <code>ca.crt</code> containing the CA bundle,
+	// and may be used instead of <code>ca</code> so that the CA may be rotated without editing the settings.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Format="namespace/name"
+	CASecret *string `json:"caSecret,omitempty"`
+	// CAConfigMap should refer to a k8s config map with key <code>ca.crt</code> containing the CA bundle.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Format="namespace/name"
+	CAConfigMap *string `json:"caConfigMap,omitempty"`
 	// CRL is a bundle of PEM-encoded certificate revocation lists to be consulted during certificate validation.
 	// +optional
 	CRL []byte `json:"crl,omitempty"`
+	// CRLSecret should refer to a k8s secret with key <code>ca.crl</code> containing the CRL bundle,
+	// and may be used instead of <code>crl</code> so that a new CRL may be published without editing the settings.
+	// If CRL refresh is enabled, the controller keeps it up to date from <code>crlUrls</code>.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Format="namespace/name"
+	CRLSecret *string `json:"crlSecret,omitempty"`
+	// CRLURLs are the HTTP(S) URLs of the CRLs the CA certificates issue,
+	// that the controller downloads into <code>crlSecret</code> if CRL refresh is enabled.
+	// Each CRL must be signed by one of the CA certificates.
+	// A CRL that is not newer than the stored CRL of its issuer, by CRL number or this update time, is rejected.
+	// +optional
+	// +kubebuilder:validation:items:Pattern=`^https?://`
+	CRLURLs []string `json:"crlUrls,omitempty"`
+	// CRLConfigMap should refer to a k8s config map with key <code>ca.crl</code> containing the CRL bundle.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Format="namespace/name"
+	CRLConfigMap *string `json:"crlConfigMap,omitempty"`
 	// Enforcement controls Pomerium's behavior when a client does not present a trusted client certificate.
 	// +optional
 	// +kubebuilder:validation:Optional
@@ -309,7 +341,13 @@ type PomeriumSpec struct {
 	// In a default Pomerium installation manifest, they would be generated via a
 	// <a href="https://github.com/pomerium/ingress-controller/blob/main/config/gen_secrets/job.yaml">one-time job</a>
 	// and stored in a <code>pomerium/bootstrap</code> Secret.
-	// You may re-run the job to rotate the secrets, or update the Secret values manually.
+	// To rotate the secrets, run <code>gen-secrets --rotate</code> repeatedly, or set the
+	// <code>secrets.pomerium.io/rotation</code> annotation of the Secret manually:
+	// new values put in the <code>shared_secret_next</code>, <code>cookie_secret_next</code> and
+	// <code>signing_key_next</code> keys are staged in the <code>publish</code> phase,
+	// where the new signing key is published alongside the current one,
+	// and used in the <code>retire</code> phase. As Pomerium only accepts a single shared and cookie secret,
+	// switching to them signs users out. The rotation phase is reported in <code>status.secretsRotation</code>.
 	// </p>
 	// <p>
 	// When defining the Secret in a manifest, put raw values in <code>stringData</code> so
@@ -378,6 +416,12 @@ type PomeriumSpec struct {
 	DNS *DNS `json:"dns,omitempty"`
 
 	// SSH sets the ssh settings.
+	// A key may be rotated without a hard cut-over by storing the new key in the
+	// <code>ssh-privatekey-next</code> key of the secret and setting the <code>ssh.pomerium.io/rotation</code>
+	// annotation of the secret to <code>publish</code>, which advertises the new host key alongside the old one
+	// while the user CA keeps signing with the old key, and then to <code>retire</code>, which serves
+	// only the new host key or signs with the new user CA key.
+	// The <code>gen-secrets</code> command generates the keys and advances the rotation.
 	SSH *SSH `json:"ssh,omitempty"`
 
 	// AllowUpgrades sets the allowed upgrade types.
@@ -596,6 +640,121 @@ type CertificateAutoProvision struct {
 	// +kubebuilder:validation:MinLength=1
 	// +kubebuilder:validation:Format="namespace/name"
 	Issuer *string `json:"issuer"`
+	// IssuerRules select the issuer for names under a domain suffix
+	// instead of the clusterIssuer or issuer, where the longest matching suffix wins.
+	// An Ingress or HTTPRoute may also select the issuer for its hostnames with the
+	// <code>certificate.pomerium.io/cluster-issuer</code> or <code>certificate.pomerium.io/issuer</code> annotation.
+	//
+	// +kubebuilder:validation:Optional
+	IssuerRules []CertificateIssuerRule `json:"issuerRules,omitempty"`
+	// Wildcard consolidates certificates for names under common parent domains
+	// into a single wildcard certificate per domain.
+	//
+	// +kubebuilder:validation:Optional
+	Wildcard *CertificateAutoProvisionWildcard `json:"wildcard,omitempty"`
+	// Batch packs multiple names into a single certificate,
+	// reducing the number of certificates and ACME orders.
+	//
+	// +kubebuilder:validation:Optional
+	Batch *CertificateAutoProvisionBatch `json:"batch,omitempty"`
+	// ACME provisions certificates directly from an ACME server instead of cert-manager,
+	// storing them in secrets in the same namespace as the controller pod.
+	// It replaces clusterIssuer or issuer, so may not be combined with them, while wildcard
+	// and batch apply the same way, and names selected by issuerRules or issuer annotations
+	// still get cert-manager certificates. Wildcard certificates require dns01Provider.
+	//
+	// +kubebuilder:validation:Optional
+	ACME *CertificateAutoProvisionACME `json:"acme,omitempty"`
+}
+
+// CertificateAutoProvisionACME are the settings for provisioning certificates
+// from an ACME server without cert-manager.
+type CertificateAutoProvisionACME struct {
+	// Directory is the ACME directory URL,
+	// i.e. <code>https://acme-v02.api.letsencrypt.org/directory</code>.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Format=uri
+	Directory string `json:"directory"`
+	// Email is the contact address registered with the ACME account.
+	//
+	// +kubebuilder:validation:Optional
+	Email *string `json:"email,omitempty"`
+	// DNS01Provider is the name of a DNS provider registered with the controller,
+	// that solves DNS-01 challenges, i.e. `webhook` when the controller runs with
+	// --certificate-acme-dns01-webhook-url. If not set, HTTP-01 challenges are solved
+	// through a Pomerium route to the controller.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	DNS01Provider *string `json:"dns01Provider,omitempty"`
+}
+
+// CertificateIssuerRule selects the cert-manager issuer for names under a domain suffix.
+type CertificateIssuerRule struct {
+	// Suffix is the domain suffix, i.e. corp matches both corp and a.b.corp.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Suffix string `json:"suffix"`
+	// The cert-manager ClusterIssuer that will be used for matching names.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	ClusterIssuer *string `json:"clusterIssuer"`
+	// The cert-manager Issuer that will be used for matching names.
+	// It must be in the same namespace as the other certificates.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Format="namespace/name"
+	Issuer *string `json:"issuer"`
+}
+
+// CertificateAutoProvisionWildcard are the settings for requesting wildcard certificates
+// instead of a certificate per name.
+type CertificateAutoProvisionWildcard struct {
+	// Domains whose subdomains share a wildcard certificate,
+	// i.e. for apps.example.com a single certificate for *.apps.example.com
+	// is requested. Names outside of these domains, including the domains themselves,
+	// get a certificate per name.
+	//
+	// +kubebuilder:validation:MinItems=1
+	Domains []string `json:"domains"`
+	// The cert-manager ClusterIssuer that will be used for wildcard certificates.
+	// Wildcard certificates may only be issued via DNS-01 challenges.
+	// Defaults to the issuer used for other certificates.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	ClusterIssuer *string `json:"clusterIssuer"`
+	// The cert-manager Issuer that will be used for wildcard certificates.
+	// It must be in the same namespace as the other certificates.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Format="namespace/name"
+	Issuer *string `json:"issuer"`
+}
+
+// CertificateAutoProvisionBatch are the settings for requesting certificates
+// with multiple names. As names come and go, certificates that lost names are updated
+// and filled up with new names first, while other certificates are not reissued.
+type CertificateAutoProvisionBatch struct {
+	// MaxNames is the maximum number of names in a single certificate.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=2
+	// +kubebuilder:validation:Maximum=100
+	MaxNames int32 `json:"maxNames"`
+	// GroupBy determines which names may share a certificate:
+	// <code>registered_domain</code> (default) only packs names of the same registered domain,
+	// i.e. a.example.com and b.c.example.com, <code>namespace</code> only packs names
+	// of Ingress and HTTPRoute objects in the same namespace, while <code>none</code> packs any names together.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=registered_domain;namespace;none
+	GroupBy *string `json:"groupBy,omitempty"`
 }
 
 // ResourceStatus represents the outcome of the latest attempt to reconcile
@@ -622,15 +781,102 @@ type CertificateAutoProvisionStatus struct {
 	DataBrokerLastUpdated metav1.Time `json:"dataBrokerLastUpdated,omitzero"`
 }
 
+// CertificateStatus describes the certificate covering a route hostname,
+// and any problem with the certificate served for it.
+type CertificateStatus struct {
+	// Hostname of the route.
+	Hostname string `json:"hostname"`
+	// Secret is the namespace/name of the secret holding the certificate, if known.
+	// +optional
+	Secret string `json:"secret,omitempty"`
+	// Record is the databroker record the certificate is loaded from,
+	// if the secret is not known.
+	// +optional
+	Record string `json:"record,omitempty"`
+	// Issuer of the certificate.
+	// +optional
+	Issuer string `json:"issuer,omitempty"`
+	// NotAfter is when the certificate expires.
+	// +optional
+	NotAfter *metav1.Time `json:"notAfter,omitempty"`
+	// Reason is set if there is a problem with the certificate, and is one of
+	// <code>missing</code>, if no certificate covers the hostname,
+	// <code>expired</code>, <code>expiring</code> or <code>mismatch</code>.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the problem.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// SSHKeyStatus describes an ssh host key or user CA key secret and its rotation.
+type SSHKeyStatus struct {
+	// Secret is the namespace/name of the secret holding the key.
+	Secret string `json:"secret"`
+	// Usage is either <code>hostKey</code> or <code>userCaKey</code>.
+	Usage string `json:"usage"`
+	// Fingerprint is the SHA256 fingerprint of the current key.
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// PublicKey is the current public key, in the authorized_keys format.
+	// +optional
+	PublicKey string `json:"publicKey,omitempty"`
+	// NextFingerprint is the SHA256 fingerprint of the key being rotated to.
+	// +optional
+	NextFingerprint string `json:"nextFingerprint,omitempty"`
+	// NextPublicKey is the public key being rotated to, in the authorized_keys format.
+	// +optional
+	NextPublicKey string `json:"nextPublicKey,omitempty"`
+	// Rotation is the rotation stage, <code>publish</code> or <code>retire</code>,
+	// if a rotation is in progress.
+	// +optional
+	Rotation string `json:"rotation,omitempty"`
+	// RotationStarted is when the new key was published.
+	// +optional
+	RotationStarted *metav1.Time `json:"rotationStarted,omitempty"`
+}
+
+// SecretsRotationStatus describes a rotation of the bootstrap secrets in progress.
+type SecretsRotationStatus struct {
+	// Secret is the namespace/name of the bootstrap secret.
+	Secret string `json:"secret"`
+	// Phase is either <code>publish</code>, while the new values are staged and the new signing key
+	// is published alongside the current one, or <code>retire</code>, once the new values are in use
+	// and the old ones may be removed.
+	Phase string `json:"phase"`
+	// Keys lists the secret keys being rotated.
+	// +optional
+	Keys []string `json:"keys,omitempty"`
+	// Started is when the new values were staged.
+	// +optional
+	Started *metav1.Time `json:"started,omitempty"`
+	// PhaseObservedAt is when the current phase was first applied.
+	PhaseObservedAt metav1.Time `json:"phaseObservedAt"`
+	// Message describes the phase and the next step.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // PomeriumStatus represents configuration and Ingress status.
 type PomeriumStatus struct {
 	// Status of certificate auto provisioning.
 	// +optional
 	CertificateAutoProvisionStatus *CertificateAutoProvisionStatus `json:"certificateAutoProvisionStatus,omitzero"`
+	// Certificates lists every route hostname along with the certificate covering it,
+	// and the hostnames whose served certificate is missing, has expired, expires soon
+	// or does not match the hostname.
+	// +optional
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
 	// Routes provide per-Ingress status.
 	Routes map[string]ResourceStatus `json:"ingress,omitempty"`
+	// SecretsRotation reports a rotation of the bootstrap secrets in progress.
+	// +optional
+	SecretsRotation *SecretsRotationStatus `json:"secretsRotation,omitempty"`
 	// SettingsStatus represent most recent main configuration reconciliation status.
 	SettingsStatus *ResourceStatus `json:"settingsStatus,omitempty"`
+	// SSHKeys lists the ssh host key and user CA key secrets along with their rotation stage.
+	// +optional
+	SSHKeys []SSHKeyStatus `json:"sshKeys,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -24,6 +24,149 @@ func (in *Authenticate) DeepCopy() *Authenticate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Backend) DeepCopyInto(out *Backend) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Backend.
+func (in *Backend) DeepCopy() *Backend {
+	if in == nil {
+		return nil
+	}
+	out := new(Backend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Backend) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendDirectResponse) DeepCopyInto(out *BackendDirectResponse) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendDirectResponse.
+func (in *BackendDirectResponse) DeepCopy() *BackendDirectResponse {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendDirectResponse)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendList) DeepCopyInto(out *BackendList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Backend, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendList.
+func (in *BackendList) DeepCopy() *BackendList {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BackendList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendRedirect) DeepCopyInto(out *BackendRedirect) {
+	*out = *in
+	if in.Scheme != nil {
+		in, out := &in.Scheme, &out.Scheme
+		*out = new(string)
+		**out = **in
+	}
+	if in.Host != nil {
+		in, out := &in.Host, &out.Host
+		*out = new(string)
+		**out = **in
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(string)
+		**out = **in
+	}
+	if in.StatusCode != nil {
+		in, out := &in.StatusCode, &out.StatusCode
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendRedirect.
+func (in *BackendRedirect) DeepCopy() *BackendRedirect {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendRedirect)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackendSpec) DeepCopyInto(out *BackendSpec) {
+	*out = *in
+	if in.DirectResponse != nil {
+		in, out := &in.DirectResponse, &out.DirectResponse
+		*out = new(BackendDirectResponse)
+		**out = **in
+	}
+	if in.Redirect != nil {
+		in, out := &in.Redirect, &out.Redirect
+		*out = new(BackendRedirect)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalURL != nil {
+		in, out := &in.ExternalURL, &out.ExternalURL
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackendSpec.
+func (in *BackendSpec) DeepCopy() *BackendSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackendSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateAutoProvision) DeepCopyInto(out *CertificateAutoProvision) {
 	*out = *in
@@ -37,6 +180,28 @@ func (in *CertificateAutoProvision) DeepCopyInto(out *CertificateAutoProvision)
 		*out = new(string)
 		**out = **in
 	}
+	if in.IssuerRules != nil {
+		in, out := &in.IssuerRules, &out.IssuerRules
+		*out = make([]CertificateIssuerRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Wildcard != nil {
+		in, out := &in.Wildcard, &out.Wildcard
+		*out = new(CertificateAutoProvisionWildcard)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Batch != nil {
+		in, out := &in.Batch, &out.Batch
+		*out = new(CertificateAutoProvisionBatch)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ACME != nil {
+		in, out := &in.ACME, &out.ACME
+		*out = new(CertificateAutoProvisionACME)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateAutoProvision.
@@ -49,6 +214,51 @@ func (in *CertificateAutoProvision) DeepCopy() *CertificateAutoProvision {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateAutoProvisionACME) DeepCopyInto(out *CertificateAutoProvisionACME) {
+	*out = *in
+	if in.Email != nil {
+		in, out := &in.Email, &out.Email
+		*out = new(string)
+		**out = **in
+	}
+	if in.DNS01Provider != nil {
+		in, out := &in.DNS01Provider, &out.DNS01Provider
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateAutoProvisionACME.
+func (in *CertificateAutoProvisionACME) DeepCopy() *CertificateAutoProvisionACME {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateAutoProvisionACME)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateAutoProvisionBatch) DeepCopyInto(out *CertificateAutoProvisionBatch) {
+	*out = *in
+	if in.GroupBy != nil {
+		in, out := &in.GroupBy, &out.GroupBy
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateAutoProvisionBatch.
+func (in *CertificateAutoProvisionBatch) DeepCopy() *CertificateAutoProvisionBatch {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateAutoProvisionBatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateAutoProvisionStatus) DeepCopyInto(out *CertificateAutoProvisionStatus) {
 	*out = *in
@@ -65,6 +275,80 @@ func (in *CertificateAutoProvisionStatus) DeepCopy() *CertificateAutoProvisionSt
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateAutoProvisionWildcard) DeepCopyInto(out *CertificateAutoProvisionWildcard) {
+	*out = *in
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterIssuer != nil {
+		in, out := &in.ClusterIssuer, &out.ClusterIssuer
+		*out = new(string)
+		**out = **in
+	}
+	if in.Issuer != nil {
+		in, out := &in.Issuer, &out.Issuer
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateAutoProvisionWildcard.
+func (in *CertificateAutoProvisionWildcard) DeepCopy() *CertificateAutoProvisionWildcard {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateAutoProvisionWildcard)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateIssuerRule) DeepCopyInto(out *CertificateIssuerRule) {
+	*out = *in
+	if in.ClusterIssuer != nil {
+		in, out := &in.ClusterIssuer, &out.ClusterIssuer
+		*out = new(string)
+		**out = **in
+	}
+	if in.Issuer != nil {
+		in, out := &in.Issuer, &out.Issuer
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateIssuerRule.
+func (in *CertificateIssuerRule) DeepCopy() *CertificateIssuerRule {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateIssuerRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateStatus) DeepCopyInto(out *CertificateStatus) {
+	*out = *in
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateStatus.
+func (in *CertificateStatus) DeepCopy() *CertificateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CircuitBreakerThresholds) DeepCopyInto(out *CircuitBreakerThresholds) {
 	*out = *in
@@ -223,11 +507,36 @@ func (in *DownstreamMTLS) DeepCopyInto(out *DownstreamMTLS) {
 		*out = make([]byte, len(*in))
 		copy(*out, *in)
 	}
+	if in.CASecret != nil {
+		in, out := &in.CASecret, &out.CASecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.CAConfigMap != nil {
+		in, out := &in.CAConfigMap, &out.CAConfigMap
+		*out = new(string)
+		**out = **in
+	}
 	if in.CRL != nil {
 		in, out := &in.CRL, &out.CRL
 		*out = make([]byte, len(*in))
 		copy(*out, *in)
 	}
+	if in.CRLSecret != nil {
+		in, out := &in.CRLSecret, &out.CRLSecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.CRLURLs != nil {
+		in, out := &in.CRLURLs, &out.CRLURLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CRLConfigMap != nil {
+		in, out := &in.CRLConfigMap, &out.CRLConfigMap
+		*out = new(string)
+		**out = **in
+	}
 	if in.Enforcement != nil {
 		in, out := &in.Enforcement, &out.Enforcement
 		*out = new(string)
@@ -270,6 +579,94 @@ func (in *FileStorage) DeepCopy() *FileStorage {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostnamePolicy) DeepCopyInto(out *HostnamePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostnamePolicy.
+func (in *HostnamePolicy) DeepCopy() *HostnamePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HostnamePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostnamePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostnamePolicyList) DeepCopyInto(out *HostnamePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]HostnamePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostnamePolicyList.
+func (in *HostnamePolicyList) DeepCopy() *HostnamePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(HostnamePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *HostnamePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostnamePolicySpec) DeepCopyInto(out *HostnamePolicySpec) {
+	*out = *in
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostnamePolicySpec.
+func (in *HostnamePolicySpec) DeepCopy() *HostnamePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HostnamePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IdentityProvider) DeepCopyInto(out *IdentityProvider) {
 	*out = *in
@@ -659,6 +1056,13 @@ func (in *PomeriumStatus) DeepCopyInto(out *PomeriumStatus) {
 		*out = new(CertificateAutoProvisionStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = make([]CertificateStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Routes != nil {
 		in, out := &in.Routes, &out.Routes
 		*out = make(map[string]ResourceStatus, len(*in))
@@ -666,11 +1070,23 @@ func (in *PomeriumStatus) DeepCopyInto(out *PomeriumStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.SecretsRotation != nil {
+		in, out := &in.SecretsRotation, &out.SecretsRotation
+		*out = new(SecretsRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SettingsStatus != nil {
 		in, out := &in.SettingsStatus, &out.SettingsStatus
 		*out = new(ResourceStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SSHKeys != nil {
+		in, out := &in.SSHKeys, &out.SSHKeys
+		*out = make([]SSHKeyStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PomeriumStatus.
@@ -780,6 +1196,50 @@ func (in *SSH) DeepCopy() *SSH {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHKeyStatus) DeepCopyInto(out *SSHKeyStatus) {
+	*out = *in
+	if in.RotationStarted != nil {
+		in, out := &in.RotationStarted, &out.RotationStarted
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHKeyStatus.
+func (in *SSHKeyStatus) DeepCopy() *SSHKeyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHKeyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretsRotationStatus) DeepCopyInto(out *SecretsRotationStatus) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Started != nil {
+		in, out := &in.Started, &out.Started
+		*out = (*in).DeepCopy()
+	}
+	in.PhaseObservedAt.DeepCopyInto(&out.PhaseObservedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretsRotationStatus.
+func (in *SecretsRotationStatus) DeepCopy() *SecretsRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretsRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Storage) DeepCopyInto(out *Storage) {
 	*out = *in
@@ -24,6 +24,8 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/pomerium/ingress-controller/controllers"
+	"github.com/pomerium/ingress-controller/controllers/certificate"
+	"github.com/pomerium/ingress-controller/controllers/expiry"
 	"github.com/pomerium/ingress-controller/controllers/gateway"
 	"github.com/pomerium/ingress-controller/controllers/ingress"
 	"github.com/pomerium/ingress-controller/controllers/settings"
@@ -70,10 +72,16 @@ type allCmdParam struct {
 	gatewayConfig           *gateway.ControllerConfig
 	updateStatusFromService string
 	dumpConfigDiff          bool
+	configShards            int
 	syncAPIURL              string
 	syncAPINamespaceID      string
 	syncAPIToken            string
 	syncAPIBootstrap        bool
+	syncAPIDriftScan        time.Duration
+	syncAPIDriftPrune       bool
+	syncAPIClusterID        string
+	syncAPIStrictOwnership  bool
+	syncAPIDryRun           bool
 
 	// bootstrapMetricsAddr for bootstrap configuration controller metrics
 	bootstrapMetricsAddr string
@@ -86,6 +94,9 @@ type allCmdParam struct {
 	configControllerShutdownTimeout time.Duration
 
 	certificateControllerName string
+	certificateOpts           []certificate.Option
+	certificateExpiry         *expiry.Config
+	crlRefreshInterval        time.Duration
 
 	cfg config.Config
 }
@@ -207,12 +218,21 @@ func (s *allCmdOptions) getParam(ctx context.Context) (*allCmdParam, error) {
 		gatewayConfig:                   gatewayConfig,
 		updateStatusFromService:         s.UpdateStatusFromService,
 		dumpConfigDiff:                  s.debugDumpConfigDiff,
+		configShards:                    s.ConfigShards,
 		configControllerShutdownTimeout: s.configControllerShutdownTimeout,
 		syncAPIURL:                      s.SyncAPIURL,
 		syncAPINamespaceID:              s.SyncAPINamespaceID,
 		syncAPIToken:                    s.SyncAPIToken,
 		syncAPIBootstrap:                s.syncAPIIngress != "",
+		syncAPIDriftScan:                s.SyncAPIDriftScan,
+		syncAPIDriftPrune:               s.SyncAPIDriftPrune,
+		syncAPIClusterID:                s.SyncAPIClusterID,
+		syncAPIStrictOwnership:          s.SyncAPIStrictOwnership,
+		syncAPIDryRun:                   s.SyncAPIDryRun,
 		certificateControllerName:       s.CertificateControllerOptions.Name,
+		certificateOpts:                 s.CertificateControllerOptions.getOptions(),
+		certificateExpiry:               s.getCertificateExpiryConfig(),
+		crlRefreshInterval:              s.CRLRefreshInterval,
 	}
 	if err := p.makeBootstrapConfig(ctx, *s); err != nil {
 		return nil, fmt.Errorf("bootstrap: %w", err)
@@ -430,12 +450,22 @@ func (s *allCmdParam) buildController(ctx context.Context, cfg *config.Config) (
 			}
 			dialAddressOverride = net.JoinHostPort("localhost", port)
 		}
-		reconciler, err = pomerium.NewAPIReconciler(s.syncAPIURL, s.syncAPINamespaceID, s.syncAPIToken, s.cfg.Options, dialAddressOverride)
+		var apiOpts []pomerium.APIReconcilerOption
+		if s.syncAPIStrictOwnership {
+			apiOpts = append(apiOpts, pomerium.WithStrictOwnership())
+		}
+		if s.syncAPIDryRun {
+			apiOpts = append(apiOpts, pomerium.WithDryRun())
+		}
+		if s.syncAPIClusterID != "" {
+			apiOpts = append(apiOpts, pomerium.WithClusterID(s.syncAPIClusterID))
+		}
+		reconciler, err = pomerium.NewAPIReconciler(s.syncAPIURL, s.syncAPINamespaceID, s.syncAPIToken, s.cfg.Options, dialAddressOverride, apiOpts...)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		reconciler = pomerium.NewDataBrokerReconciler(client, s.dumpConfigDiff)
+		reconciler = pomerium.NewDataBrokerReconciler(client, s.dumpConfigDiff, s.configShards)
 	}
 	c := &controllers.Controller{
 		Reconciler:              reconciler,
@@ -454,6 +484,13 @@ func (s *allCmdParam) buildController(ctx context.Context, cfg *config.Config) (
 		GlobalSettings:            &s.settings,
 		GatewayControllerConfig:   s.gatewayConfig,
 		CertificateControllerName: s.certificateControllerName,
+		CertificateCtrlOpts:       s.certificateOpts,
+		CertificateExpiry:         s.certificateExpiry,
+		CRLRefreshInterval:        s.crlRefreshInterval,
+	}
+	if s.syncAPIURL != "" && !s.syncAPIDryRun {
+		c.DriftScanInterval = s.syncAPIDriftScan
+		c.DriftPrune = s.syncAPIDriftPrune
 	}
 
 	return c, nil
@@ -7,10 +7,32 @@ import (
 )
 
 type certificateControllerOptions struct {
-	Name string
+	Name                string
+	ACMEHTTP01Addr      string
+	ACMEHTTP01URL       string
+	ACMEDNS01WebhookURL string
 }
 
 func (o *certificateControllerOptions) setupFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&o.Name, "certificate-controller-name", certificate.DefaultControllerName,
 		"the name of the certificate controller")
+	flags.StringVar(&o.ACMEHTTP01Addr, "certificate-acme-http01-addr", "127.0.0.1:28089",
+		"the address to serve ACME HTTP-01 challenges on, when certificates are provisioned from an ACME server")
+	flags.StringVar(&o.ACMEHTTP01URL, "certificate-acme-http01-url", "",
+		"the URL Pomerium routes ACME HTTP-01 challenges to, defaults to the challenge address")
+	flags.StringVar(&o.ACMEDNS01WebhookURL, "certificate-acme-dns01-webhook-url", "",
+		"the URL of a webhook that publishes ACME DNS-01 challenge TXT records, selected with dns01Provider: "+
+			certificate.DNSWebhookProviderName+" in the ACME settings")
+}
+
+func (o *certificateControllerOptions) getOptions() []certificate.Option {
+	var opts []certificate.Option
+	if o.ACMEHTTP01Addr != "" {
+		opts = append(opts, certificate.WithACMEHTTP01(o.ACMEHTTP01Addr, o.ACMEHTTP01URL))
+	}
+	if o.ACMEDNS01WebhookURL != "" {
+		opts = append(opts, certificate.WithDNSProvider(certificate.DNSWebhookProviderName,
+			certificate.NewDNSWebhookProvider(o.ACMEDNS01WebhookURL, nil)))
+	}
+	return opts
 }
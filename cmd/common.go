@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"time"
@@ -111,3 +112,27 @@ func runHealthz(ctx context.Context, addr string, readyChecks ...healthz.HealthC
 
 	return srv.ListenAndServe()
 }
+
+// runDebugServer serves the read-only debug handler to clients presenting the bearer token
+func runDebugServer(ctx context.Context, addr, token string, handler http.Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	srv := http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		}),
+		ReadHeaderTimeout: time.Millisecond * 100,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	return srv.ListenAndServe()
+}
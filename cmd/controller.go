@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/sync/errgroup"
@@ -19,7 +21,9 @@ import (
 	"github.com/pomerium/pomerium/pkg/grpcutil"
 
 	"github.com/pomerium/ingress-controller/controllers"
+	"github.com/pomerium/ingress-controller/controllers/certificate"
 	"github.com/pomerium/ingress-controller/pomerium"
+	"github.com/pomerium/ingress-controller/util"
 )
 
 type controllerCmd struct {
@@ -37,6 +41,20 @@ type controllerCmd struct {
 	leaderElectionID        string
 	leaderElectionNamespace string
 
+	configOutputFile      string
+	configOutputConfigMap string
+	configOutputSecret    string
+
+	syncTargets         []string
+	fanOutRetryInterval time.Duration
+	fanOutTimeout       time.Duration
+
+	debugConfigAddr  string
+	debugConfigToken string
+	debugConfigDiffs int
+	configDebugger   *pomerium.ConfigDebugger
+	coverageReport   *certificate.CoverageReport
+
 	sharedSecret string
 
 	debug bool
@@ -68,6 +86,15 @@ const (
 	tlsOverrideCertificateName = "databroker-tls-override-certificate-name"
 	leaderElectionID           = "leader-election-id"
 	leaderElectionNamespace    = "leader-election-namespace"
+	configOutputFile           = "config-output-file"
+	configOutputConfigMap      = "config-output-configmap"
+	configOutputSecret         = "config-output-secret"
+	syncTargets                = "sync-targets"
+	fanOutRetryInterval        = "fan-out-retry-interval"
+	fanOutTimeout              = "fan-out-timeout"
+	debugConfigAddr            = "debug-config-addr"
+	debugConfigToken           = "debug-config-token"
+	debugConfigDiffs           = "debug-config-diffs"
 )
 
 func (s *controllerCmd) setupFlags() error {
@@ -84,6 +111,25 @@ func (s *controllerCmd) setupFlags() error {
 		"override the certificate name used for the databroker connection")
 	flags.StringVar(&s.leaderElectionID, leaderElectionID, "pomerium-ingress-controller", "leader election lease name")
 	flags.StringVar(&s.leaderElectionNamespace, leaderElectionNamespace, "", "leader election lease namespace")
+	flags.StringVar(&s.configOutputFile, configOutputFile, "",
+		"write Pomerium configuration to this config.yaml file path instead of the databroker")
+	flags.StringVar(&s.configOutputConfigMap, configOutputConfigMap, "",
+		"write Pomerium configuration to the config.yaml key of this namespace/name ConfigMap instead of the databroker")
+	flags.StringVar(&s.configOutputSecret, configOutputSecret, "",
+		"write Pomerium configuration to the config.yaml key of this namespace/name Secret instead of the databroker")
+	flags.StringSliceVar(&s.syncTargets, syncTargets, nil,
+		fmt.Sprintf("Pomerium targets to sync configuration to, any of %s, %s and %s, "+
+			"i.e. to keep a disaster recovery deployment up to date; "+
+			"defaults to the unified API if %s is set, the config output if set, or the databroker otherwise",
+			syncTargetAPI, syncTargetDataBroker, syncTargetConfigOutput, syncAPIURL))
+	flags.DurationVar(&s.fanOutRetryInterval, fanOutRetryInterval, 30*time.Second,
+		"how often to retry a Pomerium target that failed to apply configuration, when syncing to several targets")
+	flags.DurationVar(&s.fanOutTimeout, fanOutTimeout, time.Minute,
+		"how long a Pomerium target may take to apply a change before it is retried in the background, when syncing to several targets")
+	flags.StringVar(&s.debugConfigAddr, debugConfigAddr, "",
+		"serve the applied databroker, sync API or config file configuration, route sources, recent changes and certificate coverage on this address, secrets redacted")
+	flags.StringVar(&s.debugConfigToken, debugConfigToken, "", "bearer token required by the debug config listener")
+	flags.IntVar(&s.debugConfigDiffs, debugConfigDiffs, 20, "number of recent configuration changes kept by the debug config listener")
 
 	flags.StringVar(&s.sharedSecret, sharedSecret, "",
 		"base64-encoded shared secret for signing JWTs")
@@ -110,6 +156,14 @@ func (s *controllerCmd) exec(*cobra.Command, []string) error {
 		return runHealthz(ctx, s.probeAddr, healthz.NamedCheck("acquire-lease", c.ReadyzCheck))
 	})
 	eg.Go(func() error { return c.Run(ctx) })
+	if s.configDebugger != nil {
+		mux := http.NewServeMux()
+		mux.Handle("/certificates", s.coverageReport)
+		mux.Handle("/", s.configDebugger)
+		eg.Go(func() error {
+			return runDebugServer(ctx, s.debugConfigAddr, s.debugConfigToken, mux)
+		})
+	}
 
 	return eg.Wait()
 }
@@ -165,28 +219,166 @@ func (s *controllerCmd) buildController(ctx context.Context) (*controllers.Contr
 		IngressCtrlOpts:         opts,
 		GatewayControllerConfig: gatewayConfig,
 		GlobalSettings:          globalSettings,
+		CertificateExpiry:       s.getCertificateExpiryConfig(),
+		CRLRefreshInterval:      s.CRLRefreshInterval,
 	}
 
-	if s.SyncAPIURL != "" {
-		c.Reconciler, err = pomerium.NewAPIReconciler(
-			s.SyncAPIURL, s.SyncAPINamespaceID, s.SyncAPIToken, pomerium_config.NewDefaultOptions(), "")
-		if err != nil {
-			return nil, err
+	configWriter, err := s.getConfigWriter()
+	if err != nil {
+		return nil, err
+	}
+
+	var dbOpts []pomerium.DataBrokerReconcilerOption
+	if s.debugConfigAddr != "" {
+		if s.debugConfigToken == "" {
+			return nil, fmt.Errorf("%s is required with %s", debugConfigToken, debugConfigAddr)
 		}
+		s.configDebugger = pomerium.NewConfigDebugger(s.debugConfigDiffs)
+		dbOpts = append(dbOpts, pomerium.WithConfigDebugger(s.configDebugger))
+		s.coverageReport = certificate.NewCoverageReport()
+		c.CertificateCtrlOpts = append(c.CertificateCtrlOpts, certificate.WithCoverageReport(s.coverageReport))
+	}
+
+	targets, err := s.getSyncTargets(configWriter != nil)
+	if err != nil {
+		return nil, err
+	}
+	var fanOut []pomerium.FanOutTarget
+	for _, target := range targets {
+		var r pomerium.Reconciler
+		switch target {
+		case syncTargetAPI:
+			r, err = s.newAPIReconciler()
+			if err != nil {
+				return nil, err
+			}
+			c.MgrOpts.LeaderElection = true
+			if !s.SyncAPIDryRun {
+				c.DriftScanInterval = s.SyncAPIDriftScan
+			}
+			c.DriftPrune = s.SyncAPIDriftPrune
+		case syncTargetConfigOutput:
+			var fileOpts []pomerium.FileReconcilerOption
+			if s.configDebugger != nil {
+				fileOpts = append(fileOpts, pomerium.WithFileConfigDebugger(s.configDebugger))
+			}
+			r = pomerium.NewFileReconciler(configWriter, pomerium_config.NewDefaultOptions(), fileOpts...)
+			c.MgrOpts.LeaderElection = true
+		case syncTargetDataBroker:
+			conn, err := s.getDataBrokerConnection(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("databroker connection: %w", err)
+			}
+			c.DataBrokerServiceClient = databroker.NewDataBrokerServiceClient(conn)
+			r = pomerium.NewDataBrokerReconciler(c.DataBrokerServiceClient, s.debug, s.ConfigShards, dbOpts...)
+		}
+		fanOut = append(fanOut, pomerium.FanOutTarget{Name: target, Timeout: s.fanOutTimeout, Reconciler: r})
+	}
+
+	if len(fanOut) == 1 {
+		c.Reconciler = fanOut[0].Reconciler
+	} else {
+		// retrying failed targets in the background requires a single instance
+		c.Reconciler = pomerium.NewFanOutReconciler(s.fanOutRetryInterval, fanOut...)
 		c.MgrOpts.LeaderElection = true
+	}
+
+	if c.MgrOpts.LeaderElection {
 		c.MgrOpts.LeaderElectionID = s.leaderElectionID
 		c.MgrOpts.LeaderElectionNamespace = s.leaderElectionNamespace
-		return c, nil
 	} else if f := s.Flags(); f.Changed(leaderElectionID) || f.Changed(leaderElectionNamespace) {
-		return nil, fmt.Errorf("kubernetes leader election can be used only with sync API")
+		return nil, fmt.Errorf("kubernetes leader election can be used only with sync API, config output or several sync targets")
 	}
+	return c, nil
+}
 
-	conn, err := s.getDataBrokerConnection(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("databroker connection: %w", err)
+const (
+	syncTargetAPI          = "api"
+	syncTargetDataBroker   = "databroker"
+	syncTargetConfigOutput = "config-output"
+)
+
+// getSyncTargets returns the validated Pomerium targets to sync configuration to
+func (s *controllerCmd) getSyncTargets(configOutput bool) ([]string, error) {
+	if len(s.syncTargets) == 0 {
+		switch {
+		case s.SyncAPIURL != "" && configOutput:
+			return nil, fmt.Errorf("%s cannot be used with config output, unless both are listed in %s", syncAPIURL, syncTargets)
+		case s.SyncAPIURL != "":
+			return []string{syncTargetAPI}, nil
+		case configOutput:
+			return []string{syncTargetConfigOutput}, nil
+		default:
+			return []string{syncTargetDataBroker}, nil
+		}
 	}
 
-	c.DataBrokerServiceClient = databroker.NewDataBrokerServiceClient(conn)
-	c.Reconciler = pomerium.NewDataBrokerReconciler(c.DataBrokerServiceClient, s.debug)
-	return c, nil
+	seen := make(map[string]bool, len(s.syncTargets))
+	for _, target := range s.syncTargets {
+		switch {
+		case seen[target]:
+			return nil, fmt.Errorf("%s: %s is listed more than once", syncTargets, target)
+		case target == syncTargetAPI && s.SyncAPIURL == "":
+			return nil, fmt.Errorf("%s: %s requires %s", syncTargets, target, syncAPIURL)
+		case target == syncTargetConfigOutput && !configOutput:
+			return nil, fmt.Errorf("%s: %s requires one of %s, %s or %s",
+				syncTargets, target, configOutputFile, configOutputConfigMap, configOutputSecret)
+		case target != syncTargetAPI && target != syncTargetConfigOutput && target != syncTargetDataBroker:
+			return nil, fmt.Errorf("%s: unknown target %s, expected any of %s, %s and %s",
+				syncTargets, target, syncTargetAPI, syncTargetDataBroker, syncTargetConfigOutput)
+		}
+		seen[target] = true
+	}
+	return s.syncTargets, nil
+}
+
+func (s *controllerCmd) newAPIReconciler() (pomerium.Reconciler, error) {
+	var apiOpts []pomerium.APIReconcilerOption
+	if s.SyncAPIStrictOwnership {
+		apiOpts = append(apiOpts, pomerium.WithStrictOwnership())
+	}
+	if s.SyncAPIDryRun {
+		apiOpts = append(apiOpts, pomerium.WithDryRun())
+	}
+	if s.SyncAPIClusterID != "" {
+		apiOpts = append(apiOpts, pomerium.WithClusterID(s.SyncAPIClusterID))
+	}
+	if s.configDebugger != nil {
+		apiOpts = append(apiOpts, pomerium.WithAPIConfigDebugger(s.configDebugger))
+	}
+	return pomerium.NewAPIReconciler(
+		s.SyncAPIURL, s.SyncAPINamespaceID, s.SyncAPIToken, pomerium_config.NewDefaultOptions(), "", apiOpts...)
+}
+
+// getConfigWriter returns the writer for file-based Pomerium configuration, or nil if the databroker should be used
+func (s *controllerCmd) getConfigWriter() (pomerium.ConfigWriter, error) {
+	var writers []pomerium.ConfigWriter
+	if s.configOutputFile != "" {
+		writers = append(writers, pomerium.NewFileConfigWriter(s.configOutputFile))
+	}
+	for flag, val := range map[string]string{
+		configOutputConfigMap: s.configOutputConfigMap,
+		configOutputSecret:    s.configOutputSecret,
+	} {
+		if val == "" {
+			continue
+		}
+		name, err := util.ParseNamespacedName(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%s: %w", flag, val, err)
+		}
+		if flag == configOutputSecret {
+			writers = append(writers, pomerium.NewSecretWriter(*name))
+		} else {
+			writers = append(writers, pomerium.NewConfigMapWriter(*name))
+		}
+	}
+
+	switch {
+	case len(writers) == 0:
+		return nil, nil
+	case len(writers) > 1:
+		return nil, fmt.Errorf("only one of %s, %s and %s may be set", configOutputFile, configOutputConfigMap, configOutputSecret)
+	}
+	return writers[0], nil
 }
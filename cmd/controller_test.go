@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -24,14 +25,50 @@ func TestFlags(t *testing.T) {
 		tlsInsecureSkipVerify:      "true",
 		tlsOverrideCertificateName: "override",
 		namespaces:                 "one,two,three",
+		namespaceSelector:          "tenant in (a,b)",
 		sharedSecret:               "secret",
 		debug:                      "true",
 		updateStatusFromService:    "some/service",
+		batchWindow:                "250ms",
 	} {
 		os.Setenv(envName(k), v)
 	}
 	cmd.setupFlags()
 	assert.Equal(t, []string{"one", "two", "three"}, cmd.Namespaces)
+	assert.Equal(t, "tenant in (a,b)", cmd.NamespaceSelector)
+	selector, err := cmd.getNamespaceSelector()
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant in (a,b)", selector.String())
+	assert.Equal(t, 250*time.Millisecond, cmd.BatchWindow)
+	assert.Equal(t, 100, cmd.BatchSize)
+	assert.Equal(t, 4, cmd.BatchConcurrency)
 	assert.Equal(t, caData, cmd.tlsCA)
 	assert.Equal(t, true, cmd.debug)
 }
+
+func TestSyncTargets(t *testing.T) {
+	cmd := new(controllerCmd)
+
+	targets, err := cmd.getSyncTargets(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{syncTargetDataBroker}, targets)
+
+	cmd.SyncAPIURL = "https://api.example.com"
+	_, err = cmd.getSyncTargets(true)
+	assert.Error(t, err, "sync API and config output should be listed explicitly")
+
+	cmd.syncTargets = []string{syncTargetAPI, syncTargetDataBroker, syncTargetConfigOutput}
+	targets, err = cmd.getSyncTargets(true)
+	assert.NoError(t, err)
+	assert.Equal(t, cmd.syncTargets, targets)
+
+	for _, invalid := range [][]string{
+		{syncTargetAPI, syncTargetAPI},
+		{syncTargetDataBroker, "zero"},
+		{syncTargetConfigOutput},
+	} {
+		cmd.syncTargets = invalid
+		_, err = cmd.getSyncTargets(false)
+		assert.Error(t, err, invalid)
+	}
+}
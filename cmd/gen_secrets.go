@@ -1,22 +1,35 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	runtime_ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/pomerium/ingress-controller/model"
 	"github.com/pomerium/ingress-controller/util"
 )
 
 type genSecretsCmd struct {
-	secrets string
-	debug   bool
+	secrets             string
+	sshHostKeys         []string
+	sshUserCAKey        string
+	rotate              bool
+	rotationGracePeriod time.Duration
+	debug               bool
+
+	// deprecated, only rotate the ssh key secrets
+	sshRotate              bool
+	sshRotationGracePeriod time.Duration
 
 	cobra.Command
 }
@@ -42,6 +55,22 @@ func (s *genSecretsCmd) setupFlags() error {
 		return err
 	}
 	flags.StringVar(&s.secrets, "secrets", "", "namespaced name of a Secret object to generate")
+	flags.StringSliceVar(&s.sshHostKeys, "ssh-host-keys", nil, "namespaced names of ssh host key Secret objects to generate")
+	flags.StringVar(&s.sshUserCAKey, "ssh-user-ca-key", "", "namespaced name of an ssh user CA key Secret object to generate")
+	flags.BoolVar(&s.rotate, "rotate", false,
+		"advance the rotation of existing secrets by one stage: stage and publish new values, switch to them, then remove the current values")
+	flags.DurationVar(&s.rotationGracePeriod, "rotation-grace-period", 24*time.Hour,
+		"minimum time each rotation stage lasts: new values are published before switching to them, and switched to before the current values are removed")
+	flags.BoolVar(&s.sshRotate, "ssh-rotate", false,
+		"advance the rotation of existing ssh key secrets by one stage: publish a new key, retire the current key, then replace it")
+	flags.DurationVar(&s.sshRotationGracePeriod, "ssh-rotation-grace-period", 24*time.Hour,
+		"minimum time a new ssh key is published before the current key may be retired")
+	if err := flags.MarkDeprecated("ssh-rotate", "use --rotate, that also rotates the --secrets bootstrap secret"); err != nil {
+		return err
+	}
+	if err := flags.MarkDeprecated("ssh-rotation-grace-period", "use --rotation-grace-period"); err != nil {
+		return err
+	}
 
 	v := viper.New()
 	var err error
@@ -64,9 +93,8 @@ func (s *genSecretsCmd) exec(*cobra.Command, []string) error {
 	setupLogger(s.debug)
 	ctx := runtime_ctrl.SetupSignalHandler()
 
-	name, err := util.ParseNamespacedName(s.secrets)
-	if err != nil {
-		return fmt.Errorf("%s=%s: %w", globalSettings, s.secrets, err)
+	if s.secrets == "" && len(s.sshHostKeys) == 0 && s.sshUserCAKey == "" {
+		return fmt.Errorf("at least one of --secrets, --ssh-host-keys or --ssh-user-ca-key is required")
 	}
 
 	cfg, err := runtime_ctrl.GetConfig()
@@ -84,24 +112,188 @@ func (s *genSecretsCmd) exec(*cobra.Command, []string) error {
 		return fmt.Errorf("client: %w", err)
 	}
 
-	// Check if secret already exists
+	secretsRotation := rotationOptions{rotate: s.rotate, gracePeriod: s.rotationGracePeriod}
+	sshRotation := rotationOptions{rotate: s.rotate || s.sshRotate, gracePeriod: s.rotationGracePeriod}
+	if s.PersistentFlags().Changed("ssh-rotation-grace-period") {
+		sshRotation.gracePeriod = s.sshRotationGracePeriod
+	}
+
+	if s.secrets != "" {
+		if err := genSecret(ctx, c, s.secrets, util.NewBootstrapSecrets, bootstrapSecretsRotation, secretsRotation); err != nil {
+			return fmt.Errorf("%s=%s: %w", globalSettings, s.secrets, err)
+		}
+	}
+	for _, ref := range s.sshHostKeys {
+		if err := genSecret(ctx, c, ref, util.NewSSHKeySecret, sshKeyRotation, sshRotation); err != nil {
+			return fmt.Errorf("ssh host key %s: %w", ref, err)
+		}
+	}
+	if s.sshUserCAKey != "" {
+		if err := genSecret(ctx, c, s.sshUserCAKey, util.NewSSHKeySecret, sshKeyRotation, sshRotation); err != nil {
+			return fmt.Errorf("ssh user ca key %s: %w", s.sshUserCAKey, err)
+		}
+	}
+	return nil
+}
+
+// rotationOptions controls whether and how fast existing secrets are rotated
+type rotationOptions struct {
+	rotate      bool
+	gracePeriod time.Duration
+}
+
+// genSecret creates a secret unless it exists, in which case it advances its rotation if requested
+func genSecret(
+	ctx context.Context,
+	c client.Client,
+	ref string,
+	create func(types.NamespacedName) (*corev1.Secret, error),
+	rotation keyRotation,
+	opts rotationOptions,
+) error {
+	name, err := util.ParseNamespacedName(ref)
+	if err != nil {
+		return err
+	}
+
 	existing := &corev1.Secret{}
 	err = c.Get(ctx, *name, existing)
-	if err == nil {
-		// Secret already exists, exit gracefully
+	if apierrors.IsNotFound(err) {
+		secret, err := create(*name)
+		if err != nil {
+			return fmt.Errorf("generate secrets: %w", err)
+		}
+		if err := c.Create(ctx, secret); err != nil {
+			return fmt.Errorf("create secret: %w", err)
+		}
 		return nil
-	}
-	if !apierrors.IsNotFound(err) {
+	} else if err != nil {
 		return fmt.Errorf("check existing secret: %w", err)
 	}
+	if !opts.rotate {
+		return nil
+	}
 
-	secret, err := util.NewBootstrapSecrets(*name)
+	orig := existing.DeepCopy()
+	err = rotation.advance(existing, time.Now(), opts.gracePeriod)
+	if err != nil && !errors.Is(err, errGracePeriod) {
+		return fmt.Errorf("rotate: %w", err)
+	}
+	// a rotation waiting for the grace period may have its started timestamp re-stamped
+	if err := c.Patch(ctx, existing, client.MergeFrom(orig)); err != nil {
+		return fmt.Errorf("update secret: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("rotate: %w", err)
+	}
+	return nil
+}
+
+// errGracePeriod is returned when a rotation stage may not be advanced yet
+var errGracePeriod = errors.New("the grace period has not elapsed")
+
+// keyRotation describes how the keys of a secret are rotated
+type keyRotation struct {
+	// keys are the secret keys that are rotated
+	keys []string
+	// nextKey returns the secret key holding the value key is being rotated to
+	nextKey func(key string) string
+	// generate returns the new values by key
+	generate func() (map[string][]byte, error)
+	// stage returns the rotation stage of a secret
+	stage             func(*corev1.Secret) (string, error)
+	stageAnnotation   string
+	startedAnnotation string
+}
+
+var sshKeyRotation = keyRotation{
+	keys:    []string{model.SSHPrivateKey},
+	nextKey: func(string) string { return model.SSHNextPrivateKey },
+	generate: func() (map[string][]byte, error) {
+		key, err := util.NewSSHPrivateKey()
+		return map[string][]byte{model.SSHPrivateKey: key}, err
+	},
+	stage:             model.SSHKeyRotation,
+	stageAnnotation:   model.SSHKeyRotationAnnotation,
+	startedAnnotation: model.SSHKeyRotationStartedAnnotation,
+}
+
+var bootstrapSecretsRotation = keyRotation{
+	keys:     model.BootstrapSecretKeys,
+	nextKey:  model.NextBootstrapSecretKey,
+	generate: util.NewBootstrapSecretsData,
+	stage: func(secret *corev1.Secret) (string, error) {
+		stage, _, err := model.BootstrapSecretsRotation(secret)
+		return stage, err
+	},
+	stageAnnotation:   model.BootstrapSecretsRotationAnnotation,
+	startedAnnotation: model.BootstrapSecretsRotationStartedAnnotation,
+}
+
+// advance advances the rotation of a secret by one stage:
+// it generates and publishes the next values, switches to them once they have been published
+// for the grace period, and finally replaces the current values with the next ones
+// once they have been switched to for the grace period.
+func (r keyRotation) advance(secret *corev1.Secret, now time.Time, gracePeriod time.Duration) error {
+	stage, err := r.stage(secret)
 	if err != nil {
-		return fmt.Errorf("generate secrets: %w", err)
+		return err
 	}
 
-	if err := c.Create(ctx, secret); err != nil {
-		return fmt.Errorf("create secret: %w", err)
+	switch stage {
+	case "":
+		values, err := r.generate()
+		if err != nil {
+			return err
+		}
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		for _, key := range r.keys {
+			secret.Data[r.nextKey(key)] = values[key]
+		}
+		secret.Annotations[r.stageAnnotation] = model.RotationPublish
+		secret.Annotations[r.startedAnnotation] = now.UTC().Format(time.RFC3339)
+	case model.RotationPublish:
+		if err := r.waitGracePeriod(secret, now, gracePeriod); err != nil {
+			return err
+		}
+		secret.Annotations[r.stageAnnotation] = model.RotationRetire
+		secret.Annotations[r.startedAnnotation] = now.UTC().Format(time.RFC3339)
+	case model.RotationRetire:
+		if err := r.waitGracePeriod(secret, now, gracePeriod); err != nil {
+			return err
+		}
+		for _, key := range r.keys {
+			if next, ok := secret.Data[r.nextKey(key)]; ok {
+				secret.Data[key] = next
+				delete(secret.Data, r.nextKey(key))
+			}
+		}
+		delete(secret.Annotations, r.stageAnnotation)
+		delete(secret.Annotations, r.startedAnnotation)
+	}
+	return nil
+}
+
+// waitGracePeriod returns errGracePeriod unless the current stage started at least the grace period ago.
+// A missing or invalid started timestamp is not eligible either:
+// it is re-stamped with now, so that the grace period starts over.
+func (r keyRotation) waitGracePeriod(secret *corev1.Secret, now time.Time, gracePeriod time.Duration) error {
+	started, err := time.Parse(time.RFC3339, secret.Annotations[r.startedAnnotation])
+	if err != nil {
+		secret.Annotations[r.startedAnnotation] = now.UTC().Format(time.RFC3339)
+		return fmt.Errorf("%w: the %s stage has no valid start time, it was set to %s and may only be advanced after %s",
+			errGracePeriod, secret.Annotations[r.stageAnnotation],
+			now.UTC().Format(time.RFC3339), now.Add(gracePeriod).UTC().Format(time.RFC3339))
+	}
+	if now.Before(started.Add(gracePeriod)) {
+		return fmt.Errorf("%w: the %s stage started at %s and may only be advanced after %s",
+			errGracePeriod, secret.Annotations[r.stageAnnotation],
+			started.Format(time.RFC3339), started.Add(gracePeriod).Format(time.RFC3339))
 	}
 	return nil
 }
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+func TestSSHKeyRotation(t *testing.T) {
+	t.Parallel()
+
+	secret, err := util.NewSSHKeySecret(types.NamespacedName{Namespace: "pomerium", Name: "ssh-host-key"})
+	require.NoError(t, err)
+	current := secret.Data[model.SSHPrivateKey]
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	grace := time.Hour
+
+	require.NoError(t, sshKeyRotation.advance(secret, now, grace))
+	assert.Equal(t, model.RotationPublish, secret.Annotations[model.SSHKeyRotationAnnotation])
+	assert.Equal(t, "2026-01-01T00:00:00Z", secret.Annotations[model.SSHKeyRotationStartedAnnotation])
+	assert.Equal(t, current, secret.Data[model.SSHPrivateKey])
+	next := secret.Data[model.SSHNextPrivateKey]
+	assert.NotEmpty(t, next)
+	assert.NotEqual(t, current, next)
+
+	assert.ErrorIs(t, sshKeyRotation.advance(secret, now.Add(time.Minute), grace), errGracePeriod,
+		"the current key should not be retired before the grace period")
+	assert.Equal(t, model.RotationPublish, secret.Annotations[model.SSHKeyRotationAnnotation])
+
+	require.NoError(t, sshKeyRotation.advance(secret, now.Add(grace), grace))
+	assert.Equal(t, model.RotationRetire, secret.Annotations[model.SSHKeyRotationAnnotation])
+	assert.Equal(t, "2026-01-01T01:00:00Z", secret.Annotations[model.SSHKeyRotationStartedAnnotation])
+	assert.Equal(t, current, secret.Data[model.SSHPrivateKey])
+
+	assert.ErrorIs(t, sshKeyRotation.advance(secret, now.Add(grace+time.Minute), grace), errGracePeriod,
+		"the current key should not be replaced before the grace period")
+	assert.Equal(t, model.RotationRetire, secret.Annotations[model.SSHKeyRotationAnnotation])
+
+	require.NoError(t, sshKeyRotation.advance(secret, now.Add(2*grace), grace))
+	assert.Equal(t, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "pomerium", Name: "ssh-host-key", Annotations: map[string]string{}},
+		Data:       map[string][]byte{model.SSHPrivateKey: next},
+		Type:       corev1.SecretTypeSSHAuth,
+	}, secret)
+
+	secret.Annotations[model.SSHKeyRotationAnnotation] = "unknown"
+	assert.Error(t, sshKeyRotation.advance(secret, now, grace))
+}
+
+func TestRotationInvalidStartedTimestamp(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	grace := time.Hour
+
+	for _, stage := range []string{model.RotationPublish, model.RotationRetire} {
+		for name, annotations := range map[string]map[string]string{
+			"missing":     {model.SSHKeyRotationAnnotation: stage},
+			"unparseable": {model.SSHKeyRotationAnnotation: stage, model.SSHKeyRotationStartedAnnotation: "yesterday"},
+		} {
+			t.Run(stage+"/"+name, func(t *testing.T) {
+				t.Parallel()
+
+				secret, err := util.NewSSHKeySecret(types.NamespacedName{Namespace: "pomerium", Name: "ssh-host-key"})
+				require.NoError(t, err)
+				secret.Data[model.SSHNextPrivateKey] = []byte("next")
+				secret.Annotations = maps.Clone(annotations)
+				data := maps.Clone(secret.Data)
+
+				assert.ErrorIs(t, sshKeyRotation.advance(secret, now, grace), errGracePeriod)
+				assert.Equal(t, stage, secret.Annotations[model.SSHKeyRotationAnnotation])
+				assert.Equal(t, "2026-01-01T00:00:00Z", secret.Annotations[model.SSHKeyRotationStartedAnnotation],
+					"the started timestamp should be re-stamped")
+				assert.Equal(t, data, secret.Data)
+
+				assert.ErrorIs(t, sshKeyRotation.advance(secret, now.Add(time.Minute), grace), errGracePeriod)
+				require.NoError(t, sshKeyRotation.advance(secret, now.Add(grace), grace))
+				assert.NotEqual(t, stage, secret.Annotations[model.SSHKeyRotationAnnotation])
+			})
+		}
+	}
+}
+
+func TestBootstrapSecretsRotation(t *testing.T) {
+	t.Parallel()
+
+	secret, err := util.NewBootstrapSecrets(types.NamespacedName{Namespace: "pomerium", Name: "bootstrap"})
+	require.NoError(t, err)
+	current := maps.Clone(secret.Data)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, bootstrapSecretsRotation.advance(secret, now, 0))
+	stage, keys, err := model.BootstrapSecretsRotation(secret)
+	require.NoError(t, err)
+	assert.Equal(t, model.RotationPublish, stage)
+	assert.Equal(t, model.BootstrapSecretKeys, keys)
+	next := make(map[string][]byte)
+	for _, key := range model.BootstrapSecretKeys {
+		assert.Equal(t, current[key], secret.Data[key], key)
+		next[key] = secret.Data[model.NextBootstrapSecretKey(key)]
+		assert.NotEqual(t, current[key], next[key], key)
+	}
+
+	require.NoError(t, bootstrapSecretsRotation.advance(secret, now, 0))
+	assert.Equal(t, model.RotationRetire, secret.Annotations[model.BootstrapSecretsRotationAnnotation])
+
+	require.NoError(t, bootstrapSecretsRotation.advance(secret, now, 0))
+	assert.Equal(t, next, secret.Data)
+	assert.Empty(t, secret.Annotations)
+}
@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	validate "github.com/go-playground/validator/v10"
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 
 	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/controllers/expiry"
 	"github.com/pomerium/ingress-controller/controllers/gateway"
 	"github.com/pomerium/ingress-controller/controllers/ingress"
 	"github.com/pomerium/ingress-controller/util"
@@ -19,11 +22,23 @@ type ingressControllerOpts struct {
 	GatewayClassName        string `validate:"required"`
 	AnnotationPrefix        string `validate:"required"`
 	Namespaces              []string
+	NamespaceSelector       string
 	UpdateStatusFromService string ``
 	GlobalSettings          string `validate:"required"`
 	SyncAPIURL              string
 	SyncAPINamespaceID      string
 	SyncAPIToken            string
+	SyncAPIDriftScan        time.Duration
+	SyncAPIDriftPrune       bool
+	SyncAPIClusterID        string `validate:"required_if=SyncAPIDriftPrune true,excludes=/"`
+	SyncAPIStrictOwnership  bool
+	SyncAPIDryRun           bool
+	ConfigShards            int `validate:"gte=-1"`
+	BatchWindow             time.Duration
+	BatchSize               int `validate:"gte=1"`
+	BatchConcurrency        int `validate:"gte=1,lte=64"`
+	CertificateExpiryWarn   time.Duration
+	CRLRefreshInterval      time.Duration
 }
 
 const (
@@ -32,6 +47,7 @@ const (
 	gatewayClassControllerName = "gateway-class-controller-name"
 	annotationPrefix           = "prefix"
 	namespaces                 = "namespaces"
+	namespaceSelector          = "namespace-selector"
 	sharedSecret               = "shared-secret"
 	updateStatusFromService    = "update-status-from-service"
 	globalSettings             = "pomerium-config"
@@ -39,6 +55,17 @@ const (
 	syncAPIURL                 = "sync-api-url"
 	syncAPINamespaceID         = "sync-api-namespace-id"
 	syncAPIToken               = "sync-api-token" //nolint:gosec
+	syncAPIDriftScan           = "sync-api-drift-scan-interval"
+	syncAPIDriftPrune          = "sync-api-drift-prune"
+	syncAPIClusterID           = "sync-api-cluster-id"
+	syncAPIStrictOwnership     = "sync-api-strict-ownership"
+	syncAPIDryRun              = "sync-api-dry-run"
+	configShards               = "config-shards"
+	batchWindow                = "batch-window"
+	batchSize                  = "batch-size"
+	batchConcurrency           = "batch-concurrency"
+	certificateExpiryWarn      = "certificate-expiry-warning"
+	crlRefreshInterval         = "downstream-mtls-crl-refresh-interval"
 )
 
 func (s *ingressControllerOpts) setupFlags(flags *pflag.FlagSet) {
@@ -47,12 +74,36 @@ func (s *ingressControllerOpts) setupFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&s.GatewayClassName, gatewayClassControllerName, gateway.DefaultClassControllerName, "GatewayClass controller name")
 	flags.StringVar(&s.AnnotationPrefix, annotationPrefix, ingress.DefaultAnnotationPrefix, "Ingress annotation prefix")
 	flags.StringSliceVar(&s.Namespaces, namespaces, nil, "namespaces to watch, or none to watch all namespaces")
+	flags.StringVar(&s.NamespaceSelector, namespaceSelector, "", "label selector of namespaces to watch, i.e. pomerium.io/tenant=true")
 	flags.StringVar(&s.UpdateStatusFromService, updateStatusFromService, "", "update ingress status from given service status (pomerium-proxy)")
 	flags.StringVar(&s.GlobalSettings, globalSettings, "",
 		fmt.Sprintf("namespace/name to a resource of type %s/Settings", icsv1.GroupVersion.Group))
 	flags.StringVar(&s.SyncAPIURL, syncAPIURL, "", "unified API sync URL")
 	flags.StringVar(&s.SyncAPINamespaceID, syncAPINamespaceID, "", "unified API sync namespace ID")
 	flags.StringVar(&s.SyncAPIToken, syncAPIToken, "", "unified API sync token")
+	flags.DurationVar(&s.SyncAPIDriftScan, syncAPIDriftScan, 10*time.Minute,
+		"how often to restore unified API routes, policies and key pairs changed outside of Kubernetes, 0 to disable. "+
+			"Objects are restored to the state last written since the controller started, no scan runs before all Ingresses were synced")
+	flags.BoolVar(&s.SyncAPIDriftPrune, syncAPIDriftPrune, false,
+		"delete unified API routes, policies and key pairs synced from this cluster that no Kubernetes object refers to, requires "+
+			syncAPIClusterID)
+	flags.StringVar(&s.SyncAPIClusterID, syncAPIClusterID, "",
+		"unique ID of this cluster, stamped on unified API objects so that clusters syncing to one namespace do not prune each other's objects")
+	flags.BoolVar(&s.SyncAPIStrictOwnership, syncAPIStrictOwnership, false,
+		"do not modify unified API objects not synced from the Kubernetes object referencing them, unless adopted with the api.pomerium.io/adopt annotation")
+	flags.BoolVar(&s.SyncAPIDryRun, syncAPIDryRun, false,
+		"only log the changes that would be made to the unified API and Kubernetes objects")
+	flags.IntVar(&s.ConfigShards, configShards, 0,
+		"split Ingress-defined routes across this many databroker records by host, 0 or 1 for a single record, -1 for a record per host")
+	flags.DurationVar(&s.BatchWindow, batchWindow, 0,
+		"gather Ingress changes for this long and apply them to Pomerium configuration at once, 0 to apply each change immediately")
+	flags.IntVar(&s.BatchSize, batchSize, 100, "max number of Ingress changes applied at once when batching is enabled")
+	flags.IntVar(&s.BatchConcurrency, batchConcurrency, 4,
+		"max number of Ingresses reading their dependencies from the API server at a time when batching is enabled, up to 64")
+	flags.DurationVar(&s.CertificateExpiryWarn, certificateExpiryWarn, 30*24*time.Hour,
+		"report served certificates that expire within this duration as warning events and in the Pomerium CRD status, 0 to disable")
+	flags.DurationVar(&s.CRLRefreshInterval, crlRefreshInterval, 0,
+		"how often to download the downstream mTLS crlUrls into the crlSecret, 0 to disable")
 }
 
 func (s *ingressControllerOpts) Validate() error {
@@ -71,11 +122,30 @@ func (s *ingressControllerOpts) getGlobalSettings() (*types.NamespacedName, erro
 	return name, nil
 }
 
+func (s *ingressControllerOpts) getNamespaceSelector() (labels.Selector, error) {
+	if s.NamespaceSelector == "" {
+		return nil, nil
+	}
+
+	selector, err := labels.Parse(s.NamespaceSelector)
+	if err != nil {
+		return nil, fmt.Errorf("%s=%s: %w", namespaceSelector, s.NamespaceSelector, err)
+	}
+	return selector, nil
+}
+
 func (s *ingressControllerOpts) getIngressControllerOptions() ([]ingress.Option, error) {
+	selector, err := s.getNamespaceSelector()
+	if err != nil {
+		return nil, err
+	}
+
 	opts := []ingress.Option{
 		ingress.WithNamespaces(s.Namespaces),
+		ingress.WithNamespaceSelector(selector),
 		ingress.WithAnnotationPrefix(s.AnnotationPrefix),
 		ingress.WithControllerName(s.ClassName),
+		ingress.WithBatching(s.BatchWindow, s.BatchSize, s.BatchConcurrency),
 	}
 	if name, err := s.getGlobalSettings(); err != nil {
 		return nil, err
@@ -97,8 +167,14 @@ func (s *ingressControllerOpts) getGatewayControllerConfig() (*gateway.Controlle
 		return nil, nil
 	}
 
+	selector, err := s.getNamespaceSelector()
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &gateway.ControllerConfig{
-		ControllerName: s.GatewayClassName,
+		ControllerName:    s.GatewayClassName,
+		NamespaceSelector: selector,
 	}
 	if s.UpdateStatusFromService != "" {
 		name, err := util.ParseNamespacedName(s.UpdateStatusFromService)
@@ -109,3 +185,13 @@ func (s *ingressControllerOpts) getGatewayControllerConfig() (*gateway.Controlle
 	}
 	return cfg, nil
 }
+
+func (s *ingressControllerOpts) getCertificateExpiryConfig() *expiry.Config {
+	if s.CertificateExpiryWarn <= 0 {
+		return nil
+	}
+	return &expiry.Config{
+		IngressClassController: s.ClassName,
+		WarnBefore:             s.CertificateExpiryWarn,
+	}
+}
@@ -0,0 +1,736 @@
+package certificate
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	certmanager_meta_v1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"golang.org/x/crypto/acme"
+	core_v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+)
+
+const (
+	// acmeProvisionerLabelName marks the secrets holding certificates issued by the ACME provisioner
+	acmeProvisionerLabelName  = "certificate.pomerium.io/provisioner"
+	acmeProvisionerLabelValue = "acme"
+	// acmeDNSNamesAnnotation are the comma separated names a certificate issued by the ACME provisioner is for
+	acmeDNSNamesAnnotation = "certificate.pomerium.io/dns-names"
+	// acmeDirectoryAnnotation is the directory URL of the ACME server that issued the certificate
+	acmeDirectoryAnnotation = "certificate.pomerium.io/acme-directory"
+
+	acmeAccountSecretName = "pomerium-acme-account"
+	acmeAccountKeyData    = "account.key"
+	acmeSecretNamePrefix  = "pomerium-acme-"
+
+	acmeChallengePath = "/.well-known/acme-challenge/"
+	// acmeOrderTimeout bounds the time spent on ordering a single certificate
+	acmeOrderTimeout = 2 * time.Minute
+	// acmeOrderPollInterval is how often the controller checks on orders running in the background
+	acmeOrderPollInterval = 5 * time.Second
+
+	// acmeIssuerKind is the issuer kind of certificates planned for the ACME server,
+	// where the issuer name is the directory URL
+	acmeIssuerKind = "ACME"
+)
+
+// acmeIssuerReference returns the issuer the certificates from the ACME server are planned with,
+// so that they are grouped the same way as cert-manager certificates.
+func acmeIssuerReference(settings *pomerium_ingress_v1.CertificateAutoProvisionACME) certmanager_meta_v1.IssuerReference {
+	return certmanager_meta_v1.IssuerReference{Kind: acmeIssuerKind, Name: settings.Directory}
+}
+
+func isACMEIssuer(issuer certmanager_meta_v1.IssuerReference) bool {
+	return issuer.Kind == acmeIssuerKind
+}
+
+// A DNSProvider solves ACME DNS-01 challenges by publishing TXT records.
+type DNSProvider interface {
+	// Present creates a TXT record with the value for the fqdn, i.e. _acme-challenge.example.com.
+	Present(ctx context.Context, fqdn, value string) error
+	// CleanUp removes the TXT record once the challenge is complete.
+	CleanUp(ctx context.Context, fqdn, value string) error
+}
+
+// acmeProvisioner orders certificates from an ACME server and stores them in secrets.
+type acmeProvisioner struct {
+	cfg              *controllerConfig
+	kubernetesClient client.Client
+	responder        *acmeChallengeResponder
+
+	mu sync.Mutex
+	// clients are the registered ACME clients by directory URL
+	clients map[string]*acme.Client
+	// routesPublished is when the HTTP-01 challenge route for a name was first published
+	routesPublished map[string]time.Time
+	// orders are the orders running in the background, or completed since the last reconcile,
+	// by the name of the secret the certificate is saved in
+	orders map[string]*acmeOrder
+}
+
+// acmeOrder is a certificate order running in the background.
+type acmeOrder struct {
+	operation Operation
+	dnsNames  []string
+}
+
+func newACMEProvisioner(cfg *controllerConfig, kubernetesClient client.Client) *acmeProvisioner {
+	return &acmeProvisioner{
+		cfg:              cfg,
+		kubernetesClient: kubernetesClient,
+		responder:        newACMEChallengeResponder(cfg.acmeHTTP01Addr),
+		clients:          make(map[string]*acme.Client),
+		routesPublished:  make(map[string]time.Time),
+		orders:           make(map[string]*acmeOrder),
+	}
+}
+
+// filter returns the planned certificates the provisioner can order:
+// wildcard names may only be validated with DNS-01 challenges.
+func (p *acmeProvisioner) filter(
+	ctx context.Context,
+	settings *pomerium_ingress_v1.CertificateAutoProvisionACME,
+	planned []plannedCertificate,
+) []plannedCertificate {
+	if settings == nil || settings.DNS01Provider != nil {
+		return planned
+	}
+	return slices.DeleteFunc(planned, func(plan plannedCertificate) bool {
+		if slices.ContainsFunc(plan.dnsNames, func(name string) bool { return strings.HasPrefix(name, "*.") }) {
+			log.FromContext(ctx).Info("certificate-controller: wildcard names require a dns-01 provider, skipping",
+				"dns-names", plan.dnsNames)
+			return true
+		}
+		return false
+	})
+}
+
+// challengeRoutes returns the routes Pomerium needs to forward HTTP-01 challenges
+// for the names to the challenge responder.
+func (p *acmeProvisioner) challengeRoutes(names []string) []*configpb.Route {
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	published := make(map[string]time.Time, len(names))
+	routes := make([]*configpb.Route, 0, len(names))
+	for _, name := range names {
+		published[name] = now
+		if t, ok := p.routesPublished[name]; ok {
+			published[name] = t
+		}
+		routes = append(routes, &configpb.Route{
+			Name:                             "acme-http01-" + name,
+			From:                             "http://" + name,
+			Prefix:                           acmeChallengePath,
+			To:                               []string{p.cfg.acmeHTTP01URL},
+			AllowPublicUnauthenticatedAccess: true,
+		})
+	}
+	p.routesPublished = published
+	return routes
+}
+
+// reconcile orders the planned certificates that don't have a valid certificate yet in the background,
+// renews certificates close to their expiry and removes the certificates no longer needed,
+// unless some certificates are still pending. It returns when the next renewal is due,
+// or when to check on the orders running in the background.
+func (p *acmeProvisioner) reconcile(
+	ctx context.Context,
+	namespace string,
+	settings *pomerium_ingress_v1.CertificateAutoProvisionACME,
+	planned []plannedCertificate,
+	secrets []core_v1.Secret,
+) (requeueAfter time.Duration, err error) {
+	now := time.Now()
+	current := acmeSecretsByName(secrets)
+
+	requeue := func(d time.Duration) {
+		if requeueAfter == 0 || d < requeueAfter {
+			requeueAfter = max(d, time.Second)
+		}
+	}
+
+	needed := make(map[string]bool, len(planned))
+	var orders []plannedCertificate
+	for _, plan := range planned {
+		plan.certificate = acmeSecretNameFor(plan)
+		needed[plan.certificate] = true
+		if secret, ok := current[plan.certificate]; ok && acmeCertificateFor(secret).key() == plan.key() {
+			if renewAt, ok := acmeRenewalTime(secret); ok && renewAt.After(now) {
+				p.forgetOrder(plan.certificate)
+				requeue(renewAt.Sub(now))
+				continue
+			}
+		}
+		orders = append(orders, plan)
+	}
+
+	// stop the orders that are not needed anymore
+	p.mu.Lock()
+	for name, order := range p.orders {
+		if !needed[name] {
+			order.operation.StopNow()
+			delete(p.orders, name)
+		}
+	}
+	p.mu.Unlock()
+
+	// remove the certificates that are not needed anymore, once the certificates replacing them are issued
+	if acmePending(planned, secrets) == 0 {
+		for name, secret := range current {
+			if !needed[name] {
+				if err := p.deleteSecret(ctx, secret); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+
+	if len(orders) == 0 {
+		return requeueAfter, nil
+	}
+	if settings.DNS01Provider == nil && p.cfg.acmeHTTP01URL == "" {
+		return 0, fmt.Errorf("acme http-01 challenges require the controller to serve them, use a dns-01 provider instead")
+	}
+
+	var errs []error
+	for _, plan := range orders {
+		// give Pomerium time to pick up the challenge routes
+		if settings.DNS01Provider == nil {
+			if wait, ok := p.routesWait(plan.dnsNames); !ok || wait > 0 {
+				requeue(wait)
+				continue
+			}
+		}
+
+		p.mu.Lock()
+		order := p.orders[plan.certificate]
+		p.mu.Unlock()
+		switch {
+		case order == nil || !slices.Equal(order.dnsNames, plan.dnsNames):
+			p.startOrder(ctx, namespace, settings, plan)
+			requeue(acmeOrderPollInterval)
+		case order.operation.Active():
+			requeue(acmeOrderPollInterval)
+		case order.operation.Error() != nil:
+			// the order is started again on the next reconcile, that is rate limited on errors
+			errs = append(errs, fmt.Errorf("error ordering certificate for %s: %w",
+				strings.Join(plan.dnsNames, ", "), order.operation.Error()))
+			p.forgetOrder(plan.certificate)
+		default:
+			// the certificate was saved, wait until the secret is updated
+			requeue(acmeOrderPollInterval)
+		}
+	}
+	return requeueAfter, errors.Join(errs...)
+}
+
+// routesWait returns how long until Pomerium picked up the HTTP-01 challenge routes for all the names,
+// and false if some of the routes are not published yet.
+func (p *acmeProvisioner) routesWait(names []string) (time.Duration, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var wait time.Duration
+	for _, name := range names {
+		published, ok := p.routesPublished[name]
+		if !ok {
+			return 0, false
+		}
+		wait = max(wait, time.Until(published.Add(p.cfg.acmePropagationDelay)))
+	}
+	return wait, true
+}
+
+// startOrder orders the planned certificate in the background,
+// replacing any order for the same secret.
+func (p *acmeProvisioner) startOrder(
+	ctx context.Context,
+	namespace string,
+	settings *pomerium_ingress_v1.CertificateAutoProvisionACME,
+	plan plannedCertificate,
+) {
+	logger := log.FromContext(ctx)
+	settings = settings.DeepCopy()
+	order := &acmeOrder{operation: NewOperation(), dnsNames: plan.dnsNames}
+
+	p.mu.Lock()
+	if prev, ok := p.orders[plan.certificate]; ok {
+		prev.operation.StopNow()
+	}
+	p.orders[plan.certificate] = order
+	p.mu.Unlock()
+
+	order.operation.Start(func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(log.IntoContext(ctx, logger), acmeOrderTimeout)
+		defer cancel()
+
+		c, err := p.client(ctx, namespace, settings)
+		if err == nil {
+			err = p.order(ctx, c, namespace, settings, plan)
+		}
+		if err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+			logger.Error(err, "certificate-controller: error ordering acme certificate",
+				"dns-names", plan.dnsNames)
+		}
+		return err
+	})
+}
+
+// forgetOrder removes the order for the secret, unless it is still running.
+func (p *acmeProvisioner) forgetOrder(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if order, ok := p.orders[name]; ok && !order.operation.Active() {
+		delete(p.orders, name)
+	}
+}
+
+// client returns an ACME client with a registered account for the directory.
+func (p *acmeProvisioner) client(
+	ctx context.Context,
+	namespace string,
+	settings *pomerium_ingress_v1.CertificateAutoProvisionACME,
+) (*acme.Client, error) {
+	p.mu.Lock()
+	c, ok := p.clients[settings.Directory]
+	p.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	key, err := p.accountKey(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	c = &acme.Client{
+		Key:          key,
+		DirectoryURL: settings.Directory,
+		HTTPClient:   p.cfg.acmeHTTPClient,
+		UserAgent:    "pomerium-ingress-controller",
+	}
+	account := new(acme.Account)
+	if settings.Email != nil {
+		account.Contact = []string{"mailto:" + *settings.Email}
+	}
+	if _, err := c.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, fmt.Errorf("error registering acme account: %w", err)
+	}
+	log.FromContext(ctx).Info("certificate-controller: registered acme account",
+		"directory", settings.Directory)
+
+	p.mu.Lock()
+	p.clients[settings.Directory] = c
+	p.mu.Unlock()
+	return c, nil
+}
+
+// accountKey returns the key of the ACME account, which is created on first use.
+func (p *acmeProvisioner) accountKey(ctx context.Context, namespace string) (crypto.Signer, error) {
+	var secret core_v1.Secret
+	err := p.kubernetesClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: acmeAccountSecretName}, &secret)
+	if err == nil {
+		block, _ := pem.Decode(secret.Data[acmeAccountKeyData])
+		if block == nil {
+			return nil, fmt.Errorf("acme account secret (%s/%s) has no %s", namespace, acmeAccountSecretName, acmeAccountKeyData)
+		}
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing acme account key: %w", err)
+		}
+		return key, nil
+	} else if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("error retrieving acme account secret: %w", err)
+	}
+
+	key, keyPEM, err := generateKey()
+	if err != nil {
+		return nil, err
+	}
+	log.FromContext(ctx).Info("certificate-controller: creating acme account secret",
+		"name", acmeAccountSecretName,
+		"namespace", namespace)
+	if err := p.kubernetesClient.Create(ctx, &core_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      acmeAccountSecretName,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			acmeAccountKeyData: keyPEM,
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("error creating acme account secret: %w", err)
+	}
+	return key, nil
+}
+
+// order obtains a certificate for the planned names and saves it in the planned secret.
+func (p *acmeProvisioner) order(
+	ctx context.Context,
+	c *acme.Client,
+	namespace string,
+	settings *pomerium_ingress_v1.CertificateAutoProvisionACME,
+	plan plannedCertificate,
+) error {
+	log.FromContext(ctx).Info("certificate-controller: ordering acme certificate",
+		"directory", settings.Directory,
+		"dns-names", plan.dnsNames)
+	order, err := c.AuthorizeOrder(ctx, acme.DomainIDs(plan.dnsNames...))
+	if err != nil {
+		return fmt.Errorf("error creating order: %w", err)
+	}
+	for _, authzURL := range order.AuthzURLs {
+		if err := p.authorize(ctx, c, settings, authzURL); err != nil {
+			return err
+		}
+	}
+	order, err = c.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return fmt.Errorf("error waiting for order: %w", err)
+	}
+
+	key, keyPEM, err := generateKey()
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: plan.dnsNames}, key)
+	if err != nil {
+		return fmt.Errorf("error creating certificate request: %w", err)
+	}
+	chain, _, err := c.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return fmt.Errorf("error finalizing order: %w", err)
+	}
+	var certPEM []byte
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	return p.saveSecret(ctx, namespace, plan, certPEM, keyPEM)
+}
+
+// authorize solves a challenge of the authorization, unless it is already valid.
+func (p *acmeProvisioner) authorize(
+	ctx context.Context,
+	c *acme.Client,
+	settings *pomerium_ingress_v1.CertificateAutoProvisionACME,
+	authzURL string,
+) error {
+	authz, err := c.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return fmt.Errorf("error retrieving authorization: %w", err)
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	challengeType := "http-01"
+	if settings.DNS01Provider != nil {
+		challengeType = "dns-01"
+	}
+	idx := slices.IndexFunc(authz.Challenges, func(chal *acme.Challenge) bool {
+		return chal.Type == challengeType
+	})
+	if idx < 0 {
+		return fmt.Errorf("acme server offers no %s challenge for %s", challengeType, authz.Identifier.Value)
+	}
+	chal := authz.Challenges[idx]
+
+	switch challengeType {
+	case "dns-01":
+		provider, ok := p.cfg.dnsProviders[*settings.DNS01Provider]
+		if !ok {
+			return fmt.Errorf("unknown dns-01 provider %s", *settings.DNS01Provider)
+		}
+		value, err := c.DNS01ChallengeRecord(chal.Token)
+		if err != nil {
+			return fmt.Errorf("error computing dns-01 challenge record: %w", err)
+		}
+		fqdn := "_acme-challenge." + strings.TrimPrefix(authz.Identifier.Value, "*.")
+		if err := provider.Present(ctx, fqdn, value); err != nil {
+			return fmt.Errorf("error presenting dns-01 challenge record: %w", err)
+		}
+		defer func() {
+			if err := provider.CleanUp(context.WithoutCancel(ctx), fqdn, value); err != nil {
+				log.FromContext(ctx).Error(err, "certificate-controller: error cleaning up dns-01 challenge record",
+					"fqdn", fqdn)
+			}
+		}()
+		// give the record time to reach the authoritative servers
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(p.cfg.acmePropagationDelay):
+		}
+	default:
+		keyAuth, err := c.HTTP01ChallengeResponse(chal.Token)
+		if err != nil {
+			return fmt.Errorf("error computing http-01 challenge response: %w", err)
+		}
+		p.responder.set(chal.Token, keyAuth)
+		defer p.responder.delete(chal.Token)
+	}
+
+	if _, err := c.Accept(ctx, chal); err != nil {
+		return fmt.Errorf("error accepting %s challenge: %w", challengeType, err)
+	}
+	if _, err := c.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("error waiting for authorization: %w", err)
+	}
+	return nil
+}
+
+// saveSecret creates or updates the secret of the planned certificate.
+func (p *acmeProvisioner) saveSecret(
+	ctx context.Context,
+	namespace string,
+	plan plannedCertificate,
+	certPEM, keyPEM []byte,
+) error {
+	annotations := map[string]string{
+		acmeDNSNamesAnnotation:  strings.Join(plan.dnsNames, ","),
+		acmeDirectoryAnnotation: plan.issuer.Name,
+	}
+	data := map[string][]byte{
+		core_v1.TLSCertKey:       certPEM,
+		core_v1.TLSPrivateKeyKey: keyPEM,
+	}
+
+	var secret core_v1.Secret
+	err := p.kubernetesClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: plan.certificate}, &secret)
+	if err == nil {
+		log.FromContext(ctx).Info("certificate-controller: updating acme certificate secret",
+			"name", secret.Name,
+			"namespace", secret.Namespace,
+			"dns-names", plan.dnsNames)
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		maps.Copy(secret.Annotations, annotations)
+		secret.Data = data
+		if err := p.kubernetesClient.Update(ctx, &secret); err != nil {
+			return fmt.Errorf("error updating secret (%s/%s): %w", secret.Namespace, secret.Name, err)
+		}
+		return nil
+	} else if !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error retrieving secret (%s/%s): %w", namespace, plan.certificate, err)
+	}
+
+	secret = core_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      plan.certificate,
+			Namespace: namespace,
+			Labels: map[string]string{
+				managedByLabelName:       managedByLabelValue,
+				acmeProvisionerLabelName: acmeProvisionerLabelValue,
+			},
+			Annotations: annotations,
+		},
+		Type: core_v1.SecretTypeTLS,
+		Data: data,
+	}
+	log.FromContext(ctx).Info("certificate-controller: creating acme certificate secret",
+		"name", secret.Name,
+		"namespace", secret.Namespace,
+		"dns-names", plan.dnsNames)
+	if err := p.kubernetesClient.Create(ctx, &secret); err != nil {
+		return fmt.Errorf("error creating secret (%s/%s): %w", secret.Namespace, secret.Name, err)
+	}
+	return nil
+}
+
+func (p *acmeProvisioner) deleteSecret(ctx context.Context, secret *core_v1.Secret) error {
+	log.FromContext(ctx).Info("certificate-controller: deleting acme certificate secret",
+		"name", secret.Name,
+		"namespace", secret.Namespace,
+		"dns-names", secret.Annotations[acmeDNSNamesAnnotation])
+	if err := p.kubernetesClient.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error deleting existing secret (%s/%s): %w", secret.Namespace, secret.Name, err)
+	}
+	return nil
+}
+
+// acmeSecretsByName returns the secrets holding certificates issued by the ACME provisioner.
+func acmeSecretsByName(secrets []core_v1.Secret) map[string]*core_v1.Secret {
+	out := make(map[string]*core_v1.Secret)
+	for i := range secrets {
+		if secrets[i].Labels[acmeProvisionerLabelName] == acmeProvisionerLabelValue {
+			out[secrets[i].Name] = &secrets[i]
+		}
+	}
+	return out
+}
+
+// acmeCertificates returns the plans the certificates issued by the ACME provisioner match.
+func acmeCertificates(secrets []core_v1.Secret) []plannedCertificate {
+	var out []plannedCertificate
+	for _, secret := range acmeSecretsByName(secrets) {
+		out = append(out, acmeCertificateFor(secret))
+	}
+	return out
+}
+
+// acmeCertificateFor returns the plan a certificate issued by the ACME provisioner matches.
+func acmeCertificateFor(secret *core_v1.Secret) plannedCertificate {
+	var dnsNames []string
+	if v := secret.Annotations[acmeDNSNamesAnnotation]; v != "" {
+		dnsNames = strings.Split(v, ",")
+	}
+	slices.Sort(dnsNames)
+	return plannedCertificate{
+		dnsNames:    dnsNames,
+		issuer:      certmanager_meta_v1.IssuerReference{Kind: acmeIssuerKind, Name: secret.Annotations[acmeDirectoryAnnotation]},
+		certificate: secret.Name,
+	}
+}
+
+// acmePending returns the number of planned certificates that were not issued yet.
+func acmePending(planned []plannedCertificate, secrets []core_v1.Secret) int {
+	current := acmeSecretsByName(secrets)
+	var pending int
+	for _, plan := range planned {
+		if _, ok := current[acmeSecretNameFor(plan)]; !ok {
+			pending++
+		}
+	}
+	return pending
+}
+
+// acmeSecretNameFor returns the name of the secret holding the planned certificate:
+// the existing secret the plan was packed into, or a name derived from the planned names,
+// that is stable so that concurrent orders can't create duplicate secrets.
+func acmeSecretNameFor(plan plannedCertificate) string {
+	if plan.certificate != "" {
+		return plan.certificate
+	}
+	h := sha256.Sum256([]byte(strings.Join(plan.dnsNames, ",")))
+	return acmeSecretNamePrefix + hex.EncodeToString(h[:10])
+}
+
+// acmeRenewalTime returns when the certificate in the secret should be renewed,
+// after two thirds of its lifetime.
+func acmeRenewalTime(secret *core_v1.Secret) (time.Time, bool) {
+	block, _ := pem.Decode(secret.Data[core_v1.TLSCertKey])
+	if block == nil {
+		return time.Time{}, false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, false
+	}
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotAfter.Add(-lifetime / 3), true
+}
+
+func generateKey() (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating key: %w", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error encoding key: %w", err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// acmeChallengeResponder serves the key authorizations of pending HTTP-01 challenges.
+type acmeChallengeResponder struct {
+	addr string
+	// enabled is closed once HTTP-01 challenges are first needed
+	enabled    chan struct{}
+	enableOnce sync.Once
+
+	mu     sync.RWMutex
+	tokens map[string]string
+}
+
+func newACMEChallengeResponder(addr string) *acmeChallengeResponder {
+	return &acmeChallengeResponder{
+		addr:    addr,
+		enabled: make(chan struct{}),
+		tokens:  make(map[string]string),
+	}
+}
+
+// enable starts serving the challenges, if the responder was started
+func (r *acmeChallengeResponder) enable() {
+	r.enableOnce.Do(func() { close(r.enabled) })
+}
+
+func (r *acmeChallengeResponder) set(token, keyAuth string) {
+	r.mu.Lock()
+	r.tokens[token] = keyAuth
+	r.mu.Unlock()
+}
+
+func (r *acmeChallengeResponder) delete(token string) {
+	r.mu.Lock()
+	delete(r.tokens, token)
+	r.mu.Unlock()
+}
+
+func (r *acmeChallengeResponder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	token, ok := strings.CutPrefix(req.URL.Path, acmeChallengePath)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	r.mu.RLock()
+	keyAuth, ok := r.tokens[token]
+	r.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(keyAuth))
+}
+
+// Start implements manager.Runnable and serves the challenges until the context is canceled.
+// The responder only listens once the settings ask for certificates from an ACME server with HTTP-01 challenges.
+func (r *acmeChallengeResponder) Start(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-r.enabled:
+	}
+
+	srv := &http.Server{
+		Addr:              r.addr,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("acme http-01 challenge responder: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package certificate_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-logr/zapr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	controllerconfig "sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/controllers/certificate"
+	"github.com/pomerium/ingress-controller/internal/testutil"
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+	databrokerpb "github.com/pomerium/pomerium/pkg/grpc/databroker"
+	"github.com/pomerium/pomerium/pkg/grpcutil"
+)
+
+// TestACMEWithPebble provisions a certificate from a local Pebble ACME server,
+// without the cert-manager CRDs installed. It is skipped unless PEBBLE_DIRECTORY
+// is set to the directory URL, i.e. https://localhost:14000/dir, and PEBBLE_CA_CERT
+// to the path of Pebble's test/certs/pebble.minica.pem, as the test job in
+// .github/workflows/test.yaml does with a Pebble service container.
+//
+// Pebble has to either be started with PEBBLE_VA_ALWAYS_VALID=1, or resolve
+// app.example.com to this host, where HTTP-01 challenges are served on
+// PEBBLE_HTTP01_ADDR (127.0.0.1:5002 by default).
+func TestACMEWithPebble(t *testing.T) {
+	directory, caCert := os.Getenv("PEBBLE_DIRECTORY"), os.Getenv("PEBBLE_CA_CERT")
+	if directory == "" || caCert == "" {
+		t.Skip("PEBBLE_DIRECTORY and PEBBLE_CA_CERT are not set")
+	}
+	http01Addr := os.Getenv("PEBBLE_HTTP01_ADDR")
+	if http01Addr == "" {
+		http01Addr = "127.0.0.1:5002"
+	}
+	log.SetLogger(zapr.NewLogger(zaptest.NewLogger(t)))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	caPEM, err := os.ReadFile(caCert)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(caPEM), "invalid pebble CA certificate")
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, pomerium_ingress_v1.AddToScheme(scheme))
+
+	env := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+		Scheme:                scheme,
+		UseExistingCluster:    new(false),
+	}
+	cfg, err := env.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, env.Stop()) })
+
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Create(ctx, &core_v1.Namespace{
+		ObjectMeta: meta_v1.ObjectMeta{Name: testNamespace},
+	}))
+
+	dataBroker := testutil.NewInMemoryDataBroker(t)
+	_, err = dataBroker.Put(ctx, &databrokerpb.PutRequest{
+		Records: []*databrokerpb.Record{
+			databrokerpb.NewRecord(&configpb.Route{Id: new("route-1"), From: "https://app.example.com"}),
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, k8sClient.Create(ctx, &pomerium_ingress_v1.Pomerium{
+		ObjectMeta: meta_v1.ObjectMeta{Name: testGlobalSettingsName},
+		Spec: pomerium_ingress_v1.PomeriumSpec{
+			Secrets: testNamespace + "/secrets",
+			CertificateAutoProvision: &pomerium_ingress_v1.CertificateAutoProvision{
+				ACME: &pomerium_ingress_v1.CertificateAutoProvisionACME{
+					Directory: directory,
+					Email:     new("admin@example.com"),
+				},
+			},
+		},
+	}))
+
+	mgr, err := controllerruntime.NewManager(cfg, controllerruntime.Options{
+		Scheme:                 scheme,
+		Metrics:                metricsserver.Options{BindAddress: "0"},
+		HealthProbeBindAddress: "0",
+		Controller: controllerconfig.Controller{
+			SkipNameValidation: new(true),
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, certificate.NewCertificateController(
+		mgr,
+		dataBroker,
+		certificate.WithGlobalSettingsName(types.NamespacedName{Name: testGlobalSettingsName}),
+		certificate.WithNamespace(testNamespace),
+		certificate.WithACMEHTTP01(http01Addr, ""),
+		certificate.WithACMEHTTPClient(httpClient),
+		certificate.WithACMEPropagationDelay(0),
+	))
+	go func() {
+		if err := mgr.Start(ctx); err != nil && ctx.Err() == nil {
+			t.Errorf("manager exited with error: %v", err)
+		}
+	}()
+
+	assert.Eventually(t, func() bool {
+		var sl core_v1.SecretList
+		if err := k8sClient.List(ctx, &sl, client.InNamespace(testNamespace)); err != nil {
+			return false
+		}
+		return slices.ContainsFunc(sl.Items, func(s core_v1.Secret) bool {
+			block, _ := pem.Decode(s.Data[core_v1.TLSCertKey])
+			if block == nil {
+				return false
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			return err == nil && slices.Equal(cert.DNSNames, []string{"app.example.com"})
+		})
+	}, time.Minute, time.Second, "expected a secret with a certificate for app.example.com")
+
+	assert.Eventually(t, func() bool {
+		res, err := dataBroker.Get(ctx, &databrokerpb.GetRequest{
+			Type: grpcutil.GetTypeURL(new(configpb.Config)),
+			Id:   "pomerium-certificate-controller-config",
+		})
+		if err != nil {
+			return false
+		}
+		var cfg configpb.Config
+		if err := res.GetRecord().GetData().UnmarshalTo(&cfg); err != nil {
+			return false
+		}
+		return len(cfg.GetSettings().GetCertificates()) == 1 &&
+			slices.ContainsFunc(cfg.GetRoutes(), func(r *configpb.Route) bool {
+				return r.GetFrom() == "http://app.example.com"
+			})
+	}, 10*time.Second, 100*time.Millisecond, "expected the certificate and the http-01 challenge route in the databroker")
+}
@@ -0,0 +1,282 @@
+package certificate
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+)
+
+func TestACMEChallengeResponder(t *testing.T) {
+	t.Parallel()
+
+	r := newACMEChallengeResponder("")
+	r.set("token1", "token1.thumbprint")
+
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	code, body := get("/.well-known/acme-challenge/token1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "token1.thumbprint", body)
+
+	code, _ = get("/.well-known/acme-challenge/token2")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = get("/token1")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	r.delete("token1")
+	code, _ = get("/.well-known/acme-challenge/token1")
+	assert.Equal(t, http.StatusNotFound, code, "completed challenges should not be served")
+}
+
+func TestACMEChallengeResponderStart(t *testing.T) {
+	t.Parallel()
+
+	r := newACMEChallengeResponder("127.0.0.1:0")
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan error, 1)
+	go func() { done <- r.Start(ctx) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("responder should wait until enabled, got %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "responder should stop without listening when never enabled")
+	case <-time.After(5 * time.Second):
+		t.Fatal("responder did not stop")
+	}
+}
+
+func TestACMEChallengeRoutes(t *testing.T) {
+	t.Parallel()
+
+	p := newACMEProvisioner(getControllerConfig(WithACMEHTTP01("127.0.0.1:28089", "")), nil)
+	routes := p.challengeRoutes([]string{"a.example.com"})
+	require.Len(t, routes, 1)
+	assert.Equal(t, "http://a.example.com", routes[0].From)
+	assert.Equal(t, "/.well-known/acme-challenge/", routes[0].Prefix)
+	assert.Equal(t, []string{"http://127.0.0.1:28089"}, routes[0].To)
+	assert.True(t, routes[0].AllowPublicUnauthenticatedAccess)
+
+	first := p.routesPublished["a.example.com"]
+	p.challengeRoutes([]string{"a.example.com", "b.example.com"})
+	assert.Equal(t, first, p.routesPublished["a.example.com"], "should keep when a route was first published")
+	assert.Contains(t, p.routesPublished, "b.example.com")
+
+	p.challengeRoutes([]string{"b.example.com"})
+	assert.NotContains(t, p.routesPublished, "a.example.com", "should forget routes that were removed")
+}
+
+func TestACMEReconcile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	namespace := "default"
+	settings := &pomerium_ingress_v1.CertificateAutoProvisionACME{Directory: "https://acme.invalid/directory"}
+	issuer := acmeIssuerReference(settings)
+	plan := func(names ...string) plannedCertificate {
+		return plannedCertificate{dnsNames: names, issuer: issuer}
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	makeSecret := func(names []string, notBefore, notAfter time.Time) *core_v1.Secret {
+		key, _, err := generateKey()
+		require.NoError(t, err)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			DNSNames:     names,
+			NotBefore:    notBefore,
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		require.NoError(t, err)
+		return &core_v1.Secret{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Name:      acmeSecretNameFor(plan(names...)),
+				Namespace: namespace,
+				Labels: map[string]string{
+					managedByLabelName:       managedByLabelValue,
+					acmeProvisionerLabelName: acmeProvisionerLabelValue,
+				},
+				Annotations: map[string]string{
+					acmeDNSNamesAnnotation:  strings.Join(names, ","),
+					acmeDirectoryAnnotation: settings.Directory,
+				},
+			},
+			Data: map[string][]byte{
+				core_v1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			},
+		}
+	}
+
+	listSecrets := func(t *testing.T, cl client.Client) ([]core_v1.Secret, []string) {
+		t.Helper()
+		var sl core_v1.SecretList
+		require.NoError(t, cl.List(ctx, &sl, client.InNamespace(namespace)))
+		var names []string
+		for _, s := range sl.Items {
+			names = append(names, s.Annotations[acmeDNSNamesAnnotation])
+		}
+		return sl.Items, names
+	}
+
+	t.Run("valid certificates are kept until renewal", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Now()
+		valid := makeSecret([]string{"a.example.com", "b.example.com"}, now.Add(-30*24*time.Hour), now.Add(60*24*time.Hour))
+		unused := makeSecret([]string{"c.example.com"}, now.Add(-30*24*time.Hour), now.Add(60*24*time.Hour))
+		cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(valid, unused).Build()
+		p := newACMEProvisioner(getControllerConfig(), cl)
+
+		secrets, _ := listSecrets(t, cl)
+		planned := []plannedCertificate{plan("a.example.com", "b.example.com")}
+		assert.Zero(t, acmePending(planned, secrets))
+		requeueAfter, err := p.reconcile(ctx, namespace, settings, planned, secrets)
+		require.NoError(t, err, "should not contact the acme server")
+		assert.InDelta(t, (30 * 24 * time.Hour).Hours(), requeueAfter.Hours(), 1,
+			"should requeue after two thirds of the lifetime")
+		_, names := listSecrets(t, cl)
+		assert.Equal(t, []string{"a.example.com,b.example.com"}, names, "should remove certificates not needed anymore")
+	})
+
+	t.Run("existing certificates are matched by the planned certificates", func(t *testing.T) {
+		t.Parallel()
+
+		now := time.Now()
+		secret := makeSecret([]string{"b.example.com", "a.example.com"}, now.Add(-time.Hour), now.Add(time.Hour))
+		assert.Equal(t, plannedCertificate{
+			dnsNames:    []string{"a.example.com", "b.example.com"},
+			issuer:      issuer,
+			certificate: secret.Name,
+		}, acmeCertificateFor(secret))
+
+		secret.Annotations[acmeDirectoryAnnotation] = "https://other.invalid/directory"
+		assert.NotEqual(t, plan("a.example.com", "b.example.com").key(), acmeCertificateFor(secret).key(),
+			"certificates from another acme server should be reissued")
+	})
+
+	t.Run("orders wait for the challenge routes", func(t *testing.T) {
+		t.Parallel()
+
+		cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+		p := newACMEProvisioner(getControllerConfig(
+			WithACMEHTTP01("127.0.0.1:0", ""),
+			WithACMEPropagationDelay(time.Minute),
+		), cl)
+		p.challengeRoutes([]string{"a.example.com", "b.example.com"})
+
+		planned := []plannedCertificate{plan("a.example.com", "b.example.com")}
+		assert.Equal(t, 1, acmePending(planned, nil))
+		requeueAfter, err := p.reconcile(ctx, namespace, settings, planned, nil)
+		require.NoError(t, err)
+		assert.InDelta(t, time.Minute.Seconds(), requeueAfter.Seconds(), 1)
+		assert.Empty(t, p.orders, "should not order before the challenge routes are published")
+	})
+
+	t.Run("orders run in the background", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			<-release
+			http.Error(w, "unavailable", http.StatusBadRequest)
+		}))
+		defer srv.Close()
+
+		settings := &pomerium_ingress_v1.CertificateAutoProvisionACME{
+			Directory:     srv.URL,
+			DNS01Provider: new("webhook"),
+		}
+		key, _, err := generateKey()
+		require.NoError(t, err)
+		p := newACMEProvisioner(getControllerConfig(), fake.NewClientBuilder().WithScheme(scheme).Build())
+		p.clients[settings.Directory] = &acme.Client{Key: key, DirectoryURL: srv.URL}
+		planned := []plannedCertificate{{dnsNames: []string{"a.example.com"}, issuer: acmeIssuerReference(settings)}}
+
+		requeueAfter, err := p.reconcile(ctx, namespace, settings, planned, nil)
+		require.NoError(t, err, "should not wait for the order")
+		assert.Equal(t, acmeOrderPollInterval, requeueAfter)
+		order := p.orders[acmeSecretNameFor(planned[0])]
+		require.NotNil(t, order)
+
+		requeueAfter, err = p.reconcile(ctx, namespace, settings, planned, nil)
+		require.NoError(t, err)
+		assert.Equal(t, acmeOrderPollInterval, requeueAfter, "should check on the running order")
+		assert.Same(t, order, p.orders[acmeSecretNameFor(planned[0])], "should not start another order")
+
+		close(release)
+		assert.Error(t, order.operation.Wait())
+		_, err = p.reconcile(ctx, namespace, settings, planned, nil)
+		assert.Error(t, err, "should report the failed order")
+		assert.Empty(t, p.orders, "should order again on the next reconcile")
+	})
+
+	t.Run("orders not planned anymore are stopped", func(t *testing.T) {
+		t.Parallel()
+
+		p := newACMEProvisioner(getControllerConfig(), fake.NewClientBuilder().WithScheme(scheme).Build())
+		order := &acmeOrder{operation: NewOperation(), dnsNames: []string{"a.example.com"}}
+		order.operation.Start(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		p.orders[acmeSecretNameFor(plan("a.example.com"))] = order
+
+		_, err := p.reconcile(ctx, namespace, settings, nil, nil)
+		require.NoError(t, err)
+		assert.ErrorIs(t, order.operation.Wait(), context.Canceled)
+		assert.Empty(t, p.orders)
+	})
+
+	t.Run("http-01 requires the challenge responder", func(t *testing.T) {
+		t.Parallel()
+
+		cl := fake.NewClientBuilder().WithScheme(scheme).Build()
+		p := newACMEProvisioner(getControllerConfig(), cl)
+		_, err := p.reconcile(ctx, namespace, settings, []plannedCertificate{plan("a.example.com")}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("wildcard names require dns-01", func(t *testing.T) {
+		t.Parallel()
+
+		p := newACMEProvisioner(getControllerConfig(), nil)
+		planned := []plannedCertificate{plan("*.example.com"), plan("a.example.org")}
+		assert.Equal(t, []plannedCertificate{plan("a.example.org")},
+			p.filter(ctx, settings, slices.Clone(planned)))
+
+		dns01 := &pomerium_ingress_v1.CertificateAutoProvisionACME{Directory: settings.Directory, DNS01Provider: new("webhook")}
+		assert.Equal(t, planned, p.filter(ctx, dns01, slices.Clone(planned)))
+	})
+}
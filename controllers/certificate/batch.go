@@ -0,0 +1,179 @@
+package certificate
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"strings"
+
+	certmanager_meta_v1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/hashicorp/go-set/v3"
+	"golang.org/x/net/publicsuffix"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+)
+
+// Certificate batch groupings.
+const (
+	batchGroupByRegisteredDomain = "registered_domain"
+	batchGroupByNamespace        = "namespace"
+	batchGroupByNone             = "none"
+)
+
+// certificateBatches packs names into certificates with multiple DNS names.
+type certificateBatches struct {
+	maxNames int
+	groupBy  string
+	// namespaces are the namespaces of the Ingress or HTTPRoute objects defining routes for the names,
+	// that names are grouped by with batchGroupByNamespace
+	namespaces map[string]string
+}
+
+type certificateBatch struct {
+	plannedCertificate
+	group string
+	// changed is set if names were removed from or added to the certificate
+	changed bool
+}
+
+func getCertificateBatches(settings *pomerium_ingress_v1.CertificateAutoProvisionBatch) *certificateBatches {
+	if settings == nil {
+		return nil
+	}
+
+	b := &certificateBatches{
+		maxNames: int(settings.MaxNames),
+		groupBy:  batchGroupByRegisteredDomain,
+	}
+	if settings.GroupBy != nil {
+		b.groupBy = *settings.GroupBy
+	}
+	return b
+}
+
+// setNamespaces records the namespace of the oldest object defining routes for each hostname.
+func (b *certificateBatches) setNamespaces(objects []hostnameObject) {
+	b.namespaces = make(map[string]string)
+	for _, obj := range objects {
+		for _, hostname := range obj.hostnames {
+			hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+			if _, ok := b.namespaces[hostname]; !ok {
+				b.namespaces[hostname] = obj.GetNamespace()
+			}
+		}
+	}
+}
+
+// group returns the group of names that may share a certificate with the name.
+// Names of an unknown namespace are grouped together with batchGroupByNamespace.
+func (b *certificateBatches) group(name string) string {
+	switch b.groupBy {
+	case batchGroupByNone:
+		return ""
+	case batchGroupByNamespace:
+		return b.namespaces[name]
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return name
+	}
+	return domain
+}
+
+// pack combines the planned certificates for single names into batches per issuer.
+// The existing certificates are either cert-manager certificates or secrets from the ACME server,
+// identified by their certificate name.
+//
+// Existing certificates keep the names that are still needed, so that unaffected
+// certificates are not reissued. Names under a pending wildcard certificate are
+// retained until the wildcard certificate is issued. New names are added to
+// certificates that changed anyway first, then to certificates that have room left,
+// and new certificates are only planned for the names that remain.
+func (b *certificateBatches) pack(
+	planned []plannedCertificate,
+	existing []plannedCertificate,
+	pendingWildcards *set.Set[string],
+) []plannedCertificate {
+	var out []plannedCertificate
+	names := make(map[string]certmanager_meta_v1.IssuerReference)
+	for _, p := range planned {
+		if len(p.dnsNames) == 1 && !strings.HasPrefix(p.dnsNames[0], "*.") {
+			names[p.dnsNames[0]] = p.issuer
+		} else {
+			out = append(out, p)
+		}
+	}
+
+	// keep the names that are still needed in the existing certificates
+	var batches []*certificateBatch
+	existing = slices.SortedFunc(slices.Values(existing), func(x, y plannedCertificate) int {
+		return cmp.Compare(x.certificate, y.certificate)
+	})
+	for _, current := range existing {
+		if len(current.dnsNames) > b.maxNames {
+			continue
+		}
+
+		batch := &certificateBatch{plannedCertificate: plannedCertificate{
+			issuer:      current.issuer,
+			certificate: current.certificate,
+		}}
+		for _, name := range current.dnsNames {
+			if issuer, ok := names[name]; ok {
+				if issuer != current.issuer {
+					continue
+				}
+			} else if !pendingWildcards.Contains(wildcardParent(name)) {
+				continue
+			}
+			group := b.group(name)
+			if len(batch.dnsNames) > 0 && group != batch.group {
+				continue
+			}
+			batch.group = group
+			batch.dnsNames = append(batch.dnsNames, name)
+			delete(names, name)
+		}
+		if len(batch.dnsNames) == 0 {
+			continue
+		}
+		batch.changed = len(batch.dnsNames) != len(current.dnsNames)
+		batches = append(batches, batch)
+	}
+
+	add := func(name string, onlyChanged bool) bool {
+		issuer, group := names[name], b.group(name)
+		for _, batch := range batches {
+			if batch.issuer != issuer || batch.group != group || len(batch.dnsNames) >= b.maxNames ||
+				(onlyChanged && !batch.changed) {
+				continue
+			}
+			batch.dnsNames = append(batch.dnsNames, name)
+			batch.changed = true
+			return true
+		}
+		return false
+	}
+
+	var remaining []string
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		if !add(name, true) {
+			remaining = append(remaining, name)
+		}
+	}
+	for _, name := range remaining {
+		if !add(name, false) {
+			batches = append(batches, &certificateBatch{
+				plannedCertificate: plannedCertificate{dnsNames: []string{name}, issuer: names[name]},
+				group:              b.group(name),
+				changed:            true,
+			})
+		}
+	}
+
+	for _, batch := range batches {
+		slices.Sort(batch.dnsNames)
+		out = append(out, batch.plannedCertificate)
+	}
+	return out
+}
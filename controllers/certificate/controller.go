@@ -19,17 +19,25 @@ import (
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	core_v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/tools/record"
 	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/util"
+	"github.com/pomerium/ingress-controller/util/metrics"
 	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
 	databrokerpb "github.com/pomerium/pomerium/pkg/grpc/databroker"
 	"github.com/pomerium/pomerium/pkg/grpcutil"
@@ -47,6 +55,11 @@ type certificateController struct {
 	dataBrokerClient databrokerpb.DataBrokerServiceClient
 
 	dataBrokerCollector *dataBrokerCollector
+	acme                *acmeProvisioner
+
+	recorder record.EventRecorder
+	// reportedMissing are the hostnames without a certificate already reported in events
+	reportedMissing map[string]bool
 }
 
 // NewCertificateController creates a new certificate controller.
@@ -54,27 +67,38 @@ func NewCertificateController(
 	mgr controllerruntime.Manager,
 	dataBrokerClient databrokerpb.DataBrokerServiceClient,
 	options ...Option,
-) {
+) error {
 	c := &certificateController{
 		cfg:              getControllerConfig(options...),
 		kubernetesClient: mgr.GetClient(),
 		dataBrokerClient: dataBrokerClient,
 	}
+	c.recorder = mgr.GetEventRecorderFor(c.cfg.controllerName)
 	c.dataBrokerCollector = newDataBrokerCollector(c)
+	c.acme = newACMEProvisioner(c.cfg, c.kubernetesClient)
+	if c.cfg.acmeHTTP01Addr != "" {
+		if err := mgr.Add(c.acme.responder); err != nil {
+			return fmt.Errorf("error adding acme http-01 challenge responder: %w", err)
+		}
+	}
 	go c.run(mgr)
+	return nil
 }
 
 func (c *certificateController) Reconcile(ctx context.Context, _ controllerruntime.Request) (res controllerruntime.Result, err error) {
+	defer func(start time.Time) { metrics.ObserveReconcile("Certificate", start, err) }(time.Now())
+
 	log.FromContext(ctx).Info("certificate-controller: reconciling")
-	return res, c.reconcile(ctx)
+	res.RequeueAfter, err = c.reconcile(ctx)
+	return res, err
 }
 
-func (c *certificateController) reconcile(ctx context.Context) error {
+func (c *certificateController) reconcile(ctx context.Context) (time.Duration, error) {
 	// retrieve the settings, certificates and secrets
 
 	var settings pomerium_ingress_v1.Pomerium
 	if err := c.kubernetesClient.Get(ctx, c.cfg.globalSettingsName, &settings); err != nil {
-		return fmt.Errorf("error retrieving pomerium settings: %w", err)
+		return 0, fmt.Errorf("error retrieving pomerium settings: %w", err)
 	}
 
 	var namespace string
@@ -87,7 +111,7 @@ func (c *certificateController) reconcile(ctx context.Context) error {
 	} else if settings.Spec.CertificateAutoProvision != nil && settings.Spec.CertificateAutoProvision.Issuer != nil {
 		name, err := util.ParseNamespacedName(*settings.Spec.CertificateAutoProvision.Issuer)
 		if err != nil {
-			return fmt.Errorf("error parsing certificate auto provision issuer: %w", err)
+			return 0, fmt.Errorf("error parsing certificate auto provision issuer: %w", err)
 		}
 		namespace = name.Namespace
 		issuer = certmanager_meta_v1.IssuerReference{
@@ -105,8 +129,47 @@ func (c *certificateController) reconcile(ctx context.Context) error {
 		var err error
 		namespace, err = GetInClusterNamespace()
 		if err != nil {
-			return err
+			return 0, err
+		}
+	}
+
+	var acmeSettings *pomerium_ingress_v1.CertificateAutoProvisionACME
+	if settings.Spec.CertificateAutoProvision != nil {
+		acmeSettings = settings.Spec.CertificateAutoProvision.ACME
+	}
+	if acmeSettings != nil && issuer.Name != "" {
+		return 0, fmt.Errorf("certificate auto provision may not use acme together with a cert-manager issuer")
+	}
+	// the ACME server issues the certificates that would otherwise come from the default issuer,
+	// while issuer rules and annotations may still select cert-manager issuers
+	if acmeSettings != nil {
+		issuer = acmeIssuerReference(acmeSettings)
+	}
+
+	issuers, err := c.getIssuerSelector(ctx, settings.Spec.CertificateAutoProvision, namespace, issuer)
+	if err != nil {
+		return 0, err
+	}
+
+	var wildcard *wildcardCertificates
+	var batches *certificateBatches
+	if settings.Spec.CertificateAutoProvision != nil {
+		wildcard, err = getWildcardCertificates(settings.Spec.CertificateAutoProvision.Wildcard, namespace, issuer)
+		if err != nil {
+			return 0, err
 		}
+		batches = getCertificateBatches(settings.Spec.CertificateAutoProvision.Batch)
+		if batches != nil && batches.groupBy == batchGroupByNamespace {
+			objects, err := c.listHostnameObjects(ctx)
+			if err != nil {
+				return 0, err
+			}
+			batches.setNamespaces(objects)
+		}
+	}
+	if wildcard != nil && isACMEIssuer(wildcard.issuer) && acmeSettings.DNS01Provider == nil {
+		log.FromContext(ctx).Info("certificate-controller: wildcard certificates from an acme server require a dns-01 provider, skipping")
+		wildcard = nil
 	}
 
 	var cl certmanager_v1.CertificateList
@@ -115,7 +178,10 @@ func (c *certificateController) reconcile(ctx context.Context) error {
 		client.MatchingLabels{
 			managedByLabelName: managedByLabelValue,
 		}); err != nil {
-		return fmt.Errorf("error listing certmanager certificates: %w", err)
+		// cert-manager is only required for certificates from cert-manager issuers
+		if !meta.IsNoMatchError(err) || (issuer.Name != "" && !isACMEIssuer(issuer)) {
+			return 0, fmt.Errorf("error listing certmanager certificates: %w", err)
+		}
 	}
 
 	var sl core_v1.SecretList
@@ -124,82 +190,161 @@ func (c *certificateController) reconcile(ctx context.Context) error {
 		client.MatchingLabels{
 			managedByLabelName: managedByLabelValue,
 		}); err != nil {
-		return fmt.Errorf("error listing secrets: %w", err)
+		return 0, fmt.Errorf("error listing secrets: %w", err)
+	}
+
+	planned, err := c.reconcileCertificates(ctx, namespace, issuers, wildcard, batches, cl.Items, sl.Items)
+	if err != nil {
+		return 0, errors.Join(err, c.reconcileSecrets(ctx, sl.Items), c.reportCoverage(ctx, &settings, sl.Items))
+	}
+	planned = c.acme.filter(ctx, acmeSettings, planned)
+
+	// publish the HTTP-01 challenge routes along with the issued certificates
+	var routes []*configpb.Route
+	if acmeSettings != nil && acmeSettings.DNS01Provider == nil {
+		c.acme.responder.enable()
+		routes = c.acme.challengeRoutes(plannedNames(planned))
+	}
+	if err := c.reconcileSecrets(ctx, sl.Items, routes...); err != nil {
+		return 0, errors.Join(err, c.reportCoverage(ctx, &settings, sl.Items))
 	}
 
-	return errors.Join(
-		c.reconcileCertificates(ctx, namespace, issuer, cl.Items),
-		c.reconcileSecrets(ctx, sl.Items),
-	)
+	requeueAfter, err := c.acme.reconcile(ctx, namespace, acmeSettings, planned, sl.Items)
+	return requeueAfter, errors.Join(err, c.reportCoverage(ctx, &settings, sl.Items))
 }
 
+// reconcileCertificates plans the certificates for the missing names and maintains the ones
+// from cert-manager issuers. It returns the planned certificates to order from the ACME server.
 func (c *certificateController) reconcileCertificates(
 	ctx context.Context,
 	namespace string,
-	issuer certmanager_meta_v1.IssuerReference,
+	issuers *issuerSelector,
+	wildcard *wildcardCertificates,
+	batches *certificateBatches,
 	certificates []certmanager_v1.Certificate,
-) error {
-	// delete any certificates with a different cluster issuer
-	var certificatesForIssuer []certmanager_v1.Certificate
-	for _, cert := range certificates {
-		if cert.Spec.IssuerRef.Kind != issuer.Kind || cert.Spec.IssuerRef.Name != issuer.Name {
+	secrets []core_v1.Secret,
+) ([]plannedCertificate, error) {
+	// if there's no issuer, don't provision any certificates
+	if issuers.defaultIssuer.Name == "" {
+		for _, cert := range certificates {
 			if err := c.deleteCertificate(ctx, &cert); err != nil {
-				return err
+				return nil, err
 			}
-		} else {
-			certificatesForIssuer = append(certificatesForIssuer, cert)
 		}
+		return nil, nil
 	}
 
-	// if there's no cluster issuer, stop the collector and don't provision any certificates
-	if issuer.Name == "" {
-		c.dataBrokerCollector.Stop()
-		return nil
+	// determine the certificates needed for the missing names
+	if err := c.dataBrokerCollector.Sync(); err != nil {
+		return nil, fmt.Errorf("error syncing databroker data: %w", err)
+	}
+	planned := planCertificates(c.dataBrokerCollector.MissingNames(), issuers, wildcard)
+
+	// wildcard certificates replace the certificates for names under them once they are issued,
+	// certificates from the ACME server are only saved once issued
+	existing := acmeCertificates(secrets)
+	ready := set.New[string](0)
+	for _, p := range existing {
+		ready.Insert(p.key())
+	}
+	for _, cert := range certificates {
+		current := plannedCertificateFor(&cert)
+		existing = append(existing, current)
+		if isCertificateReady(&cert) {
+			ready.Insert(current.key())
+		}
+	}
+	pendingWildcards := set.New[string](0)
+	for _, p := range planned {
+		if strings.HasPrefix(p.dnsNames[0], "*.") && !ready.Contains(p.key()) {
+			pendingWildcards.Insert(p.dnsNames[0])
+		}
 	}
 
-	// determine the missing names
-	if err := c.dataBrokerCollector.Sync(); err != nil {
-		return fmt.Errorf("error syncing databroker data: %w", err)
+	if batches != nil {
+		planned = batches.pack(planned, existing, pendingWildcards)
+	}
+	var acmePlanned []plannedCertificate
+	updates := make(map[string]plannedCertificate)
+	missing := make(map[string]plannedCertificate)
+	for _, p := range planned {
+		switch {
+		case isACMEIssuer(p.issuer):
+			acmePlanned = append(acmePlanned, p)
+		case p.certificate != "":
+			updates[p.certificate] = p
+		default:
+			missing[p.key()] = p
+		}
 	}
-	missingNames := set.From(c.dataBrokerCollector.MissingNames())
+	// certificates from cert-manager are kept until the ACME server issued the certificates replacing them
+	acmePending := acmePending(acmePlanned, secrets) > 0
 
-	// remove any missing names for which we've already created certificates
-	for _, cert := range certificatesForIssuer {
-		used := false
-		for _, name := range cert.Spec.DNSNames {
-			if missingNames.Contains(name) {
-				missingNames.Remove(name)
-				used = true
+	for _, cert := range certificates {
+		current := plannedCertificateFor(&cert)
+		// update certificates that were re-packed
+		if p, ok := updates[cert.Name]; ok {
+			delete(updates, cert.Name)
+			if current.key() != p.key() {
+				if err := c.updateCertificate(ctx, &cert, p.dnsNames); err != nil {
+					return nil, err
+				}
 			}
+			continue
 		}
-		// if the certificate is not being used, remove it
-		if !used {
-			if err := c.deleteCertificate(ctx, &cert); err != nil {
-				return err
-			}
+		// keep any certificates we've already created
+		if _, ok := missing[current.key()]; ok {
+			delete(missing, current.key())
+			continue
+		}
+		// remove the certificates that are not needed anymore, unless they are being
+		// replaced by a certificate that is not issued yet
+		if acmePending || slices.ContainsFunc(current.dnsNames, func(name string) bool {
+			return pendingWildcards.Contains(wildcardParent(name))
+		}) {
+			continue
+		}
+		if err := c.deleteCertificate(ctx, &cert); err != nil {
+			return nil, err
 		}
 	}
 
 	// create any certificates for any missing names
-	for name := range missingNames.Items() {
-		if err := c.createCertificate(ctx, namespace, issuer, name); err != nil {
-			return err
+	for _, p := range planned {
+		if _, ok := missing[p.key()]; !ok || p.certificate != "" {
+			continue
+		}
+		if err := c.createCertificate(ctx, namespace, p.issuer, p.dnsNames); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	return acmePlanned, nil
 }
 
+// plannedNames returns the names of the planned certificates.
+func plannedNames(planned []plannedCertificate) []string {
+	var names []string
+	for _, p := range planned {
+		names = append(names, p.dnsNames...)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// reconcileSecrets saves the certificates in the secrets to the databroker,
+// along with any routes the controller needs.
 func (c *certificateController) reconcileSecrets(
 	ctx context.Context,
 	secrets []core_v1.Secret,
+	routes ...*configpb.Route,
 ) error {
 	// make sure secrets are sorted so we get a deterministic encoding
 	secrets = slices.SortedFunc(slices.Values(secrets), func(x, y core_v1.Secret) int {
 		return cmp.Or(cmp.Compare(x.Name, y.Name), cmp.Compare(x.UID, y.UID))
 	})
 
-	cfg := new(configpb.Config)
+	cfg := &configpb.Config{Routes: routes}
 	for _, s := range secrets {
 		certPEM := s.Data["tls.crt"]
 		keyPEM := s.Data["tls.key"]
@@ -220,37 +365,51 @@ func (c *certificateController) reconcileSecrets(
 func (c *certificateController) run(mgr controllerruntime.Manager) {
 	ctx := context.Background()
 
-	// wait for all the CRDs to be available
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for _, gvk := range []schema.GroupVersionKind{
-		schema.FromAPIVersionAndKind("cert-manager.io/v1", "Certificate"),
-		schema.FromAPIVersionAndKind("ingress.pomerium.io/v1", "Pomerium"),
-	} {
-		for i := 0; ; i++ {
-			_, err := mgr.GetCache().GetInformerForKind(ctx, gvk)
-			if err == nil {
-				break
-			}
-			if i == 0 {
-				log.FromContext(ctx).
-					Info("certificate-controller: required CRD does not exist, the certificate controller will not run until it is created",
-						"controller", c.cfg.controllerName,
-						"group-version-kind", gvk.String())
-			}
-			<-ticker.C
-		}
-	}
+	c.waitForCRD(ctx, mgr, schema.FromAPIVersionAndKind("ingress.pomerium.io/v1", "Pomerium"),
+		"certificate-controller: required CRD does not exist, the certificate controller will not run until it is created")
 
-	err := controllerruntime.NewControllerManagedBy(mgr).
+	// Ingress and HTTPRoute annotations may select the issuer for their hostnames
+	annotationChanged := builder.WithPredicates(predicate.AnnotationChangedPredicate{})
+	b := controllerruntime.NewControllerManagedBy(mgr).
 		Named(c.cfg.controllerName).
 		Watches(new(core_v1.Secret), &handler.EnqueueRequestForObject{}).
 		Watches(new(pomerium_ingress_v1.Pomerium), &handler.EnqueueRequestForObject{}).
-		Watches(new(certmanager_v1.Certificate), &handler.EnqueueRequestForObject{}).
-		Complete(c)
+		Watches(new(networking_v1.Ingress), &handler.EnqueueRequestForObject{}, annotationChanged)
+	if c.cfg.httpRoutes {
+		b = b.Watches(new(gateway_v1.HTTPRoute), &handler.EnqueueRequestForObject{}, annotationChanged)
+	}
+	ctrl, err := b.Build(c)
 	if err != nil {
 		log.FromContext(ctx).Error(err, "error building certificate controller")
+		return
+	}
+
+	// cert-manager is not required when certificates are provisioned from an ACME server directly
+	c.waitForCRD(ctx, mgr, schema.FromAPIVersionAndKind("cert-manager.io/v1", "Certificate"),
+		"certificate-controller: cert-manager CRD does not exist, certificates can only be provisioned with acme until it is created")
+	err = ctrl.Watch(source.Kind(mgr.GetCache(), new(certmanager_v1.Certificate),
+		&handler.TypedEnqueueRequestForObject[*certmanager_v1.Certificate]{}))
+	if err != nil {
+		log.FromContext(ctx).Error(err, "error watching cert-manager certificates")
+	}
+}
+
+// waitForCRD blocks until the CRD for the kind is available.
+func (c *certificateController) waitForCRD(ctx context.Context, mgr controllerruntime.Manager, gvk schema.GroupVersionKind, msg string) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		_, err := mgr.GetCache().GetInformerForKind(ctx, gvk)
+		if err == nil {
+			return
+		}
+		if i == 0 {
+			log.FromContext(ctx).Info(msg,
+				"controller", c.cfg.controllerName,
+				"group-version-kind", gvk.String())
+		}
+		<-ticker.C
 	}
 }
 
@@ -258,7 +417,7 @@ func (c *certificateController) createCertificate(
 	ctx context.Context,
 	namespace string,
 	issuer certmanager_meta_v1.IssuerReference,
-	dnsName string,
+	dnsNames []string,
 ) error {
 	k8sName := "pomerium-certificate-" + rand.String(16)
 	cert := &certmanager_v1.Certificate{
@@ -276,7 +435,7 @@ func (c *certificateController) createCertificate(
 					managedByLabelName: managedByLabelValue,
 				},
 			},
-			DNSNames:  []string{dnsName},
+			DNSNames:  dnsNames,
 			IssuerRef: issuer,
 		},
 	}
@@ -284,13 +443,30 @@ func (c *certificateController) createCertificate(
 		"namespace", namespace,
 		"issuer-kind", issuer.Kind,
 		"issuer-name", issuer.Name,
-		"dns-name", dnsName)
+		"dns-names", dnsNames)
 	if err := c.kubernetesClient.Create(ctx, cert); err != nil {
 		return fmt.Errorf("error creating certificate: %w", err)
 	}
 	return nil
 }
 
+func (c *certificateController) updateCertificate(
+	ctx context.Context,
+	cert *certmanager_v1.Certificate,
+	dnsNames []string,
+) error {
+	log.FromContext(ctx).Info("certificate-controller: updating certificate",
+		"name", cert.Name,
+		"namespace", cert.Namespace,
+		"dns-names", dnsNames)
+	cert = cert.DeepCopy()
+	cert.Spec.DNSNames = dnsNames
+	if err := c.kubernetesClient.Update(ctx, cert); err != nil {
+		return fmt.Errorf("error updating certificate (%s/%s): %w", cert.Namespace, cert.Name, err)
+	}
+	return nil
+}
+
 func (c *certificateController) deleteCertificate(ctx context.Context, cert *certmanager_v1.Certificate) error {
 	log.FromContext(ctx).Info("certificate-controller: deleting certificate",
 		"name", cert.Name,
@@ -1,6 +1,11 @@
 package certificate
 
-import "k8s.io/apimachinery/pkg/types"
+import (
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
 
 // DefaultControllerName is the default controller name.
 var DefaultControllerName = "pomerium-certificate"
@@ -11,6 +16,21 @@ type controllerConfig struct {
 	// if not set, discover the namespace from the issuer or the pod where the
 	// controller is running
 	namespace *string
+	// httpRoutes enables selecting issuers with HTTPRoute annotations
+	httpRoutes bool
+
+	// acmeHTTP01Addr is the address the ACME HTTP-01 challenge responder listens on
+	acmeHTTP01Addr string
+	// acmeHTTP01URL is the URL Pomerium forwards ACME HTTP-01 challenges to
+	acmeHTTP01URL string
+	// acmeHTTPClient is used for requests to the ACME server
+	acmeHTTPClient *http.Client
+	// acmePropagationDelay is the time given to challenge routes and DNS records to propagate
+	acmePropagationDelay time.Duration
+	dnsProviders         map[string]DNSProvider
+
+	// coverageReport if set, receives the certificate coverage of the route hostnames
+	coverageReport *CoverageReport
 }
 
 // An Option customizes the config.
@@ -37,9 +57,66 @@ func WithNamespace(namespace string) Option {
 	}
 }
 
+// WithHTTPRoutes enables selecting certificate issuers with HTTPRoute annotations,
+// which requires the Gateway API CRDs to be installed.
+func WithHTTPRoutes() Option {
+	return func(cfg *controllerConfig) {
+		cfg.httpRoutes = true
+	}
+}
+
+// WithACMEHTTP01 serves ACME HTTP-01 challenges on the address, and routes the
+// challenges for names that need a certificate to the URL via Pomerium.
+// If the URL is empty, it is derived from the address.
+func WithACMEHTTP01(addr, url string) Option {
+	return func(cfg *controllerConfig) {
+		cfg.acmeHTTP01Addr = addr
+		cfg.acmeHTTP01URL = url
+		if url == "" {
+			cfg.acmeHTTP01URL = "http://" + addr
+		}
+	}
+}
+
+// WithACMEHTTPClient sets the HTTP client used for requests to the ACME server,
+// i.e. to trust the CA of a private ACME server.
+func WithACMEHTTPClient(client *http.Client) Option {
+	return func(cfg *controllerConfig) {
+		cfg.acmeHTTPClient = client
+	}
+}
+
+// WithACMEPropagationDelay sets how long challenge routes and DNS records
+// are given to propagate before the ACME server is asked to validate them.
+func WithACMEPropagationDelay(delay time.Duration) Option {
+	return func(cfg *controllerConfig) {
+		cfg.acmePropagationDelay = delay
+	}
+}
+
+// WithDNSProvider registers a DNS provider for ACME DNS-01 challenges,
+// that may be selected by name in the Pomerium settings.
+func WithDNSProvider(name string, provider DNSProvider) Option {
+	return func(cfg *controllerConfig) {
+		if cfg.dnsProviders == nil {
+			cfg.dnsProviders = make(map[string]DNSProvider)
+		}
+		cfg.dnsProviders[name] = provider
+	}
+}
+
+// WithCoverageReport keeps the certificate coverage of the route hostnames
+// in the report, so that it may be served for troubleshooting.
+func WithCoverageReport(report *CoverageReport) Option {
+	return func(cfg *controllerConfig) {
+		cfg.coverageReport = report
+	}
+}
+
 func getControllerConfig(options ...Option) *controllerConfig {
 	cfg := new(controllerConfig)
 	WithControllerName(DefaultControllerName)(cfg)
+	WithACMEPropagationDelay(10 * time.Second)(cfg)
 	for _, o := range options {
 		o(cfg)
 	}
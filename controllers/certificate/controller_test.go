@@ -2,6 +2,8 @@ package certificate
 
 import (
 	"context"
+	"maps"
+	"slices"
 	"testing"
 
 	certmanager_api "github.com/cert-manager/cert-manager/pkg/api"
@@ -10,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	core_v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -17,6 +20,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/internal/certificate"
 )
 
@@ -26,6 +30,7 @@ func TestReconcileCertificates(t *testing.T) {
 	ctx := context.Background()
 	namespace := "default"
 	issuer := certmanager_meta_v1.IssuerReference{Kind: "ClusterIssuer", Name: "letsencrypt"}
+	issuers := &issuerSelector{defaultIssuer: issuer}
 
 	scheme := runtime.NewScheme()
 	require.NoError(t, clientgoscheme.AddToScheme(scheme))
@@ -34,7 +39,7 @@ func TestReconcileCertificates(t *testing.T) {
 	newController := func(t *testing.T, initObjs ...client.Object) *certificateController {
 		t.Helper()
 		cl := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjs...).Build()
-		c := &certificateController{kubernetesClient: cl}
+		c := &certificateController{cfg: getControllerConfig(), kubernetesClient: cl}
 		c.dataBrokerCollector = newDataBrokerCollector(c)
 		return c
 	}
@@ -79,7 +84,7 @@ func TestReconcileCertificates(t *testing.T) {
 
 	t.Run("no issuer skips provisioning", func(t *testing.T) {
 		c := newController(t)
-		err := c.reconcileCertificates(ctx, namespace, certmanager_meta_v1.IssuerReference{}, nil)
+		_, err := c.reconcileCertificates(ctx, namespace, &issuerSelector{}, nil, nil, nil, nil)
 		assert.NoError(t, err)
 		assert.Empty(t, listCerts(t, c))
 	})
@@ -90,7 +95,7 @@ func TestReconcileCertificates(t *testing.T) {
 		secret := &core_v1.Secret{ObjectMeta: meta_v1.ObjectMeta{Namespace: namespace, Name: "a-secret"}}
 		c := newController(t, cert, secret)
 
-		err := c.reconcileCertificates(ctx, namespace, certmanager_meta_v1.IssuerReference{}, []certmanager_v1.Certificate{*cert})
+		_, err := c.reconcileCertificates(ctx, namespace, &issuerSelector{}, nil, nil, []certmanager_v1.Certificate{*cert}, nil)
 		require.NoError(t, err)
 
 		assert.Empty(t, listCerts(t, c), "mismatched cert should be deleted")
@@ -105,7 +110,7 @@ func TestReconcileCertificates(t *testing.T) {
 			{"r", "1"}: {"a.example.com"},
 		})
 
-		err := c.reconcileCertificates(ctx, namespace, issuer, []certmanager_v1.Certificate{*cert})
+		_, err := c.reconcileCertificates(ctx, namespace, issuers, nil, nil, []certmanager_v1.Certificate{*cert}, nil)
 		require.NoError(t, err)
 
 		remaining := listCerts(t, c)
@@ -121,7 +126,7 @@ func TestReconcileCertificates(t *testing.T) {
 			{"r", "1"}: {"b.example.com"},
 		})
 
-		err := c.reconcileCertificates(ctx, namespace, issuer, []certmanager_v1.Certificate{*certA, *certB})
+		_, err := c.reconcileCertificates(ctx, namespace, issuers, nil, nil, []certmanager_v1.Certificate{*certA, *certB}, nil)
 		require.NoError(t, err)
 
 		remaining := listCerts(t, c)
@@ -135,7 +140,7 @@ func TestReconcileCertificates(t *testing.T) {
 			{"r", "1"}: {"new.example.com"},
 		})
 
-		err := c.reconcileCertificates(ctx, namespace, issuer, nil)
+		_, err := c.reconcileCertificates(ctx, namespace, issuers, nil, nil, nil, nil)
 		require.NoError(t, err)
 
 		created := listCerts(t, c)
@@ -159,7 +164,7 @@ func TestReconcileCertificates(t *testing.T) {
 			{"r", "1"}: {"a.example.com"},
 		})
 
-		err := c.reconcileCertificates(ctx, namespace, issuer, []certmanager_v1.Certificate{*cert})
+		_, err := c.reconcileCertificates(ctx, namespace, issuers, nil, nil, []certmanager_v1.Certificate{*cert}, nil)
 		require.NoError(t, err)
 
 		created := listCerts(t, c)
@@ -167,4 +172,170 @@ func TestReconcileCertificates(t *testing.T) {
 			assert.Equal(t, issuer, created[0].Spec.IssuerRef)
 		}
 	})
+
+	t.Run("consolidates names under wildcard domains", func(t *testing.T) {
+		dns01 := certmanager_meta_v1.IssuerReference{Kind: "ClusterIssuer", Name: "letsencrypt-dns01"}
+		wildcard := &wildcardCertificates{domains: []string{"apps.example.com"}, issuer: dns01}
+		c := newController(t)
+		initDataBroker(t, c, map[recordKey][]string{
+			{"r", "1"}: {"a.apps.example.com", "b.apps.example.com"},
+			{"r", "2"}: {"apps.example.com", "x.y.apps.example.com", "other.example.org"},
+		})
+
+		_, err := c.reconcileCertificates(ctx, namespace, issuers, wildcard, nil, nil, nil)
+		require.NoError(t, err)
+
+		got := make(map[string]certmanager_meta_v1.IssuerReference)
+		for _, cert := range listCerts(t, c) {
+			require.Len(t, cert.Spec.DNSNames, 1)
+			got[cert.Spec.DNSNames[0]] = cert.Spec.IssuerRef
+		}
+		assert.Equal(t, map[string]certmanager_meta_v1.IssuerReference{
+			"*.apps.example.com":   dns01,
+			"apps.example.com":     issuer,
+			"x.y.apps.example.com": issuer,
+			"other.example.org":    issuer,
+		}, got)
+	})
+
+	t.Run("keeps certificates until the wildcard certificate is ready", func(t *testing.T) {
+		wildcard := &wildcardCertificates{domains: []string{"apps.example.com"}, issuer: issuer}
+		certA := makeCert("a", issuer, "a.apps.example.com")
+		certW := makeCert("w", issuer, "*.apps.example.com")
+		c := newController(t, certA, certW)
+		initDataBroker(t, c, map[recordKey][]string{
+			{"r", "1"}: {"a.apps.example.com"},
+		})
+
+		_, err := c.reconcileCertificates(ctx, namespace, issuers, wildcard, nil, []certmanager_v1.Certificate{*certA, *certW}, nil)
+		require.NoError(t, err)
+		assert.Len(t, listCerts(t, c), 2, "per-name certificate should be kept while the wildcard certificate is issued")
+
+		certW.Status.Conditions = []certmanager_v1.CertificateCondition{{
+			Type:   certmanager_v1.CertificateConditionReady,
+			Status: certmanager_meta_v1.ConditionTrue,
+		}}
+		_, err = c.reconcileCertificates(ctx, namespace, issuers, wildcard, nil, []certmanager_v1.Certificate{*certA, *certW}, nil)
+		require.NoError(t, err)
+		remaining := listCerts(t, c)
+		if assert.Len(t, remaining, 1) {
+			assert.Equal(t, "w", remaining[0].Name)
+		}
+	})
+
+	t.Run("packs names into certificates and re-packs them as names change", func(t *testing.T) {
+		batches := &certificateBatches{maxNames: 2, groupBy: batchGroupByRegisteredDomain}
+		c := newController(t)
+		initDataBroker(t, c, map[recordKey][]string{
+			{"r", "1"}: {"a.example.com", "b.example.com", "c.example.com", "x.example.org"},
+		})
+
+		dnsNames := func(certs []certmanager_v1.Certificate) map[string][]string {
+			m := make(map[string][]string)
+			for _, cert := range certs {
+				m[cert.Name] = cert.Spec.DNSNames
+			}
+			return m
+		}
+
+		_, err := c.reconcileCertificates(ctx, namespace, issuers, nil, batches, nil, nil)
+		require.NoError(t, err)
+		before := listCerts(t, c)
+		assert.ElementsMatch(t, [][]string{
+			{"a.example.com", "b.example.com"},
+			{"c.example.com"},
+			{"x.example.org"},
+		}, slices.Collect(maps.Values(dnsNames(before))))
+
+		c.dataBrokerCollector.matcher.Update(recordKey{"r", "1"}, nil, []string{"b.example.com", "c.example.com", "d.example.com", "x.example.org"})
+		_, err = c.reconcileCertificates(ctx, namespace, issuers, nil, batches, before, nil)
+		require.NoError(t, err)
+		after := listCerts(t, c)
+		assert.ElementsMatch(t, [][]string{
+			{"b.example.com", "d.example.com"},
+			{"c.example.com"},
+			{"x.example.org"},
+		}, slices.Collect(maps.Values(dnsNames(after))), "new name should replace the removed one")
+
+		for _, cert := range before {
+			if len(cert.Spec.DNSNames) == 1 {
+				idx := slices.IndexFunc(after, func(c certmanager_v1.Certificate) bool { return c.Name == cert.Name })
+				if assert.GreaterOrEqual(t, idx, 0, "unaffected certificate should be kept") {
+					assert.Equal(t, cert.ResourceVersion, after[idx].ResourceVersion, "unaffected certificate should not be modified")
+				}
+			}
+		}
+	})
+
+	t.Run("packs names per namespace", func(t *testing.T) {
+		ingress := func(namespace, name string, hosts ...string) *networking_v1.Ingress {
+			obj := &networking_v1.Ingress{ObjectMeta: meta_v1.ObjectMeta{Namespace: namespace, Name: name}}
+			for _, host := range hosts {
+				obj.Spec.Rules = append(obj.Spec.Rules, networking_v1.IngressRule{Host: host})
+			}
+			return obj
+		}
+		c := newController(t,
+			ingress("team-a", "apps", "a.example.com", "b.example.org"),
+			ingress("team-b", "apps", "c.example.com", "d.example.com", "e.example.com"),
+		)
+		initDataBroker(t, c, map[recordKey][]string{
+			{"r", "1"}: {"a.example.com", "b.example.org", "c.example.com", "d.example.com", "e.example.com"},
+		})
+
+		objects, err := c.listHostnameObjects(ctx)
+		require.NoError(t, err)
+		batches := &certificateBatches{maxNames: 2, groupBy: batchGroupByNamespace}
+		batches.setNamespaces(objects)
+		_, err = c.reconcileCertificates(ctx, namespace, issuers, nil, batches, nil, nil)
+		require.NoError(t, err)
+
+		var got [][]string
+		for _, cert := range listCerts(t, c) {
+			got = append(got, cert.Spec.DNSNames)
+		}
+		assert.ElementsMatch(t, [][]string{
+			{"a.example.com", "b.example.org"},
+			{"c.example.com", "d.example.com"},
+			{"e.example.com"},
+		}, got, "names should only share certificates with names of the same namespace")
+	})
+
+	t.Run("selects the issuer per name", func(t *testing.T) {
+		private := certmanager_meta_v1.IssuerReference{Kind: "ClusterIssuer", Name: "private-ca"}
+		corpIngress := &networking_v1.Ingress{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Namespace:   "apps",
+				Name:        "wiki",
+				Annotations: map[string]string{ClusterIssuerAnnotation: "private-ca"},
+			},
+			Spec: networking_v1.IngressSpec{Rules: []networking_v1.IngressRule{{Host: "wiki.example.com"}}},
+		}
+		c := newController(t, corpIngress)
+		initDataBroker(t, c, map[recordKey][]string{
+			{"r", "1"}: {"a.corp", "b.public.corp", "wiki.example.com", "www.example.com"},
+		})
+
+		selector, err := c.getIssuerSelector(ctx, &pomerium_ingress_v1.CertificateAutoProvision{
+			IssuerRules: []pomerium_ingress_v1.CertificateIssuerRule{
+				{Suffix: "corp", ClusterIssuer: new("private-ca")},
+				{Suffix: "public.corp", ClusterIssuer: new("letsencrypt")},
+			},
+		}, namespace, issuer)
+		require.NoError(t, err)
+		_, err = c.reconcileCertificates(ctx, namespace, selector, nil, nil, nil, nil)
+		require.NoError(t, err)
+
+		got := make(map[string]certmanager_meta_v1.IssuerReference)
+		for _, cert := range listCerts(t, c) {
+			require.Len(t, cert.Spec.DNSNames, 1)
+			got[cert.Spec.DNSNames[0]] = cert.Spec.IssuerRef
+		}
+		assert.Equal(t, map[string]certmanager_meta_v1.IssuerReference{
+			"a.corp":           private,
+			"b.public.corp":    issuer,
+			"wiki.example.com": private,
+			"www.example.com":  issuer,
+		}, got, "the annotation and the longest matching suffix should select the issuer")
+	})
 }
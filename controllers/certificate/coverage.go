@@ -0,0 +1,232 @@
+package certificate
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	core_v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/internal/certificate"
+)
+
+const reasonCertificateMissing = "CertificateMissing"
+
+// CoverageReport keeps the latest certificate coverage of the route hostnames,
+// and serves it over HTTP for troubleshooting. It is safe for concurrent use.
+type CoverageReport struct {
+	mu       sync.Mutex
+	coverage []pomerium_ingress_v1.CertificateStatus
+}
+
+// NewCoverageReport returns an empty coverage report.
+func NewCoverageReport() *CoverageReport {
+	return new(CoverageReport)
+}
+
+// set replaces the coverage. It is a no-op on a nil report.
+func (r *CoverageReport) set(coverage []pomerium_ingress_v1.CertificateStatus) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.coverage = coverage
+	r.mu.Unlock()
+}
+
+// ServeHTTP serves the coverage at /certificates
+func (r *CoverageReport) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.TrimSuffix(req.URL.Path, "/") != "/certificates" {
+		http.NotFound(w, req)
+		return
+	}
+
+	r.mu.Lock()
+	coverage := r.coverage
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(coverage)
+}
+
+// reportCoverage records which certificate covers each route hostname in the Pomerium status,
+// and reports the hostnames no certificate covers on the Ingress and HTTPRoute objects defining them.
+// The managed secrets hold the certificates provisioned by this controller.
+func (c *certificateController) reportCoverage(
+	ctx context.Context,
+	settings *pomerium_ingress_v1.Pomerium,
+	managed []core_v1.Secret,
+) error {
+	if c.dataBrokerClient == nil {
+		return nil
+	}
+	if err := c.dataBrokerCollector.Sync(); err != nil {
+		return fmt.Errorf("error syncing databroker data: %w", err)
+	}
+
+	objects, err := c.listHostnameObjects(ctx)
+	if err != nil {
+		return err
+	}
+	secrets, err := c.getCertificateSecrets(ctx, settings, objects, managed)
+	if err != nil {
+		return err
+	}
+	coverage := buildCoverage(c.dataBrokerCollector.Coverage(), managed, secrets)
+	c.cfg.coverageReport.set(coverage)
+	c.reportMissingNames(objects, coverage)
+
+	err = certificate.UpdateStatus(ctx, c.kubernetesClient, client.ObjectKeyFromObject(settings),
+		func(cur []pomerium_ingress_v1.CertificateStatus) []pomerium_ingress_v1.CertificateStatus {
+			return certificate.MergeCoverage(cur, coverage)
+		})
+	if err != nil {
+		return fmt.Errorf("error updating certificate coverage: %w", err)
+	}
+	return nil
+}
+
+// reportMissingNames emits a warning event on the objects defining a route hostname
+// once no certificate covers it.
+func (c *certificateController) reportMissingNames(objects []hostnameObject, coverage []pomerium_ingress_v1.CertificateStatus) {
+	missing := make(map[string]bool)
+	for _, hc := range coverage {
+		if hc.Reason == certificate.StatusMissing {
+			missing[hc.Hostname] = true
+		}
+	}
+	reported := c.reportedMissing
+	c.reportedMissing = missing
+	if c.recorder == nil {
+		return
+	}
+
+	for _, obj := range objects {
+		for _, hostname := range obj.hostnames {
+			hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+			if missing[hostname] && !reported[hostname] {
+				c.recorder.Eventf(obj.Object, core_v1.EventTypeWarning, reasonCertificateMissing,
+					"no certificate covers hostname %s", hostname)
+			}
+		}
+	}
+}
+
+// getCertificateSecrets returns the secrets referenced by the Pomerium settings, Ingress and Gateway objects,
+// along with the managed secrets, by the SHA-256 fingerprint of their certificates.
+func (c *certificateController) getCertificateSecrets(
+	ctx context.Context,
+	settings *pomerium_ingress_v1.Pomerium,
+	objects []hostnameObject,
+	managed []core_v1.Secret,
+) (map[[sha256.Size]byte]types.NamespacedName, error) {
+	refs := certificate.SettingsSecretRefs(settings)
+	for _, obj := range objects {
+		if ingress, ok := obj.Object.(*networking_v1.Ingress); ok {
+			refs = append(refs, certificate.IngressSecretRefs(ingress)...)
+		}
+	}
+	if c.cfg.httpRoutes {
+		var gl gateway_v1.GatewayList
+		if err := c.kubernetesClient.List(ctx, &gl); err != nil {
+			return nil, fmt.Errorf("error listing gateways: %w", err)
+		}
+		for i := range gl.Items {
+			refs = append(refs, certificate.GatewaySecretRefs(&gl.Items[i])...)
+		}
+	}
+
+	out := make(map[[sha256.Size]byte]types.NamespacedName)
+	seen := make(map[types.NamespacedName]struct{})
+	add := func(name types.NamespacedName, secret *core_v1.Secret) {
+		for cert := range certificate.IterateServerCertificatesFromPEM(secret.Data[core_v1.TLSCertKey]) {
+			out[sha256.Sum256(cert.Raw)] = name
+		}
+	}
+	for _, ref := range refs {
+		if _, ok := seen[ref.Secret]; ok {
+			continue
+		}
+		seen[ref.Secret] = struct{}{}
+		var secret core_v1.Secret
+		if err := c.kubernetesClient.Get(ctx, ref.Secret, &secret); apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("error getting secret %s: %w", ref.Secret, err)
+		}
+		add(ref.Secret, &secret)
+	}
+	for _, secret := range managed {
+		add(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, &secret)
+	}
+	return out, nil
+}
+
+// buildCoverage picks the latest expiring certificate covering each hostname, either from the databroker
+// or one of the managed secrets, which the databroker collector ignores.
+func buildCoverage(
+	hostnames []hostnameCoverage,
+	managed []core_v1.Secret,
+	secrets map[[sha256.Size]byte]types.NamespacedName,
+) []pomerium_ingress_v1.CertificateStatus {
+	var managedCerts []*x509.Certificate
+	for _, secret := range managed {
+		managedCerts = slices.AppendSeq(managedCerts, certificate.IterateServerCertificatesFromPEM(secret.Data[core_v1.TLSCertKey]))
+	}
+
+	var out []pomerium_ingress_v1.CertificateStatus
+	for _, hc := range hostnames {
+		var best *coveringCertificate
+		candidates := slices.Clone(hc.certificates)
+		for _, cert := range managedCerts {
+			if cert.VerifyHostname(hc.name) == nil {
+				candidates = append(candidates, coveringCertificate{cert: cert})
+			}
+		}
+		for i := range candidates {
+			if best == nil || candidates[i].cert.NotAfter.After(best.cert.NotAfter) {
+				best = &candidates[i]
+			}
+		}
+
+		if best == nil {
+			out = append(out, pomerium_ingress_v1.CertificateStatus{
+				Hostname: hc.name,
+				Reason:   certificate.StatusMissing,
+				Message:  "no certificate covers the hostname",
+			})
+			continue
+		}
+		cc := pomerium_ingress_v1.CertificateStatus{
+			Hostname: hc.name,
+			Issuer:   best.cert.Issuer.String(),
+			NotAfter: &meta_v1.Time{Time: best.cert.NotAfter},
+		}
+		if name, ok := secrets[sha256.Sum256(best.cert.Raw)]; ok {
+			cc.Secret = name.String()
+		} else {
+			cc.Record = best.record.ID
+		}
+		out = append(out, cc)
+	}
+	return out
+}
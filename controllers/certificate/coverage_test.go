@@ -0,0 +1,99 @@
+package certificate
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/internal/certificate"
+)
+
+func TestBuildCoverage(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+	makeCert := func(issuer string, notAfter time.Time, dnsNames ...string) *x509.Certificate {
+		key, _, err := generateKey()
+		require.NoError(t, err)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: issuer},
+			DNSNames:     dnsNames,
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     notAfter,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert
+	}
+
+	wildcard := makeCert("wildcard", now.Add(30*24*time.Hour), "*.example.com")
+	renewed := makeCert("renewed", now.Add(60*24*time.Hour), "app.example.com")
+	provisioned := makeCert("provisioned", now.Add(90*24*time.Hour), "provisioned.example.com")
+	managed := []core_v1.Secret{{
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: "pomerium", Name: "pomerium-certificate-1"},
+		Data: map[string][]byte{
+			core_v1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: provisioned.Raw}),
+		},
+	}}
+	secrets := map[[sha256.Size]byte]types.NamespacedName{
+		sha256.Sum256(wildcard.Raw):    {Namespace: "default", Name: "wildcard-tls"},
+		sha256.Sum256(provisioned.Raw): {Namespace: "pomerium", Name: "pomerium-certificate-1"},
+	}
+
+	coverage := buildCoverage([]hostnameCoverage{
+		{name: "api.example.com", certificates: []coveringCertificate{
+			{record: recordKey{ID: "ingress-controller"}, cert: wildcard},
+		}},
+		{name: "app.example.com", certificates: []coveringCertificate{
+			{record: recordKey{ID: "ingress-controller"}, cert: wildcard},
+			{record: recordKey{ID: "static"}, cert: renewed},
+		}},
+		{name: "missing.other.com"},
+		{name: "provisioned.example.com"},
+	}, managed, secrets)
+
+	assert.Equal(t, []pomerium_ingress_v1.CertificateStatus{
+		{Hostname: "api.example.com", Secret: "default/wildcard-tls", Issuer: "CN=wildcard", NotAfter: &meta_v1.Time{Time: wildcard.NotAfter}},
+		{Hostname: "app.example.com", Record: "static", Issuer: "CN=renewed", NotAfter: &meta_v1.Time{Time: renewed.NotAfter}},
+		{Hostname: "missing.other.com", Reason: certificate.StatusMissing, Message: "no certificate covers the hostname"},
+		{Hostname: "provisioned.example.com", Secret: "pomerium/pomerium-certificate-1", Issuer: "CN=provisioned", NotAfter: &meta_v1.Time{Time: provisioned.NotAfter}},
+	}, coverage)
+
+	t.Run("report", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewCoverageReport()
+		r.set(coverage)
+
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/certificates", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got []pomerium_ingress_v1.CertificateStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Len(t, got, 4)
+		assert.Equal(t, certificate.StatusMissing, got[2].Reason)
+
+		rec = httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
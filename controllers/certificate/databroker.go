@@ -2,6 +2,7 @@ package certificate
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
@@ -40,8 +41,21 @@ type dataBrokerCollector struct {
 	configServerVersion, configRecordVersion                   uint64
 	versionedConfigServerVersion, versionedConfigRecordVersion uint64
 
-	mu      sync.Mutex
-	matcher certificate.Matcher[recordKey]
+	mu           sync.Mutex
+	matcher      certificate.Matcher[recordKey]
+	certificates map[recordKey][]*x509.Certificate
+}
+
+// coveringCertificate is a certificate from a databroker record matching a route hostname.
+type coveringCertificate struct {
+	record recordKey
+	cert   *x509.Certificate
+}
+
+// hostnameCoverage is a route hostname along with the certificates matching it.
+type hostnameCoverage struct {
+	name         string
+	certificates []coveringCertificate
 }
 
 func newDataBrokerCollector(controller *certificateController) *dataBrokerCollector {
@@ -60,9 +74,28 @@ func (c *dataBrokerCollector) MissingNames() []string {
 	return missingNames
 }
 
-// Stop will stop any background sync processes.
-func (c *dataBrokerCollector) Stop() {
-	_ = c.operation.Stop()
+// Coverage returns all the route hostnames, sorted, along with the certificates
+// from the databroker matching them. It returns nil if no data has been synced yet.
+func (c *dataBrokerCollector) Coverage() []hostnameCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.matcher == nil {
+		return nil
+	}
+	var out []hostnameCoverage
+	for _, nc := range c.matcher.Coverage() {
+		hc := hostnameCoverage{name: nc.Name}
+		for _, key := range nc.Keys {
+			for _, cert := range c.certificates[key] {
+				if cert.VerifyHostname(nc.Name) == nil {
+					hc.certificates = append(hc.certificates, coveringCertificate{record: key, cert: cert})
+				}
+			}
+		}
+		out = append(out, hc)
+	}
+	return out
 }
 
 // Sync syncs data from the databroker. If no data has been synced successfully
@@ -106,6 +139,7 @@ func (c *dataBrokerCollector) Sync() error {
 func (c *dataBrokerCollector) init(ctx context.Context) error {
 	c.mu.Lock()
 	c.matcher = certificate.NewMatcher[recordKey]()
+	c.certificates = make(map[recordKey][]*x509.Certificate)
 	c.mu.Unlock()
 
 	eg, ctx := errgroup.WithContext(ctx)
@@ -113,14 +147,11 @@ func (c *dataBrokerCollector) init(ctx context.Context) error {
 		var err error
 		c.keyPairServerVersion, c.keyPairRecordVersion, err = syncLatestRecords(ctx, c.controller.dataBrokerClient,
 			func(record *databrokerpb.Record, keyPair *configpb.KeyPair) {
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				c.updateRecord(recordKey{Type: record.GetType(), ID: record.GetId()},
 					removeEmpty([]*configpb.KeyPair{keyPair}),
 					nil,
 					nil,
 				)
-				c.mu.Lock()
-				c.matcher.Update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
-				c.mu.Unlock()
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing latest key pairs: %w", err)
@@ -131,14 +162,11 @@ func (c *dataBrokerCollector) init(ctx context.Context) error {
 		var err error
 		c.routeServerVersion, c.routeRecordVersion, err = syncLatestRecords(ctx, c.controller.dataBrokerClient,
 			func(record *databrokerpb.Record, route *configpb.Route) {
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				c.updateRecord(recordKey{Type: record.GetType(), ID: record.GetId()},
 					nil,
 					removeEmpty([]*configpb.Route{route}),
 					nil,
 				)
-				c.mu.Lock()
-				c.matcher.Update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
-				c.mu.Unlock()
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing latest routes: %w", err)
@@ -149,14 +177,11 @@ func (c *dataBrokerCollector) init(ctx context.Context) error {
 		var err error
 		c.settingsServerVersion, c.settingsRecordVersion, err = syncLatestRecords(ctx, c.controller.dataBrokerClient,
 			func(record *databrokerpb.Record, settings *configpb.Settings) {
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				c.updateRecord(recordKey{Type: record.GetType(), ID: record.GetId()},
 					nil,
 					nil,
 					removeEmpty([]*configpb.Settings{settings}),
 				)
-				c.mu.Lock()
-				c.matcher.Update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
-				c.mu.Unlock()
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing latest settings: %w", err)
@@ -171,14 +196,11 @@ func (c *dataBrokerCollector) init(ctx context.Context) error {
 				if record.GetId() == dataBrokerConfigRecordID {
 					return
 				}
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				c.updateRecord(recordKey{Type: record.GetType(), ID: record.GetId()},
 					nil,
 					removeEmpty(config.GetRoutes()),
 					removeEmpty([]*configpb.Settings{config.GetSettings()}),
 				)
-				c.mu.Lock()
-				c.matcher.Update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
-				c.mu.Unlock()
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing latest configs: %w", err)
@@ -189,14 +211,11 @@ func (c *dataBrokerCollector) init(ctx context.Context) error {
 		var err error
 		c.versionedConfigServerVersion, c.versionedConfigRecordVersion, err = syncLatestRecords(ctx, c.controller.dataBrokerClient,
 			func(record *databrokerpb.Record, versionedConfig *configpb.VersionedConfig) {
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				c.updateRecord(recordKey{Type: record.GetType(), ID: record.GetId()},
 					nil,
 					removeEmpty(versionedConfig.GetConfig().GetRoutes()),
 					removeEmpty([]*configpb.Settings{versionedConfig.GetConfig().GetSettings()}),
 				)
-				c.mu.Lock()
-				c.matcher.Update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
-				c.mu.Unlock()
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing latest versioned configs: %w", err)
@@ -207,13 +226,11 @@ func (c *dataBrokerCollector) init(ctx context.Context) error {
 }
 
 func (c *dataBrokerCollector) sync(ctx context.Context) error {
-	update := func(key recordKey, certificateNames, routeNames []string) {
+	update := func(key recordKey, keyPairs []*configpb.KeyPair, routes []*configpb.Route, settings []*configpb.Settings) {
 		log.FromContext(ctx).Info("certificate-controller: databroker record updated",
 			"record-type", key.Type,
 			"record-id", key.ID)
-		c.mu.Lock()
-		c.matcher.Update(key, certificateNames, routeNames)
-		c.mu.Unlock()
+		c.updateRecord(key, keyPairs, routes, settings)
 
 		if err := c.controller.kubernetesClient.Status().Patch(ctx, &pomerium_ingress_v1.Pomerium{
 			ObjectMeta: meta_v1.ObjectMeta{
@@ -238,12 +255,11 @@ func (c *dataBrokerCollector) sync(ctx context.Context) error {
 		err := syncRecords(ctx, c.controller.dataBrokerClient,
 			c.keyPairServerVersion, c.keyPairRecordVersion,
 			func(record *databrokerpb.Record, keyPair *configpb.KeyPair) {
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				update(recordKey{Type: record.GetType(), ID: record.GetId()},
 					removeEmpty([]*configpb.KeyPair{keyPair}),
 					nil,
 					nil,
 				)
-				update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing key pairs: %w", err)
@@ -254,12 +270,11 @@ func (c *dataBrokerCollector) sync(ctx context.Context) error {
 		err := syncRecords(ctx, c.controller.dataBrokerClient,
 			c.routeServerVersion, c.routeRecordVersion,
 			func(record *databrokerpb.Record, route *configpb.Route) {
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				update(recordKey{Type: record.GetType(), ID: record.GetId()},
 					nil,
 					removeEmpty([]*configpb.Route{route}),
 					nil,
 				)
-				update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing routes: %w", err)
@@ -270,12 +285,11 @@ func (c *dataBrokerCollector) sync(ctx context.Context) error {
 		err := syncRecords(ctx, c.controller.dataBrokerClient,
 			c.settingsServerVersion, c.settingsRecordVersion,
 			func(record *databrokerpb.Record, settings *configpb.Settings) {
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				update(recordKey{Type: record.GetType(), ID: record.GetId()},
 					nil,
 					nil,
 					removeEmpty([]*configpb.Settings{settings}),
 				)
-				update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing settings: %w", err)
@@ -290,12 +304,11 @@ func (c *dataBrokerCollector) sync(ctx context.Context) error {
 				if record.GetId() == dataBrokerConfigRecordID {
 					return
 				}
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				update(recordKey{Type: record.GetType(), ID: record.GetId()},
 					nil,
 					removeEmpty(config.GetRoutes()),
 					removeEmpty([]*configpb.Settings{config.Settings}),
 				)
-				update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing configs: %w", err)
@@ -306,12 +319,11 @@ func (c *dataBrokerCollector) sync(ctx context.Context) error {
 		err := syncRecords(ctx, c.controller.dataBrokerClient,
 			c.versionedConfigServerVersion, c.versionedConfigRecordVersion,
 			func(record *databrokerpb.Record, versionedConfig *configpb.VersionedConfig) {
-				certificateNames, routeNames := certificate.GetNamesFromConfig(
+				update(recordKey{Type: record.GetType(), ID: record.GetId()},
 					nil,
 					removeEmpty(versionedConfig.GetConfig().GetRoutes()),
 					removeEmpty([]*configpb.Settings{versionedConfig.GetConfig().GetSettings()}),
 				)
-				update(recordKey{Type: record.GetType(), ID: record.GetId()}, certificateNames, routeNames)
 			})
 		if err != nil {
 			return fmt.Errorf("error syncing configs: %w", err)
@@ -321,6 +333,34 @@ func (c *dataBrokerCollector) sync(ctx context.Context) error {
 	return eg.Wait()
 }
 
+// updateRecord updates the names and certificates found in a databroker record.
+func (c *dataBrokerCollector) updateRecord(
+	key recordKey,
+	keyPairs []*configpb.KeyPair,
+	routes []*configpb.Route,
+	settings []*configpb.Settings,
+) {
+	certificateNames, routeNames := certificate.GetNamesFromConfig(keyPairs, routes, settings)
+	var certs []*x509.Certificate
+	for _, kp := range keyPairs {
+		certs = slices.AppendSeq(certs, certificate.IterateServerCertificatesFromPEM(kp.GetCertificate()))
+	}
+	for _, s := range settings {
+		for _, sc := range s.GetCertificates() {
+			certs = slices.AppendSeq(certs, certificate.IterateServerCertificatesFromPEM(sc.GetCertBytes()))
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.matcher.Update(key, certificateNames, routeNames)
+	if len(certs) == 0 {
+		delete(c.certificates, key)
+	} else {
+		c.certificates[key] = certs
+	}
+}
+
 func syncRecords[T any, TMsg interface {
 	*T
 	proto.Message
@@ -0,0 +1,67 @@
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DNSWebhookProviderName is the name the webhook DNS provider is selected by in the Pomerium settings.
+const DNSWebhookProviderName = "webhook"
+
+type dnsWebhookProvider struct {
+	url    string
+	client *http.Client
+}
+
+type dnsWebhookRecord struct {
+	FQDN  string `json:"fqdn"`
+	Value string `json:"value"`
+}
+
+// NewDNSWebhookProvider returns a DNS provider that delegates publishing the TXT records
+// to a webhook, for DNS services the controller has no built-in support for.
+// The record is sent as JSON, i.e. {"fqdn":"_acme-challenge.example.com.","value":"..."},
+// in a POST request to create it and in a DELETE request to remove it.
+// Any response status other than 2xx is an error.
+func NewDNSWebhookProvider(url string, client *http.Client) DNSProvider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &dnsWebhookProvider{url: url, client: client}
+}
+
+func (p *dnsWebhookProvider) Present(ctx context.Context, fqdn, value string) error {
+	return p.do(ctx, http.MethodPost, fqdn, value)
+}
+
+func (p *dnsWebhookProvider) CleanUp(ctx context.Context, fqdn, value string) error {
+	return p.do(ctx, http.MethodDelete, fqdn, value)
+}
+
+func (p *dnsWebhookProvider) do(ctx context.Context, method, fqdn, value string) error {
+	body, err := json.Marshal(dnsWebhookRecord{FQDN: fqdn, Value: value})
+	if err != nil {
+		return fmt.Errorf("error encoding dns webhook request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating dns webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling dns webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("dns webhook %s %s: %s: %s", method, fqdn, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package certificate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSWebhookProvider(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var record dnsWebhookRecord
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&record)) {
+			return
+		}
+		got = append(got, r.Method+" "+record.FQDN+" "+record.Value)
+		if record.FQDN == "_acme-challenge.denied.example.com" {
+			http.Error(w, "zone not managed", http.StatusForbidden)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	p := NewDNSWebhookProvider(srv.URL, srv.Client())
+	require.NoError(t, p.Present(t.Context(), "_acme-challenge.example.com", "value"))
+	require.NoError(t, p.CleanUp(t.Context(), "_acme-challenge.example.com", "value"))
+	assert.Equal(t, []string{
+		"POST _acme-challenge.example.com value",
+		"DELETE _acme-challenge.example.com value",
+	}, got)
+
+	err := p.Present(t.Context(), "_acme-challenge.denied.example.com", "value")
+	assert.ErrorContains(t, err, "zone not managed")
+}
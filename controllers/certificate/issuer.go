@@ -0,0 +1,205 @@
+package certificate
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	certmanager_meta_v1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+// Annotations of Ingress and HTTPRoute objects that select the issuer
+// of auto-provisioned certificates for their hostnames.
+const (
+	// ClusterIssuerAnnotation is the name of a cert-manager ClusterIssuer.
+	ClusterIssuerAnnotation = "certificate.pomerium.io/cluster-issuer"
+	// IssuerAnnotation is the namespace/name of a cert-manager Issuer,
+	// that must be in the same namespace as the other certificates.
+	IssuerAnnotation = "certificate.pomerium.io/issuer"
+)
+
+// issuerRule selects the issuer for names under a domain suffix.
+type issuerRule struct {
+	suffix string
+	issuer certmanager_meta_v1.IssuerReference
+}
+
+// issuerSelector chooses the issuer of the certificate for a name.
+type issuerSelector struct {
+	defaultIssuer certmanager_meta_v1.IssuerReference
+	rules         []issuerRule
+	// overrides are the issuers selected by annotations, by hostname
+	overrides map[string]certmanager_meta_v1.IssuerReference
+}
+
+// issuer returns the issuer selected by annotations, then the rule with the longest
+// matching suffix, and the default issuer otherwise.
+func (s *issuerSelector) issuer(name string) certmanager_meta_v1.IssuerReference {
+	if issuer, ok := s.overrides[name]; ok {
+		return issuer
+	}
+
+	var best *issuerRule
+	for i, rule := range s.rules {
+		if name != rule.suffix && !strings.HasSuffix(name, "."+rule.suffix) {
+			continue
+		}
+		if best == nil || len(rule.suffix) > len(best.suffix) {
+			best = &s.rules[i]
+		}
+	}
+	if best != nil {
+		return best.issuer
+	}
+	return s.defaultIssuer
+}
+
+// overridden returns whether the issuer for the name is selected by an annotation.
+func (s *issuerSelector) overridden(name string) bool {
+	_, ok := s.overrides[name]
+	return ok
+}
+
+// parseIssuerReference returns a reference to either the ClusterIssuer or the Issuer,
+// that must be in the namespace where certificates are created.
+func parseIssuerReference(clusterIssuer, issuer *string, namespace string) (certmanager_meta_v1.IssuerReference, bool, error) {
+	switch {
+	case clusterIssuer != nil:
+		return certmanager_meta_v1.IssuerReference{
+			Kind: "ClusterIssuer",
+			Name: *clusterIssuer,
+		}, true, nil
+	case issuer != nil:
+		name, err := util.ParseNamespacedName(*issuer)
+		if err != nil {
+			return certmanager_meta_v1.IssuerReference{}, false, err
+		}
+		if name.Namespace != namespace {
+			return certmanager_meta_v1.IssuerReference{}, false,
+				fmt.Errorf("issuer %s must be in the %s namespace, where certificates are created", name, namespace)
+		}
+		return certmanager_meta_v1.IssuerReference{
+			Kind: "Issuer",
+			Name: name.Name,
+		}, true, nil
+	default:
+		return certmanager_meta_v1.IssuerReference{}, false, nil
+	}
+}
+
+func (c *certificateController) getIssuerSelector(
+	ctx context.Context,
+	settings *pomerium_ingress_v1.CertificateAutoProvision,
+	namespace string,
+	defaultIssuer certmanager_meta_v1.IssuerReference,
+) (*issuerSelector, error) {
+	s := &issuerSelector{
+		defaultIssuer: defaultIssuer,
+		overrides:     make(map[string]certmanager_meta_v1.IssuerReference),
+	}
+	if settings == nil || defaultIssuer.Name == "" {
+		return s, nil
+	}
+
+	for _, rule := range settings.IssuerRules {
+		issuer, ok, err := parseIssuerReference(rule.ClusterIssuer, rule.Issuer, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing issuer rule for %s: %w", rule.Suffix, err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("issuer rule for %s must set either clusterIssuer or issuer", rule.Suffix)
+		}
+		s.rules = append(s.rules, issuerRule{
+			suffix: strings.ToLower(strings.TrimSuffix(rule.Suffix, ".")),
+			issuer: issuer,
+		})
+	}
+
+	objects, err := c.listHostnameObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		var clusterIssuer, issuerName *string
+		if v, ok := obj.GetAnnotations()[ClusterIssuerAnnotation]; ok {
+			clusterIssuer = &v
+		}
+		if v, ok := obj.GetAnnotations()[IssuerAnnotation]; ok {
+			issuerName = &v
+		}
+		if clusterIssuer == nil && issuerName == nil {
+			continue
+		}
+		issuer, _, err := parseIssuerReference(clusterIssuer, issuerName, namespace)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "certificate-controller: ignoring invalid issuer annotation",
+				"namespace", obj.GetNamespace(),
+				"name", obj.GetName())
+			continue
+		}
+		for _, hostname := range obj.hostnames {
+			hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+			if _, ok := s.overrides[hostname]; !ok {
+				s.overrides[hostname] = issuer
+			}
+		}
+	}
+	return s, nil
+}
+
+// hostnameObject is an Ingress or HTTPRoute along with the hostnames it defines routes for.
+type hostnameObject struct {
+	client.Object
+	hostnames []string
+}
+
+// listHostnameObjects lists the Ingress and, if enabled, HTTPRoute objects,
+// oldest first, so that the oldest object takes precedence if several refer to the same hostname.
+func (c *certificateController) listHostnameObjects(ctx context.Context) ([]hostnameObject, error) {
+	var il networking_v1.IngressList
+	if err := c.kubernetesClient.List(ctx, &il); err != nil {
+		return nil, fmt.Errorf("error listing ingresses: %w", err)
+	}
+	var hrl gateway_v1.HTTPRouteList
+	if c.cfg.httpRoutes {
+		if err := c.kubernetesClient.List(ctx, &hrl); err != nil {
+			return nil, fmt.Errorf("error listing http routes: %w", err)
+		}
+	}
+
+	var objects []hostnameObject
+	for _, ingress := range il.Items {
+		obj := hostnameObject{Object: &ingress}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host != "" {
+				obj.hostnames = append(obj.hostnames, rule.Host)
+			}
+		}
+		objects = append(objects, obj)
+	}
+	for _, route := range hrl.Items {
+		obj := hostnameObject{Object: &route}
+		for _, hostname := range route.Spec.Hostnames {
+			obj.hostnames = append(obj.hostnames, string(hostname))
+		}
+		objects = append(objects, obj)
+	}
+
+	slices.SortStableFunc(objects, func(x, y hostnameObject) int {
+		return cmp.Or(
+			x.GetCreationTimestamp().Compare(y.GetCreationTimestamp().Time),
+			cmp.Compare(x.GetNamespace(), y.GetNamespace()),
+			cmp.Compare(x.GetName(), y.GetName()),
+		)
+	})
+	return objects, nil
+}
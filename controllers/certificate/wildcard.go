@@ -0,0 +1,128 @@
+package certificate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	certmanager_v1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	certmanager_meta_v1 "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/internal/certificate"
+)
+
+// wildcardCertificates consolidates names directly under the domains
+// into a single wildcard certificate per domain.
+type wildcardCertificates struct {
+	domains []string
+	issuer  certmanager_meta_v1.IssuerReference
+}
+
+// A plannedCertificate is a certificate the controller should maintain.
+type plannedCertificate struct {
+	dnsNames []string
+	issuer   certmanager_meta_v1.IssuerReference
+	// certificate is the name of an existing certificate
+	// that should be updated to match the plan
+	certificate string
+}
+
+// key identifies certificates with the same issuer and names.
+func (p plannedCertificate) key() string {
+	return p.issuer.Kind + "/" + p.issuer.Name + ":" + strings.Join(p.dnsNames, ",")
+}
+
+func getWildcardCertificates(
+	settings *pomerium_ingress_v1.CertificateAutoProvisionWildcard,
+	namespace string,
+	issuer certmanager_meta_v1.IssuerReference,
+) (*wildcardCertificates, error) {
+	if settings == nil {
+		return nil, nil
+	}
+
+	wc := &wildcardCertificates{issuer: issuer}
+	for _, domain := range settings.Domains {
+		wc.domains = append(wc.domains, strings.ToLower(strings.TrimSuffix(domain, ".")))
+	}
+	if issuer, ok, err := parseIssuerReference(settings.ClusterIssuer, settings.Issuer, namespace); err != nil {
+		return nil, fmt.Errorf("error parsing wildcard certificate issuer: %w", err)
+	} else if ok {
+		wc.issuer = issuer
+	}
+	return wc, nil
+}
+
+// planCertificates returns the certificates that cover the missing names:
+// names directly under one of the wildcard domains share a wildcard certificate,
+// unless an annotation selects their issuer, and any other name gets its own
+// certificate from the issuer selected for it.
+func planCertificates(
+	missingNames []string,
+	issuers *issuerSelector,
+	wildcard *wildcardCertificates,
+) []plannedCertificate {
+	idx := certificate.NewNameIndex[string]()
+	for _, name := range missingNames {
+		idx.Add(name, []string{name})
+	}
+
+	var planned []plannedCertificate
+	if wildcard != nil {
+		for _, domain := range wildcard.domains {
+			dnsName := "*." + domain
+			names := slices.DeleteFunc(idx.Lookup(dnsName, true), issuers.overridden)
+			if len(names) == 0 {
+				continue
+			}
+			planned = append(planned, plannedCertificate{dnsNames: []string{dnsName}, issuer: wildcard.issuer})
+			for _, name := range names {
+				idx.Remove(name)
+			}
+		}
+	}
+	for _, name := range idx.Keys() {
+		planned = append(planned, plannedCertificate{dnsNames: []string{name}, issuer: issuers.issuer(name)})
+	}
+
+	slices.SortFunc(planned, func(x, y plannedCertificate) int {
+		return slices.Compare(x.dnsNames, y.dnsNames)
+	})
+	return planned
+}
+
+// plannedCertificateFor returns the plan an existing certificate matches.
+func plannedCertificateFor(cert *certmanager_v1.Certificate) plannedCertificate {
+	dnsNames := make([]string, 0, len(cert.Spec.DNSNames))
+	for _, name := range cert.Spec.DNSNames {
+		dnsNames = append(dnsNames, strings.ToLower(name))
+	}
+	slices.Sort(dnsNames)
+	return plannedCertificate{
+		dnsNames: dnsNames,
+		issuer: certmanager_meta_v1.IssuerReference{
+			Kind: cert.Spec.IssuerRef.Kind,
+			Name: cert.Spec.IssuerRef.Name,
+		},
+		certificate: cert.Name,
+	}
+}
+
+// wildcardParent returns the wildcard name that would cover the name.
+func wildcardParent(name string) string {
+	_, suffix, ok := strings.Cut(name, ".")
+	if !ok {
+		return ""
+	}
+	return "*." + suffix
+}
+
+func isCertificateReady(cert *certmanager_v1.Certificate) bool {
+	for _, cond := range cert.Status.Conditions {
+		if cond.Type == certmanager_v1.CertificateConditionReady {
+			return cond.Status == certmanager_meta_v1.ConditionTrue
+		}
+	}
+	return false
+}
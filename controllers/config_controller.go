@@ -13,15 +13,20 @@ import (
 	runtime_ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/pomerium/pomerium/pkg/databrokerutil"
 	"github.com/pomerium/pomerium/pkg/grpc/databroker"
 
 	"github.com/pomerium/ingress-controller/controllers/certificate"
+	"github.com/pomerium/ingress-controller/controllers/crl"
+	"github.com/pomerium/ingress-controller/controllers/drift"
+	"github.com/pomerium/ingress-controller/controllers/expiry"
 	"github.com/pomerium/ingress-controller/controllers/gateway"
 	"github.com/pomerium/ingress-controller/controllers/ingress"
 	"github.com/pomerium/ingress-controller/controllers/reporter"
 	"github.com/pomerium/ingress-controller/controllers/settings"
+	"github.com/pomerium/ingress-controller/model"
 	"github.com/pomerium/ingress-controller/pomerium"
 	health_ctrl "github.com/pomerium/ingress-controller/util/health"
 )
@@ -50,6 +55,18 @@ type Controller struct {
 	GlobalSettings *types.NamespacedName
 	// CertificateControllerName is the name of the certificate controller.
 	CertificateControllerName string
+	// CertificateCtrlOpts are additional certificate controller options
+	CertificateCtrlOpts []certificate.Option
+	// DriftScanInterval if set, and the reconciler supports it, periodically restores
+	// Pomerium configuration that was changed outside of Kubernetes
+	DriftScanInterval time.Duration
+	// DriftPrune deletes Pomerium configuration objects no longer referenced by Kubernetes objects during the drift scan
+	DriftPrune bool
+	// CertificateExpiry if set, periodically reports served certificates that expire soon
+	CertificateExpiry *expiry.Config
+	// CRLRefreshInterval if set, periodically refreshes the downstream mTLS CRL secret
+	// from the CRL URLs in the settings
+	CRLRefreshInterval time.Duration
 
 	running int32
 }
@@ -87,27 +104,76 @@ func (c *Controller) RunLeased(ctx context.Context) (err error) {
 		ar.SetK8sClient(mgr.GetClient())
 	}
 
-	if err = ingress.NewIngressController(mgr, c.Reconciler, c.getIngressOpts(mgr)...); err != nil {
+	ingressOpts := c.getIngressOpts(mgr)
+	var gatewayConfig *gateway.ControllerConfig
+	if c.GatewayControllerConfig != nil {
+		// Ingress and Gateway routes are reconciled independently, and need to agree on
+		// which one owns a route both of them claim
+		routeClaims := model.NewRouteClaimIndex()
+		ingressOpts = append(ingressOpts, ingress.WithRouteClaimIndex(routeClaims))
+		gatewayConfig = new(*c.GatewayControllerConfig)
+		gatewayConfig.RouteClaims = routeClaims
+		gatewayConfig.GlobalSettings = c.GlobalSettings
+	}
+
+	if err = ingress.NewIngressController(mgr, c.Reconciler, ingressOpts...); err != nil {
 		return fmt.Errorf("create ingress controller: %w", err)
 	}
 	if c.GlobalSettings != nil {
 		if err = settings.NewSettingsController(mgr, c.Reconciler, *c.GlobalSettings, "pomerium-crd", true, health_ctrl.SettingsReconciler); err != nil {
 			return fmt.Errorf("create settings controller: %w", err)
 		}
-		certificate.NewCertificateController(mgr, c.DataBrokerServiceClient,
+		certOpts := []certificate.Option{
 			certificate.WithControllerName(c.CertificateControllerName),
-			certificate.WithGlobalSettingsName(*c.GlobalSettings))
+			certificate.WithGlobalSettingsName(*c.GlobalSettings),
+		}
+		if gatewayConfig != nil {
+			certOpts = append(certOpts, certificate.WithHTTPRoutes())
+		}
+		certOpts = append(certOpts, c.CertificateCtrlOpts...)
+		if err = certificate.NewCertificateController(mgr, c.DataBrokerServiceClient, certOpts...); err != nil {
+			return fmt.Errorf("create certificate controller: %w", err)
+		}
+		if c.CRLRefreshInterval > 0 {
+			if err = crl.NewRefresher(mgr, *c.GlobalSettings, c.CRLRefreshInterval); err != nil {
+				return fmt.Errorf("create crl refresher: %w", err)
+			}
+		}
 	} else {
 		log.FromContext(ctx).V(1).Info("no Pomerium CRD")
 	}
 
-	if c.GatewayControllerConfig != nil {
-		err := gateway.NewControllers(ctx, mgr, c.Reconciler, *c.GatewayControllerConfig)
+	if gatewayConfig != nil {
+		err := gateway.NewControllers(ctx, mgr, c.Reconciler, *gatewayConfig)
 		if err != nil {
 			return err
 		}
 	}
 
+	if ds, ok := c.Reconciler.(pomerium.DriftScanner); ok && c.DriftScanInterval > 0 {
+		if err = drift.NewDriftController(mgr, ds, c.DriftScanInterval, c.DriftPrune); err != nil {
+			return fmt.Errorf("create drift controller: %w", err)
+		}
+	}
+
+	if c.CertificateExpiry != nil {
+		cfg := *c.CertificateExpiry
+		cfg.GlobalSettings = c.GlobalSettings
+		if gatewayConfig != nil {
+			cfg.GatewayClassController = gatewayConfig.ControllerName
+		}
+		if err = expiry.NewMonitor(mgr, cfg); err != nil {
+			return fmt.Errorf("create certificate expiry monitor: %w", err)
+		}
+	}
+
+	// reconcilers may need to run in the background, i.e. to retry failed updates
+	if runnable, ok := c.Reconciler.(manager.Runnable); ok {
+		if err = mgr.Add(runnable); err != nil {
+			return fmt.Errorf("add reconciler: %w", err)
+		}
+	}
+
 	c.setRunning(true)
 	if err = mgr.Start(ctx); err != nil {
 		return fmt.Errorf("running controller: %w", err)
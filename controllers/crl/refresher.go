@@ -0,0 +1,330 @@
+// Package crl keeps the downstream mTLS certificate revocation list secret up to date
+package crl
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/controllers/settings"
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+const (
+	controllerName = "pomerium-crl-refresh"
+
+	reasonCRLRefreshFailed = "CRLRefreshFailed"
+
+	// maxCRLSize limits the size of a downloaded CRL
+	maxCRLSize   = 16 << 20
+	fetchTimeout = time.Minute
+)
+
+type refresher struct {
+	client.Client
+	record.EventRecorder
+	cache cache.Cache
+
+	settings   types.NamespacedName
+	httpClient *http.Client
+}
+
+// NewRefresher downloads the CRLs from the Pomerium CRD downstreamMtls.crlUrls every interval,
+// and whenever the downstreamMtls settings change, and stores them in the secret downstreamMtls.crlSecret refers to,
+// which in turn triggers the settings reconciliation.
+func NewRefresher(mgr ctrl.Manager, name types.NamespacedName, interval time.Duration) error {
+	r := &refresher{
+		Client:        mgr.GetClient(),
+		EventRecorder: mgr.GetEventRecorderFor(controllerName),
+		cache:         mgr.GetCache(),
+		settings:      name,
+		httpClient:    &http.Client{Timeout: fetchTimeout},
+	}
+	err := mgr.Add(&util.Periodic{
+		Interval:   interval,
+		RunOnStart: true,
+		Trigger:    r.watchSettings,
+		Run:        r.refresh,
+	})
+	if err != nil {
+		return fmt.Errorf("add crl refresher: %w", err)
+	}
+	return nil
+}
+
+// watchSettings returns a channel that receives when the downstreamMtls settings
+// of the Pomerium CRD are created or changed
+func (r *refresher) watchSettings(ctx context.Context) (<-chan struct{}, error) {
+	informer, err := r.cache.GetInformer(ctx, new(icsv1.Pomerium))
+	if err != nil {
+		return nil, fmt.Errorf("get settings informer: %w", err)
+	}
+	changed := make(chan struct{}, 1)
+	notify := func(obj any, prev *icsv1.DownstreamMTLS) {
+		pom, ok := obj.(*icsv1.Pomerium)
+		if !ok || pom.Name != r.settings.Name || equality.Semantic.DeepEqual(pom.Spec.DownstreamMTLS, prev) {
+			return
+		}
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	_, err = informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if !isInInitialList {
+				notify(obj, nil)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			if prev, ok := oldObj.(*icsv1.Pomerium); ok {
+				notify(newObj, prev.Spec.DownstreamMTLS)
+			}
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("watch settings: %w", err)
+	}
+	return changed, nil
+}
+
+func (r *refresher) refresh(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName(controllerName)
+
+	var pom icsv1.Pomerium
+	if err := r.Get(ctx, r.settings, &pom); apierrors.IsNotFound(err) {
+		return
+	} else if err != nil {
+		logger.Error(err, "get settings", "name", r.settings)
+		return
+	}
+	mtls := pom.Spec.DownstreamMTLS
+	if mtls == nil || mtls.CRLSecret == nil || len(mtls.CRLURLs) == 0 {
+		return
+	}
+
+	if err := r.refreshSecret(ctx, mtls); err != nil {
+		logger.Error(err, "refresh crl")
+		r.Event(&pom, corev1.EventTypeWarning, reasonCRLRefreshFailed, err.Error())
+	}
+}
+
+func (r *refresher) refreshSecret(ctx context.Context, mtls *icsv1.DownstreamMTLS) error {
+	name, err := util.ParseNamespacedName(*mtls.CRLSecret)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", *mtls.CRLSecret, err)
+	}
+
+	ca := mtls.CA
+	if len(ca) == 0 {
+		bundles, err := settings.FetchDownstreamMTLS(ctx, r.Client, &icsv1.DownstreamMTLS{
+			CASecret:    mtls.CASecret,
+			CAConfigMap: mtls.CAConfigMap,
+		})
+		if err != nil {
+			return err
+		}
+		ca = bundles.CA
+	}
+	fetched, err := fetchCRLs(ctx, r.httpClient, mtls.CRLURLs, parseCertificates(ca))
+	if err != nil {
+		return err
+	}
+
+	var secret corev1.Secret
+	if err := r.Get(ctx, *name, &secret); apierrors.IsNotFound(err) {
+		secret = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: name.Namespace, Name: name.Name},
+			Data:       map[string][]byte{model.CRLKey: encodeCRLs(fetched)},
+		}
+		if err := r.Create(ctx, &secret); err != nil {
+			return fmt.Errorf("create %s: %w", name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("get %s: %w", name, err)
+	}
+
+	crls, staleErr := keepNewest(parseCRLs(secret.Data[model.CRLKey]), fetched)
+	crl := encodeCRLs(crls)
+	if bytes.Equal(secret.Data[model.CRLKey], crl) {
+		return staleErr
+	}
+	orig := secret.DeepCopy()
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[model.CRLKey] = crl
+	if err := r.Patch(ctx, &secret, client.MergeFrom(orig)); err != nil {
+		return fmt.Errorf("update %s: %w", name, err)
+	}
+	return staleErr
+}
+
+// keepNewest returns the fetched CRLs, except that a stored CRL of the same issuer is kept
+// unless the fetched one is newer, so that a stale or replayed copy of a CRL does not un-revoke certificates.
+// An error is returned for every fetched CRL that was rejected.
+func keepNewest(stored, fetched []*x509.RevocationList) ([]*x509.RevocationList, error) {
+	out := make([]*x509.RevocationList, 0, len(fetched))
+	var errs []error
+	for _, crl := range fetched {
+		idx := slices.IndexFunc(stored, func(s *x509.RevocationList) bool {
+			return bytes.Equal(s.RawIssuer, crl.RawIssuer) && bytes.Equal(s.AuthorityKeyId, crl.AuthorityKeyId)
+		})
+		if idx < 0 || bytes.Equal(stored[idx].Raw, crl.Raw) || isNewer(crl, stored[idx]) {
+			out = append(out, crl)
+			continue
+		}
+		errs = append(errs, fmt.Errorf("crl of %s (number %s, this update %s) is not newer than the current one (number %s, this update %s)",
+			crl.Issuer, crl.Number, crl.ThisUpdate.Format(time.RFC3339),
+			stored[idx].Number, stored[idx].ThisUpdate.Format(time.RFC3339)))
+		out = append(out, stored[idx])
+	}
+	return out, errors.Join(errs...)
+}
+
+// isNewer reports whether a CRL supersedes the current CRL of the same issuer,
+// by its CRL number if both have one, and by its this update time otherwise
+func isNewer(crl, current *x509.RevocationList) bool {
+	if crl.Number != nil && current.Number != nil {
+		return crl.Number.Cmp(current.Number) > 0
+	}
+	return crl.ThisUpdate.After(current.ThisUpdate)
+}
+
+// parseCRLs returns the CRLs in a PEM bundle, skipping the ones that fail to parse
+func parseCRLs(data []byte) []*x509.RevocationList {
+	var crls []*x509.RevocationList
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return crls
+		}
+		if block.Type != "X509 CRL" {
+			continue
+		}
+		if crl, err := x509.ParseRevocationList(block.Bytes); err == nil {
+			crls = append(crls, crl)
+		}
+	}
+}
+
+// encodeCRLs returns the CRLs as a PEM bundle
+func encodeCRLs(crls []*x509.RevocationList) []byte {
+	var out []byte
+	for _, crl := range crls {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl.Raw})...)
+	}
+	return out
+}
+
+// parseCertificates returns the certificates in a PEM bundle, skipping the ones that fail to parse
+func parseCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+// fetchCRLs downloads the CRLs from the URLs.
+// Each CRL must be signed by the CA certificate that issued it, so that a forged CRL does not replace the current one.
+func fetchCRLs(ctx context.Context, httpClient *http.Client, urls []string, cas []*x509.Certificate) ([]*x509.RevocationList, error) {
+	out := make([]*x509.RevocationList, 0, len(urls))
+	for _, u := range urls {
+		crl, err := fetchCRL(ctx, httpClient, u, cas)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, crl)
+	}
+	return out, nil
+}
+
+// fetchCRL downloads a DER or PEM encoded CRL, that must be signed by one of the CA certificates
+func fetchCRL(ctx context.Context, httpClient *http.Client, url string, cas []*x509.Certificate) (*x509.RevocationList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCRLSize))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+
+	if block, _ := pem.Decode(data); block != nil && block.Type == "X509 CRL" {
+		data = block.Bytes
+	}
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: parse: %w", url, err)
+	}
+	issuer, err := crlIssuer(crl, cas)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("%s: issued by %s: %w", url, issuer.Subject, err)
+	}
+	return crl, nil
+}
+
+// crlIssuer returns the CA certificate the CRL names as its issuer,
+// preferring the one with a matching key identifier when several share a subject
+func crlIssuer(crl *x509.RevocationList, cas []*x509.Certificate) (*x509.Certificate, error) {
+	var issuer *x509.Certificate
+	for _, ca := range cas {
+		if !bytes.Equal(ca.RawSubject, crl.RawIssuer) {
+			continue
+		}
+		if len(crl.AuthorityKeyId) > 0 && bytes.Equal(ca.SubjectKeyId, crl.AuthorityKeyId) {
+			return ca, nil
+		}
+		if issuer == nil {
+			issuer = ca
+		}
+	}
+	if issuer == nil {
+		return nil, fmt.Errorf("issuer %s is not a downstream mTLS CA certificate", crl.Issuer)
+	}
+	return issuer, nil
+}
@@ -0,0 +1,197 @@
+package crl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
+)
+
+func TestFetchCRLs(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	makeCA := func(name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		}
+		if parent == nil {
+			parent, parentKey = tmpl, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+		return cert, key
+	}
+	makeCRL := func(issuer *x509.Certificate, key *ecdsa.PrivateKey) []byte {
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(1),
+			ThisUpdate: now,
+			NextUpdate: now.Add(time.Hour),
+			RevokedCertificateEntries: []x509.RevocationListEntry{
+				{SerialNumber: big.NewInt(42), RevocationTime: now},
+			},
+		}, issuer, key)
+		require.NoError(t, err)
+		return der
+	}
+
+	root, rootKey := makeCA("root", nil, nil)
+	intermediate, intermediateKey := makeCA("intermediate", root, rootKey)
+	forged, forgedKey := makeCA("intermediate", nil, nil)
+	other, otherKey := makeCA("other", nil, nil)
+
+	crls := map[string][]byte{
+		"/root.crl":         makeCRL(root, rootKey),
+		"/intermediate.crl": pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: makeCRL(intermediate, intermediateKey)}),
+		"/forged.crl":       makeCRL(forged, forgedKey),
+		"/other.crl":        makeCRL(other, otherKey),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := crls[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	var bundle []byte
+	for _, ca := range []*x509.Certificate{root, intermediate} {
+		bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})...)
+	}
+	cas := parseCertificates(bundle)
+
+	out, err := fetchCRLs(t.Context(), srv.Client(), []string{srv.URL + "/intermediate.crl", srv.URL + "/root.crl"}, cas)
+	require.NoError(t, err)
+	var issuers []string
+	for _, crl := range parseCRLs(encodeCRLs(out)) {
+		issuers = append(issuers, crl.Issuer.CommonName)
+	}
+	assert.Equal(t, []string{"intermediate", "root"}, issuers, "the CRL of an intermediate should be verified against the intermediate")
+
+	_, err = fetchCRLs(t.Context(), srv.Client(), []string{srv.URL + "/root.crl", srv.URL + "/forged.crl"}, cas)
+	assert.Error(t, err, "a CRL not signed by the CA it names should be rejected")
+	_, err = fetchCRLs(t.Context(), srv.Client(), []string{srv.URL + "/other.crl"}, cas)
+	assert.ErrorContains(t, err, "is not a downstream mTLS CA certificate")
+	_, err = fetchCRLs(t.Context(), srv.Client(), []string{srv.URL + "/root.crl", srv.URL + "/missing.crl"}, cas)
+	assert.ErrorContains(t, err, "404", "a CRL that fails to download should not be dropped from the secret")
+}
+
+func TestRefreshSecretKeepsNewest(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	makeCRL := func(number int64, thisUpdate time.Time) []byte {
+		der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(number),
+			ThisUpdate: thisUpdate,
+			NextUpdate: thisUpdate.Add(time.Hour),
+		}, ca, key)
+		require.NoError(t, err)
+		return der
+	}
+
+	var served []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(served)
+	}))
+	t.Cleanup(srv.Close)
+
+	name := types.NamespacedName{Namespace: "pomerium", Name: "crl"}
+	r := &refresher{
+		Client:     fake.NewClientBuilder().Build(),
+		httpClient: srv.Client(),
+	}
+	mtls := &icsv1.DownstreamMTLS{
+		CA:        pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}),
+		CRLSecret: new(name.String()),
+		CRLURLs:   []string{srv.URL + "/root.crl"},
+	}
+	stored := func() []byte {
+		var secret corev1.Secret
+		require.NoError(t, r.Get(t.Context(), name, &secret))
+		return secret.Data[model.CRLKey]
+	}
+	bundle := func(der []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+	}
+
+	current := makeCRL(2, now)
+	served = current
+	require.NoError(t, r.refreshSecret(t.Context(), mtls))
+	assert.Equal(t, bundle(current), stored())
+
+	served = makeCRL(1, now.Add(time.Minute))
+	assert.ErrorContains(t, r.refreshSecret(t.Context(), mtls), "is not newer",
+		"a CRL with a lower number should not replace the current one")
+	assert.Equal(t, bundle(current), stored())
+
+	served = makeCRL(2, now.Add(time.Minute))
+	assert.Error(t, r.refreshSecret(t.Context(), mtls), "a different CRL with the same number should be rejected")
+	assert.Equal(t, bundle(current), stored())
+
+	served = current
+	require.NoError(t, r.refreshSecret(t.Context(), mtls))
+
+	next := makeCRL(3, now.Add(time.Minute))
+	served = next
+	require.NoError(t, r.refreshSecret(t.Context(), mtls))
+	assert.Equal(t, bundle(next), stored())
+}
+
+func TestIsNewer(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	crl := func(number *big.Int, thisUpdate time.Time) *x509.RevocationList {
+		return &x509.RevocationList{Number: number, ThisUpdate: thisUpdate}
+	}
+	assert.True(t, isNewer(crl(big.NewInt(2), now), crl(big.NewInt(1), now.Add(time.Hour))),
+		"the CRL number should take precedence over this update")
+	assert.False(t, isNewer(crl(big.NewInt(1), now.Add(time.Hour)), crl(big.NewInt(2), now)))
+	assert.False(t, isNewer(crl(big.NewInt(1), now.Add(time.Hour)), crl(big.NewInt(1), now)))
+	assert.True(t, isNewer(crl(nil, now.Add(time.Hour)), crl(big.NewInt(1), now)))
+	assert.False(t, isNewer(crl(nil, now), crl(nil, now)))
+}
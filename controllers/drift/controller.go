@@ -0,0 +1,66 @@
+// Package drift periodically restores Pomerium configuration that was changed outside of Kubernetes
+package drift
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pomerium/ingress-controller/pomerium"
+	"github.com/pomerium/ingress-controller/util"
+	"github.com/pomerium/ingress-controller/util/metrics"
+)
+
+const (
+	controllerName = "pomerium-drift"
+
+	reasonConfigDrift = "ConfigDrift"
+)
+
+type driftController struct {
+	pomerium.DriftScanner
+	record.EventRecorder
+
+	prune bool
+}
+
+// NewDriftController scans Pomerium configuration for drift every interval,
+// and reports each correction as a Kubernetes event on the object the configuration is synced from.
+func NewDriftController(
+	mgr ctrl.Manager,
+	scanner pomerium.DriftScanner,
+	interval time.Duration,
+	prune bool,
+) error {
+	c := &driftController{
+		DriftScanner:  scanner,
+		EventRecorder: mgr.GetEventRecorderFor(controllerName),
+		prune:         prune,
+	}
+	if err := mgr.Add(&util.Periodic{Interval: interval, Run: c.scan}); err != nil {
+		return fmt.Errorf("add drift controller: %w", err)
+	}
+	return nil
+}
+
+func (c *driftController) scan(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName(controllerName)
+
+	drifts, err := c.ScanDrift(ctx, c.prune)
+	for _, d := range drifts {
+		metrics.ObserveConfigDrift(d.Type, string(d.Kind))
+		logger.Info("corrected drift", "type", d.Type, "id", d.ID, "drift", d.Kind)
+		if d.Owner != nil {
+			c.Event(d.Owner, corev1.EventTypeWarning, reasonConfigDrift, d.String())
+		}
+	}
+	if err != nil {
+		metrics.ObserveConfigDriftScanError()
+		logger.Error(err, "drift scan")
+	}
+}
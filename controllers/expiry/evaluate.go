@@ -0,0 +1,126 @@
+package expiry
+
+import (
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/internal/certificate"
+)
+
+const (
+	reasonCertificateExpired  = "CertificateExpired"
+	reasonCertificateExpiring = "CertificateExpiring"
+	reasonCertificateMismatch = "CertificateMismatch"
+)
+
+type servedCertificate struct {
+	certificate.SecretRef
+	cert *x509.Certificate
+	err  error
+}
+
+type event struct {
+	owner   client.Object
+	reason  string
+	message string
+}
+
+type evaluation struct {
+	events []event
+	// statuses of the hostnames with a problem, sorted by hostname
+	statuses []icsv1.CertificateStatus
+	// notAfter is the expiry of the best certificate served per hostname
+	notAfter map[string]time.Time
+}
+
+// evaluate reports each served certificate that has expired, expires within warnBefore or does not match its hostname,
+// and determines the status of each hostname from the best, that is latest expiring, certificate served for it
+func evaluate(served []servedCertificate, now time.Time, warnBefore time.Duration) evaluation {
+	res := evaluation{notAfter: make(map[string]time.Time)}
+
+	seen := make(map[event]bool)
+	addEvent := func(e event) {
+		if !seen[e] {
+			seen[e] = true
+			res.events = append(res.events, e)
+		}
+	}
+
+	var hostnames []string
+	for _, s := range served {
+		switch {
+		case s.cert == nil:
+			addEvent(event{s.Owner, reasonCertificateMismatch,
+				fmt.Sprintf("secret %s does not contain a valid certificate: %v", s.Secret, s.err)})
+			continue
+		case s.Hostname != "" && s.cert.VerifyHostname(s.Hostname) != nil:
+			addEvent(event{s.Owner, reasonCertificateMismatch,
+				fmt.Sprintf("certificate in secret %s is not valid for %s, only for %v", s.Secret, s.Hostname, s.cert.DNSNames)})
+		case !now.Before(s.cert.NotAfter):
+			addEvent(event{s.Owner, reasonCertificateExpired,
+				fmt.Sprintf("certificate in secret %s expired at %s", s.Secret, s.cert.NotAfter.Format(time.RFC3339))})
+		case s.cert.NotAfter.Sub(now) <= warnBefore:
+			addEvent(event{s.Owner, reasonCertificateExpiring,
+				fmt.Sprintf("certificate in secret %s expires at %s", s.Secret, s.cert.NotAfter.Format(time.RFC3339))})
+		}
+		if s.Hostname == "" {
+			hostnames = append(hostnames, s.cert.DNSNames...)
+		}
+	}
+	for _, s := range served {
+		if s.Hostname != "" {
+			hostnames = append(hostnames, s.Hostname)
+		}
+	}
+	slices.Sort(hostnames)
+	hostnames = slices.Compact(hostnames)
+
+	for _, hostname := range hostnames {
+		var best *servedCertificate
+		for i := range served {
+			s := &served[i]
+			if s.cert == nil || s.cert.VerifyHostname(hostname) != nil {
+				continue
+			}
+			if best == nil || s.cert.NotAfter.After(best.cert.NotAfter) {
+				best = s
+			}
+		}
+
+		if best == nil {
+			idx := slices.IndexFunc(served, func(s servedCertificate) bool { return s.Hostname == hostname })
+			res.statuses = append(res.statuses, icsv1.CertificateStatus{
+				Hostname: hostname,
+				Reason:   certificate.StatusMismatch,
+				Secret:   served[idx].Secret.String(),
+				Message:  fmt.Sprintf("no valid certificate is served for %s", hostname),
+			})
+			continue
+		}
+
+		res.notAfter[hostname] = best.cert.NotAfter
+		status := icsv1.CertificateStatus{
+			Hostname: hostname,
+			Secret:   best.Secret.String(),
+			NotAfter: &metav1.Time{Time: best.cert.NotAfter},
+		}
+		switch {
+		case !now.Before(best.cert.NotAfter):
+			status.Reason = certificate.StatusExpired
+			status.Message = fmt.Sprintf("certificate expired at %s", best.cert.NotAfter.Format(time.RFC3339))
+		case best.cert.NotAfter.Sub(now) <= warnBefore:
+			status.Reason = certificate.StatusExpiring
+			status.Message = fmt.Sprintf("certificate expires at %s", best.cert.NotAfter.Format(time.RFC3339))
+		default:
+			continue
+		}
+		res.statuses = append(res.statuses, status)
+	}
+	return res
+}
@@ -0,0 +1,92 @@
+package expiry
+
+import (
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/internal/certificate"
+)
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	warnBefore := 30 * day
+
+	ing := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"}}
+	settings := &icsv1.Pomerium{ObjectMeta: metav1.ObjectMeta{Name: "global"}}
+	cert := func(notAfter time.Time, names ...string) *x509.Certificate {
+		return &x509.Certificate{DNSNames: names, NotBefore: now.Add(-60 * day), NotAfter: notAfter}
+	}
+	served := func(hostname string, owner *networkingv1.Ingress, secret string, c *x509.Certificate) servedCertificate {
+		return servedCertificate{
+			SecretRef: certificate.SecretRef{Hostname: hostname, Owner: owner, Secret: types.NamespacedName{Namespace: "default", Name: secret}},
+			cert:      c,
+		}
+	}
+
+	res := evaluate([]servedCertificate{
+		served("valid.example.com", ing, "valid", cert(now.Add(90*day), "valid.example.com")),
+		served("expiring.example.com", ing, "expiring", cert(now.Add(10*day), "expiring.example.com")),
+		served("expired.example.com", ing, "expired", cert(now.Add(-day), "expired.example.com")),
+		// a renewed certificate is served along with the expired one
+		served("renewed.example.com", ing, "renewed-old", cert(now.Add(-day), "renewed.example.com")),
+		served("renewed.example.com", ing, "renewed-new", cert(now.Add(90*day), "renewed.example.com")),
+		served("mismatch.example.com", ing, "mismatch", cert(now.Add(90*day), "other.example.com")),
+		{
+			SecretRef: certificate.SecretRef{Owner: settings, Secret: types.NamespacedName{Namespace: "pomerium", Name: "default"}},
+			cert:      cert(now.Add(5*day), "default.example.com"),
+		},
+		{
+			SecretRef: certificate.SecretRef{Hostname: "invalid.example.com", Owner: ing, Secret: types.NamespacedName{Namespace: "default", Name: "invalid"}},
+			err:       errors.New("no PEM encoded certificate found"),
+		},
+	}, now, warnBefore)
+
+	var reasons []string
+	for _, e := range res.events {
+		reasons = append(reasons, e.reason+" "+e.owner.GetName())
+	}
+	assert.ElementsMatch(t, []string{
+		reasonCertificateExpiring + " app",
+		reasonCertificateExpired + " app",
+		reasonCertificateExpired + " app",
+		reasonCertificateMismatch + " app",
+		reasonCertificateExpiring + " global",
+		reasonCertificateMismatch + " app",
+	}, reasons)
+
+	got := make(map[string]string)
+	for _, s := range res.statuses {
+		got[s.Hostname] = s.Reason + " " + s.Secret
+	}
+	assert.Equal(t, map[string]string{
+		"default.example.com":  certificate.StatusExpiring + " pomerium/default",
+		"expired.example.com":  certificate.StatusExpired + " default/expired",
+		"expiring.example.com": certificate.StatusExpiring + " default/expiring",
+		"invalid.example.com":  certificate.StatusMismatch + " default/invalid",
+		"mismatch.example.com": certificate.StatusMismatch + " default/mismatch",
+	}, got)
+	assert.IsNonDecreasing(t, func() []string {
+		var hostnames []string
+		for _, s := range res.statuses {
+			hostnames = append(hostnames, s.Hostname)
+		}
+		return hostnames
+	}(), "statuses should be sorted by hostname")
+
+	require.Contains(t, res.notAfter, "renewed.example.com")
+	assert.Equal(t, now.Add(90*day), res.notAfter["renewed.example.com"], "the latest expiring certificate should be used")
+	assert.Equal(t, now.Add(10*day), res.notAfter["expiring.example.com"])
+	assert.NotContains(t, res.notAfter, "mismatch.example.com")
+}
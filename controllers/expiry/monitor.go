@@ -0,0 +1,233 @@
+// Package expiry periodically checks certificates served by Pomerium for approaching expiry
+package expiry
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/controllers/ingress"
+	"github.com/pomerium/ingress-controller/internal/certificate"
+	"github.com/pomerium/ingress-controller/util"
+	"github.com/pomerium/ingress-controller/util/metrics"
+)
+
+const (
+	controllerName = "pomerium-certificate-expiry"
+
+	// DefaultInterval is how often served certificates are checked by default
+	DefaultInterval = time.Hour
+)
+
+// Config is the certificate expiry monitor configuration
+type Config struct {
+	// IngressClassController selects Ingresses by the controller of their IngressClass
+	IngressClassController string
+	// GatewayClassController selects Gateways by the controller of their GatewayClass, empty if Gateway API is disabled
+	GatewayClassController string
+	// GlobalSettings is the Pomerium CRD whose certificates are checked, and that has the status updated
+	GlobalSettings *types.NamespacedName
+	// Interval is how often to check the certificates, DefaultInterval if not set
+	Interval time.Duration
+	// WarnBefore is how long before the expiry certificates are reported as expiring
+	WarnBefore time.Duration
+}
+
+type monitor struct {
+	client.Client
+	record.EventRecorder
+
+	Config
+}
+
+// NewMonitor checks the certificates referenced by Ingresses, Gateways and the Pomerium CRD every interval,
+// and reports the ones that have expired, expire soon or do not match their hostname
+// as Kubernetes events, metrics and Pomerium CRD status.
+func NewMonitor(mgr ctrl.Manager, cfg Config) error {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	m := &monitor{
+		Client:        mgr.GetClient(),
+		EventRecorder: mgr.GetEventRecorderFor(controllerName),
+		Config:        cfg,
+	}
+	if err := mgr.Add(&util.Periodic{Interval: cfg.Interval, RunOnStart: true, Run: m.scan}); err != nil {
+		return fmt.Errorf("add certificate expiry monitor: %w", err)
+	}
+	return nil
+}
+
+func (m *monitor) scan(ctx context.Context) {
+	logger := log.FromContext(ctx).WithName(controllerName)
+
+	served, err := m.getServedCertificates(ctx)
+	if err != nil {
+		logger.Error(err, "list served certificates")
+		return
+	}
+
+	res := evaluate(served, time.Now(), m.WarnBefore)
+	for _, e := range res.events {
+		m.Event(e.owner, corev1.EventTypeWarning, e.reason, e.message)
+	}
+	metrics.SetCertificateExpiry(res.notAfter)
+	problems := make(map[string]string, len(res.statuses))
+	for _, s := range res.statuses {
+		problems[s.Hostname] = s.Reason
+	}
+	metrics.SetCertificateProblems(problems)
+
+	if err := m.updateStatus(ctx, res.statuses); err != nil {
+		logger.Error(err, "update certificate status")
+	}
+}
+
+func (m *monitor) updateStatus(ctx context.Context, problems []icsv1.CertificateStatus) error {
+	if m.GlobalSettings == nil {
+		return nil
+	}
+	return certificate.UpdateStatus(ctx, m.Client, *m.GlobalSettings, func(cur []icsv1.CertificateStatus) []icsv1.CertificateStatus {
+		return certificate.MergeProblems(cur, problems)
+	})
+}
+
+func (m *monitor) getServedCertificates(ctx context.Context) ([]servedCertificate, error) {
+	var refs []certificate.SecretRef
+	for _, fn := range []func(context.Context) ([]certificate.SecretRef, error){
+		m.getIngressCertificates,
+		m.getGatewayCertificates,
+		m.getSettingsCertificates,
+	} {
+		r, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, r...)
+	}
+
+	out := make([]servedCertificate, 0, len(refs))
+	for _, ref := range refs {
+		var secret corev1.Secret
+		if err := m.Get(ctx, ref.Secret, &secret); apierrors.IsNotFound(err) {
+			// missing secrets are already reported when the configuration is reconciled
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("get secret %s: %w", ref.Secret, err)
+		}
+		cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+		out = append(out, servedCertificate{SecretRef: ref, cert: cert, err: err})
+	}
+	return out, nil
+}
+
+func (m *monitor) getIngressCertificates(ctx context.Context) ([]certificate.SecretRef, error) {
+	var icl networkingv1.IngressClassList
+	if err := m.List(ctx, &icl); err != nil {
+		return nil, fmt.Errorf("list ingress classes: %w", err)
+	}
+	classes := make(map[string]bool)
+	var defaultClass string
+	for _, ic := range icl.Items {
+		if ic.Spec.Controller != m.IngressClassController {
+			continue
+		}
+		classes[ic.Name] = true
+		if isDefault, _ := strconv.ParseBool(ic.Annotations[ingress.IngressClassDefaultAnnotationKey]); isDefault {
+			defaultClass = ic.Name
+		}
+	}
+	if len(classes) == 0 {
+		return nil, nil
+	}
+
+	var il networkingv1.IngressList
+	if err := m.List(ctx, &il); err != nil {
+		return nil, fmt.Errorf("list ingresses: %w", err)
+	}
+	var refs []certificate.SecretRef
+	for i := range il.Items {
+		ing := &il.Items[i]
+		className := ing.Annotations[ingress.IngressClassAnnotationKey]
+		if ing.Spec.IngressClassName != nil {
+			className = *ing.Spec.IngressClassName
+		}
+		if className == "" {
+			className = defaultClass
+		}
+		if !classes[className] {
+			continue
+		}
+		refs = append(refs, certificate.IngressSecretRefs(ing)...)
+	}
+	return refs, nil
+}
+
+func (m *monitor) getGatewayCertificates(ctx context.Context) ([]certificate.SecretRef, error) {
+	if m.GatewayClassController == "" {
+		return nil, nil
+	}
+	var gcl gateway_v1.GatewayClassList
+	if err := m.List(ctx, &gcl); err != nil {
+		return nil, fmt.Errorf("list gateway classes: %w", err)
+	}
+	classes := make(map[gateway_v1.ObjectName]bool)
+	for _, gc := range gcl.Items {
+		if string(gc.Spec.ControllerName) == m.GatewayClassController {
+			classes[gateway_v1.ObjectName(gc.Name)] = true
+		}
+	}
+	if len(classes) == 0 {
+		return nil, nil
+	}
+
+	var gl gateway_v1.GatewayList
+	if err := m.List(ctx, &gl); err != nil {
+		return nil, fmt.Errorf("list gateways: %w", err)
+	}
+	var refs []certificate.SecretRef
+	for i := range gl.Items {
+		gw := &gl.Items[i]
+		if !classes[gw.Spec.GatewayClassName] {
+			continue
+		}
+		refs = append(refs, certificate.GatewaySecretRefs(gw)...)
+	}
+	return refs, nil
+}
+
+func (m *monitor) getSettingsCertificates(ctx context.Context) ([]certificate.SecretRef, error) {
+	if m.GlobalSettings == nil {
+		return nil, nil
+	}
+	var obj icsv1.Pomerium
+	if err := m.Get(ctx, *m.GlobalSettings, &obj); apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get settings: %w", err)
+	}
+	return certificate.SettingsSecretRefs(&obj), nil
+}
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
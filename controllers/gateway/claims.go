@@ -0,0 +1,241 @@
+package gateway
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/pomerium/ingress-controller/model"
+)
+
+const (
+	// routeReasonConflicted is used when all matches of a route are claimed by older Ingresses.
+	routeReasonConflicted gateway_v1.RouteConditionReason = "Conflicted"
+	// routeConditionPartiallyServed is set on accepted routes with some matches claimed by older Ingresses.
+	routeConditionPartiallyServed gateway_v1.RouteConditionType = "PartiallyServed"
+	// routeReasonClaimedByIngress is used when some matches of a route are claimed by older Ingresses.
+	routeReasonClaimedByIngress gateway_v1.RouteConditionReason = "ClaimedByIngress"
+)
+
+// processRouteClaims registers HTTPRoute claims in the shared route claim index,
+// removes matches that were lost to older Ingress objects from the config,
+// and reports conflicts in the status of the affected HTTPRoutes.
+// Matches are only removed on the hostnames they were lost on.
+// A route that lost all of its matches is not accepted, otherwise the lost matches
+// are reported in a separate condition.
+func (c *gatewayController) processRouteClaims(config *model.GatewayConfig, o *objects) {
+	if c.RouteClaims == nil {
+		return
+	}
+
+	owners := make(map[types.NamespacedName]model.RouteClaimOwner)
+	claims := make(map[model.RouteClaimOwner][]model.RouteClaim)
+	for i := range config.Routes {
+		r := &config.Routes[i]
+		name := types.NamespacedName{Namespace: r.Namespace, Name: r.Name}
+		owner := model.RouteClaimOwner{
+			Key:               model.Key{Kind: model.RouteClaimKindHTTPRoute, NamespacedName: name},
+			CreationTimestamp: r.CreationTimestamp.Time,
+		}
+		owners[name] = owner
+		for _, h := range r.Hostnames {
+			for _, rule := range r.Spec.Rules {
+				for _, m := range routeRuleMatches(rule) {
+					if claim, ok := httpRouteMatchClaim(h, m); ok {
+						claims[owner] = append(claims[owner], claim)
+					}
+				}
+			}
+		}
+	}
+	c.RouteClaims.SetKind(model.RouteClaimKindHTTPRoute, claims)
+
+	lost := make(map[types.NamespacedName][]model.RouteClaimConflict)
+	for name, owner := range owners {
+		if l := c.RouteClaims.Lost(owner.Key); len(l) > 0 {
+			lost[name] = l
+		}
+	}
+
+	unserved := make(map[types.NamespacedName]bool)
+	routes := make([]model.GatewayHTTPRouteConfig, 0, len(config.Routes))
+	for _, r := range config.Routes {
+		name := types.NamespacedName{Namespace: r.Namespace, Name: r.Name}
+		l, ok := lost[name]
+		if !ok {
+			routes = append(routes, r)
+			continue
+		}
+		split := splitRouteClaims(r, l)
+		if len(split) == 0 {
+			unserved[name] = true
+			continue
+		}
+		routes = append(routes, split...)
+	}
+	config.Routes = routes
+
+	for _, infos := range o.HTTPRoutesByGateway {
+		for _, info := range infos {
+			name := types.NamespacedName{Namespace: info.route.Namespace, Name: info.route.Name}
+			l, ok := lost[name]
+			if !ok {
+				meta.RemoveStatusCondition(&info.status.Conditions, string(routeConditionPartiallyServed))
+				continue
+			}
+			msgs := make([]string, 0, len(l))
+			for _, conflict := range l {
+				msgs = append(msgs, conflict.String())
+			}
+			if unserved[name] {
+				meta.RemoveStatusCondition(&info.status.Conditions, string(routeConditionPartiallyServed))
+				upsertCondition(&info.status.Conditions, info.route.Generation, metav1.Condition{
+					Type:    string(gateway_v1.RouteConditionAccepted),
+					Status:  metav1.ConditionFalse,
+					Reason:  string(routeReasonConflicted),
+					Message: fmt.Sprintf("conflicting matches are not configured: %s", strings.Join(msgs, "; ")),
+				})
+				continue
+			}
+			upsertCondition(&info.status.Conditions, info.route.Generation, metav1.Condition{
+				Type:   string(routeConditionPartiallyServed),
+				Status: metav1.ConditionTrue,
+				Reason: string(routeReasonClaimedByIngress),
+				Message: fmt.Sprintf("some matches on %s are not configured, other hostnames are not affected: %s",
+					strings.Join(routeClaimHosts(l), ", "), strings.Join(msgs, "; ")),
+			})
+		}
+	}
+}
+
+// routeClaimHosts returns the sorted hostnames of the claims
+func routeClaimHosts(conflicts []model.RouteClaimConflict) []string {
+	var hosts []string
+	for _, c := range conflicts {
+		if !slices.Contains(hosts, c.Host) {
+			hosts = append(hosts, c.Host)
+		}
+	}
+	slices.Sort(hosts)
+	return hosts
+}
+
+// routeRuleMatches returns rule matches, an empty list matches all requests.
+func routeRuleMatches(rule gateway_v1.HTTPRouteRule) []gateway_v1.HTTPRouteMatch {
+	if len(rule.Matches) == 0 {
+		return []gateway_v1.HTTPRouteMatch{{}}
+	}
+	return rule.Matches
+}
+
+// httpRouteMatchClaim returns a route claim for a match that only depends on the request path,
+// as matches on headers, query params or method do not conflict with Ingress paths.
+func httpRouteMatchClaim(hostname gateway_v1.Hostname, m gateway_v1.HTTPRouteMatch) (model.RouteClaim, bool) {
+	if len(m.Headers) > 0 || len(m.QueryParams) > 0 || m.Method != nil {
+		return model.RouteClaim{}, false
+	}
+
+	claim := model.RouteClaim{
+		Host:     string(hostname),
+		PathType: model.RouteClaimPrefix,
+		Path:     "/",
+	}
+	if m.Path == nil {
+		return claim, true
+	}
+	if m.Path.Value != nil {
+		claim.Path = *m.Path.Value
+	}
+	if m.Path.Type != nil {
+		switch *m.Path.Type {
+		case gateway_v1.PathMatchExact:
+			claim.PathType = model.RouteClaimExact
+		case gateway_v1.PathMatchRegularExpression:
+			claim.PathType = model.RouteClaimRegex
+		}
+	}
+	return claim, true
+}
+
+// splitRouteClaims returns the route config without matches that correspond to lost claims.
+// As routes are translated for every combination of hostname and match, hostnames with lost claims
+// are split into their own route config, so that the remaining hostnames keep all matches.
+// Route configs that are left without rules are omitted.
+// Split route configs share the HTTPRoute metadata, see [model.GatewayHTTPRouteConfig.Split].
+func splitRouteClaims(r model.GatewayHTTPRouteConfig, lost []model.RouteClaimConflict) []model.GatewayHTTPRouteConfig {
+	lostHosts := make(map[gateway_v1.Hostname]bool)
+	for _, c := range lost {
+		lostHosts[gateway_v1.Hostname(c.Host)] = true
+	}
+
+	var out []model.GatewayHTTPRouteConfig
+	var kept []gateway_v1.Hostname
+	for _, h := range r.Hostnames {
+		if !lostHosts[h] {
+			kept = append(kept, h)
+		}
+	}
+	if len(kept) > 0 {
+		rc := r
+		rc.Hostnames = kept
+		out = append(out, rc)
+	}
+	for i, h := range r.Hostnames {
+		if !lostHosts[h] {
+			continue
+		}
+		rc := r
+		rc.Hostnames = []gateway_v1.Hostname{h}
+		rc.HTTPRoute = withoutRouteClaims(r.HTTPRoute, h, lost)
+		rc.Split = i + 1
+		if len(rc.Spec.Rules) > 0 {
+			out = append(out, rc)
+		}
+	}
+	return out
+}
+
+// withoutRouteClaims returns a copy of the HTTPRoute without matches that correspond to claims lost on the hostname,
+// rules that are left without matches are removed.
+func withoutRouteClaims(
+	route *gateway_v1.HTTPRoute,
+	hostname gateway_v1.Hostname,
+	lost []model.RouteClaimConflict,
+) *gateway_v1.HTTPRoute {
+	skip := make(map[model.RouteClaim]bool, len(lost))
+	for _, c := range lost {
+		skip[c.RouteClaim] = true
+	}
+	isLost := func(m gateway_v1.HTTPRouteMatch) bool {
+		claim, ok := httpRouteMatchClaim(hostname, m)
+		return ok && skip[claim]
+	}
+
+	dst := route.DeepCopy()
+	rules := dst.Spec.Rules[:0]
+	for _, rule := range dst.Spec.Rules {
+		if len(rule.Matches) == 0 {
+			if !isLost(gateway_v1.HTTPRouteMatch{}) {
+				rules = append(rules, rule)
+			}
+			continue
+		}
+		matches := rule.Matches[:0]
+		for _, m := range rule.Matches {
+			if !isLost(m) {
+				matches = append(matches, m)
+			}
+		}
+		if len(matches) > 0 {
+			rule.Matches = matches
+			rules = append(rules, rule)
+		}
+	}
+	dst.Spec.Rules = rules
+	return dst
+}
@@ -0,0 +1,161 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/pomerium/ingress-controller/model"
+)
+
+func newClaimsTestRoute(name string, created time.Time, paths ...string) *gateway_v1.HTTPRoute {
+	route := &gateway_v1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+			Generation:        1,
+		},
+	}
+	for _, p := range paths {
+		route.Spec.Rules = append(route.Spec.Rules, gateway_v1.HTTPRouteRule{
+			Matches: []gateway_v1.HTTPRouteMatch{{
+				Path: &gateway_v1.HTTPPathMatch{
+					Type:  new(gateway_v1.PathMatchPathPrefix),
+					Value: new(p),
+				},
+			}},
+		})
+	}
+	return route
+}
+
+func routePaths(route *gateway_v1.HTTPRoute) []string {
+	var paths []string
+	for _, rule := range route.Spec.Rules {
+		for _, m := range rule.Matches {
+			paths = append(paths, *m.Path.Value)
+		}
+	}
+	return paths
+}
+
+func TestWithoutRouteClaims(t *testing.T) {
+	route := newClaimsTestRoute("route", time.Now(), "/a", "/b")
+	route.Spec.Rules = append(route.Spec.Rules, gateway_v1.HTTPRouteRule{})
+	lost := []model.RouteClaimConflict{
+		{RouteClaim: model.RouteClaim{Host: "a.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/a"}},
+		{RouteClaim: model.RouteClaim{Host: "b.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/"}},
+	}
+
+	dst := withoutRouteClaims(route, "a.localhost.pomerium.io", lost)
+	assert.Equal(t, []string{"/b"}, routePaths(dst))
+	assert.Len(t, dst.Spec.Rules, 2, "rule matching all requests should be kept")
+
+	dst = withoutRouteClaims(route, "b.localhost.pomerium.io", lost)
+	assert.Equal(t, []string{"/a", "/b"}, routePaths(dst))
+	assert.Len(t, dst.Spec.Rules, 2, "rule matching all requests should be removed")
+
+	assert.Len(t, route.Spec.Rules, 3, "original route should not be modified")
+}
+
+func TestSplitRouteClaims(t *testing.T) {
+	r := model.GatewayHTTPRouteConfig{
+		HTTPRoute: newClaimsTestRoute("route", time.Now(), "/a", "/b"),
+		Hostnames: []gateway_v1.Hostname{"a.localhost.pomerium.io", "b.localhost.pomerium.io", "c.localhost.pomerium.io"},
+	}
+	lost := []model.RouteClaimConflict{
+		{RouteClaim: model.RouteClaim{Host: "b.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/a"}},
+		{RouteClaim: model.RouteClaim{Host: "c.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/a"}},
+		{RouteClaim: model.RouteClaim{Host: "c.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/b"}},
+	}
+
+	split := splitRouteClaims(r, lost)
+	require.Len(t, split, 2, "hostname that lost all matches should be omitted")
+	assert.Equal(t, []gateway_v1.Hostname{"a.localhost.pomerium.io"}, split[0].Hostnames)
+	assert.Equal(t, []string{"/a", "/b"}, routePaths(split[0].HTTPRoute))
+	assert.Equal(t, 0, split[0].Split)
+	assert.Equal(t, []gateway_v1.Hostname{"b.localhost.pomerium.io"}, split[1].Hostnames)
+	assert.Equal(t, []string{"/b"}, routePaths(split[1].HTTPRoute))
+	assert.Equal(t, 2, split[1].Split, "split should follow the hostname index")
+
+	lost = append(lost,
+		model.RouteClaimConflict{RouteClaim: model.RouteClaim{Host: "a.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/a"}},
+		model.RouteClaimConflict{RouteClaim: model.RouteClaim{Host: "a.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/b"}},
+		model.RouteClaimConflict{RouteClaim: model.RouteClaim{Host: "b.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/b"}},
+	)
+	assert.Empty(t, splitRouteClaims(r, lost))
+}
+
+func TestProcessRouteClaims(t *testing.T) {
+	idx := model.NewRouteClaimIndex()
+	c := &gatewayController{ControllerConfig: ControllerConfig{RouteClaims: idx}}
+
+	now := time.Now()
+	idx.Set(model.RouteClaimOwner{
+		Key: model.Key{
+			Kind:           model.RouteClaimKindIngress,
+			NamespacedName: types.NamespacedName{Namespace: "default", Name: "ingress"},
+		},
+		CreationTimestamp: now,
+	}, []model.RouteClaim{
+		{Host: "a.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/a"},
+		{Host: "a.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/c"},
+	})
+
+	partial := newClaimsTestRoute("partial", now.Add(time.Second), "/a", "/b")
+	unserved := newClaimsTestRoute("unserved", now.Add(time.Second), "/a")
+	older := newClaimsTestRoute("older", now.Add(-time.Second), "/c")
+	hostnames := []gateway_v1.Hostname{"a.localhost.pomerium.io", "b.localhost.pomerium.io"}
+	config := &model.GatewayConfig{Routes: []model.GatewayHTTPRouteConfig{
+		{HTTPRoute: partial, Hostnames: hostnames},
+		{HTTPRoute: unserved, Hostnames: hostnames[:1]},
+		{HTTPRoute: older, Hostnames: hostnames[:1]},
+	}}
+
+	statuses := make(map[string]*gateway_v1.RouteParentStatus)
+	var infos []httpRouteInfo
+	for _, route := range []*gateway_v1.HTTPRoute{partial, unserved, older} {
+		statuses[route.Name] = new(gateway_v1.RouteParentStatus)
+		infos = append(infos, httpRouteInfo{route: route, status: statuses[route.Name]})
+	}
+	o := &objects{HTTPRoutesByGateway: map[refKey][]httpRouteInfo{{Name: "gateway"}: infos}}
+
+	c.processRouteClaims(config, o)
+
+	type served struct {
+		name     string
+		hostname gateway_v1.Hostname
+		paths    []string
+	}
+	var got []served
+	for _, r := range config.Routes {
+		for _, h := range r.Hostnames {
+			got = append(got, served{r.Name, h, routePaths(r.HTTPRoute)})
+		}
+	}
+	assert.ElementsMatch(t, []served{
+		{"partial", "a.localhost.pomerium.io", []string{"/b"}},
+		{"partial", "b.localhost.pomerium.io", []string{"/a", "/b"}},
+		{"older", "a.localhost.pomerium.io", []string{"/c"}},
+	}, got)
+
+	cond := meta.FindStatusCondition(statuses["partial"].Conditions, string(routeConditionPartiallyServed))
+	require.NotNil(t, cond)
+	assert.Equal(t, string(routeReasonClaimedByIngress), cond.Reason)
+	assert.Contains(t, cond.Message, "a.localhost.pomerium.io")
+	assert.NotContains(t, cond.Message, "b.localhost.pomerium.io")
+
+	cond = meta.FindStatusCondition(statuses["unserved"].Conditions, string(gateway_v1.RouteConditionAccepted))
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, string(routeReasonConflicted), cond.Reason)
+
+	assert.Empty(t, statuses["older"].Conditions)
+}
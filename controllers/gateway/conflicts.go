@@ -0,0 +1,51 @@
+package gateway
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/pomerium/ingress-controller/model"
+)
+
+const (
+	// routeConditionConflicted is set on HTTPRoutes with routes that match exactly the same requests
+	// as routes of another HTTPRoute with the same priority, so only one of them is served.
+	routeConditionConflicted gateway_v1.RouteConditionType = "Conflicted"
+	// routeReasonSamePriority is used when conflicting routes have the same priority.
+	routeReasonSamePriority gateway_v1.RouteConditionReason = "SamePriority"
+)
+
+// processRouteConflicts reports conflicts between routes of different HTTPRoutes
+// in the status of the affected HTTPRoutes, and clears conflicts that were resolved.
+func processRouteConflicts(o *objects, conflicts []model.GatewayRouteConflict) {
+	// conflicts may be reported more than once if the config is synced to multiple targets
+	msgs := make(map[types.NamespacedName][]string)
+	for _, c := range conflicts {
+		if msg := c.String(); !slices.Contains(msgs[c.HTTPRoute], msg) {
+			msgs[c.HTTPRoute] = append(msgs[c.HTTPRoute], msg)
+		}
+	}
+
+	for _, infos := range o.HTTPRoutesByGateway {
+		for _, info := range infos {
+			m, ok := msgs[types.NamespacedName{Namespace: info.route.Namespace, Name: info.route.Name}]
+			if !ok {
+				meta.RemoveStatusCondition(&info.status.Conditions, string(routeConditionConflicted))
+				continue
+			}
+			slices.Sort(m)
+			upsertCondition(&info.status.Conditions, info.route.Generation, metav1.Condition{
+				Type:    string(routeConditionConflicted),
+				Status:  metav1.ConditionTrue,
+				Reason:  string(routeReasonSamePriority),
+				Message: fmt.Sprintf("conflicting routes: %s", strings.Join(m, "; ")),
+			})
+		}
+	}
+}
@@ -3,21 +3,31 @@ package gateway
 
 import (
 	context "context"
+	"errors"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
 	gateway_v1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	icgv1alpha1 "github.com/pomerium/ingress-controller/apis/gateway/v1alpha1"
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
 	"github.com/pomerium/ingress-controller/pomerium"
+	"github.com/pomerium/ingress-controller/util"
+	"github.com/pomerium/ingress-controller/util/metrics"
 )
 
 // DefaultClassControllerName is the default GatewayClass ControllerName.
@@ -29,6 +39,12 @@ type ControllerConfig struct {
 	ControllerName string
 	// Gateway addresses are determined from this service.
 	ServiceName types.NamespacedName
+	// NamespaceSelector restricts Gateways and HTTPRoutes to namespaces matching the labels, nil for all.
+	NamespaceSelector labels.Selector
+	// RouteClaims is shared with the ingress controller to detect conflicting routes, may be nil.
+	RouteClaims model.RouteClaimIndex
+	// GlobalSettings is the Pomerium CRD that PolicyFilter downstream mTLS requirements are validated against, may be nil.
+	GlobalSettings *types.NamespacedName
 }
 
 // NewControllers sets up GatewayClass and Gateway controllers.
@@ -85,7 +101,7 @@ func NewGatewayController(
 			}}
 		})
 
-	err = ctrl.NewControllerManagedBy(mgr).
+	bldr := ctrl.NewControllerManagedBy(mgr).
 		Named("gateway").
 		Watches(
 			&gateway_v1.Gateway{},
@@ -95,14 +111,23 @@ func NewGatewayController(
 		Watches(
 			&gateway_v1.HTTPRoute{},
 			enqueueRequest,
-			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+			builder.WithPredicates(predicate.Or[client.Object](predicate.GenerationChangedPredicate{}, routePriorityChangedPredicate)),
 		).
 		Watches(&corev1.Secret{}, enqueueRequest).
 		Watches(&corev1.Namespace{}, enqueueRequest).
 		Watches(&corev1.Service{}, enqueueRequest).
 		Watches(&gateway_v1beta1.ReferenceGrant{}, enqueueRequest).
 		Watches(&icgv1alpha1.PolicyFilter{}, enqueueRequest).
-		Complete(gtc)
+		Watches(&icsv1.HostnamePolicy{}, enqueueRequest).
+		Watches(
+			&icsv1.Pomerium{},
+			enqueueRequest,
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		)
+	if config.RouteClaims != nil {
+		bldr = bldr.WatchesRawSource(source.Channel(watchRouteClaims(config), enqueueRequest))
+	}
+	err = bldr.Complete(gtc)
 	if err != nil {
 		return fmt.Errorf("build controller: %w", err)
 	}
@@ -110,7 +135,36 @@ func NewGatewayController(
 	return nil
 }
 
-func (c *gatewayController) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl.Result, error) {
+// routePriorityChangedPredicate triggers reconciliation when the route priority annotation changes,
+// as annotation updates do not bump the object generation
+var routePriorityChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectOld == nil || e.ObjectNew == nil {
+			return false
+		}
+		return e.ObjectOld.GetAnnotations()[model.GatewayRoutePriorityAnnotation] !=
+			e.ObjectNew.GetAnnotations()[model.GatewayRoutePriorityAnnotation]
+	},
+}
+
+// watchRouteClaims returns a channel that is notified when an Ingress claims or releases
+// a route that is also claimed by an HTTPRoute.
+func watchRouteClaims(config ControllerConfig) <-chan event.GenericEvent {
+	ch := make(chan event.GenericEvent)
+	config.RouteClaims.Subscribe(model.RouteClaimKindHTTPRoute, func(name types.NamespacedName) {
+		evt := event.GenericEvent{Object: &gateway_v1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+		}}
+		// do not block the caller, that may be holding up another controller
+		go func() { ch <- evt }()
+	})
+	return ch
+}
+
+func (c *gatewayController) Reconcile(ctx context.Context, _ ctrl.Request) (_ ctrl.Result, err error) {
+	// Gateways and HTTPRoutes are reconciled together
+	defer func(start time.Time) { metrics.ObserveReconcile("Gateway", start, err) }(time.Now())
+
 	o, err := c.fetchObjects(ctx)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -121,7 +175,12 @@ func (c *gatewayController) Reconcile(ctx context.Context, _ ctrl.Request) (ctrl
 		return ctrl.Result{}, err
 	}
 
+	ctx = util.WithBin[model.GatewayRouteConflict](ctx)
 	_, err = c.SetGatewayConfig(ctx, config)
+	if err == nil {
+		processRouteConflicts(o, util.Get[model.GatewayRouteConflict](ctx))
+	}
+	err = errors.Join(err, c.updateModifiedHTTPRouteStatus(ctx, o.OriginalHTTPRouteStatus))
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -4,10 +4,12 @@ import (
 	context "context"
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	icgv1alpha1 "github.com/pomerium/ingress-controller/apis/gateway/v1alpha1"
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/model"
 	"github.com/pomerium/ingress-controller/pomerium/gateway"
 )
@@ -18,7 +20,7 @@ func (c *gatewayController) processExtensionFilters(
 	o *objects,
 ) error {
 	for _, pf := range o.PolicyFilters {
-		if err := c.processPolicyFilter(ctx, pf); err != nil {
+		if err := c.processPolicyFilter(ctx, pf, o.DownstreamMTLS); err != nil {
 			return err
 		}
 	}
@@ -29,15 +31,21 @@ func (c *gatewayController) processExtensionFilters(
 func (c *gatewayController) processPolicyFilter(
 	ctx context.Context,
 	pf *icgv1alpha1.PolicyFilter,
+	downstreamMTLS *icsv1.DownstreamMTLS,
 ) error {
-	// Check to see if we already have a parsed representation of this filter.
+	// Check to see if we already have a parsed representation of this filter,
+	// that was validated against the same global downstream mTLS settings.
 	k := refKeyForObject(pf)
 	f := c.extensionFilters[k]
-	if f.object != nil && f.object.GetGeneration() == pf.Generation {
+	if f.object != nil && f.object.GetGeneration() == pf.Generation &&
+		equality.Semantic.DeepEqual(f.downstreamMTLS, downstreamMTLS) {
 		return nil
 	}
 
 	filter, err := gateway.NewPolicyFilter(pf)
+	if err == nil {
+		err = filter.CheckDownstreamMTLS(downstreamMTLS)
+	}
 
 	// Set a "Valid" condition with information about whether the policy could be parsed.
 	validCondition := metav1.Condition{
@@ -57,14 +65,20 @@ func (c *gatewayController) processPolicyFilter(
 		}
 	}
 
-	c.extensionFilters[k] = objectAndFilter{pf, filter}
+	// Routes referring to an invalid filter are rejected, as the filter is not found.
+	var ef model.ExtensionFilter
+	if err == nil {
+		ef = filter
+	}
+	c.extensionFilters[k] = objectAndFilter{pf, ef, downstreamMTLS}
 
 	return nil
 }
 
 type objectAndFilter struct {
-	object client.Object
-	filter model.ExtensionFilter
+	object         client.Object
+	filter         model.ExtensionFilter
+	downstreamMTLS *icsv1.DownstreamMTLS
 }
 
 func makeExtensionFilterMap(
@@ -5,25 +5,35 @@ import (
 
 	"github.com/hashicorp/go-set/v3"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
 	gateway_v1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	icgv1alpha1 "github.com/pomerium/ingress-controller/apis/gateway/v1alpha1"
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
 	"github.com/pomerium/ingress-controller/util"
 )
 
 // objects holds all relevant Gateway objects and their dependencies.
 type objects struct {
-	Gateways                map[refKey]*gateway_v1.Gateway
-	HTTPRoutesByGateway     map[refKey][]httpRouteInfo
+	Gateways            map[refKey]*gateway_v1.Gateway
+	HTTPRoutesByGateway map[refKey][]httpRouteInfo
+	// UnwatchedHTTPRoutes are HTTPRoutes in namespaces that do not match the namespace selector,
+	// any routes previously synced for them are removed
+	UnwatchedHTTPRoutes     []*gateway_v1.HTTPRoute
 	OriginalHTTPRouteStatus []httpRouteAndOriginalStatus
 	Namespaces              map[string]*corev1.Namespace
 	ReferenceGrants         referenceGrantMap
 	TLSSecrets              map[refKey]*corev1.Secret
 	Services                map[types.NamespacedName]*corev1.Service
 	PolicyFilters           map[types.NamespacedName]*icgv1alpha1.PolicyFilter
+	HostnamePolicies        model.HostnamePolicies
+	// DownstreamMTLS are the global downstream mTLS settings, nil if not set
+	DownstreamMTLS *icsv1.DownstreamMTLS
 }
 
 type httpRouteAndOriginalStatus struct {
@@ -35,6 +45,18 @@ type httpRouteAndOriginalStatus struct {
 func (c *gatewayController) fetchObjects(ctx context.Context) (*objects, error) {
 	var o objects
 
+	// Fetch all Namespaces (the labels may be needed for the allowedRoutes restrictions
+	// and the namespace selector).
+	var nl corev1.NamespaceList
+	if err := c.List(ctx, &nl); err != nil {
+		return nil, err
+	}
+	o.Namespaces = make(map[string]*corev1.Namespace)
+	for i := range nl.Items {
+		n := &nl.Items[i]
+		o.Namespaces[n.Name] = n
+	}
+
 	// Fetch all GatewayClasses and filter by controller name.
 	var gcl gateway_v1.GatewayClassList
 	if err := c.List(ctx, &gcl); err != nil {
@@ -56,7 +78,7 @@ func (c *gatewayController) fetchObjects(ctx context.Context) (*objects, error)
 	o.Gateways = make(map[refKey]*gateway_v1.Gateway)
 	for i := range gl.Items {
 		g := &gl.Items[i]
-		if gcNames.Contains(string(g.Spec.GatewayClassName)) {
+		if gcNames.Contains(string(g.Spec.GatewayClassName)) && c.isNamespaceWatched(&o, g.Namespace) {
 			o.Gateways[refKeyForObject(g)] = g
 		}
 	}
@@ -69,6 +91,10 @@ func (c *gatewayController) fetchObjects(ctx context.Context) (*objects, error)
 	o.HTTPRoutesByGateway = make(map[refKey][]httpRouteInfo)
 	for i := range hrl.Items {
 		hr := &hrl.Items[i]
+		if !c.isNamespaceWatched(&o, hr.Namespace) {
+			o.UnwatchedHTTPRoutes = append(o.UnwatchedHTTPRoutes, hr)
+			continue
+		}
 		o.OriginalHTTPRouteStatus = append(o.OriginalHTTPRouteStatus,
 			httpRouteAndOriginalStatus{route: hr, originalStatus: hr.Status.DeepCopy()})
 		ensureRouteParentStatusExists(hr, c.ControllerName)
@@ -82,17 +108,6 @@ func (c *gatewayController) fetchObjects(ctx context.Context) (*objects, error)
 		}
 	}
 
-	// Fetch all Namespaces (the labels may be needed for the allowedRoutes restrictions).
-	var nl corev1.NamespaceList
-	if err := c.List(ctx, &nl); err != nil {
-		return nil, err
-	}
-	o.Namespaces = make(map[string]*corev1.Namespace)
-	for i := range nl.Items {
-		n := &nl.Items[i]
-		o.Namespaces[n.Name] = n
-	}
-
 	// Fetch all ReferenceGrants.
 	var rgl gateway_v1beta1.ReferenceGrantList
 	if err := c.List(ctx, &rgl); err != nil {
@@ -133,9 +148,35 @@ func (c *gatewayController) fetchObjects(ctx context.Context) (*objects, error)
 		o.PolicyFilters[util.GetNamespacedName(pf)] = pf
 	}
 
+	// Fetch the global settings, if any.
+	if c.GlobalSettings != nil {
+		var settings icsv1.Pomerium
+		if err := c.Get(ctx, *c.GlobalSettings, &settings); err == nil {
+			o.DownstreamMTLS = settings.Spec.DownstreamMTLS
+		} else if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	// Fetch all HostnamePolicies.
+	var hpl icsv1.HostnamePolicyList
+	if err := c.List(ctx, &hpl); err != nil {
+		return nil, err
+	}
+	o.HostnamePolicies = hpl.Items
+
 	return &o, nil
 }
 
+// isNamespaceWatched checks whether the namespace matches the configured namespace selector.
+func (c *gatewayController) isNamespaceWatched(o *objects, namespace string) bool {
+	if c.NamespaceSelector == nil {
+		return true
+	}
+	ns := o.Namespaces[namespace]
+	return ns != nil && c.NamespaceSelector.Matches(labels.Set(ns.Labels))
+}
+
 type httpRouteInfo struct {
 	route  *gateway_v1.HTTPRoute
 	parent *gateway_v1.ParentReference
@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+	gateway_v1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	icgv1alpha1 "github.com/pomerium/ingress-controller/apis/gateway/v1alpha1"
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+)
+
+func TestFetchObjectsUnwatchedNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, gateway_v1.Install(scheme))
+	require.NoError(t, gateway_v1beta1.Install(scheme))
+	require.NoError(t, icgv1alpha1.AddToScheme(scheme))
+	require.NoError(t, icsv1.AddToScheme(scheme))
+
+	watched := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "watched",
+		Labels: map[string]string{"pomerium": "true"},
+	}}
+	// the label was removed from this namespace after its HTTPRoute was synced
+	unlabeled := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}}
+	newRoute := func(namespace string) *gateway_v1.HTTPRoute {
+		return &gateway_v1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "route", Namespace: namespace}}
+	}
+
+	cl := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(watched, unlabeled, newRoute("watched"), newRoute("unlabeled")).
+		WithIndex(&corev1.Secret{}, "type",
+			func(o client.Object) []string { return []string{string(o.(*corev1.Secret).Type)} }).
+		Build()
+	c := &gatewayController{
+		Client: cl,
+		ControllerConfig: ControllerConfig{
+			NamespaceSelector: labels.SelectorFromSet(labels.Set{"pomerium": "true"}),
+		},
+		extensionFilters: make(map[refKey]objectAndFilter),
+	}
+
+	o, err := c.fetchObjects(t.Context())
+	require.NoError(t, err)
+	require.Len(t, o.UnwatchedHTTPRoutes, 1)
+	assert.Equal(t, "unlabeled", o.UnwatchedHTTPRoutes[0].Namespace)
+	require.Len(t, o.OriginalHTTPRouteStatus, 1)
+	assert.Equal(t, "watched", o.OriginalHTTPRouteStatus[0].route.Namespace)
+
+	config, err := c.processGateways(t.Context(), o)
+	require.NoError(t, err)
+	require.Len(t, config.Routes, 1, "unwatched route should be passed on for removal")
+	assert.Equal(t, "unlabeled", config.Routes[0].Namespace)
+	assert.True(t, config.Routes[0].Removed)
+	assert.True(t, config.Routes[0].IsDeleted())
+}
@@ -7,13 +7,14 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/pomerium/ingress-controller/model"
 )
 
-// processGateways updates the status of all Gateways and associated routes, and returns a
-// GatewayConfig object with all valid configuration.
+// processGateways updates the status of all Gateways and computes the status of associated routes,
+// and returns a GatewayConfig object with all valid configuration.
 func (c *gatewayController) processGateways(
 	ctx context.Context,
 	o *objects,
@@ -30,8 +31,15 @@ func (c *gatewayController) processGateways(
 		}
 	}
 
-	if err := c.updateModifiedHTTPRouteStatus(ctx, o.OriginalHTTPRouteStatus); err != nil {
-		return nil, err
+	c.processRouteClaims(&config, o)
+
+	// HTTPRoutes whose namespace stopped matching the namespace selector
+	// are passed on as removed, so that their routes and finalizers are cleaned up.
+	for _, hr := range o.UnwatchedHTTPRoutes {
+		config.Routes = append(config.Routes, model.GatewayHTTPRouteConfig{
+			HTTPRoute: hr,
+			Removed:   true,
+		})
 	}
 
 	return &config, nil
@@ -80,6 +88,7 @@ func (c *gatewayController) processGateway(
 				Hostnames:        result.Hostnames,
 				ValidBackendRefs: result.ValidBackendRefs,
 				Services:         o.Services,
+				Priority:         result.Priority,
 			})
 		}
 	}
@@ -175,7 +184,10 @@ func (c *gatewayController) updateModifiedHTTPRouteStatus(
 ) error {
 	for _, r := range s {
 		if !equality.Semantic.DeepEqual(r.route.Status, r.originalStatus) {
-			if err := c.Status().Update(ctx, r.route); err != nil {
+			// the route may have been patched while syncing the config, so its status is merged
+			original := r.route.DeepCopy()
+			original.Status = *r.originalStatus
+			if err := c.Status().Patch(ctx, r.route, client.MergeFrom(original)); err != nil {
 				return fmt.Errorf("couldn't update status for route %q: %w", r.route.Name, err)
 			}
 		}
@@ -2,11 +2,14 @@ package gateway
 
 import (
 	context "context"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/pomerium/ingress-controller/util/metrics"
 )
 
 type gatewayClassController struct {
@@ -31,7 +34,9 @@ func NewGatewayClassController(
 		Complete(gtcc)
 }
 
-func (c *gatewayClassController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (c *gatewayClassController) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	defer func(start time.Time) { metrics.ObserveReconcile("GatewayClass", start, err) }(time.Now())
+
 	var gc gateway_v1.GatewayClass
 	if err := c.Get(ctx, req.NamespacedName, &gc); err != nil {
 		return ctrl.Result{}, err
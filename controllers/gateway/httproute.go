@@ -11,11 +11,14 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/pomerium/ingress-controller/model"
 )
 
 type httpRouteResult struct {
 	Hostnames        []gateway_v1.Hostname
 	ValidBackendRefs backendRefSet
+	Priority         int32
 }
 
 // processHTTPRoute checks the validity of an HTTPRoute, updates its status accordingly, and
@@ -39,6 +42,18 @@ func processHTTPRoute(
 		return result
 	}
 
+	priority, err := model.GetGatewayRoutePriority(r.route)
+	if err != nil {
+		upsertCondition(&r.status.Conditions, r.route.Generation, metav1.Condition{
+			Type:    string(gateway_v1.RouteConditionAccepted),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(gateway_v1.RouteReasonUnsupportedValue),
+			Message: err.Error(),
+		})
+		return result
+	}
+	result.Priority = priority
+
 	result.ValidBackendRefs = validateHTTPRouteBackendRefsResolved(o, r)
 
 	// An HTTPRoute may specify a listener name directly. In this case we should check for route
@@ -52,6 +67,7 @@ func processHTTPRoute(
 		ra := processHTTPRouteForListener(o, g, l, r.route)
 		setRouteStatusAccepted(r, ra.reason)
 		result.Hostnames = ra.hostnames
+		checkHostnamePolicies(o, r, &result)
 		return result
 	}
 
@@ -72,9 +88,29 @@ func processHTTPRoute(
 	}
 	setRouteStatusAccepted(r, reason)
 	result.Hostnames = hostnamesSet.Slice()
+	checkHostnamePolicies(o, r, &result)
 	return result
 }
 
+// routeReasonNotAllowedByHostnamePolicy is used when the route namespace may not use some of its hostnames.
+const routeReasonNotAllowedByHostnamePolicy gateway_v1.RouteConditionReason = "NotAllowedByHostnamePolicy"
+
+// checkHostnamePolicies rejects the route if any of its hostnames is restricted to other namespaces.
+func checkHostnamePolicies(o *objects, r httpRouteInfo, result *httpRouteResult) {
+	for _, h := range result.Hostnames {
+		if err := o.HostnamePolicies.CheckHostname(o.Namespaces[r.route.Namespace], string(h)); err != nil {
+			upsertCondition(&r.status.Conditions, r.route.Generation, metav1.Condition{
+				Type:    string(gateway_v1.RouteConditionAccepted),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(routeReasonNotAllowedByHostnamePolicy),
+				Message: err.Error(),
+			})
+			result.Hostnames = nil
+			return
+		}
+	}
+}
+
 func anyBackendRefHasFilters(rules []gateway_v1.HTTPRouteRule) bool {
 	for i := range rules {
 		rule := &rules[i]
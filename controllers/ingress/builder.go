@@ -34,12 +34,19 @@ func NewIngressController(
 		MultiIngressStatusReporter: []reporter.IngressStatusReporter{
 			&reporter.IngressEventReporter{EventRecorder: mgr.GetEventRecorderFor(controllerName)},
 			&reporter.IngressLogReporter{V: 1, Name: controllerName},
+			&reporter.IngressMetricsReporter{},
 		},
 	}
 	ic.initComplete = newOnce(ic.reconcileInitial)
 	for _, opt := range opts {
 		opt(ic)
 	}
+	if ic.batchWindow > 0 {
+		ic.IngressReconciler = pomerium.NewBatchIngressReconciler(ic.IngressReconciler, ic.batchWindow, ic.batchSize)
+		if ic.batchConcurrency > 0 {
+			ic.fetchLimit = make(chan struct{}, ic.batchConcurrency)
+		}
+	}
 
 	if err := ic.SetupWithManager(mgr); err != nil {
 		return fmt.Errorf("unable to create controller: %w", err)
@@ -0,0 +1,147 @@
+package ingress
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+// WithRouteClaimIndex makes ingress controller register routes it claims in a shared index,
+// and skip the ones that are already claimed by an older HTTPRoute
+func WithRouteClaimIndex(idx model.RouteClaimIndex) Option {
+	return func(ic *ingressController) {
+		ic.routeClaims = idx
+	}
+}
+
+// claimRoutes registers routes of the ingress in the route claim index,
+// reports any conflicts and returns ingress config without routes that were lost to HTTPRoutes
+func (r *ingressController) claimRoutes(ctx context.Context, ic *model.IngressConfig) *model.IngressConfig {
+	if r.routeClaims == nil {
+		return ic
+	}
+
+	key := model.Key{Kind: model.RouteClaimKindIngress, NamespacedName: ic.GetIngressNamespacedName()}
+	r.routeClaims.Set(model.RouteClaimOwner{
+		Key:               key,
+		CreationTimestamp: ic.Ingress.CreationTimestamp.Time,
+	}, getIngressRouteClaims(ic))
+
+	lost := r.routeClaims.Lost(key)
+	if len(lost) == 0 {
+		return ic
+	}
+	util.Add(ctx, lost...)
+	return withoutRouteClaims(ic, lost)
+}
+
+// releaseRoutes removes routes of the ingress from the route claim index
+func (r *ingressController) releaseRoutes(name types.NamespacedName) {
+	if r.routeClaims == nil {
+		return
+	}
+	r.routeClaims.Delete(model.Key{Kind: model.RouteClaimKindIngress, NamespacedName: name})
+}
+
+// getIngressRouteClaims returns routes the ingress would configure
+func getIngressRouteClaims(ic *model.IngressConfig) []model.RouteClaim {
+	if ic.IsSSHUpstream() || ic.IsTCPUpstream() || ic.IsUDPUpstream() {
+		return nil
+	}
+
+	var claims []model.RouteClaim
+	if claim, ok := getDefaultBackendRouteClaim(ic); ok {
+		claims = append(claims, claim)
+	}
+	for _, rule := range ic.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			if claim, ok := getPathRouteClaim(ic, rule.Host, p); ok {
+				claims = append(claims, claim)
+			}
+		}
+	}
+	return claims
+}
+
+func getDefaultBackendRouteClaim(ic *model.IngressConfig) (model.RouteClaim, bool) {
+	if ic.Spec.DefaultBackend == nil || len(ic.Spec.TLS) != 1 || len(ic.Spec.TLS[0].Hosts) != 1 {
+		return model.RouteClaim{}, false
+	}
+	return model.RouteClaim{
+		Host:     ic.Spec.TLS[0].Hosts[0],
+		PathType: model.RouteClaimPrefix,
+		Path:     "/",
+	}, true
+}
+
+func getPathRouteClaim(ic *model.IngressConfig, host string, p networkingv1.HTTPIngressPath) (model.RouteClaim, bool) {
+	if host == "" {
+		host = "*"
+	}
+	if p.PathType == nil {
+		return model.RouteClaim{}, false
+	}
+
+	claim := model.RouteClaim{Host: host, Path: p.Path}
+	switch *p.PathType {
+	case networkingv1.PathTypeExact:
+		claim.PathType = model.RouteClaimExact
+	case networkingv1.PathTypePrefix:
+		claim.PathType = model.RouteClaimPrefix
+	case networkingv1.PathTypeImplementationSpecific:
+		if ic.IsPathRegex() {
+			claim.PathType = model.RouteClaimRegex
+		} else {
+			claim.PathType = model.RouteClaimPrefix
+		}
+	default:
+		return model.RouteClaim{}, false
+	}
+	if claim.Path == "" && claim.PathType == model.RouteClaimPrefix {
+		claim.Path = "/"
+	}
+	return claim, true
+}
+
+// withoutRouteClaims returns a copy of ingress config that does not contain paths matching lost claims
+func withoutRouteClaims(ic *model.IngressConfig, lost []model.RouteClaimConflict) *model.IngressConfig {
+	skip := make(map[model.RouteClaim]bool, len(lost))
+	for _, c := range lost {
+		skip[c.RouteClaim] = true
+	}
+
+	dst := *ic
+	dst.Ingress = ic.Ingress.DeepCopy()
+
+	if claim, ok := getDefaultBackendRouteClaim(&dst); ok && skip[claim] {
+		dst.Spec.DefaultBackend = nil
+	}
+	rules := dst.Spec.Rules[:0]
+	for _, rule := range dst.Spec.Rules {
+		if rule.HTTP == nil {
+			rules = append(rules, rule)
+			continue
+		}
+		paths := rule.HTTP.Paths[:0]
+		for _, p := range rule.HTTP.Paths {
+			if claim, ok := getPathRouteClaim(&dst, rule.Host, p); ok && skip[claim] {
+				continue
+			}
+			paths = append(paths, p)
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		rule.HTTP.Paths = paths
+		rules = append(rules, rule)
+	}
+	dst.Spec.Rules = rules
+	return &dst
+}
@@ -0,0 +1,97 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+func newClaimsTestIngress(created time.Time, rules map[string][]string) *model.IngressConfig {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              "ingress",
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+	for _, host := range []string{"a.localhost.pomerium.io", "b.localhost.pomerium.io"} {
+		paths := rules[host]
+		if len(paths) == 0 {
+			continue
+		}
+		rule := networkingv1.IngressRule{
+			Host:             host,
+			IngressRuleValue: networkingv1.IngressRuleValue{HTTP: new(networkingv1.HTTPIngressRuleValue)},
+		}
+		for _, p := range paths {
+			rule.HTTP.Paths = append(rule.HTTP.Paths, networkingv1.HTTPIngressPath{
+				Path:     p,
+				PathType: new(networkingv1.PathTypePrefix),
+			})
+		}
+		ing.Spec.Rules = append(ing.Spec.Rules, rule)
+	}
+	return &model.IngressConfig{Ingress: ing}
+}
+
+func ingressPaths(ic *model.IngressConfig) map[string][]string {
+	out := make(map[string][]string)
+	for _, rule := range ic.Spec.Rules {
+		for _, p := range rule.HTTP.Paths {
+			out[rule.Host] = append(out[rule.Host], p.Path)
+		}
+	}
+	return out
+}
+
+func TestClaimRoutes(t *testing.T) {
+	idx := model.NewRouteClaimIndex()
+	r := &ingressController{routeClaims: idx}
+
+	now := time.Now()
+	route := model.RouteClaimOwner{
+		Key: model.Key{
+			Kind:           model.RouteClaimKindHTTPRoute,
+			NamespacedName: types.NamespacedName{Namespace: "default", Name: "route"},
+		},
+		CreationTimestamp: now,
+	}
+	idx.SetKind(model.RouteClaimKindHTTPRoute, map[model.RouteClaimOwner][]model.RouteClaim{
+		route: {
+			{Host: "a.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/a"},
+			{Host: "b.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/b"},
+		},
+	})
+
+	ic := newClaimsTestIngress(now.Add(time.Second), map[string][]string{
+		"a.localhost.pomerium.io": {"/a", "/b"},
+		"b.localhost.pomerium.io": {"/b"},
+	})
+	ctx := util.WithBin[model.RouteClaimConflict](context.Background())
+	got := r.claimRoutes(ctx, ic)
+	assert.Equal(t, map[string][]string{
+		"a.localhost.pomerium.io": {"/b"},
+	}, ingressPaths(got), "lost paths and rules left without paths should be removed")
+	assert.Equal(t, []model.RouteClaimConflict{
+		{RouteClaim: model.RouteClaim{Host: "a.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/a"}, Winner: route.Key},
+		{RouteClaim: model.RouteClaim{Host: "b.localhost.pomerium.io", PathType: model.RouteClaimPrefix, Path: "/b"}, Winner: route.Key},
+	}, util.Get[model.RouteClaimConflict](ctx))
+	assert.Len(t, ic.Spec.Rules, 2, "original ingress should not be modified")
+
+	older := newClaimsTestIngress(now.Add(-time.Second), map[string][]string{
+		"a.localhost.pomerium.io": {"/a", "/b"},
+		"b.localhost.pomerium.io": {"/b"},
+	})
+	assert.Same(t, older, r.claimRoutes(context.Background(), older), "older ingress should keep all routes")
+
+	r.releaseRoutes(older.GetIngressNamespacedName())
+	assert.Empty(t, idx.Lost(route.Key))
+}
@@ -1,17 +1,21 @@
 package ingress
 
 import (
+	"context"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/controllers/reporter"
@@ -44,6 +48,8 @@ type ingressController struct {
 
 	// Namespaces to listen to, nil/empty to listen to all
 	namespaces map[string]bool
+	// namespaceSelector restricts namespaces to listen to by their labels, nil to listen to all
+	namespaceSelector labels.Selector
 
 	// ingressStatusReporter is used to report ingress status changes
 	reporter.MultiIngressStatusReporter
@@ -55,7 +61,21 @@ type ingressController struct {
 	// globalSettings defines which global settings object to watch
 	globalSettings *types.NamespacedName
 
+	// batchWindow if set, gathers ingress changes for that long and applies them at once
+	batchWindow time.Duration
+	// batchSize is the max number of ingress changes applied at once
+	batchSize int
+	// batchConcurrency is the max number of ingresses fetching their dependencies concurrently when batching
+	batchConcurrency int
+	// fetchLimit holds a slot for each ingress fetching its dependencies when batching, nil if not limited.
+	// Reconcile workers waiting for their batch to be applied do not hold a slot.
+	fetchLimit chan struct{}
+
+	// routeClaims is shared with the gateway controller to detect conflicting routes, may be nil
+	routeClaims model.RouteClaimIndex
+
 	// object Kinds are frequently used, do not change and are cached
+	backendKind      string
 	endpointsKind    string
 	ingressKind      string
 	ingressClassKind string
@@ -104,6 +124,27 @@ func WithNamespaces(ns []string) Option {
 	}
 }
 
+// WithNamespaceSelector requires ingress controller to only monitor namespaces matching the label selector,
+// ingresses are reconciled or removed from Pomerium as namespaces gain or lose matching labels
+func WithNamespaceSelector(selector labels.Selector) Option {
+	return func(ic *ingressController) {
+		if selector != nil && !selector.Empty() {
+			ic.namespaceSelector = selector
+		}
+	}
+}
+
+// WithBatching makes ingress controller gather up to size changes to Pomerium configuration
+// for up to window before applying them at once, while up to concurrency ingresses fetch their dependencies at a time.
+// Zero window disables batching.
+func WithBatching(window time.Duration, size, concurrency int) Option {
+	return func(ic *ingressController) {
+		ic.batchWindow = window
+		ic.batchSize = size
+		ic.batchConcurrency = concurrency
+	}
+}
+
 // WithUpdateIngressStatusFromService configures ingress controller to watch a designated service (pomerium proxy)
 // for its load balancer status, and update all managed ingresses accordingly
 func WithUpdateIngressStatusFromService(name types.NamespacedName) Option {
@@ -126,13 +167,14 @@ func (r *ingressController) SetupWithManager(mgr ctrl.Manager) error {
 
 	// cache frequently used object kinds
 	r.secretKind = generic.GVKForType[*corev1.Secret](r.Scheme).Kind
+	r.backendKind = generic.GVKForType[*icsv1.Backend](r.Scheme).Kind
 	r.ingressKind = generic.GVKForType[*networkingv1.Ingress](r.Scheme).Kind
 	r.serviceKind = generic.GVKForType[*corev1.Service](r.Scheme).Kind
 	r.settingsKind = generic.GVKForType[*icsv1.Pomerium](r.Scheme).Kind
 	r.endpointsKind = generic.GVKForType[*corev1.Endpoints](r.Scheme).Kind
 	r.ingressClassKind = generic.GVKForType[*networkingv1.IngressClass](r.Scheme).Kind
 
-	err := ctrl.NewControllerManagedBy(mgr).
+	bldr := ctrl.NewControllerManagedBy(mgr).
 		Named(controllerName).
 		For(&networkingv1.Ingress{}).
 		Watches(
@@ -145,19 +187,68 @@ func (r *ingressController) SetupWithManager(mgr ctrl.Manager) error {
 		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.getDependantIngressFn(r.secretKind))).
 		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(r.getDependantIngressFn(r.serviceKind))).
 		Watches(&corev1.Endpoints{}, handler.EnqueueRequestsFromMapFunc(r.getDependantIngressFn(r.endpointsKind))).
-		WithEventFilter(predicate.ResourceVersionChangedPredicate{}).
-		Complete(r)
+		Watches(&icsv1.Backend{}, handler.EnqueueRequestsFromMapFunc(r.getDependantIngressFn(r.backendKind))).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.watchNamespace()),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		).
+		Watches(&icsv1.HostnamePolicy{}, handler.EnqueueRequestsFromMapFunc(r.watchHostnamePolicy())).
+		Watches(
+			&icsv1.Pomerium{},
+			handler.EnqueueRequestsFromMapFunc(r.getDependantIngressFn(r.settingsKind)),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		WithEventFilter(predicate.ResourceVersionChangedPredicate{})
+	if workers := r.maxConcurrentReconciles(); workers > 1 {
+		bldr = bldr.WithOptions(controller.Options{MaxConcurrentReconciles: workers})
+	}
+	if r.routeClaims != nil {
+		bldr = bldr.WatchesRawSource(source.Channel(r.watchRouteClaims(), &handler.EnqueueRequestForObject{}))
+	}
+	err := bldr.Complete(r)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// maxConcurrentReconciles returns the number of reconcile workers.
+// When batching, each worker waits until its change is applied, so there is a worker per change of a full batch.
+func (r *ingressController) maxConcurrentReconciles() int {
+	if r.batchWindow <= 0 {
+		return 1
+	}
+	return max(r.batchSize, r.batchConcurrency, 1)
+}
+
+// limitFetch waits until the ingress may fetch its dependencies, and returns a func to release the slot
+func (r *ingressController) limitFetch(ctx context.Context) (release func(), err error) {
+	if r.fetchLimit == nil {
+		return func() {}, nil
+	}
+	select {
+	case r.fetchLimit <- struct{}{}:
+		return func() { <-r.fetchLimit }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 func (r *ingressController) isWatching(obj client.Object) bool {
 	if len(r.namespaces) == 0 {
 		return true
 	}
 
+	if ns, ok := obj.(*corev1.Namespace); ok {
+		return r.namespaces[ns.Name]
+	}
+
+	// global settings are cluster-scoped
+	if _, ok := obj.(*icsv1.Pomerium); ok {
+		return true
+	}
+
 	if (r.updateStatusFromService != nil) &&
 		(*r.updateStatusFromService == types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}) {
 		return true
@@ -2,15 +2,21 @@ package ingress
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	controllers_mock "github.com/pomerium/ingress-controller/controllers/mock"
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/pomerium"
 )
 
 func TestManagingIngressClass(t *testing.T) {
@@ -127,3 +133,49 @@ func TestManagingIngressClass(t *testing.T) {
 		}
 	}
 }
+
+type countingBatchReconciler struct {
+	pomerium.IngressReconciler
+	batches []int
+}
+
+func (r *countingBatchReconciler) Apply(_ context.Context, changes []pomerium.IngressChange) ([]bool, error) {
+	r.batches = append(r.batches, len(changes))
+	return make([]bool, len(changes)), nil
+}
+
+func TestBatchingWorkers(t *testing.T) {
+	const size, concurrency = 10, 2
+	next := new(countingBatchReconciler)
+	r := &ingressController{
+		batchWindow:      time.Hour,
+		batchSize:        size,
+		batchConcurrency: concurrency,
+		fetchLimit:       make(chan struct{}, concurrency),
+	}
+	r.IngressReconciler = pomerium.NewBatchIngressReconciler(next, r.batchWindow, r.batchSize)
+
+	// every worker fetches its dependencies and then waits for its batch to be applied,
+	// so a batch can only fill up if there are at least as many workers as the batch size
+	workers := r.maxConcurrentReconciles()
+	require.Greater(t, workers, concurrency)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Go(func() {
+			release, err := r.limitFetch(ctx)
+			if !assert.NoError(t, err) {
+				return
+			}
+			release()
+			_, err = r.Upsert(ctx, &model.IngressConfig{Ingress: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: fmt.Sprintf("ingress-%d", i)},
+			}})
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, []int{size}, next.batches, "all changes should be applied at once")
+}
@@ -6,8 +6,10 @@ import (
 	"reflect"
 
 	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -71,3 +73,71 @@ func (r *ingressController) watchIngressClass() handler.MapFunc {
 		return deps
 	}
 }
+
+// watchHostnamePolicy returns all watched ingresses, as any of them may be affected by a hostname policy change
+func (r *ingressController) watchHostnamePolicy() handler.MapFunc {
+	return func(ctx context.Context, a client.Object) []reconcile.Request {
+		logger := log.FromContext(ctx)
+		il := new(networkingv1.IngressList)
+		if err := r.Client.List(ctx, il); err != nil {
+			logger.Error(err, "list")
+			return nil
+		}
+		deps := make([]reconcile.Request, 0, len(il.Items))
+		for i := range il.Items {
+			if !r.isWatching(&il.Items[i]) {
+				continue
+			}
+			deps = append(deps, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      il.Items[i].Name,
+					Namespace: il.Items[i].Namespace,
+				},
+			})
+		}
+		logger.V(5).Info("watch", "deps", deps, "hostnamePolicy", a.GetName())
+		return deps
+	}
+}
+
+// watchNamespace returns ingresses in a namespace which labels have changed,
+// as labels affect whether the namespace is watched and which hostnames its ingresses may use
+func (r *ingressController) watchNamespace() handler.MapFunc {
+	return func(ctx context.Context, a client.Object) []reconcile.Request {
+		if !r.isWatching(a) {
+			return nil
+		}
+
+		logger := log.FromContext(ctx)
+		il := new(networkingv1.IngressList)
+		if err := r.Client.List(ctx, il, client.InNamespace(a.GetName())); err != nil {
+			logger.Error(err, "list")
+			return nil
+		}
+		deps := make([]reconcile.Request, 0, len(il.Items))
+		for i := range il.Items {
+			deps = append(deps, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      il.Items[i].Name,
+					Namespace: il.Items[i].Namespace,
+				},
+			})
+		}
+		logger.V(5).Info("watch", "deps", deps, "namespace", a.GetName())
+		return deps
+	}
+}
+
+// watchRouteClaims returns a channel of ingresses that need be reconciled
+// because an HTTPRoute claimed or released some of their routes
+func (r *ingressController) watchRouteClaims() <-chan event.GenericEvent {
+	ch := make(chan event.GenericEvent)
+	r.routeClaims.Subscribe(model.RouteClaimKindIngress, func(name types.NamespacedName) {
+		evt := event.GenericEvent{Object: &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+		}}
+		// do not block the caller, that may be holding up another controller
+		go func() { ch <- evt }()
+	})
+	return ch
+}
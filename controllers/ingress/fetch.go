@@ -7,10 +7,12 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/controllers/deps"
 	"github.com/pomerium/ingress-controller/model"
 )
@@ -28,7 +30,21 @@ func (r *ingressController) fetchIngress(ctx context.Context, ingress *networkin
 		_ = client.Get(ctx, *r.updateStatusFromService, new(corev1.Service))
 	}
 
-	return FetchIngress(ctx, client, ingress, r.annotationPrefix)
+	ic, err := FetchIngress(ctx, client, ingress, r.annotationPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	// route downstream mTLS requirements are validated against the global settings
+	if _, ok := ingress.Annotations[fmt.Sprintf("%s/%s", r.annotationPrefix, model.DownstreamMTLSEnforcement)]; ok && r.globalSettings != nil {
+		var settings icsv1.Pomerium
+		if err := client.Get(ctx, *r.globalSettings, &settings); err == nil {
+			ic.DownstreamMTLS = settings.Spec.DownstreamMTLS
+		} else if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("get settings %s: %w", r.globalSettings, err)
+		}
+	}
+	return ic, nil
 }
 
 // FetchIngress populates a model.IngressConfig for ingress.
@@ -48,12 +64,18 @@ func FetchIngress(
 		return nil, fmt.Errorf("services: %w", err)
 	}
 
+	backends, err := fetchIngressBackends(ctx, client, ingress)
+	if err != nil {
+		return nil, fmt.Errorf("backends: %w", err)
+	}
+
 	return &model.IngressConfig{
 		AnnotationPrefix: annotationPrefix,
 		Ingress:          ingress,
 		Endpoints:        endpoints,
 		Secrets:          secrets,
 		Services:         services,
+		Backends:         backends,
 	}, nil
 }
 
@@ -72,6 +94,9 @@ func fetchIngressServices(ctx context.Context, client client.Client, ingress *ne
 		}
 		for _, p := range rule.HTTP.Paths {
 			svc := p.Backend.Service
+			if svc == nil && p.Backend.Resource != nil {
+				continue
+			}
 			if svc == nil {
 				return nil, nil, fmt.Errorf("rule host=%s path=%s has no backend service defined", rule.Host, p.Path)
 			}
@@ -87,6 +112,13 @@ func fetchIngressServices(ctx context.Context, client client.Client, ingress *ne
 		return sm, em, nil
 	}
 
+	if ingress.Spec.DefaultBackend.Service == nil {
+		if ingress.Spec.DefaultBackend.Resource != nil {
+			return sm, em, nil
+		}
+		return nil, nil, fmt.Errorf("defaultBackend has no backend service defined")
+	}
+
 	if err := fetchIngressService(ctx, client, sm, em,
 		types.NamespacedName{
 			Name:      ingress.Spec.DefaultBackend.Service.Name,
@@ -98,6 +130,50 @@ func fetchIngressServices(ctx context.Context, client client.Client, ingress *ne
 	return sm, em, nil
 }
 
+// fetchIngressBackends returns Pomerium Backend resources referred from the ingress path backend spec
+func fetchIngressBackends(ctx context.Context, client client.Client, ingress *networkingv1.Ingress) (
+	map[types.NamespacedName]*icsv1.Backend,
+	error,
+) {
+	bm := make(map[types.NamespacedName]*icsv1.Backend)
+
+	fetch := func(backend *networkingv1.IngressBackend) error {
+		if backend.Service != nil || backend.Resource == nil {
+			return nil
+		}
+		if !model.IsPomeriumBackend(backend) {
+			return fmt.Errorf("unsupported resource %s, only %s.%s is supported",
+				backend.Resource.Kind, icsv1.BackendKind, icsv1.GroupVersion.Group)
+		}
+		name := types.NamespacedName{Name: backend.Resource.Name, Namespace: ingress.Namespace}
+		obj := new(icsv1.Backend)
+		if err := client.Get(ctx, name, obj); err != nil {
+			return fmt.Errorf("get %s %s: %w", icsv1.BackendKind, name.String(), err)
+		}
+		bm[name] = obj
+		return nil
+	}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			if err := fetch(&p.Backend); err != nil {
+				return nil, fmt.Errorf("rule host=%s path=%s: %w", rule.Host, p.Path, err)
+			}
+		}
+	}
+
+	if ingress.Spec.DefaultBackend != nil {
+		if err := fetch(ingress.Spec.DefaultBackend); err != nil {
+			return nil, fmt.Errorf("defaultBackend: %w", err)
+		}
+	}
+
+	return bm, nil
+}
+
 func fetchIngressService(
 	ctx context.Context,
 	client client.Client,
@@ -0,0 +1,70 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
+)
+
+// getHostnamePolicies returns all hostname policies, and the namespace they should be checked against
+func (r *ingressController) getHostnamePolicies(ctx context.Context, namespace string) (model.HostnamePolicies, *corev1.Namespace, error) {
+	var list icsv1.HostnamePolicyList
+	if err := r.Client.List(ctx, &list); err != nil {
+		return nil, nil, fmt.Errorf("list hostname policies: %w", err)
+	}
+	if len(list.Items) == 0 {
+		return nil, nil, nil
+	}
+
+	ns := new(corev1.Namespace)
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, nil, fmt.Errorf("get namespace %s: %w", namespace, err)
+	}
+	return list.Items, ns, nil
+}
+
+// checkIngressHostnames verifies that the ingress namespace may use all hostnames the ingress refers to
+func checkIngressHostnames(policies model.HostnamePolicies, ns *corev1.Namespace, ingress *networkingv1.Ingress) error {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host == "" {
+			hosts = append(hosts, "*")
+		} else {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	if ingress.Spec.DefaultBackend != nil {
+		for _, tls := range ingress.Spec.TLS {
+			hosts = append(hosts, tls.Hosts...)
+		}
+	}
+
+	for _, host := range hosts {
+		if err := policies.CheckHostname(ns, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rejectIngress removes the ingress from Pomerium configuration, and reports it was not reconciled
+func (r *ingressController) rejectIngress(ctx context.Context, ingress *networkingv1.Ingress, reason error) (ctrl.Result, error) {
+	name := types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}
+	if _, err := r.IngressReconciler.Delete(ctx, name); err != nil {
+		return ctrl.Result{Requeue: true}, fmt.Errorf("deleting ingress: %w", err)
+	}
+	r.releaseRoutes(name)
+	r.IngressNotReconciled(ctx, ingress, reason)
+	return ctrl.Result{}, nil
+}
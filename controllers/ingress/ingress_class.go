@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"strconv"
 
+	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -51,6 +54,17 @@ func (r *ingressController) getManagingClass(ctx context.Context, ing *networkin
 		return nil, fmt.Errorf("ingress %s/%s is not in the namespace list this controller is managing", ing.Namespace, ing.Name)
 	}
 
+	if r.namespaceSelector != nil {
+		ns := new(corev1.Namespace)
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: ing.Namespace}, ns); err != nil {
+			return nil, err
+		}
+		if !r.namespaceSelector.Matches(labels.Set(ns.Labels)) {
+			return nil, fmt.Errorf("ingress %s/%s namespace does not match the namespace selector %s this controller is managing",
+				ing.Namespace, ing.Name, r.namespaceSelector.String())
+		}
+	}
+
 	icl := new(networkingv1.IngressClassList)
 	if err := r.Client.List(ctx, icl); err != nil {
 		return nil, err
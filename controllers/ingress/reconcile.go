@@ -3,6 +3,7 @@ package ingress
 import (
 	"context"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -12,6 +13,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+	"github.com/pomerium/ingress-controller/util/metrics"
 )
 
 // reconcileInitial walks over all ingresses and updates configuration at once
@@ -32,7 +35,10 @@ func (r *ingressController) reconcileInitial(ctx context.Context) (err error) {
 		return fmt.Errorf("list ingresses: %w", err)
 	}
 
+	// a Pomerium target that fails to apply the initial sync affects all ingresses
+	ctx = util.WithBin[model.TargetFailure](ctx)
 	var ics []*model.IngressConfig
+	var ctxs []context.Context
 	for i := range ingressList.Items {
 		ingress := &ingressList.Items[i]
 		res, err := r.isManaging(ctx, ingress)
@@ -47,19 +53,29 @@ func (r *ingressController) reconcileInitial(ctx context.Context) (err error) {
 		if err != nil {
 			return fmt.Errorf("fetch ingress %s/%s: %w", ingress.Namespace, ingress.Name, err)
 		}
+		policies, ns, err := r.getHostnamePolicies(ctx, ingress.Namespace)
+		if err != nil {
+			return fmt.Errorf("ingress %s/%s: %w", ingress.Namespace, ingress.Name, err)
+		}
+		if err := checkIngressHostnames(policies, ns, ingress); err != nil {
+			r.IngressNotReconciled(ctx, ingress, err)
+			continue
+		}
 		logger.V(1).Info("fetch", "ingress", ingress.Name, "secrets", len(ic.Secrets), "services", len(ic.Services))
-		ics = append(ics, ic)
+		ingressCtx := util.WithBin[model.RouteClaimConflict](ctx)
+		ics = append(ics, r.claimRoutes(ingressCtx, ic))
+		ctxs = append(ctxs, ingressCtx)
 	}
 
 	_, err = r.IngressReconciler.Set(ctx, ics)
 	for i := range ics {
 		ingress := ics[i].Ingress
 		if err != nil {
-			r.IngressNotReconciled(ctx, ingress, err)
-		} else if err := r.updateIngressStatus(ctx, ingress); err != nil {
-			r.IngressNotReconciled(ctx, ingress, fmt.Errorf("update /status: %w", err))
+			r.IngressNotReconciled(ctxs[i], ingress, err)
+		} else if err := r.updateIngressStatus(ctxs[i], ingress); err != nil {
+			r.IngressNotReconciled(ctxs[i], ingress, fmt.Errorf("update /status: %w", err))
 		} else {
-			r.IngressReconciled(ctx, ingress)
+			r.IngressReconciled(ctxs[i], ingress)
 		}
 	}
 
@@ -70,7 +86,9 @@ const reasonIngressDeleted = "Ingress resource was deleted"
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-func (r *ingressController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *ingressController) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	defer func(start time.Time) { metrics.ObserveReconcile("Ingress", start, err) }(time.Now())
+
 	if err := r.initComplete.yield(ctx); err != nil {
 		return ctrl.Result{Requeue: true}, fmt.Errorf("initial reconciliation: %w", err)
 	}
@@ -94,7 +112,12 @@ func (r *ingressController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return r.deleteIngress(ctx, req.NamespacedName, managing.reasonIfNot)
 	}
 
+	release, err := r.limitFetch(ctx)
+	if err != nil {
+		return ctrl.Result{Requeue: true}, fmt.Errorf("wait to fetch ingress related resources: %w", err)
+	}
 	ic, err := r.fetchIngress(ctx, ingress)
+	release()
 	if err != nil {
 		r.IngressNotReconciled(ctx, ingress, err)
 		return ctrl.Result{Requeue: true}, fmt.Errorf("fetch ingress related resources: %w", err)
@@ -115,12 +138,26 @@ func (r *ingressController) deleteIngress(ctx context.Context, name types.Namesp
 	if changed {
 		r.IngressDeleted(ctx, name, reason)
 	}
+	r.releaseRoutes(name)
 	r.DeleteCascade(model.Key{Kind: r.ingressKind, NamespacedName: name})
 	return ctrl.Result{}, nil
 }
 
 func (r *ingressController) upsertIngress(ctx context.Context, ic *model.IngressConfig) (ctrl.Result, error) {
-	_, err := r.IngressReconciler.Upsert(ctx, ic)
+	ctx = util.WithBin[model.RouteConflict](ctx)
+	ctx = util.WithBin[model.RouteClaimConflict](ctx)
+	ctx = util.WithBin[model.TargetFailure](ctx)
+
+	policies, ns, err := r.getHostnamePolicies(ctx, ic.Ingress.Namespace)
+	if err != nil {
+		r.IngressNotReconciled(ctx, ic.Ingress, err)
+		return ctrl.Result{Requeue: true}, fmt.Errorf("hostname policies: %w", err)
+	}
+	if err := checkIngressHostnames(policies, ns, ic.Ingress); err != nil {
+		return r.rejectIngress(ctx, ic.Ingress, err)
+	}
+
+	_, err = r.IngressReconciler.Upsert(ctx, r.claimRoutes(ctx, ic))
 	if err != nil {
 		r.IngressNotReconciled(ctx, ic.Ingress, err)
 		return ctrl.Result{Requeue: true}, fmt.Errorf("upsert: %w", err)
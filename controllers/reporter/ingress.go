@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -18,6 +19,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+	"github.com/pomerium/ingress-controller/util/metrics"
 )
 
 // IngressStatusReporter updates status of ingress objects
@@ -49,6 +53,7 @@ func (r *IngressSettingsReporter) IngressReconciled(ctx context.Context, ingress
 					ObservedAt:         metav1.Time{Time: time.Now()},
 					Reconciled:         true,
 					Error:              nil,
+					Warnings:           append(getRouteConflictWarnings(ctx), getTargetFailureWarnings(ctx)...),
 				}},
 		},
 	}
@@ -114,16 +119,47 @@ const (
 	reasonPomeriumConfigUpdated     = "Updated"
 	reasonPomeriumConfigValidation  = "Validation"
 	reasonPomeriumConfigUpdateError = "UpdateError"
+	reasonRouteConflict             = "RouteConflict"
+	reasonRouteClaimConflict        = "Conflicted"
+	reasonTargetOutOfSync           = "TargetOutOfSync"
 	msgPomeriumConfigUpdated        = "config updated"
 	msgPomeriumConfigRejected       = "config rejected"
 )
 
 // IngressReconciled an ingress was successfully reconciled with Pomerium
-func (r *IngressEventReporter) IngressReconciled(_ context.Context, ingress *networkingv1.Ingress) error {
+func (r *IngressEventReporter) IngressReconciled(ctx context.Context, ingress *networkingv1.Ingress) error {
+	for _, c := range util.Get[model.RouteConflict](ctx) {
+		r.EventRecorder.Event(ingress, corev1.EventTypeWarning, reasonRouteConflict, c.String())
+	}
+	for _, c := range util.Get[model.RouteClaimConflict](ctx) {
+		r.EventRecorder.Event(ingress, corev1.EventTypeWarning, reasonRouteClaimConflict, c.String())
+	}
+	for _, msg := range getTargetFailureWarnings(ctx) {
+		r.EventRecorder.Event(ingress, corev1.EventTypeWarning, reasonTargetOutOfSync, msg)
+	}
 	r.EventRecorder.Event(ingress, corev1.EventTypeNormal, reasonPomeriumConfigUpdated, msgPomeriumConfigUpdated)
 	return nil
 }
 
+func getRouteConflictWarnings(ctx context.Context) []string {
+	var out []string
+	for _, c := range util.Get[model.RouteConflict](ctx) {
+		out = append(out, c.String())
+	}
+	for _, c := range util.Get[model.RouteClaimConflict](ctx) {
+		out = append(out, c.String())
+	}
+	return out
+}
+
+func getTargetFailureWarnings(ctx context.Context) []string {
+	var out []string
+	for _, f := range util.Get[model.TargetFailure](ctx) {
+		out = append(out, f.String())
+	}
+	return out
+}
+
 // IngressNotReconciled an updated ingress resource was received,
 // however it could not be reconciled with Pomerium due to errors
 func (r *IngressEventReporter) IngressNotReconciled(_ context.Context, ingress *networkingv1.Ingress, reason error) error {
@@ -189,7 +225,14 @@ func (r *IngressLogReporter) logger(ctx context.Context, namespace, name string)
 
 // IngressReconciled an ingress was successfully reconciled with Pomerium
 func (r *IngressLogReporter) IngressReconciled(ctx context.Context, ingress *networkingv1.Ingress) error {
-	r.logger(ctx, ingress.Namespace, ingress.Name).Info("ok")
+	logger := r.logger(ctx, ingress.Namespace, ingress.Name)
+	for _, msg := range getRouteConflictWarnings(ctx) {
+		logger.Info("route conflict", "msg", msg)
+	}
+	for _, f := range util.Get[model.TargetFailure](ctx) {
+		logger.Info("target out of sync", "target", f.Target, "error", f.Err.Error())
+	}
+	logger.Info("ok")
 	return nil
 }
 
@@ -205,3 +248,43 @@ func (r *IngressLogReporter) IngressDeleted(ctx context.Context, name types.Name
 	r.logger(ctx, name.Namespace, name.Name).Info("deleted", "reason", reason)
 	return nil
 }
+
+// IngressMetricsReporter tracks the number of ingresses that are not reconciled with Pomerium
+type IngressMetricsReporter struct {
+	mu            sync.Mutex
+	notReconciled map[types.NamespacedName]struct{}
+}
+
+func (r *IngressMetricsReporter) set(name types.NamespacedName, reconciled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.notReconciled == nil {
+		r.notReconciled = make(map[types.NamespacedName]struct{})
+	}
+	if reconciled {
+		delete(r.notReconciled, name)
+	} else {
+		r.notReconciled[name] = struct{}{}
+	}
+	metrics.SetIngressesNotReconciled(len(r.notReconciled))
+}
+
+// IngressReconciled an ingress was successfully reconciled with Pomerium
+func (r *IngressMetricsReporter) IngressReconciled(_ context.Context, ingress *networkingv1.Ingress) error {
+	r.set(types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, true)
+	return nil
+}
+
+// IngressNotReconciled an updated ingress resource was received,
+// however it could not be reconciled with Pomerium due to errors
+func (r *IngressMetricsReporter) IngressNotReconciled(_ context.Context, ingress *networkingv1.Ingress, _ error) error {
+	r.set(types.NamespacedName{Namespace: ingress.Namespace, Name: ingress.Name}, false)
+	return nil
+}
+
+// IngressDeleted an ingress resource was deleted and Pomerium no longer serves it
+func (r *IngressMetricsReporter) IngressDeleted(_ context.Context, name types.NamespacedName, _ string) error {
+	r.set(name, true)
+	return nil
+}
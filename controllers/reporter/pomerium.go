@@ -48,7 +48,7 @@ func (s *SettingsStatusReporter) SettingsUpdated(ctx context.Context, obj *icsv1
 				ObservedAt:         metav1.Time{Time: time.Now()},
 				Reconciled:         true,
 				Error:              nil,
-				Warnings:           getConfigWarnings(ctx),
+				Warnings:           append(getConfigWarnings(ctx), getTargetFailureWarnings(ctx)...),
 			},
 		},
 	}, client.MergeFrom(&icsv1.Pomerium{ObjectMeta: obj.ObjectMeta}))
@@ -64,7 +64,7 @@ func (s *SettingsStatusReporter) SettingsRejected(ctx context.Context, obj *icsv
 				ObservedAt:         metav1.Time{Time: time.Now()},
 				Reconciled:         false,
 				Error:              proto.String(err.Error()),
-				Warnings:           getConfigWarnings(ctx),
+				Warnings:           append(getConfigWarnings(ctx), getTargetFailureWarnings(ctx)...),
 			},
 		},
 	}, client.MergeFrom(&icsv1.Pomerium{ObjectMeta: obj.ObjectMeta}))
@@ -107,6 +107,9 @@ func (s *SettingsEventReporter) SettingsUpdated(ctx context.Context, obj *icsv1.
 	for _, msg := range getConfigWarnings(ctx) {
 		s.Event(obj, corev1.EventTypeWarning, reasonPomeriumConfigValidation, msg)
 	}
+	for _, msg := range getTargetFailureWarnings(ctx) {
+		s.Event(obj, corev1.EventTypeWarning, reasonTargetOutOfSync, msg)
+	}
 	s.Event(obj, corev1.EventTypeNormal, reasonPomeriumConfigUpdated, msgPomeriumConfigUpdated)
 	return nil
 }
@@ -3,6 +3,7 @@ package settings
 import (
 	context "context"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -24,6 +25,7 @@ import (
 	"github.com/pomerium/ingress-controller/pomerium"
 	"github.com/pomerium/ingress-controller/util"
 	"github.com/pomerium/ingress-controller/util/generic"
+	"github.com/pomerium/ingress-controller/util/metrics"
 )
 
 const (
@@ -93,6 +95,7 @@ func NewSettingsController(
 		ctrlCheck:    check,
 	}
 	secretKind := generic.GVKForType[*corev1.Secret](mgr.GetScheme()).Kind
+	configMapKind := generic.GVKForType[*corev1.ConfigMap](mgr.GetScheme()).Kind
 	err := ctrl.NewControllerManagedBy(mgr).
 		Named(controllerName).
 		For(new(icsv1.Pomerium), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
@@ -101,6 +104,11 @@ func NewSettingsController(
 			handler.EnqueueRequestsFromMapFunc(deps.GetDependantMapFunc(stc.Registry, secretKind)),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
 		).
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(deps.GetDependantMapFunc(stc.Registry, configMapKind)),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
 		Complete(stc)
 	if err != nil {
 		return fmt.Errorf("build controller: %w", err)
@@ -110,7 +118,9 @@ func NewSettingsController(
 }
 
 // Reconcile syncs Settings CRD with pomerium databroker
-func (c *settingsController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (c *settingsController) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
+	defer func(start time.Time) { metrics.ObserveReconcile("Pomerium", start, err) }(time.Now())
+
 	logger := log.FromContext(ctx).V(1)
 	if req.NamespacedName != c.key.NamespacedName {
 		logger.Info("ignoring", "got", req.NamespacedName, "want", c.key.NamespacedName)
@@ -123,6 +133,7 @@ func (c *settingsController) Reconcile(ctx context.Context, req ctrl.Request) (c
 	if c.emitWarnings {
 		ctx = util.WithBin[pom_cfg.FieldMsg](ctx)
 	}
+	ctx = util.WithBin[model.TargetFailure](ctx)
 
 	cfg, err := FetchConfig(ctx, c.Client, c.key.NamespacedName)
 	logger.Info("fetch", "deps", c.Registry.Deps(c.key), "error", err)
@@ -151,9 +162,12 @@ func (c *settingsController) Reconcile(ctx context.Context, req ctrl.Request) (c
 		}
 		return ctrl.Result{Requeue: true}, fmt.Errorf("set config: %w", err)
 	}
-	if changed || !statusUpToDate(&cfg.Pomerium, true) {
+	if changed || !statusUpToDate(&cfg.Pomerium, true) || len(util.Get[model.TargetFailure](ctx)) > 0 {
 		c.SettingsUpdated(ctx, &cfg.Pomerium)
 	}
+	if err := c.updateRotationStatus(ctx, cfg); err != nil {
+		return ctrl.Result{Requeue: true}, err
+	}
 
 	return ctrl.Result{}, nil
 }
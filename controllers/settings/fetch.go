@@ -10,6 +10,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/model"
 	"github.com/pomerium/ingress-controller/util"
 	"github.com/pomerium/pomerium/pkg/identity/oidc/hosted"
@@ -106,6 +107,10 @@ func fetchConfigSecrets(ctx context.Context, client client.Client, cfg *model.Co
 	return applyAll(
 		// bootstrap secrets
 		apply("bootstrap secret", required(&s.Secrets), &cfg.Secrets),
+		func() error {
+			_, _, err := model.BootstrapSecretsRotation(cfg.Secrets)
+			return err
+		},
 		// ca secrets
 		func() error {
 			for _, caSecret := range s.CASecrets {
@@ -159,6 +164,15 @@ func fetchConfigSecrets(ctx context.Context, client client.Client, cfg *model.Co
 
 			return cfg.SSHSecrets.Validate()
 		},
+		// downstream mTLS bundles
+		func() error {
+			bundles, err := FetchDownstreamMTLS(ctx, client, s.DownstreamMTLS)
+			if err != nil {
+				return fmt.Errorf("downstream mtls: %w", err)
+			}
+			cfg.DownstreamMTLS = bundles
+			return nil
+		},
 		// storage secrets
 		func() error {
 			if s.Storage == nil {
@@ -185,3 +199,56 @@ func fetchConfigSecrets(ctx context.Context, client client.Client, cfg *model.Co
 		},
 	)
 }
+
+// FetchDownstreamMTLS fetches the CA and CRL bundles the downstream mTLS settings refer to
+func FetchDownstreamMTLS(ctx context.Context, client client.Client, src *icsv1.DownstreamMTLS) (model.DownstreamMTLSBundles, error) {
+	var out model.DownstreamMTLSBundles
+	if src == nil {
+		return out, nil
+	}
+
+	var err error
+	if out.CA, err = fetchBundle(ctx, client, src.CASecret, src.CAConfigMap, model.CAKey); err != nil {
+		return out, fmt.Errorf("ca: %w", err)
+	}
+	if out.CRL, err = fetchBundle(ctx, client, src.CRLSecret, src.CRLConfigMap, model.CRLKey); err != nil {
+		return out, fmt.Errorf("crl: %w", err)
+	}
+	return out, nil
+}
+
+func fetchBundle(ctx context.Context, client client.Client, secretRef, configMapRef *string, key string) ([]byte, error) {
+	switch {
+	case secretRef != nil:
+		name, err := util.ParseNamespacedName(*secretRef)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", *secretRef, err)
+		}
+		var secret corev1.Secret
+		if err := client.Get(ctx, *name, &secret); err != nil {
+			return nil, fmt.Errorf("get %s: %w", name, err)
+		}
+		data, ok := secret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf("secret %s should have %q key", name, key)
+		}
+		return data, nil
+	case configMapRef != nil:
+		name, err := util.ParseNamespacedName(*configMapRef)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", *configMapRef, err)
+		}
+		var cm corev1.ConfigMap
+		if err := client.Get(ctx, *name, &cm); err != nil {
+			return nil, fmt.Errorf("get %s: %w", name, err)
+		}
+		if data, ok := cm.Data[key]; ok {
+			return []byte(data), nil
+		}
+		if data, ok := cm.BinaryData[key]; ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("config map %s should have %q key", name, key)
+	}
+	return nil, nil
+}
@@ -396,3 +396,62 @@ func TestFetchConfigHostedAuthenticate(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "hosted", cfg.Spec.IdentityProvider.Provider)
 }
+
+func TestFetchDownstreamMTLS(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	mc := controllers_mock.NewMockClient(gomock.NewController(t))
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	mc.EXPECT().Scheme().Return(scheme).AnyTimes()
+
+	registry := model.NewRegistry()
+	settingsKey := model.Key{Kind: "Pomerium", NamespacedName: types.NamespacedName{Name: "global"}}
+	trackingClient := deps.NewClient(mc, registry, settingsKey)
+
+	mc.EXPECT().Get(ctx, types.NamespacedName{Namespace: "pomerium", Name: "client-ca"},
+		gomock.AssignableToTypeOf(new(corev1.Secret)),
+	).Do(func(_ context.Context, name types.NamespacedName, dst *corev1.Secret, _ ...client.GetOption) {
+		*dst = corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+			Data:       map[string][]byte{model.CAKey: []byte("ca-data")},
+		}
+	}).Return(nil).AnyTimes()
+	mc.EXPECT().Get(ctx, types.NamespacedName{Namespace: "pomerium", Name: "client-crl"},
+		gomock.AssignableToTypeOf(new(corev1.ConfigMap)),
+	).Do(func(_ context.Context, name types.NamespacedName, dst *corev1.ConfigMap, _ ...client.GetOption) {
+		*dst = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+			Data:       map[string]string{model.CRLKey: "crl-data"},
+		}
+	}).Return(nil).AnyTimes()
+	mc.EXPECT().Get(ctx, types.NamespacedName{Namespace: "pomerium", Name: "missing"},
+		gomock.AssignableToTypeOf(new(corev1.Secret)),
+	).Return(errors.NewNotFound(schema.GroupResource{Group: "", Resource: "secrets"}, "missing")).AnyTimes()
+
+	bundles, err := settings.FetchDownstreamMTLS(ctx, trackingClient, &icsv1.DownstreamMTLS{
+		CASecret:     proto.String("pomerium/client-ca"),
+		CRLConfigMap: proto.String("pomerium/client-crl"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.DownstreamMTLSBundles{CA: []byte("ca-data"), CRL: []byte("crl-data")}, bundles)
+	assert.ElementsMatch(t, []model.Key{
+		{Kind: "Secret", NamespacedName: types.NamespacedName{Namespace: "pomerium", Name: "client-ca"}},
+		{Kind: "ConfigMap", NamespacedName: types.NamespacedName{Namespace: "pomerium", Name: "client-crl"}},
+	}, registry.Deps(settingsKey), "referenced objects should be tracked, so that their updates are picked up")
+
+	_, err = settings.FetchDownstreamMTLS(ctx, trackingClient, &icsv1.DownstreamMTLS{
+		CRLSecret: proto.String("pomerium/client-ca"),
+	})
+	assert.ErrorContains(t, err, `should have "ca.crl" key`)
+
+	_, err = settings.FetchDownstreamMTLS(ctx, trackingClient, &icsv1.DownstreamMTLS{
+		CASecret: proto.String("pomerium/missing"),
+	})
+	assert.Error(t, err, "a missing secret should fail")
+
+	bundles, err = settings.FetchDownstreamMTLS(ctx, trackingClient, nil)
+	require.NoError(t, err)
+	assert.Empty(t, bundles)
+}
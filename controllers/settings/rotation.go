@@ -0,0 +1,70 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+var secretsRotationMessages = map[string]string{
+	model.RotationPublish: "The new values are staged and the new signing key is published alongside the current one. " +
+		"Pomerium only accepts a single shared and cookie secret, so these switch once the rotation is advanced to retire, " +
+		"which signs users out.",
+	model.RotationRetire: "The new values are in use, and the current signing key is still published. " +
+		"Advance the rotation to remove the old values.",
+}
+
+// updateRotationStatus reports the bootstrap secrets and ssh keys rotation in the Pomerium status
+func (c *settingsController) updateRotationStatus(ctx context.Context, cfg *model.Config) error {
+	pom := &cfg.Pomerium
+	secretsRotation := getSecretsRotationStatus(cfg.Secrets, pom.Status.SecretsRotation, time.Now())
+	sshKeys := getSSHKeyStatuses(cfg.SSHSecrets)
+	if equality.Semantic.DeepEqual(pom.Status.SecretsRotation, secretsRotation) &&
+		equality.Semantic.DeepEqual(pom.Status.SSHKeys, sshKeys) {
+		return nil
+	}
+
+	obj := pom.DeepCopy()
+	obj.Status.SecretsRotation = secretsRotation
+	obj.Status.SSHKeys = sshKeys
+	if err := c.Status().Patch(ctx, obj, client.MergeFrom(pom)); err != nil {
+		return fmt.Errorf("update rotation status: %w", err)
+	}
+	return nil
+}
+
+// getSecretsRotationStatus returns the status of the bootstrap secrets rotation, or nil if none is in progress.
+// The time the current phase was first applied is kept from the previous status.
+func getSecretsRotationStatus(secret *corev1.Secret, prev *icsv1.SecretsRotationStatus, now time.Time) *icsv1.SecretsRotationStatus {
+	if secret == nil {
+		return nil
+	}
+	phase, keys, err := model.BootstrapSecretsRotation(secret)
+	if err != nil || phase == "" {
+		return nil
+	}
+
+	status := &icsv1.SecretsRotationStatus{
+		Secret:          util.GetNamespacedName(secret).String(),
+		Phase:           phase,
+		Keys:            keys,
+		PhaseObservedAt: metav1.Time{Time: now},
+		Message:         secretsRotationMessages[phase],
+	}
+	if started, err := time.Parse(time.RFC3339, secret.Annotations[model.BootstrapSecretsRotationStartedAnnotation]); err == nil {
+		status.Started = &metav1.Time{Time: started}
+	}
+	if prev != nil && prev.Secret == status.Secret && prev.Phase == status.Phase {
+		status.PhaseObservedAt = prev.PhaseObservedAt
+	}
+	return status
+}
@@ -0,0 +1,55 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pomerium/ingress-controller/model"
+)
+
+func TestGetSecretsRotationStatus(t *testing.T) {
+	t.Parallel()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "pomerium", Name: "bootstrap"},
+		Data: map[string][]byte{
+			model.SharedSecretKey: []byte("current"),
+			model.CookieSecretKey: []byte("current"),
+			"signing_key_next":    []byte("next"),
+		},
+	}
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Nil(t, getSecretsRotationStatus(secret, nil, t0), "no rotation in progress")
+
+	secret.Annotations = map[string]string{
+		model.BootstrapSecretsRotationAnnotation:        model.RotationPublish,
+		model.BootstrapSecretsRotationStartedAnnotation: "2025-12-31T00:00:00Z",
+	}
+	status := getSecretsRotationStatus(secret, nil, t0)
+	require.NotNil(t, status)
+	assert.Equal(t, "pomerium/bootstrap", status.Secret)
+	assert.Equal(t, model.RotationPublish, status.Phase)
+	assert.Equal(t, []string{model.SigningKeyKey}, status.Keys)
+	assert.Equal(t, time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC), status.Started.Time)
+	assert.Equal(t, t0, status.PhaseObservedAt.Time)
+	assert.NotEmpty(t, status.Message)
+
+	t1 := t0.Add(time.Hour)
+	assert.Equal(t, status, getSecretsRotationStatus(secret, status, t1),
+		"the time the phase was first observed should be kept")
+
+	secret.Annotations[model.BootstrapSecretsRotationAnnotation] = model.RotationRetire
+	retired := getSecretsRotationStatus(secret, status, t1)
+	require.NotNil(t, retired)
+	assert.Equal(t, model.RotationRetire, retired.Phase)
+	assert.Equal(t, t1, retired.PhaseObservedAt.Time)
+
+	delete(secret.Data, "signing_key_next")
+	assert.Nil(t, getSecretsRotationStatus(secret, retired, t1), "an invalid rotation should not be reported")
+}
@@ -0,0 +1,58 @@
+package settings
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+const (
+	sshKeyUsageHost   = "hostKey"
+	sshKeyUsageUserCA = "userCaKey"
+)
+
+func getSSHKeyStatuses(secrets model.SSHSecrets) []icsv1.SSHKeyStatus {
+	var statuses []icsv1.SSHKeyStatus
+	for _, secret := range secrets.HostKeys {
+		statuses = append(statuses, getSSHKeyStatus(secret, sshKeyUsageHost))
+	}
+	if secret := secrets.UserCAKey; secret != nil {
+		statuses = append(statuses, getSSHKeyStatus(secret, sshKeyUsageUserCA))
+	}
+	return statuses
+}
+
+func getSSHKeyStatus(secret *corev1.Secret, usage string) icsv1.SSHKeyStatus {
+	status := icsv1.SSHKeyStatus{
+		Secret:   util.GetNamespacedName(secret).String(),
+		Usage:    usage,
+		Rotation: secret.Annotations[model.SSHKeyRotationAnnotation],
+	}
+	status.Fingerprint, status.PublicKey = sshPublicKey(secret.Data[model.SSHPrivateKey])
+	status.NextFingerprint, status.NextPublicKey = sshPublicKey(secret.Data[model.SSHNextPrivateKey])
+	if started, err := time.Parse(time.RFC3339, secret.Annotations[model.SSHKeyRotationStartedAnnotation]); err == nil {
+		status.RotationStarted = &metav1.Time{Time: started}
+	}
+	return status
+}
+
+// sshPublicKey returns the SHA256 fingerprint and the authorized_keys representation of the public key
+// of a private key, or empty strings if it cannot be parsed
+func sshPublicKey(privateKey []byte) (fingerprint, publicKey string) {
+	if len(privateKey) == 0 {
+		return "", ""
+	}
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		return "", ""
+	}
+	return ssh.FingerprintSHA256(signer.PublicKey()),
+		strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+}
@@ -0,0 +1,62 @@
+package settings
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+func TestGetSSHKeyStatuses(t *testing.T) {
+	t.Parallel()
+
+	hostKey, err := util.NewSSHKeySecret(types.NamespacedName{Namespace: "pomerium", Name: "host-key"})
+	require.NoError(t, err)
+	userCAKey, err := util.NewSSHKeySecret(types.NamespacedName{Namespace: "pomerium", Name: "user-ca-key"})
+	require.NoError(t, err)
+	next, err := util.NewSSHPrivateKey()
+	require.NoError(t, err)
+	userCAKey.Data[model.SSHNextPrivateKey] = next
+	userCAKey.Annotations = map[string]string{
+		model.SSHKeyRotationAnnotation:        model.RotationPublish,
+		model.SSHKeyRotationStartedAnnotation: "2026-01-01T00:00:00Z",
+	}
+
+	publicKey := func(privateKey []byte) ssh.PublicKey {
+		signer, err := ssh.ParsePrivateKey(privateKey)
+		require.NoError(t, err)
+		return signer.PublicKey()
+	}
+	authorizedKey := func(privateKey []byte) string {
+		return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey(privateKey))))
+	}
+
+	assert.Equal(t, []icsv1.SSHKeyStatus{
+		{
+			Secret:      "pomerium/host-key",
+			Usage:       "hostKey",
+			Fingerprint: ssh.FingerprintSHA256(publicKey(hostKey.Data[model.SSHPrivateKey])),
+			PublicKey:   authorizedKey(hostKey.Data[model.SSHPrivateKey]),
+		},
+		{
+			Secret:          "pomerium/user-ca-key",
+			Usage:           "userCaKey",
+			Fingerprint:     ssh.FingerprintSHA256(publicKey(userCAKey.Data[model.SSHPrivateKey])),
+			PublicKey:       authorizedKey(userCAKey.Data[model.SSHPrivateKey]),
+			NextFingerprint: ssh.FingerprintSHA256(publicKey(next)),
+			NextPublicKey:   authorizedKey(next),
+			Rotation:        "publish",
+			RotationStarted: &metav1.Time{Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}, getSSHKeyStatuses(model.SSHSecrets{HostKeys: []*corev1.Secret{hostKey}, UserCAKey: userCAKey}))
+}
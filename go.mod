@@ -22,6 +22,7 @@ require (
 	github.com/pomerium/pomerium/pkg/grpc/config v0.0.0-20260814175132-5d3e91193dfa
 	github.com/pomerium/pomerium/pkg/grpc/databroker v0.0.0-20260814175132-5d3e91193dfa
 	github.com/pomerium/sdk-go v0.0.10-0.20260810190558-28c8b06f7141
+	github.com/prometheus/client_golang v1.24.0
 	github.com/rs/zerolog v1.35.1
 	github.com/sergi/go-diff v1.4.0
 	github.com/spf13/cobra v1.10.2
@@ -32,6 +33,7 @@ require (
 	go.opentelemetry.io/otel/trace v1.45.0
 	go.uber.org/mock v0.6.0
 	go.uber.org/zap v1.28.0
+	golang.org/x/crypto v0.55.0
 	golang.org/x/net v0.58.0
 	golang.org/x/sync v0.22.0
 	google.golang.org/grpc v1.83.0
@@ -253,7 +255,6 @@ require (
 	github.com/pomerium/protoutil v0.0.0-20260810121901-c1e5f8551cd0 // indirect
 	github.com/pomerium/webauthn v0.0.0-20260810123655-05886782cf2b // indirect
 	github.com/power-devops/perfstat v0.0.0-20240221224432-82ca36839d55 // indirect
-	github.com/prometheus/client_golang v1.24.0 // indirect
 	github.com/prometheus/client_model v0.6.2 // indirect
 	github.com/prometheus/common v0.70.0 // indirect
 	github.com/prometheus/otlptranslator v1.0.0 // indirect
@@ -317,7 +318,6 @@ require (
 	go.yaml.in/yaml/v2 v2.4.4 // indirect
 	go.yaml.in/yaml/v3 v3.0.5 // indirect
 	gocloud.dev v0.46.0 // indirect
-	golang.org/x/crypto v0.55.0 // indirect
 	golang.org/x/exp v0.0.0-20260508232706-74f9aab9d74a // indirect
 	golang.org/x/mod v0.39.0 // indirect
 	golang.org/x/oauth2 v0.36.0 // indirect
@@ -9,12 +9,22 @@ import (
 
 // A Matcher matches routes with certificate names.
 type Matcher[Key comparable] interface {
+	// Coverage returns all the route "from" hostnames, sorted, along with
+	// the keys of the certificates matching them.
+	Coverage() []NameCoverage[Key]
 	// MissingNames returns any route "from" hostnames which don't have
 	// a matching certificate.
 	MissingNames() []string
 	Update(key Key, certificateNames []string, routeNames []string)
 }
 
+// NameCoverage is a route "from" hostname along with the keys of the
+// certificates matching it. Keys is empty if the name is missing.
+type NameCoverage[Key comparable] struct {
+	Name string
+	Keys []Key
+}
+
 type matcher[Key comparable] struct {
 	certificates NameIndex[Key]
 	routes       NameIndex[Key]
@@ -30,6 +40,19 @@ func NewMatcher[Key comparable]() Matcher[Key] {
 	}
 }
 
+func (m *matcher[Key]) Coverage() []NameCoverage[Key] {
+	names := m.routes.Names()
+	slices.Sort(names)
+	coverage := make([]NameCoverage[Key], 0, len(names))
+	for _, name := range names {
+		coverage = append(coverage, NameCoverage[Key]{
+			Name: name,
+			Keys: m.certificates.Lookup(name, false),
+		})
+	}
+	return coverage
+}
+
 func (m *matcher[Key]) MissingNames() []string {
 	return m.missing.Slice()
 }
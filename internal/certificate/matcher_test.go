@@ -133,3 +133,22 @@ func TestMatcher(t *testing.T) {
 		assert.Empty(t, names(m))
 	})
 }
+
+func TestMatcherCoverage(t *testing.T) {
+	t.Parallel()
+
+	m := certificate.NewMatcher[int]()
+	m.Update(1, nil, []string{"www.example.com", "api.example.com", "app.other.com"})
+	m.Update(2, []string{"*.example.com"}, nil)
+	m.Update(3, []string{"www.example.com"}, nil)
+
+	coverage := m.Coverage()
+	for i := range coverage {
+		slices.Sort(coverage[i].Keys)
+	}
+	assert.Equal(t, []certificate.NameCoverage[int]{
+		{Name: "api.example.com", Keys: []int{2}},
+		{Name: "app.other.com"},
+		{Name: "www.example.com", Keys: []int{2, 3}},
+	}, coverage)
+}
@@ -0,0 +1,80 @@
+package certificate
+
+import (
+	networking_v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+// SecretRef is a TLS secret referenced by an Ingress, Gateway or the Pomerium settings.
+type SecretRef struct {
+	// Hostname the certificate is served for,
+	// or empty if it is served for the names in the certificate.
+	Hostname string
+	// Owner is the object referencing the secret.
+	Owner client.Object
+	// Secret holding the certificate.
+	Secret types.NamespacedName
+}
+
+// IngressSecretRefs returns the TLS secrets of an Ingress, once per TLS hostname.
+func IngressSecretRefs(ingress *networking_v1.Ingress) []SecretRef {
+	var refs []SecretRef
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+		secret := types.NamespacedName{Namespace: ingress.Namespace, Name: tls.SecretName}
+		if len(tls.Hosts) == 0 {
+			refs = append(refs, SecretRef{Owner: ingress, Secret: secret})
+		}
+		for _, host := range tls.Hosts {
+			refs = append(refs, SecretRef{Hostname: host, Owner: ingress, Secret: secret})
+		}
+	}
+	return refs
+}
+
+// GatewaySecretRefs returns the secrets the TLS listeners of a Gateway refer to,
+// with the listener hostname, if any.
+func GatewaySecretRefs(gw *gateway_v1.Gateway) []SecretRef {
+	var refs []SecretRef
+	for _, l := range gw.Spec.Listeners {
+		if l.TLS == nil {
+			continue
+		}
+		var hostname string
+		if l.Hostname != nil {
+			hostname = string(*l.Hostname)
+		}
+		for _, ref := range l.TLS.CertificateRefs {
+			if (ref.Group != nil && *ref.Group != "") || (ref.Kind != nil && *ref.Kind != "Secret") {
+				continue
+			}
+			secret := types.NamespacedName{Namespace: gw.Namespace, Name: string(ref.Name)}
+			if ref.Namespace != nil {
+				secret.Namespace = string(*ref.Namespace)
+			}
+			refs = append(refs, SecretRef{Hostname: hostname, Owner: gw, Secret: secret})
+		}
+	}
+	return refs
+}
+
+// SettingsSecretRefs returns the secrets of the Pomerium settings certificates,
+// skipping invalid references, as they are reported when the settings are reconciled.
+func SettingsSecretRefs(settings *pomerium_ingress_v1.Pomerium) []SecretRef {
+	var refs []SecretRef
+	for _, ref := range settings.Spec.Certificates {
+		secret, err := util.ParseNamespacedName(ref)
+		if err != nil {
+			continue
+		}
+		refs = append(refs, SecretRef{Owner: settings, Secret: *secret})
+	}
+	return refs
+}
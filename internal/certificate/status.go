@@ -0,0 +1,125 @@
+package certificate
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+)
+
+// Certificate status reasons.
+const (
+	// StatusMissing is set by the certificate controller if no certificate covers the hostname
+	StatusMissing = "missing"
+	// StatusExpired, StatusExpiring and StatusMismatch are set by the expiry monitor
+	// for problems with the certificates served for the hostname
+	StatusExpired  = "expired"
+	StatusExpiring = "expiring"
+	StatusMismatch = "mismatch"
+)
+
+// Both the certificate controller and the expiry monitor report into the Pomerium status certificates.
+// The certificate controller owns the certificate covering each route hostname,
+// while the expiry monitor owns the problems of the served certificates,
+// and only fills in the certificate of hostnames the certificate controller does not report.
+
+// UpdateStatus applies update to the certificates in the Pomerium status, retrying on conflicts
+// with the other writer. It is a no-op if the settings do not exist.
+func UpdateStatus(
+	ctx context.Context,
+	c client.Client,
+	name types.NamespacedName,
+	update func([]pomerium_ingress_v1.CertificateStatus) []pomerium_ingress_v1.CertificateStatus,
+) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var orig pomerium_ingress_v1.Pomerium
+		if err := c.Get(ctx, name, &orig); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		statuses := update(slices.Clone(orig.Status.Certificates))
+		if equality.Semantic.DeepEqual(orig.Status.Certificates, statuses) {
+			return nil
+		}
+		obj := orig.DeepCopy()
+		obj.Status.Certificates = statuses
+		return c.Status().Patch(ctx, obj, client.MergeFromWithOptions(&orig, client.MergeFromWithOptimisticLock{}))
+	})
+}
+
+// MergeCoverage replaces the certificates covering the route hostnames in the current statuses,
+// keeping the problems reported by the expiry monitor.
+func MergeCoverage(current, coverage []pomerium_ingress_v1.CertificateStatus) []pomerium_ingress_v1.CertificateStatus {
+	problems := make(map[string]pomerium_ingress_v1.CertificateStatus)
+	for _, s := range current {
+		if isExpiryReason(s.Reason) {
+			problems[s.Hostname] = s
+		}
+	}
+
+	out := make([]pomerium_ingress_v1.CertificateStatus, 0, len(coverage))
+	for _, s := range coverage {
+		if p, ok := problems[s.Hostname]; ok {
+			delete(problems, s.Hostname)
+			if s.Reason == "" {
+				s.Reason, s.Message = p.Reason, p.Message
+			}
+		}
+		out = append(out, s)
+	}
+	for _, p := range problems {
+		// the hostname is no longer routed, keep the problem until the expiry monitor resolves it
+		p.Issuer, p.Record = "", ""
+		out = append(out, p)
+	}
+	sortStatuses(out)
+	return out
+}
+
+// MergeProblems replaces the problems of the served certificates in the current statuses,
+// keeping the certificates covering the route hostnames reported by the certificate controller.
+func MergeProblems(current, problems []pomerium_ingress_v1.CertificateStatus) []pomerium_ingress_v1.CertificateStatus {
+	byHostname := make(map[string]pomerium_ingress_v1.CertificateStatus, len(problems))
+	for _, p := range problems {
+		byHostname[p.Hostname] = p
+	}
+
+	out := make([]pomerium_ingress_v1.CertificateStatus, 0, len(current)+len(problems))
+	for _, s := range current {
+		covered := s.Issuer != "" || s.Record != "" || s.Reason == StatusMissing
+		p, ok := byHostname[s.Hostname]
+		delete(byHostname, s.Hostname)
+		switch {
+		case !covered && !ok:
+			// the problem reported before was resolved
+			continue
+		case !covered:
+			s = p
+		case ok && s.Reason != StatusMissing:
+			s.Reason, s.Message = p.Reason, p.Message
+		case isExpiryReason(s.Reason):
+			s.Reason, s.Message = "", ""
+		}
+		out = append(out, s)
+	}
+	for _, p := range byHostname {
+		out = append(out, p)
+	}
+	sortStatuses(out)
+	return out
+}
+
+func isExpiryReason(reason string) bool {
+	return reason == StatusExpired || reason == StatusExpiring || reason == StatusMismatch
+}
+
+func sortStatuses(statuses []pomerium_ingress_v1.CertificateStatus) {
+	slices.SortFunc(statuses, func(x, y pomerium_ingress_v1.CertificateStatus) int {
+		return cmp.Compare(x.Hostname, y.Hostname)
+	})
+}
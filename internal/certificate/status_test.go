@@ -0,0 +1,61 @@
+package certificate_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pomerium_ingress_v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/internal/certificate"
+)
+
+func TestMergeStatus(t *testing.T) {
+	t.Parallel()
+
+	type status = pomerium_ingress_v1.CertificateStatus
+
+	coverage := []status{
+		{Hostname: "b.example.com", Secret: "default/b", Issuer: "CN=ca"},
+		{Hostname: "a.example.com", Record: "static", Issuer: "CN=ca"},
+		{Hostname: "c.example.com", Reason: certificate.StatusMissing, Message: "no certificate covers the hostname"},
+	}
+	problems := []status{
+		{Hostname: "a.example.com", Secret: "default/a", Reason: certificate.StatusExpiring, Message: "expiring"},
+		{Hostname: "c.example.com", Secret: "default/c", Reason: certificate.StatusMismatch, Message: "mismatch"},
+		{Hostname: "d.example.com", Secret: "default/d", Reason: certificate.StatusExpired, Message: "expired"},
+	}
+	merged := []status{
+		{Hostname: "a.example.com", Record: "static", Issuer: "CN=ca", Reason: certificate.StatusExpiring, Message: "expiring"},
+		{Hostname: "b.example.com", Secret: "default/b", Issuer: "CN=ca"},
+		{Hostname: "c.example.com", Reason: certificate.StatusMissing, Message: "no certificate covers the hostname"},
+		{Hostname: "d.example.com", Secret: "default/d", Reason: certificate.StatusExpired, Message: "expired"},
+	}
+
+	t.Run("coverage then problems", func(t *testing.T) {
+		t.Parallel()
+		got := certificate.MergeProblems(certificate.MergeCoverage(nil, coverage), problems)
+		assert.Equal(t, merged, got)
+	})
+	t.Run("problems then coverage", func(t *testing.T) {
+		t.Parallel()
+		got := certificate.MergeCoverage(certificate.MergeProblems(nil, problems), coverage)
+		assert.Equal(t, merged, got)
+	})
+	t.Run("resolved problems", func(t *testing.T) {
+		t.Parallel()
+		got := certificate.MergeProblems(merged, nil)
+		assert.Equal(t, []status{
+			{Hostname: "a.example.com", Record: "static", Issuer: "CN=ca"},
+			{Hostname: "b.example.com", Secret: "default/b", Issuer: "CN=ca"},
+			{Hostname: "c.example.com", Reason: certificate.StatusMissing, Message: "no certificate covers the hostname"},
+		}, got)
+	})
+	t.Run("hostnames no longer routed", func(t *testing.T) {
+		t.Parallel()
+		got := certificate.MergeCoverage(merged, nil)
+		assert.Equal(t, []status{
+			{Hostname: "a.example.com", Reason: certificate.StatusExpiring, Message: "expiring"},
+			{Hostname: "d.example.com", Secret: "default/d", Reason: certificate.StatusExpired, Message: "expired"},
+		}, got)
+	})
+}
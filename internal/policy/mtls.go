@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+)
+
+// Downstream mTLS enforcement modes, from the least to the most strict.
+const (
+	EnforcementPolicy                = "policy"
+	EnforcementPolicyWithDefaultDeny = "policy_with_default_deny"
+	EnforcementRejectConnection      = "reject_connection"
+)
+
+var enforcementModes = []string{
+	EnforcementPolicy,
+	EnforcementPolicyWithDefaultDeny,
+	EnforcementRejectConnection,
+}
+
+// denyInvalidClientCertificate is the rule Pomerium adds to every route
+// in the policy_with_default_deny enforcement mode
+var denyInvalidClientCertificate = map[string]any{
+	"deny": map[string]any{
+		"or": []any{map[string]any{"invalid_client_certificate": true}},
+	},
+}
+
+// CheckDownstreamMTLSEnforcement returns an error unless a route may override the global downstream mTLS
+// enforcement mode with mode. A route may only be stricter than the global settings, and reject_connection
+// may only be set globally, as it is enforced during the TLS handshake before the route is known.
+// routeCA reports whether the route has its own client CA.
+func CheckDownstreamMTLSEnforcement(mode string, global *icsv1.DownstreamMTLS, routeCA bool) error {
+	level := slices.Index(enforcementModes, strings.ToLower(mode))
+	if level < 0 {
+		return fmt.Errorf("unknown downstream mTLS enforcement mode %q, expected one of %v", mode, enforcementModes)
+	}
+	if !routeCA && (global == nil || (len(global.CA) == 0 && global.CASecret == nil && global.CAConfigMap == nil)) {
+		return fmt.Errorf("downstream mTLS enforcement requires a client CA in the global settings")
+	}
+
+	globalMode := EnforcementPolicyWithDefaultDeny
+	if global != nil && global.Enforcement != nil {
+		globalMode = strings.ToLower(*global.Enforcement)
+	}
+	globalLevel := slices.Index(enforcementModes, globalMode)
+
+	switch {
+	case level < globalLevel:
+		return fmt.Errorf("downstream mTLS enforcement %s is less strict than the global %s", mode, globalMode)
+	case level > globalLevel && enforcementModes[level] == EnforcementRejectConnection:
+		return fmt.Errorf("downstream mTLS enforcement %s may only be set in the global settings", mode)
+	}
+	return nil
+}
+
+// WithDownstreamMTLSEnforcement returns the PPL policy src with the rules the downstream mTLS enforcement mode
+// requires added, that is denying requests without a valid client certificate for policy_with_default_deny.
+func WithDownstreamMTLSEnforcement(src, mode string) (string, error) {
+	if strings.ToLower(mode) != EnforcementPolicyWithDefaultDeny {
+		return src, nil
+	}
+
+	var rules []any
+	var doc any
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return "", fmt.Errorf("couldn't parse policy: %w", err)
+	}
+	switch doc := doc.(type) {
+	case nil:
+	case []any:
+		rules = doc
+	default:
+		rules = []any{doc}
+	}
+	rules = append(rules, denyInvalidClientCertificate)
+
+	out, err := json.Marshal(rules)
+	if err != nil {
+		return "", fmt.Errorf("couldn't marshal policy: %w", err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,75 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/internal/policy"
+)
+
+func TestCheckDownstreamMTLSEnforcement(t *testing.T) {
+	t.Parallel()
+
+	withMode := func(mode string) *icsv1.DownstreamMTLS {
+		return &icsv1.DownstreamMTLS{CASecret: new("pomerium/ca"), Enforcement: new(mode)}
+	}
+	for _, tc := range []struct {
+		name    string
+		mode    string
+		global  *icsv1.DownstreamMTLS
+		routeCA bool
+		valid   bool
+	}{
+		{"stricter", "policy_with_default_deny", withMode("policy"), false, true},
+		{"same", "policy", withMode("POLICY"), false, true},
+		{"default global", "policy_with_default_deny", &icsv1.DownstreamMTLS{CA: []byte("ca")}, false, true},
+		{"same reject", "reject_connection", withMode("reject_connection"), false, true},
+		{"less strict", "policy", withMode("policy_with_default_deny"), false, false},
+		{"less strict than reject", "policy_with_default_deny", withMode("reject_connection"), false, false},
+		{"reject per route", "reject_connection", withMode("policy"), false, false},
+		{"unknown", "allow", withMode("policy"), false, false},
+		{"no global ca", "policy_with_default_deny", &icsv1.DownstreamMTLS{Enforcement: new("policy")}, false, false},
+		{"no global settings", "policy_with_default_deny", nil, false, false},
+		{"route ca", "policy_with_default_deny", nil, true, true},
+	} {
+		err := policy.CheckDownstreamMTLSEnforcement(tc.mode, tc.global, tc.routeCA)
+		if tc.valid {
+			assert.NoError(t, err, tc.name)
+		} else {
+			assert.Error(t, err, tc.name)
+		}
+	}
+}
+
+func TestWithDownstreamMTLSEnforcement(t *testing.T) {
+	t.Parallel()
+
+	deny := `{"deny":{"or":[{"invalid_client_certificate":true}]}}`
+	for _, tc := range []struct {
+		name   string
+		src    string
+		mode   string
+		expect string
+	}{
+		{"no policy", "", "policy_with_default_deny", "[" + deny + "]"},
+		{"rule", `{"allow":{"or":[{"email":{"is":"user@example.com"}}]}}`, "policy_with_default_deny",
+			`[{"allow":{"or":[{"email":{"is":"user@example.com"}}]}},` + deny + `]`},
+		{"yaml rules", "- allow:\n    or:\n      - domain:\n          is: example.com\n", "policy_with_default_deny",
+			`[{"allow":{"or":[{"domain":{"is":"example.com"}}]}},` + deny + `]`},
+	} {
+		out, err := policy.WithDownstreamMTLSEnforcement(tc.src, tc.mode)
+		require.NoError(t, err, tc.name)
+		assert.JSONEq(t, tc.expect, out, tc.name)
+	}
+
+	src := `{"allow":{"or":[{"accept":true}]}}`
+	out, err := policy.WithDownstreamMTLSEnforcement(src, "policy")
+	require.NoError(t, err)
+	assert.Equal(t, src, out, "other modes should not change the policy")
+
+	_, err = policy.WithDownstreamMTLSEnforcement("{", "policy_with_default_deny")
+	assert.Error(t, err)
+}
@@ -2,6 +2,8 @@
 package model
 
 import (
+	"fmt"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -10,6 +12,24 @@ import (
 	pb "github.com/pomerium/pomerium/pkg/grpc/config"
 )
 
+// GatewayRoutePriorityAnnotation is an integer that makes routes of an HTTPRoute take precedence
+// over routes of other HTTPRoutes for the same host, regardless of their path specificity.
+// Higher values go first, default is 0.
+const GatewayRoutePriorityAnnotation = "gateway.pomerium.io/route-priority"
+
+// GetGatewayRoutePriority returns the route priority set via annotation, or 0 if not set
+func GetGatewayRoutePriority(obj client.Object) (int32, error) {
+	v, ok := obj.GetAnnotations()[GatewayRoutePriorityAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	priority, err := parseRoutePriority(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", GatewayRoutePriorityAnnotation, err)
+	}
+	return priority, nil
+}
+
 // GatewayConfig represents the entirety of the Gateway-defined configuration.
 type GatewayConfig struct {
 	Routes           []GatewayHTTPRouteConfig
@@ -31,6 +51,24 @@ type GatewayHTTPRouteConfig struct {
 
 	// Services is a map of all known services in the cluster.
 	Services map[types.NamespacedName]*corev1.Service
+
+	// Priority of the routes relative to other HTTPRoutes, see [GatewayRoutePriorityAnnotation].
+	Priority int32
+
+	// Split identifies this config among the configs an HTTPRoute was split into because of lost route claims.
+	// It is 0 for the config that keeps all matches, and 1 + the index of the hostname in the HTTPRoute
+	// for a hostname that lost some of its matches, so that it stays the same as long as the hostnames do.
+	Split int
+
+	// Removed is set for an HTTPRoute in a namespace that no longer matches the namespace selector.
+	// Its routes are removed the same way as those of a deleted HTTPRoute.
+	Removed bool
+}
+
+// IsDeleted returns true if the routes of the HTTPRoute should be removed,
+// either because the HTTPRoute is being deleted or because it is no longer watched.
+func (r *GatewayHTTPRouteConfig) IsDeleted() bool {
+	return r.Removed || r.DeletionTimestamp != nil
 }
 
 // BackendRefChecker is used to determine which BackendRefs are valid.
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+)
+
+// HostnamePolicies restrict which namespaces may use which hostnames
+type HostnamePolicies []icsv1.HostnamePolicy
+
+// CheckHostname returns an error if objects in the namespace may not use the hostname.
+// Hostname may be a wildcard, i.e. "*.example.com", or "*" to match all hosts,
+// in which case it must also be allowed by all policies of domains it covers.
+func (p HostnamePolicies) CheckHostname(ns *corev1.Namespace, hostname string) error {
+	host := normalizeHostnamePolicyDomain(hostname)
+	wildcard := hostname == "*" || strings.HasPrefix(hostname, "*.")
+
+	var governing []*icsv1.HostnamePolicy
+	longest := -1
+	for i := range p {
+		policy := &p[i]
+		for _, domain := range policy.Spec.Domains {
+			domain = normalizeHostnamePolicyDomain(domain)
+			switch {
+			case host != "" && (host == domain || strings.HasSuffix(host, "."+domain)):
+				if len(domain) > longest {
+					longest, governing = len(domain), governing[:0]
+				}
+				if len(domain) == longest && !slices.Contains(governing, policy) {
+					governing = append(governing, policy)
+				}
+			case wildcard && (host == "" || strings.HasSuffix(domain, "."+host)):
+				allowed, err := hostnamePolicyAllows(policy, ns)
+				if err != nil {
+					return err
+				}
+				if !allowed {
+					return fmt.Errorf("hostname %s covers domain %s restricted by HostnamePolicy %s to other namespaces",
+						hostname, domain, policy.Name)
+				}
+			}
+		}
+	}
+
+	if len(governing) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(governing))
+	for _, policy := range governing {
+		allowed, err := hostnamePolicyAllows(policy, ns)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+		names = append(names, policy.Name)
+	}
+	return fmt.Errorf("hostname %s is restricted by HostnamePolicy %s to other namespaces",
+		hostname, strings.Join(names, ", "))
+}
+
+func hostnamePolicyAllows(policy *icsv1.HostnamePolicy, ns *corev1.Namespace) (bool, error) {
+	if ns == nil {
+		return false, nil
+	}
+	if slices.Contains(policy.Spec.Namespaces, ns.Name) {
+		return true, nil
+	}
+	if policy.Spec.NamespaceSelector == nil {
+		return false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("HostnamePolicy %s namespaceSelector: %w", policy.Name, err)
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// normalizeHostnamePolicyDomain strips wildcard and trailing dot, "*" becomes empty string
+func normalizeHostnamePolicyDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	domain = strings.TrimPrefix(domain, "*")
+	return strings.TrimPrefix(domain, ".")
+}
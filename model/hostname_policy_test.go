@@ -0,0 +1,60 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+)
+
+func TestHostnamePolicies(t *testing.T) {
+	policies := HostnamePolicies{{
+		ObjectMeta: metav1.ObjectMeta{Name: "corp"},
+		Spec: icsv1.HostnamePolicySpec{
+			Domains:    []string{"corp.example.com"},
+			Namespaces: []string{"platform"},
+		},
+	}, {
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Spec: icsv1.HostnamePolicySpec{
+			Domains: []string{"*.a.corp.example.com"},
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "a"},
+			},
+		},
+	}}
+
+	platform := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "platform"}}
+	teamA := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a-dev", Labels: map[string]string{"team": "a"}}}
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+
+	for _, tc := range []struct {
+		ns      *corev1.Namespace
+		host    string
+		allowed bool
+	}{
+		{other, "unrestricted.example.com", true},
+		{other, "corp.example.com", false},
+		{other, "app.corp.example.com", false},
+		{platform, "app.corp.example.com", true},
+		{platform, "CORP.example.com.", true},
+		{teamA, "app.corp.example.com", false},
+		{teamA, "app.a.corp.example.com", true},
+		{teamA, "*.a.corp.example.com", true},
+		{platform, "app.a.corp.example.com", false},
+		{platform, "*.corp.example.com", false},
+		{platform, "*", false},
+		{other, "*.example.com", false},
+		{other, "*.other.example.com", true},
+	} {
+		err := policies.CheckHostname(tc.ns, tc.host)
+		if tc.allowed {
+			assert.NoError(t, err, "%s: %s", tc.ns.Name, tc.host)
+		} else {
+			assert.Error(t, err, "%s: %s", tc.ns.Name, tc.host)
+		}
+	}
+}
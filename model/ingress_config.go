@@ -3,6 +3,7 @@ package model
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -24,6 +25,8 @@ const (
 	TLSClientSecret = "tls_client_secret"
 	// TLSDownstreamClientCASecret replaces https://pomerium.io/reference/#tls-downstream-client-certificate-authority
 	TLSDownstreamClientCASecret = "tls_downstream_client_ca_secret"
+	// DownstreamMTLSEnforcement overrides the global downstream mTLS enforcement mode for the Ingress routes
+	DownstreamMTLSEnforcement = "downstream_mtls_enforcement"
 	// TLSServerName is annotation to override TLS server name
 	TLSServerName = "tls_server_name"
 	// SecureUpstream indicate that service communication should happen over HTTPS
@@ -38,6 +41,9 @@ const (
 	TCPUpstream = "tcp_upstream"
 	// UDPUpstream indicates this route is for UDP tunneled over HTTP https://www.pomerium.com/docs/capabilities/udp/
 	UDPUpstream = "udp_upstream"
+	// RoutePriority is an integer that makes routes of this Ingress take precedence over routes of other Ingresses
+	// for the same host, regardless of their path specificity. Higher values go first, default is 0
+	RoutePriority = "route_priority"
 	// SubtleAllowEmptyHost is a required annotation when creating an ingress containing
 	// rules with an empty (catch-all) host, as it can cause unexpected behavior
 	SubtleAllowEmptyHost = "subtle_allow_empty_host"
@@ -54,8 +60,16 @@ const (
 	StorageConnectionStringKey = "connection"
 	// CAKey is certificate authority secret key
 	CAKey = "ca.crt"
+	// CRLKey is certificate revocation list secret key
+	CRLKey = "ca.crl"
 	// SSHPrivateKey is the ssh privatekey secret key
 	SSHPrivateKey = "ssh-privatekey"
+	// SSHNextPrivateKey is the ssh privatekey secret key an ssh key secret is being rotated to
+	SSHNextPrivateKey = "ssh-privatekey-next"
+	// SSHKeyRotationAnnotation is the ssh key secret annotation that sets the key rotation stage
+	SSHKeyRotationAnnotation = "ssh.pomerium.io/rotation"
+	// SSHKeyRotationStartedAnnotation is the ssh key secret annotation that records when the next key was published
+	SSHKeyRotationStartedAnnotation = "ssh.pomerium.io/rotation-started"
 	// MCPServer indicates this route is an MCP server without any additional configuration
 	MCPServer = "mcp_server"
 	// MCPClient indicates this route is an MCP client without any additional configuration
@@ -110,6 +124,9 @@ func (s SSHSecrets) Validate() error {
 			return fmt.Errorf("ssh host key secret %s should be of type %s, got %s",
 				util.GetNamespacedName(hk), corev1.SecretTypeSSHAuth, hk.Type)
 		}
+		if _, err := SSHKeyRotation(hk); err != nil {
+			return err
+		}
 	}
 
 	uk := s.UserCAKey
@@ -118,11 +135,72 @@ func (s SSHSecrets) Validate() error {
 			return fmt.Errorf("ssh user ca key secret %s should be of type %s, got %s",
 				util.GetNamespacedName(uk), corev1.SecretTypeSSHAuth, uk.Type)
 		}
+		if _, err := SSHKeyRotation(uk); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// ActiveHostKeys returns the ssh host keys to serve: the current key of each secret,
+// followed by its next key while it is published, or only the next key once the current one is retired.
+func (s SSHSecrets) ActiveHostKeys() ([][]byte, error) {
+	keys := make([][]byte, 0, len(s.HostKeys))
+	for _, secret := range s.HostKeys {
+		stage, err := SSHKeyRotation(secret)
+		if err != nil {
+			return nil, err
+		}
+		if stage != RotationRetire {
+			data, ok := secret.Data[SSHPrivateKey]
+			if !ok {
+				return nil, fmt.Errorf("missing ssh host key data in %s", util.GetNamespacedName(secret))
+			}
+			keys = append(keys, data)
+		}
+		if stage != "" {
+			keys = append(keys, secret.Data[SSHNextPrivateKey])
+		}
+	}
+	return keys, nil
+}
+
+// ActiveUserCAKey returns the ssh user CA key to sign with, that is the next key once the current one is retired,
+// or nil if there is no user CA key secret.
+func (s SSHSecrets) ActiveUserCAKey() ([]byte, error) {
+	secret := s.UserCAKey
+	if secret == nil {
+		return nil, nil
+	}
+	stage, err := SSHKeyRotation(secret)
+	if err != nil {
+		return nil, err
+	}
+	if stage == RotationRetire {
+		return secret.Data[SSHNextPrivateKey], nil
+	}
+	data, ok := secret.Data[SSHPrivateKey]
+	if !ok {
+		return nil, fmt.Errorf("missing ssh user ca key data in %s", util.GetNamespacedName(secret))
+	}
+	return data, nil
+}
+
+// SSHKeyRotation returns the rotation stage of an ssh key secret set by its SSHKeyRotationAnnotation,
+// or an empty string if no rotation is in progress.
+func SSHKeyRotation(secret *corev1.Secret) (string, error) {
+	stage, err := getRotationStage(secret, SSHKeyRotationAnnotation)
+	if err != nil || stage == "" {
+		return stage, err
+	}
+	if len(secret.Data[SSHNextPrivateKey]) == 0 {
+		return "", fmt.Errorf("ssh key secret %s: %s is required during the %s rotation stage",
+			util.GetNamespacedName(secret), SSHNextPrivateKey, stage)
+	}
+	return stage, nil
+}
+
 // StorageSecrets is a convenience grouping of storage-related secrets
 type StorageSecrets struct {
 	// Secret contains storage connection string
@@ -170,6 +248,16 @@ type Config struct {
 	SSHSecrets SSHSecrets
 	// StorageSecrets represent databroker storage settings
 	StorageSecrets StorageSecrets
+	// DownstreamMTLS are the bundles referenced by Settings.DownstreamMTLS
+	DownstreamMTLS DownstreamMTLSBundles
+}
+
+// DownstreamMTLSBundles are downstream mTLS bundles fetched from secrets or config maps
+type DownstreamMTLSBundles struct {
+	// CA is fetched from Settings.DownstreamMTLS.CASecret or CAConfigMap
+	CA []byte
+	// CRL is fetched from Settings.DownstreamMTLS.CRLSecret or CRLConfigMap
+	CRL []byte
 }
 
 // IngressConfig represents ingress and all other required resources
@@ -179,6 +267,10 @@ type IngressConfig struct {
 	Endpoints map[types.NamespacedName]*corev1.Endpoints
 	Secrets   map[types.NamespacedName]*corev1.Secret
 	Services  map[types.NamespacedName]*corev1.Service
+	// Backends are Pomerium-handled backends referenced via backend.resource
+	Backends map[types.NamespacedName]*icsv1.Backend
+	// DownstreamMTLS are the global downstream mTLS settings, that route requirements are validated against
+	DownstreamMTLS *icsv1.DownstreamMTLS
 }
 
 // IsAnnotationSet checks if a boolean annotation is set to true
@@ -216,6 +308,27 @@ func (ic *IngressConfig) UseServiceProxy() bool {
 	return ic.IsAnnotationSet(UseServiceProxy)
 }
 
+// GetRoutePriority returns the route priority set via annotation, or 0 if not set
+func (ic *IngressConfig) GetRoutePriority() (int32, error) {
+	v, ok := ic.Ingress.Annotations[fmt.Sprintf("%s/%s", ic.AnnotationPrefix, RoutePriority)]
+	if !ok {
+		return 0, nil
+	}
+	priority, err := parseRoutePriority(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", RoutePriority, err)
+	}
+	return priority, nil
+}
+
+func parseRoutePriority(v string) (int32, error) {
+	priority, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("expected an integer, got %q", v)
+	}
+	return int32(priority), nil
+}
+
 // GetNamespacedName returns namespaced name of a resource
 func (ic *IngressConfig) GetNamespacedName(name string) types.NamespacedName {
 	return types.NamespacedName{Namespace: ic.Ingress.Namespace, Name: name}
@@ -242,6 +355,15 @@ func (ic *IngressConfig) GetServicePortByName(name types.NamespacedName, port st
 	return 0, fmt.Errorf("could not find port %s on service %s", port, name.String())
 }
 
+// IsPomeriumBackend returns true if the Ingress backend refers to a Pomerium-handled Backend resource
+func IsPomeriumBackend(backend *networkingv1.IngressBackend) bool {
+	ref := backend.Resource
+	return ref != nil &&
+		ref.APIGroup != nil &&
+		*ref.APIGroup == icsv1.GroupVersion.Group &&
+		ref.Kind == icsv1.BackendKind
+}
+
 const (
 	httpSolverLabel = "acme.cert-manager.io/http01-solver"
 )
@@ -261,6 +383,7 @@ func (ic *IngressConfig) Clone() *IngressConfig {
 		Endpoints:        make(map[types.NamespacedName]*corev1.Endpoints, len(ic.Endpoints)),
 		Secrets:          make(map[types.NamespacedName]*corev1.Secret, len(ic.Secrets)),
 		Services:         make(map[types.NamespacedName]*corev1.Service, len(ic.Services)),
+		Backends:         make(map[types.NamespacedName]*icsv1.Backend, len(ic.Backends)),
 	}
 
 	for k, v := range ic.Secrets {
@@ -271,5 +394,9 @@ func (ic *IngressConfig) Clone() *IngressConfig {
 		dst.Services[k] = v.DeepCopy()
 	}
 
+	for k, v := range ic.Backends {
+		dst.Backends[k] = v.DeepCopy()
+	}
+
 	return dst
 }
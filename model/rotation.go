@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/pomerium/ingress-controller/util"
+)
+
+// Key rotation stages, set by a secret annotation.
+const (
+	// RotationPublish publishes the next value of a key alongside the current one where Pomerium supports it,
+	// while the current value remains in use
+	RotationPublish = "publish"
+	// RotationRetire switches to the next value of a key, and only keeps publishing the current one
+	// where it is needed to verify what was signed with it
+	RotationRetire = "retire"
+)
+
+const (
+	// SharedSecretKey is the bootstrap secret key of the shared secret
+	SharedSecretKey = "shared_secret"
+	// CookieSecretKey is the bootstrap secret key of the cookie secret
+	CookieSecretKey = "cookie_secret"
+	// SigningKeyKey is the bootstrap secret key of the signing key
+	SigningKeyKey = "signing_key"
+	// BootstrapSecretsRotationAnnotation is the bootstrap secret annotation that sets the secrets rotation stage
+	BootstrapSecretsRotationAnnotation = "secrets.pomerium.io/rotation"
+	// BootstrapSecretsRotationStartedAnnotation is the bootstrap secret annotation that records when the next secrets were staged
+	BootstrapSecretsRotationStartedAnnotation = "secrets.pomerium.io/rotation-started"
+)
+
+// BootstrapSecretKeys lists the bootstrap secret keys that may be rotated.
+var BootstrapSecretKeys = []string{SharedSecretKey, CookieSecretKey, SigningKeyKey}
+
+// NextBootstrapSecretKey returns the bootstrap secret key holding the value key is being rotated to.
+func NextBootstrapSecretKey(key string) string {
+	return key + "_next"
+}
+
+// BootstrapSecretsRotation returns the rotation stage of the bootstrap secret set by its BootstrapSecretsRotationAnnotation,
+// along with the keys being rotated, or an empty string if no rotation is in progress.
+func BootstrapSecretsRotation(secret *corev1.Secret) (string, []string, error) {
+	stage, err := getRotationStage(secret, BootstrapSecretsRotationAnnotation)
+	if err != nil || stage == "" {
+		return stage, nil, err
+	}
+	var keys []string
+	for _, key := range BootstrapSecretKeys {
+		if len(secret.Data[NextBootstrapSecretKey(key)]) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", nil, fmt.Errorf("bootstrap secret %s: one of %s_next, %s_next or %s_next is required during the %s rotation stage",
+			util.GetNamespacedName(secret), SharedSecretKey, CookieSecretKey, SigningKeyKey, stage)
+	}
+	return stage, keys, nil
+}
+
+// ActiveBootstrapSecrets returns the bootstrap secret values Pomerium should use in the current rotation stage.
+// Pomerium only accepts a single shared secret and cookie secret, so these switch to their next value once
+// the current one is retired. The signing key holds both keys during a rotation, as Pomerium signs with the first key
+// and publishes all of them: the next key is published first, and signs once the current key is retired.
+func ActiveBootstrapSecrets(secret *corev1.Secret) (map[string][]byte, error) {
+	stage, keys, err := BootstrapSecretsRotation(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	data := maps.Clone(secret.Data)
+	for _, key := range keys {
+		current, next := secret.Data[key], secret.Data[NextBootstrapSecretKey(key)]
+		switch {
+		case key == SigningKeyKey && stage == RotationPublish:
+			data[key] = joinPEM(current, next)
+		case key == SigningKeyKey:
+			data[key] = joinPEM(next, current)
+		case stage == RotationRetire:
+			data[key] = next
+		}
+	}
+	return data, nil
+}
+
+func joinPEM(first, second []byte) []byte {
+	if len(first) == 0 {
+		return second
+	}
+	out := slices.Clone(first)
+	if out[len(out)-1] != '\n' {
+		out = append(out, '\n')
+	}
+	return append(out, second...)
+}
+
+// getRotationStage returns the rotation stage set by the annotation of a secret,
+// or an empty string if no rotation is in progress
+func getRotationStage(secret *corev1.Secret, annotation string) (string, error) {
+	switch stage := secret.Annotations[annotation]; stage {
+	case "", RotationPublish, RotationRetire:
+		return stage, nil
+	default:
+		return "", fmt.Errorf("secret %s: unknown %s annotation %q, expected %s or %s",
+			util.GetNamespacedName(secret), annotation, stage, RotationPublish, RotationRetire)
+	}
+}
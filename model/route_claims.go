@@ -0,0 +1,278 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Route claim path types
+const (
+	RouteClaimExact  = "exact"
+	RouteClaimPrefix = "prefix"
+	RouteClaimRegex  = "regex"
+)
+
+// Route claim owner kinds
+const (
+	RouteClaimKindIngress   = "Ingress"
+	RouteClaimKindHTTPRoute = "HTTPRoute"
+)
+
+// RouteClaim identifies requests a route matches, regardless of the object that defines it
+type RouteClaim struct {
+	Host string
+	// PathType is one of RouteClaimExact, RouteClaimPrefix or RouteClaimRegex
+	PathType string
+	Path     string
+}
+
+func (c RouteClaim) String() string {
+	return fmt.Sprintf("host=%s %s=%s", c.Host, c.PathType, c.Path)
+}
+
+// RouteClaimOwner is an object that claims routes
+type RouteClaimOwner struct {
+	Key
+	// CreationTimestamp is used to pick a winner among conflicting claims, oldest wins
+	CreationTimestamp time.Time
+}
+
+// RouteClaimConflict describes a claim that was lost to an object of another kind
+type RouteClaimConflict struct {
+	RouteClaim
+	// Winner is the object that owns the route
+	Winner Key
+}
+
+// String returns a human-readable description of the conflict
+func (c RouteClaimConflict) String() string {
+	return fmt.Sprintf("%s is claimed by older %s %s/%s", c.RouteClaim.String(), c.Winner.Kind, c.Winner.Namespace, c.Winner.Name)
+}
+
+// RouteClaimIndex keeps track of routes claimed by Ingress and HTTPRoute objects.
+// These are reconciled into separate Pomerium configurations independently, so a conflict
+// between them would otherwise be resolved in a way that depends on the order routes are merged.
+// Claims are only compared between objects of different kinds, as each controller already
+// orders routes of the same kind.
+type RouteClaimIndex interface {
+	// Set replaces all claims of the owner
+	Set(owner RouteClaimOwner, claims []RouteClaim)
+	// SetKind replaces claims of all owners of the kind, owners not listed are removed
+	SetKind(kind string, owners map[RouteClaimOwner][]RouteClaim)
+	// Delete removes all claims of the owner
+	Delete(owner Key)
+	// Lost returns claims of the owner that were won by an object of another kind
+	Lost(owner Key) []RouteClaimConflict
+	// Subscribe registers a callback that is invoked when claims of an object of the kind
+	// may have been affected by a change of claims of another object
+	Subscribe(kind string, fn func(types.NamespacedName))
+}
+
+type routeClaimIndex struct {
+	sync.Mutex
+	owners      map[Key]*routeClaimOwnerState
+	claims      map[RouteClaim]map[Key]bool
+	subscribers map[string][]func(types.NamespacedName)
+}
+
+type routeClaimOwnerState struct {
+	RouteClaimOwner
+	claims map[RouteClaim]bool
+}
+
+// NewRouteClaimIndex creates an empty route claim index safe for concurrent use
+func NewRouteClaimIndex() RouteClaimIndex {
+	return &routeClaimIndex{
+		owners:      make(map[Key]*routeClaimOwnerState),
+		claims:      make(map[RouteClaim]map[Key]bool),
+		subscribers: make(map[string][]func(types.NamespacedName)),
+	}
+}
+
+// Set replaces all claims of the owner
+func (idx *routeClaimIndex) Set(owner RouteClaimOwner, claims []RouteClaim) {
+	idx.Lock()
+	affected := idx.set(owner, claims)
+	idx.Unlock()
+
+	idx.notify(affected)
+}
+
+// SetKind replaces claims of all owners of the kind, owners not listed are removed
+func (idx *routeClaimIndex) SetKind(kind string, owners map[RouteClaimOwner][]RouteClaim) {
+	idx.Lock()
+	affected := make(map[Key]bool)
+	listed := make(map[Key]bool, len(owners))
+	for owner, claims := range owners {
+		listed[owner.Key] = true
+		for k := range idx.set(owner, claims) {
+			affected[k] = true
+		}
+	}
+	for k := range idx.owners {
+		if k.Kind == kind && !listed[k] {
+			for a := range idx.delete(k) {
+				affected[a] = true
+			}
+		}
+	}
+	idx.Unlock()
+
+	idx.notify(affected)
+}
+
+// Delete removes all claims of the owner
+func (idx *routeClaimIndex) Delete(owner Key) {
+	idx.Lock()
+	affected := idx.delete(owner)
+	idx.Unlock()
+
+	idx.notify(affected)
+}
+
+// Lost returns claims of the owner that were won by an object of another kind
+func (idx *routeClaimIndex) Lost(owner Key) []RouteClaimConflict {
+	idx.Lock()
+	defer idx.Unlock()
+
+	state, ok := idx.owners[owner]
+	if !ok {
+		return nil
+	}
+
+	var out []RouteClaimConflict
+	for claim := range state.claims {
+		winner := state
+		for k := range idx.claims[claim] {
+			if k.Kind == owner.Kind {
+				continue
+			}
+			if other := idx.owners[k]; claimOwnerLess(&other.RouteClaimOwner, &winner.RouteClaimOwner) {
+				winner = other
+			}
+		}
+		if winner != state {
+			out = append(out, RouteClaimConflict{RouteClaim: claim, Winner: winner.Key})
+		}
+	}
+	slices.SortFunc(out, func(a, b RouteClaimConflict) int {
+		return strings.Compare(a.RouteClaim.String(), b.RouteClaim.String())
+	})
+	return out
+}
+
+// Subscribe registers a callback that is invoked when claims of an object of the kind may have been affected
+func (idx *routeClaimIndex) Subscribe(kind string, fn func(types.NamespacedName)) {
+	idx.Lock()
+	defer idx.Unlock()
+
+	idx.subscribers[kind] = append(idx.subscribers[kind], fn)
+}
+
+// set must be called with the lock held, and returns owners of other kinds
+// that share claims that were added or removed
+func (idx *routeClaimIndex) set(owner RouteClaimOwner, claims []RouteClaim) map[Key]bool {
+	next := make(map[RouteClaim]bool, len(claims))
+	for _, c := range claims {
+		next[c] = true
+	}
+
+	prev, ok := idx.owners[owner.Key]
+	if !ok {
+		prev = &routeClaimOwnerState{RouteClaimOwner: owner}
+	}
+
+	var changed []RouteClaim
+	for c := range prev.claims {
+		// a re-created object may have lost claims it used to win
+		if !next[c] || !prev.CreationTimestamp.Equal(owner.CreationTimestamp) {
+			changed = append(changed, c)
+			delete(idx.claims[c], owner.Key)
+			if len(idx.claims[c]) == 0 {
+				delete(idx.claims, c)
+			}
+		}
+	}
+	for c := range next {
+		if !prev.claims[c] || !prev.CreationTimestamp.Equal(owner.CreationTimestamp) {
+			changed = append(changed, c)
+		}
+		if idx.claims[c] == nil {
+			idx.claims[c] = make(map[Key]bool)
+		}
+		idx.claims[c][owner.Key] = true
+	}
+
+	idx.owners[owner.Key] = &routeClaimOwnerState{RouteClaimOwner: owner, claims: next}
+	return idx.affectedBy(owner.Key, changed)
+}
+
+// delete must be called with the lock held
+func (idx *routeClaimIndex) delete(owner Key) map[Key]bool {
+	state, ok := idx.owners[owner]
+	if !ok {
+		return nil
+	}
+	delete(idx.owners, owner)
+
+	changed := make([]RouteClaim, 0, len(state.claims))
+	for c := range state.claims {
+		changed = append(changed, c)
+		delete(idx.claims[c], owner)
+		if len(idx.claims[c]) == 0 {
+			delete(idx.claims, c)
+		}
+	}
+	return idx.affectedBy(owner, changed)
+}
+
+func (idx *routeClaimIndex) affectedBy(owner Key, changed []RouteClaim) map[Key]bool {
+	affected := make(map[Key]bool)
+	for _, c := range changed {
+		for k := range idx.claims[c] {
+			if k.Kind != owner.Kind {
+				affected[k] = true
+			}
+		}
+	}
+	return affected
+}
+
+func (idx *routeClaimIndex) notify(affected map[Key]bool) {
+	if len(affected) == 0 {
+		return
+	}
+
+	idx.Lock()
+	subscribers := make(map[string][]func(types.NamespacedName), len(idx.subscribers))
+	for kind, fns := range idx.subscribers {
+		subscribers[kind] = slices.Clone(fns)
+	}
+	idx.Unlock()
+
+	for k := range affected {
+		for _, fn := range subscribers[k.Kind] {
+			fn(k.NamespacedName)
+		}
+	}
+}
+
+// claimOwnerLess orders owners by creation timestamp, as Gateway API specifies for conflicting routes,
+// falling back to kind, namespace and name
+func claimOwnerLess(a, b *RouteClaimOwner) bool {
+	if !a.CreationTimestamp.Equal(b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(b.CreationTimestamp)
+	}
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
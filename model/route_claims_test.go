@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestRouteClaimIndex(t *testing.T) {
+	idx := NewRouteClaimIndex()
+
+	var notified []types.NamespacedName
+	idx.Subscribe(RouteClaimKindHTTPRoute, func(name types.NamespacedName) {
+		notified = append(notified, name)
+	})
+
+	now := time.Now()
+	ingress := RouteClaimOwner{
+		Key:               Key{Kind: RouteClaimKindIngress, NamespacedName: types.NamespacedName{Namespace: "default", Name: "ingress"}},
+		CreationTimestamp: now,
+	}
+	route := RouteClaimOwner{
+		Key:               Key{Kind: RouteClaimKindHTTPRoute, NamespacedName: types.NamespacedName{Namespace: "default", Name: "route"}},
+		CreationTimestamp: now.Add(time.Second),
+	}
+	shared := RouteClaim{Host: "a.localhost.pomerium.io", PathType: RouteClaimPrefix, Path: "/"}
+	other := RouteClaim{Host: "b.localhost.pomerium.io", PathType: RouteClaimPrefix, Path: "/"}
+
+	idx.SetKind(RouteClaimKindHTTPRoute, map[RouteClaimOwner][]RouteClaim{route: {shared, other}})
+	assert.Empty(t, idx.Lost(route.Key))
+
+	idx.Set(ingress, []RouteClaim{shared, shared})
+	assert.Equal(t, []types.NamespacedName{route.NamespacedName}, notified, "route should be notified of a new claim")
+	assert.Empty(t, idx.Lost(ingress.Key), "older object wins")
+	assert.Equal(t, []RouteClaimConflict{{RouteClaim: shared, Winner: ingress.Key}}, idx.Lost(route.Key))
+
+	notified = nil
+	idx.Set(ingress, []RouteClaim{shared})
+	assert.Empty(t, notified, "unchanged claims should not notify")
+
+	// objects of the same kind do not conflict
+	route2 := RouteClaimOwner{Key: Key{Kind: RouteClaimKindHTTPRoute, NamespacedName: types.NamespacedName{Name: "route2"}}}
+	idx.SetKind(RouteClaimKindHTTPRoute, map[RouteClaimOwner][]RouteClaim{route: {shared}, route2: {other}})
+	assert.Empty(t, idx.Lost(route2.Key))
+
+	// re-created ingress is newer
+	ingress.CreationTimestamp = now.Add(time.Minute)
+	idx.Set(ingress, []RouteClaim{shared})
+	assert.Empty(t, idx.Lost(route.Key))
+	assert.Equal(t, []RouteClaimConflict{{RouteClaim: shared, Winner: route.Key}}, idx.Lost(ingress.Key))
+
+	notified = nil
+	idx.Delete(ingress.Key)
+	assert.Equal(t, []types.NamespacedName{route.NamespacedName}, notified)
+	assert.Empty(t, idx.Lost(ingress.Key))
+
+	idx.SetKind(RouteClaimKindHTTPRoute, nil)
+	assert.Empty(t, idx.(*routeClaimIndex).owners)
+	assert.Empty(t, idx.(*routeClaimIndex).claims)
+}
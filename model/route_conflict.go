@@ -0,0 +1,55 @@
+package model
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RouteConflict describes a route that matches exactly the same requests
+// as a route of another Ingress with the same priority, so only one of them is served
+type RouteConflict struct {
+	// From is the source URL of the conflicting routes
+	From string
+	// Match is the path, prefix or regex both routes match on
+	Match string
+	// Ingress is the other Ingress that defines a conflicting route
+	Ingress types.NamespacedName
+	// Shadowed is true if this route is not served because the other route takes precedence
+	Shadowed bool
+}
+
+// String returns a human-readable description of the conflict
+func (c RouteConflict) String() string {
+	if c.Shadowed {
+		return fmt.Sprintf("route %s%s is shadowed by ingress %s with the same priority, set %s to resolve",
+			c.From, c.Match, c.Ingress.String(), RoutePriority)
+	}
+	return fmt.Sprintf("route %s%s shadows ingress %s with the same priority, set %s to resolve",
+		c.From, c.Match, c.Ingress.String(), RoutePriority)
+}
+
+// GatewayRouteConflict describes a route of an HTTPRoute that matches exactly the same requests
+// as a route of another HTTPRoute with the same priority, so only one of them is served
+type GatewayRouteConflict struct {
+	// HTTPRoute is the route the conflict is reported for
+	HTTPRoute types.NamespacedName
+	// From is the source URL of the conflicting routes
+	From string
+	// Match is the path, prefix or regex both routes match on
+	Match string
+	// Other is the other HTTPRoute that defines a conflicting route
+	Other types.NamespacedName
+	// Shadowed is true if this route is not served because the other route takes precedence
+	Shadowed bool
+}
+
+// String returns a human-readable description of the conflict
+func (c GatewayRouteConflict) String() string {
+	if c.Shadowed {
+		return fmt.Sprintf("route %s%s is shadowed by HTTPRoute %s with the same priority, set %s to resolve",
+			c.From, c.Match, c.Other.String(), GatewayRoutePriorityAnnotation)
+	}
+	return fmt.Sprintf("route %s%s shadows HTTPRoute %s with the same priority, set %s to resolve",
+		c.From, c.Match, c.Other.String(), GatewayRoutePriorityAnnotation)
+}
@@ -0,0 +1,17 @@
+package model
+
+import "fmt"
+
+// TargetFailure describes a Pomerium target that could not be updated,
+// while configuration was successfully applied to other targets
+type TargetFailure struct {
+	// Target is the name of the Pomerium target
+	Target string
+	// Err is the reason the target was not updated
+	Err error
+}
+
+// String returns a human-readable description of the failure
+func (f TargetFailure) String() string {
+	return fmt.Sprintf("pomerium target %s is out of sync and will be retried: %v", f.Target, f.Err)
+}
@@ -0,0 +1,178 @@
+package pomerium
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pomerium/ingress-controller/model"
+)
+
+// IngressChange is either an update or a deletion of a single ingress within a batch
+type IngressChange struct {
+	// Ctx is the context of the request that produced the change,
+	// and collects diagnostics such as route conflicts for that ingress
+	Ctx context.Context
+	// Upsert is set if the ingress should be created or updated
+	Upsert *model.IngressConfig
+	// Delete is the ingress to delete, if Upsert is not set
+	Delete types.NamespacedName
+}
+
+// name returns the name of the ingress that is changed
+func (c IngressChange) name() types.NamespacedName {
+	if c.Upsert != nil {
+		return c.Upsert.GetIngressNamespacedName()
+	}
+	return c.Delete
+}
+
+// context returns the context of the request that produced the change, or ctx if not set
+func (c IngressChange) context(ctx context.Context) context.Context {
+	if c.Ctx != nil {
+		return c.Ctx
+	}
+	return ctx
+}
+
+// IngressBatchReconciler is optionally implemented by an IngressReconciler
+// that is able to apply several ingress changes in one transaction
+type IngressBatchReconciler interface {
+	// Apply applies all changes at once, or none of them if an error is returned.
+	// It reports for each change whether it modified the configuration.
+	Apply(ctx context.Context, batch []IngressChange) (changes []bool, err error)
+}
+
+// BatchIngressReconciler gathers Upsert and Delete calls that arrive within a time window
+// and applies them to the underlying reconciler at once.
+// Each call blocks until its batch is applied, and reports whether its own change modified the configuration.
+// Unlike other reconcilers, it is safe for concurrent use.
+type BatchIngressReconciler struct {
+	next    IngressReconciler
+	window  time.Duration
+	maxSize int
+
+	// mu guards the batch being collected
+	mu      sync.Mutex
+	pending []*batchItem
+	full    chan struct{}
+
+	// applyMu serializes calls to the underlying reconciler, that is not thread safe
+	applyMu sync.Mutex
+}
+
+type batchItem struct {
+	change IngressChange
+	done   chan batchResult
+}
+
+type batchResult struct {
+	changes bool
+	err     error
+}
+
+var _ = IngressReconciler((*BatchIngressReconciler)(nil))
+
+// NewBatchIngressReconciler returns a reconciler that collects changes for up to window,
+// or until maxSize changes are pending, before applying them. Zero maxSize does not limit the batch size.
+func NewBatchIngressReconciler(next IngressReconciler, window time.Duration, maxSize int) *BatchIngressReconciler {
+	return &BatchIngressReconciler{
+		next:    next,
+		window:  window,
+		maxSize: maxSize,
+	}
+}
+
+// Upsert queues the ingress update and waits until it is applied
+func (r *BatchIngressReconciler) Upsert(ctx context.Context, ic *model.IngressConfig) (bool, error) {
+	return r.submit(ctx, IngressChange{Ctx: ctx, Upsert: ic})
+}
+
+// Delete queues the ingress deletion and waits until it is applied
+func (r *BatchIngressReconciler) Delete(ctx context.Context, namespacedName types.NamespacedName) (bool, error) {
+	return r.submit(ctx, IngressChange{Ctx: ctx, Delete: namespacedName})
+}
+
+// Set is passed to the underlying reconciler immediately, as it is already a single transaction
+func (r *BatchIngressReconciler) Set(ctx context.Context, ics []*model.IngressConfig) (bool, error) {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+
+	return r.next.Set(ctx, ics)
+}
+
+func (r *BatchIngressReconciler) submit(ctx context.Context, change IngressChange) (bool, error) {
+	item := &batchItem{change: change, done: make(chan batchResult, 1)}
+
+	r.mu.Lock()
+	r.pending = append(r.pending, item)
+	if len(r.pending) == 1 {
+		r.full = make(chan struct{})
+		go r.flush(context.WithoutCancel(ctx), r.full)
+	}
+	if len(r.pending) == r.maxSize {
+		close(r.full)
+	}
+	r.mu.Unlock()
+
+	select {
+	case res := <-item.done:
+		return res.changes, res.err
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// flush waits for the batch window to pass or the batch to fill up, and applies the batch
+func (r *BatchIngressReconciler) flush(ctx context.Context, full <-chan struct{}) {
+	timer := time.NewTimer(r.window)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-full:
+	}
+
+	r.mu.Lock()
+	batch := r.pending
+	r.pending, r.full = nil, nil
+	r.mu.Unlock()
+
+	r.apply(ctx, batch)
+}
+
+func (r *BatchIngressReconciler) apply(ctx context.Context, batch []*batchItem) {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+
+	logger := log.FromContext(ctx)
+	if br, ok := r.next.(IngressBatchReconciler); ok && len(batch) > 1 {
+		changes := make([]IngressChange, 0, len(batch))
+		for _, item := range batch {
+			changes = append(changes, item.change)
+		}
+		changed, err := br.Apply(ctx, changes)
+		if err == nil {
+			logger.V(1).Info("applied batch", "size", len(batch))
+			for i, item := range batch {
+				item.done <- batchResult{changes: changed[i]}
+			}
+			return
+		}
+		// one invalid ingress should not prevent others from being applied
+		logger.Error(err, "apply batch, falling back to individual updates", "size", len(batch))
+	}
+
+	for _, item := range batch {
+		var res batchResult
+		if ic := item.change.Upsert; ic != nil {
+			res.changes, res.err = r.next.Upsert(item.change.Ctx, ic)
+		} else {
+			res.changes, res.err = r.next.Delete(item.change.Ctx, item.change.Delete)
+		}
+		item.done <- res
+	}
+}
@@ -0,0 +1,160 @@
+package pomerium
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/pomerium/ingress-controller/internal/testutil"
+	"github.com/pomerium/ingress-controller/model"
+)
+
+type fakeBatchReconciler struct {
+	applyErr error
+	batches  [][]IngressChange
+	upserts  []types.NamespacedName
+	deletes  []types.NamespacedName
+}
+
+func (r *fakeBatchReconciler) Upsert(_ context.Context, ic *model.IngressConfig) (bool, error) {
+	r.upserts = append(r.upserts, ic.GetIngressNamespacedName())
+	if ic.Name == "invalid" {
+		return false, errors.New("invalid")
+	}
+	return true, nil
+}
+
+func (r *fakeBatchReconciler) Set(_ context.Context, _ []*model.IngressConfig) (bool, error) {
+	return true, nil
+}
+
+func (r *fakeBatchReconciler) Delete(_ context.Context, name types.NamespacedName) (bool, error) {
+	r.deletes = append(r.deletes, name)
+	return true, nil
+}
+
+func (r *fakeBatchReconciler) Apply(_ context.Context, changes []IngressChange) ([]bool, error) {
+	r.batches = append(r.batches, changes)
+	if r.applyErr != nil {
+		return nil, r.applyErr
+	}
+	changed := make([]bool, len(changes))
+	for i, change := range changes {
+		// ingresses named unchanged are already configured
+		changed[i] = change.name().Name != "unchanged"
+	}
+	return changed, nil
+}
+
+func runBatch(t *testing.T, r *BatchIngressReconciler, names ...string) map[string]batchResult {
+	t.Helper()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	out := make(map[string]batchResult)
+	for _, name := range names {
+		wg.Go(func() {
+			ic := &model.IngressConfig{Ingress: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			}}
+			changed, err := r.Upsert(t.Context(), ic)
+			mu.Lock()
+			out[name] = batchResult{changes: changed, err: err}
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+	return out
+}
+
+func TestBatchIngressReconciler(t *testing.T) {
+	t.Run("batch", func(t *testing.T) {
+		next := new(fakeBatchReconciler)
+		r := NewBatchIngressReconciler(next, 100*time.Millisecond, 0)
+		results := runBatch(t, r, "a", "unchanged", "c")
+		for name, res := range results {
+			assert.NoError(t, res.err, name)
+			assert.Equal(t, name != "unchanged", res.changes, name)
+		}
+		require.Len(t, next.batches, 1)
+		assert.Len(t, next.batches[0], 3)
+		assert.Empty(t, next.upserts)
+	})
+
+	t.Run("max size", func(t *testing.T) {
+		next := new(fakeBatchReconciler)
+		r := NewBatchIngressReconciler(next, time.Hour, 2)
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			runBatch(t, r, "a", "b")
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			t.Fatal("full batch was not applied before the window elapsed")
+		}
+		require.Len(t, next.batches, 1)
+		assert.Len(t, next.batches[0], 2)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		next := &fakeBatchReconciler{applyErr: errors.New("invalid ingress in batch")}
+		r := NewBatchIngressReconciler(next, 100*time.Millisecond, 0)
+		results := runBatch(t, r, "a", "invalid", "c")
+		assert.NoError(t, results["a"].err)
+		assert.Error(t, results["invalid"].err)
+		assert.NoError(t, results["c"].err)
+		assert.Len(t, next.batches, 1)
+		assert.Len(t, next.upserts, 3)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		next := new(fakeBatchReconciler)
+		r := NewBatchIngressReconciler(next, time.Millisecond, 0)
+		name := types.NamespacedName{Namespace: "default", Name: "a"}
+		changed, err := r.Delete(t.Context(), name)
+		assert.NoError(t, err)
+		assert.True(t, changed)
+		assert.Equal(t, []types.NamespacedName{name}, next.deletes)
+	})
+}
+
+func TestDataBrokerApply(t *testing.T) {
+	ctx := t.Context()
+	r := &DataBrokerReconciler{
+		ConfigID:                IngressControllerConfigID,
+		DataBrokerServiceClient: testutil.NewInMemoryDataBroker(t),
+		RemoveUnreferencedCerts: true,
+	}
+	_, err := r.Set(ctx, []*model.IngressConfig{
+		newTestShardIngress("a", "a.localhost.pomerium.io", "/"),
+		newTestShardIngress("b", "b.localhost.pomerium.io", "/"),
+	})
+	require.NoError(t, err)
+
+	changed, err := r.Apply(ctx, []IngressChange{
+		{Upsert: newTestShardIngress("a", "a.localhost.pomerium.io", "/")},
+		{Upsert: newTestShardIngress("c", "c.localhost.pomerium.io", "/")},
+		{Delete: types.NamespacedName{Namespace: "b", Name: "ingress"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true, true}, changed, "only ingresses that changed should be reported")
+
+	changed, err = r.Apply(ctx, []IngressChange{
+		{Upsert: newTestShardIngress("d", "d.localhost.pomerium.io", "/")},
+		{Delete: types.NamespacedName{Namespace: "d", Name: "ingress"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, false}, changed, "changes reverted within the batch should not be reported")
+}
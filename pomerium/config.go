@@ -365,11 +365,19 @@ func applyDownstreamMTLS(_ context.Context, dst *pb.Config, src *model.Config) e
 
 	dst.Settings.DownstreamMtls = new(pb.DownstreamMtlsSettings)
 
-	if len(src.Spec.DownstreamMTLS.CA) > 0 {
-		dst.Settings.DownstreamMtls.Ca = proto.String(base64.StdEncoding.EncodeToString(src.Spec.DownstreamMTLS.CA))
+	// the bundles are fetched from a secret or config map if not set inline
+	ca, crl := src.Spec.DownstreamMTLS.CA, src.Spec.DownstreamMTLS.CRL
+	if len(ca) == 0 {
+		ca = src.DownstreamMTLS.CA
 	}
-	if len(src.Spec.DownstreamMTLS.CRL) > 0 {
-		dst.Settings.DownstreamMtls.Crl = proto.String(base64.StdEncoding.EncodeToString(src.Spec.DownstreamMTLS.CRL))
+	if len(crl) == 0 {
+		crl = src.DownstreamMTLS.CRL
+	}
+	if len(ca) > 0 {
+		dst.Settings.DownstreamMtls.Ca = proto.String(base64.StdEncoding.EncodeToString(ca))
+	}
+	if len(crl) > 0 {
+		dst.Settings.DownstreamMtls.Crl = proto.String(base64.StdEncoding.EncodeToString(crl))
 	}
 	if src.Spec.DownstreamMTLS.Enforcement != nil {
 		switch strings.ToLower(*src.Spec.DownstreamMTLS.Enforcement) {
@@ -461,25 +469,25 @@ func applyDNS(_ context.Context, dst *pb.Config, src *model.Config) error {
 }
 
 func applySSH(_ context.Context, dst *pb.Config, src *model.Config) error {
-	if secrets := src.SSHSecrets.HostKeys; len(secrets) > 0 {
-		dst.Settings.SshHostKeys = &pb.Settings_StringList{Values: make([]string, 0, len(secrets))}
-		for _, secret := range secrets {
-			data, ok := secret.Data[model.SSHPrivateKey]
-			if !ok {
-				return fmt.Errorf("missing ssh host key data in %s", util.GetNamespacedName(secret))
-			}
+	hostKeys, err := src.SSHSecrets.ActiveHostKeys()
+	if err != nil {
+		return err
+	}
+	if len(hostKeys) > 0 {
+		dst.Settings.SshHostKeys = &pb.Settings_StringList{Values: make([]string, 0, len(hostKeys))}
+		for _, data := range hostKeys {
 			dst.Settings.SshHostKeys.Values = append(dst.Settings.SshHostKeys.Values, string(data))
 		}
 	} else {
 		dst.Settings.SshHostKeys = nil
 	}
 
-	if secret := src.SSHSecrets.UserCAKey; secret != nil {
-		data, ok := secret.Data[model.SSHPrivateKey]
-		if !ok {
-			return fmt.Errorf("missing ssh user ca key data in %s", util.GetNamespacedName(secret))
-		}
-		dst.Settings.SshUserCaKey = proto.String(string(data))
+	userCAKey, err := src.SSHSecrets.ActiveUserCAKey()
+	if err != nil {
+		return err
+	}
+	if userCAKey != nil {
+		dst.Settings.SshUserCaKey = proto.String(string(userCAKey))
 	} else {
 		dst.Settings.SshUserCaKey = nil
 	}
@@ -0,0 +1,332 @@
+package pomerium
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	pb "github.com/pomerium/pomerium/pkg/grpc/config"
+
+	"github.com/pomerium/ingress-controller/util"
+)
+
+const redacted = "REDACTED"
+
+// ConfigDebugger keeps the configuration last applied by the reconcilers and recent changes to it,
+// and serves them over HTTP for troubleshooting. Secrets are redacted before they are stored.
+// It is safe for concurrent use.
+type ConfigDebugger struct {
+	maxDiffs int
+
+	mu      sync.Mutex
+	records map[string]*debugRecord
+	diffs   []ConfigDiff
+}
+
+type debugRecord struct {
+	config *pb.Config
+	routes []DebugRoute
+}
+
+// ConfigDiff is a single change of a configuration record
+type ConfigDiff struct {
+	Time     time.Time `json:"time"`
+	RecordID string    `json:"record"`
+	// Trigger describes the Kubernetes objects that caused the change
+	Trigger string `json:"trigger"`
+	Diff    string `json:"diff"`
+}
+
+// DebugRoute traces a Pomerium route back to the Kubernetes object it was generated from
+type DebugRoute struct {
+	RecordID string `json:"record"`
+	Name     string `json:"name"`
+	From     string `json:"from"`
+	Source   string `json:"source"`
+}
+
+// routeSource is collected in the context while Gateway routes are translated,
+// as unlike Ingress routes, they do not encode the source object in the route ID
+type routeSource struct {
+	route  string
+	object string
+}
+
+type configTriggerKey struct{}
+
+// withConfigTrigger records what caused the configuration change made within ctx
+func withConfigTrigger(ctx context.Context, format string, args ...any) context.Context {
+	return context.WithValue(ctx, configTriggerKey{}, fmt.Sprintf(format, args...))
+}
+
+// WithAPIConfigDebugger records the synced routes and settings and their changes in the debugger
+func WithAPIConfigDebugger(debugger *ConfigDebugger) APIReconcilerOption {
+	return func(r *APIReconciler) {
+		r.debugger = debugger
+	}
+}
+
+// observeRoute records a route synced with the API in the debugger, a nil route means it was deleted
+func (r *APIReconciler) observeRoute(ctx context.Context, id string, route *pb.Route) {
+	if r.debugger == nil || id == "" {
+		return
+	}
+	var prev, next *pb.Config
+	if synced, ok := r.synced.get(apiObjectRoute, id).(*pb.Route); ok {
+		prev = &pb.Config{Routes: []*pb.Route{synced}}
+	}
+	if route != nil {
+		next = &pb.Config{Routes: []*pb.Route{route}}
+	}
+	r.debugger.observe(ctx, syncedAPIObjectKey(apiObjectRoute, id), prev, next)
+}
+
+// observeSettings records the settings synced with the API in the debugger
+func (r *APIReconciler) observeSettings(ctx context.Context, prev, next *pb.Settings) {
+	r.debugger.observe(ctx, "settings", &pb.Config{Settings: prev}, &pb.Config{Settings: next})
+}
+
+// NewConfigDebugger returns a debugger that remembers up to maxDiffs most recent changes
+func NewConfigDebugger(maxDiffs int) *ConfigDebugger {
+	return &ConfigDebugger{
+		maxDiffs: maxDiffs,
+		records:  make(map[string]*debugRecord),
+	}
+}
+
+// observe records the current state of a configuration record, and the change from prev if it differs.
+// A nil next means the record was deleted. It is a no-op on a nil debugger.
+func (d *ConfigDebugger) observe(ctx context.Context, recordID string, prev, next *pb.Config) {
+	if d == nil {
+		return
+	}
+
+	if prev == nil {
+		prev = new(pb.Config)
+	}
+	prev = redactConfig(prev)
+	var rec *debugRecord
+	if next != nil {
+		rec = &debugRecord{config: redactConfig(next)}
+		rec.routes = debugRoutes(ctx, recordID, next)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if rec == nil {
+		delete(d.records, recordID)
+		rec = &debugRecord{config: new(pb.Config)}
+	} else {
+		d.records[recordID] = rec
+	}
+	if proto.Equal(prev, rec.config) || d.maxDiffs <= 0 {
+		return
+	}
+
+	trigger, _ := ctx.Value(configTriggerKey{}).(string)
+	if len(d.diffs) == d.maxDiffs {
+		copy(d.diffs, d.diffs[1:])
+		d.diffs = d.diffs[:len(d.diffs)-1]
+	}
+	d.diffs = append(d.diffs, ConfigDiff{
+		Time:     time.Now(),
+		RecordID: recordID,
+		Trigger:  trigger,
+		Diff:     configLineDiff(prev, rec.config),
+	})
+}
+
+func debugRoutes(ctx context.Context, recordID string, cfg *pb.Config) []DebugRoute {
+	sources := make(map[string]string)
+	for _, src := range util.Get[routeSource](ctx) {
+		sources[src.route] = src.object
+	}
+
+	routes := make([]DebugRoute, 0, len(cfg.GetRoutes()))
+	for _, r := range cfg.GetRoutes() {
+		source, ok := sources[r.GetName()]
+		if !ok {
+			var id routeID
+			if err := id.Unmarshal(r.GetId()); err == nil {
+				source = fmt.Sprintf("Ingress %s/%s", id.Namespace, id.Name)
+			}
+		}
+		routes = append(routes, DebugRoute{
+			RecordID: recordID,
+			Name:     r.GetName(),
+			From:     r.GetFrom(),
+			Source:   source,
+		})
+	}
+	return routes
+}
+
+// ServeHTTP serves the current configuration at /config, route provenance at /routes
+// and recent changes, newest first, at /diffs
+func (d *ConfigDebugger) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	d.mu.Lock()
+	var out any
+	var err error
+	switch strings.TrimSuffix(req.URL.Path, "/") {
+	case "/config":
+		out, err = d.configJSON()
+	case "/routes":
+		var routes []DebugRoute
+		for _, id := range slices.Sorted(maps.Keys(d.records)) {
+			routes = append(routes, d.records[id].routes...)
+		}
+		out = routes
+	case "/diffs":
+		diffs := slices.Clone(d.diffs)
+		slices.Reverse(diffs)
+		out = diffs
+	default:
+		d.mu.Unlock()
+		http.NotFound(w, req)
+		return
+	}
+	d.mu.Unlock()
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(out)
+}
+
+func (d *ConfigDebugger) configJSON() (map[string]json.RawMessage, error) {
+	out := make(map[string]json.RawMessage, len(d.records))
+	for id, rec := range d.records {
+		data, err := protojson.Marshal(rec.config)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s: %w", id, err)
+		}
+		out[id] = data
+	}
+	return out, nil
+}
+
+// configLineDiff returns the lines that were removed, prefixed with -, and added, prefixed with +
+func configLineDiff(prev, next *pb.Config) string {
+	opts := protojson.MarshalOptions{Multiline: true}
+	dmp := diffmatchpatch.New()
+	txt1, txt2, lines := dmp.DiffLinesToChars(opts.Format(prev), opts.Format(next))
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(txt1, txt2, false), lines)
+
+	var sb strings.Builder
+	for _, diff := range diffs {
+		var prefix string
+		switch diff.Type {
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		default:
+			continue
+		}
+		for line := range strings.Lines(diff.Text) {
+			sb.WriteString(prefix)
+			sb.WriteString(line)
+		}
+	}
+	return sb.String()
+}
+
+// redactConfig returns a copy of the config with credentials and private keys replaced
+func redactConfig(cfg *pb.Config) *pb.Config {
+	cfg = proto.CloneOf(cfg)
+	redactMessage(cfg.ProtoReflect())
+	return cfg
+}
+
+func redactMessage(m protoreflect.Message) {
+	var secrets []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case isSecretField(fd):
+			secrets = append(secrets, fd)
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+					redactMessage(v.Message())
+					return true
+				})
+			}
+		case fd.IsList():
+			if fd.Message() != nil {
+				for i := range v.List().Len() {
+					redactMessage(v.List().Get(i).Message())
+				}
+			}
+		case fd.Message() != nil:
+			redactMessage(v.Message())
+		}
+		return true
+	})
+
+	// the message is not modified while ranging over it
+	for _, fd := range secrets {
+		switch {
+		case fd.IsMap() && fd.MapValue().Kind() == protoreflect.StringKind:
+			// keep the header names, which are useful for troubleshooting
+			values := m.Mutable(fd).Map()
+			values.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+				values.Set(k, protoreflect.ValueOfString(redacted))
+				return true
+			})
+		case fd.IsMap() || fd.IsList() || fd.Message() != nil:
+			m.Clear(fd)
+		case fd.Kind() == protoreflect.StringKind:
+			m.Set(fd, protoreflect.ValueOfString(redacted))
+		case fd.Kind() == protoreflect.BytesKind:
+			m.Set(fd, protoreflect.ValueOfBytes([]byte(redacted)))
+		}
+	}
+}
+
+// secretFields hold secrets, but are not recognized by their names
+var secretFields = map[protoreflect.FullName]bool{
+	// inline SSH host private keys
+	fieldFullName(&pb.Settings{}, "ssh_host_keys"): true,
+	// may be copied from a secret, see set_request_headers_secret
+	fieldFullName(&pb.Route{}, "set_request_headers"): true,
+	// usually carry the credentials of the tracing backend
+	fieldFullName(&pb.Settings{}, "otel_exporter_otlp_traces_headers"): true,
+}
+
+func fieldFullName(m proto.Message, name protoreflect.Name) protoreflect.FullName {
+	return m.ProtoReflect().Descriptor().FullName().Append(name)
+}
+
+func isSecretField(fd protoreflect.FieldDescriptor) bool {
+	if secretFields[fd.FullName()] {
+		return true
+	}
+	s := string(fd.Name())
+	for _, substr := range []string{"secret", "password", "token", "connection_string", "basic_auth", "service_account"} {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return s == "key" || strings.HasSuffix(s, "_key") || strings.HasSuffix(s, "key_bytes")
+}
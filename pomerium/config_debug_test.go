@@ -0,0 +1,135 @@
+package pomerium
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/pomerium/pomerium/config"
+	pb "github.com/pomerium/pomerium/pkg/grpc/config"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
+)
+
+func TestConfigDebugger(t *testing.T) {
+	d := NewConfigDebugger(2)
+
+	route := &pb.Route{
+		From:              "https://a.localhost.pomerium.io",
+		SetRequestHeaders: map[string]string{"X-Api-Key": "header-secret"},
+	}
+	require.NoError(t, setRouteNameID(route, types.NamespacedName{Namespace: "default", Name: "a"},
+		url.URL{Host: "a.localhost.pomerium.io"}))
+	next := &pb.Config{
+		Routes: []*pb.Route{route},
+		Settings: &pb.Settings{
+			IdpClientSecret: new("idp-secret"),
+			SshHostKeys:     &pb.Settings_StringList{Values: []string{"ssh-host-private-key"}},
+			Certificates: []*pb.Settings_Certificate{{
+				CertBytes: []byte("cert"),
+				KeyBytes:  []byte("private-key"),
+			}},
+		},
+	}
+
+	ctx := withConfigTrigger(t.Context(), "Ingress %s", "default/a")
+	d.observe(ctx, IngressControllerConfigID, nil, next)
+	d.observe(context.Background(), SharedSettingsConfigID, nil, &pb.Config{Settings: &pb.Settings{}})
+	d.observe(context.Background(), GatewayControllerConfigID, nil, &pb.Config{Settings: &pb.Settings{}})
+
+	get := func(t *testing.T, path string, dst any) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), dst))
+	}
+
+	t.Run("config is redacted", func(t *testing.T) {
+		var configs map[string]json.RawMessage
+		get(t, "/config", &configs)
+		require.Contains(t, configs, IngressControllerConfigID)
+		txt := string(configs[IngressControllerConfigID])
+		assert.NotContains(t, txt, "idp-secret")
+		assert.NotContains(t, txt, "cHJpdmF0ZS1rZXk=", "base64 encoded private key")
+		assert.NotContains(t, txt, "ssh-host-private-key")
+		assert.NotContains(t, txt, "header-secret")
+		assert.Contains(t, txt, "X-Api-Key", "header names should be kept")
+		assert.Contains(t, txt, "https://a.localhost.pomerium.io")
+		assert.Equal(t, "idp-secret", next.GetSettings().GetIdpClientSecret(), "original config should not be modified")
+	})
+
+	t.Run("route sources", func(t *testing.T) {
+		var routes []DebugRoute
+		get(t, "/routes", &routes)
+		if assert.Len(t, routes, 1) {
+			assert.Equal(t, "Ingress default/a", routes[0].Source)
+			assert.Equal(t, IngressControllerConfigID, routes[0].RecordID)
+		}
+	})
+
+	t.Run("recent diffs", func(t *testing.T) {
+		var diffs []ConfigDiff
+		get(t, "/diffs", &diffs)
+		// the oldest diff, of the ingress config, was evicted
+		require.Len(t, diffs, 2)
+		assert.Equal(t, GatewayControllerConfigID, diffs[0].RecordID)
+		assert.Equal(t, SharedSettingsConfigID, diffs[1].RecordID)
+
+		d.observe(ctx, IngressControllerConfigID, next, nil)
+		get(t, "/diffs", &diffs)
+		assert.Equal(t, "Ingress default/a", diffs[0].Trigger)
+		assert.Contains(t, diffs[0].Diff, "-")
+		assert.NotContains(t, diffs[0].Diff, "idp-secret")
+		assert.NotContains(t, diffs[0].Diff, "ssh-host-private-key")
+		assert.NotContains(t, diffs[0].Diff, "header-secret")
+	})
+}
+
+func TestFileReconcilerConfigDebugger(t *testing.T) {
+	d := NewConfigDebugger(10)
+	r := NewFileReconciler(new(memConfigWriter), config.NewDefaultOptions(), WithFileConfigDebugger(d))
+
+	ic := &model.IngressConfig{
+		AnnotationPrefix: "a",
+		Ingress: &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-ingress", Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: new("pomerium"),
+				Rules: []networkingv1.IngressRule{{
+					Host:             "a.localhost.pomerium.io",
+					IngressRuleValue: exampleIngressRuleValue,
+				}},
+			},
+		},
+		Services: map[types.NamespacedName]*corev1.Service{
+			{Name: "example-svc", Namespace: "test"}: {},
+		},
+	}
+	_, err := r.Set(t.Context(), []*model.IngressConfig{ic})
+	require.NoError(t, err)
+	_, err = r.SetConfig(t.Context(), &model.Config{Pomerium: icsv1.Pomerium{ObjectMeta: metav1.ObjectMeta{Name: "global"}}})
+	require.NoError(t, err)
+	_, err = r.Delete(t.Context(), ic.GetIngressNamespacedName())
+	require.NoError(t, err)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	require.Contains(t, d.records, fileConfigRecordID)
+	require.Len(t, d.diffs, 2)
+	assert.Equal(t, "Pomerium global", d.diffs[0].Trigger)
+	assert.Contains(t, d.diffs[0].Diff, "https://a.localhost.pomerium.io")
+	assert.Equal(t, "Ingress test/my-ingress deleted", d.diffs[1].Trigger)
+	assert.Empty(t, d.records[fileConfigRecordID].routes)
+}
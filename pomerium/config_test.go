@@ -10,6 +10,7 @@ import (
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/model"
@@ -127,6 +128,85 @@ func TestApplyConfig_DownstreamMTLS(t *testing.T) {
 	}
 }
 
+func TestApplyConfig_DownstreamMTLSBundles(t *testing.T) {
+	ctx := t.Context()
+
+	bundles := model.DownstreamMTLSBundles{CA: []byte{1, 2, 3, 4}, CRL: []byte{5, 6, 7, 8}}
+	for _, tc := range []struct {
+		name   string
+		expect *pb.DownstreamMtlsSettings
+		mtls   *v1.DownstreamMTLS
+	}{
+		{
+			"references",
+			&pb.DownstreamMtlsSettings{Ca: proto.String("AQIDBA=="), Crl: proto.String("BQYHCA==")},
+			&v1.DownstreamMTLS{CASecret: proto.String("pomerium/ca"), CRLConfigMap: proto.String("pomerium/crl")},
+		},
+		{
+			"inline takes precedence",
+			&pb.DownstreamMtlsSettings{Ca: proto.String("CQo="), Crl: proto.String("BQYHCA==")},
+			&v1.DownstreamMTLS{CA: []byte{9, 10}},
+		},
+	} {
+		src := &model.Config{
+			Pomerium: v1.Pomerium{
+				Spec: v1.PomeriumSpec{
+					DownstreamMTLS: tc.mtls,
+				},
+			},
+			DownstreamMTLS: bundles,
+		}
+		dst := new(pb.Config)
+		err := pomerium.ApplyConfig(ctx, dst, src)
+		assert.NoError(t, err,
+			"should have no error in %s", tc.name)
+		assert.Empty(t, cmp.Diff(tc.expect, dst.Settings.DownstreamMtls, protocmp.Transform()),
+			"should match in %s", tc.name)
+	}
+}
+
+func TestApplyConfig_SSHKeyRotation(t *testing.T) {
+	ctx := t.Context()
+
+	secret := func(name, stage string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "pomerium",
+				Name:        name,
+				Annotations: map[string]string{model.SSHKeyRotationAnnotation: stage},
+			},
+			Data: map[string][]byte{
+				model.SSHPrivateKey:     []byte(name + "-current"),
+				model.SSHNextPrivateKey: []byte(name + "-next"),
+			},
+			Type: corev1.SecretTypeSSHAuth,
+		}
+	}
+	for _, tc := range []struct {
+		stage     string
+		hostKeys  []string
+		userCAKey string
+	}{
+		{"", []string{"host-current"}, "ca-current"},
+		{model.RotationPublish, []string{"host-current", "host-next"}, "ca-current"},
+		{model.RotationRetire, []string{"host-next"}, "ca-next"},
+	} {
+		src := &model.Config{
+			SSHSecrets: model.SSHSecrets{
+				HostKeys:  []*corev1.Secret{secret("host", tc.stage)},
+				UserCAKey: secret("ca", tc.stage),
+			},
+		}
+		dst := new(pb.Config)
+		require.NoError(t, pomerium.ApplyConfig(ctx, dst, src), tc.stage)
+		assert.Equal(t, tc.hostKeys, dst.Settings.SshHostKeys.GetValues(), tc.stage)
+		assert.Equal(t, tc.userCAKey, dst.Settings.GetSshUserCaKey(), tc.stage)
+	}
+
+	src := &model.Config{SSHSecrets: model.SSHSecrets{HostKeys: []*corev1.Secret{secret("host", "unknown")}}}
+	assert.Error(t, pomerium.ApplyConfig(ctx, new(pb.Config), src))
+}
+
 func TestApplyConfig_MCPAllowedASMetadataDomains(t *testing.T) {
 	ctx := context.Background()
 
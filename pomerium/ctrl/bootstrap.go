@@ -192,16 +192,21 @@ func applySecrets(_ context.Context, dst *config.Options, src *model.Config) err
 
 	name := types.NamespacedName{Name: src.Secrets.Name, Namespace: src.Secrets.Namespace}
 
+	secrets, err := model.ActiveBootstrapSecrets(src.Secrets)
+	if err != nil {
+		return err
+	}
+
 	for _, secret := range []struct {
 		key string
 		len int
 		sp  *string
 	}{
-		{"shared_secret", 32, &dst.SharedKey},
-		{"cookie_secret", 32, &dst.CookieSecret},
-		{"signing_key", -1, &dst.SigningKey},
+		{model.SharedSecretKey, 32, &dst.SharedKey},
+		{model.CookieSecretKey, 32, &dst.CookieSecret},
+		{model.SigningKeyKey, -1, &dst.SigningKey},
 	} {
-		data, ok := src.Secrets.Data[secret.key]
+		data, ok := secrets[secret.key]
 		if !ok && secret.len > 0 {
 			return fmt.Errorf("secret %s is missing a key %s", name, secret.key)
 		}
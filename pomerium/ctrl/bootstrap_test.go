@@ -127,3 +127,50 @@ func TestApplyAdditional(t *testing.T) {
 		assert.Equal(t, []byte("not-a-real-key"), opts.CertificateData[0].KeyBytes)
 	}
 }
+
+func TestSecretsRotation(t *testing.T) {
+	secrets, err := util.NewBootstrapSecrets(types.NamespacedName{})
+	require.NoError(t, err)
+	next, err := util.NewBootstrapSecretsData()
+	require.NoError(t, err)
+	for key, value := range next {
+		secrets.Data[model.NextBootstrapSecretKey(key)] = value
+	}
+	current := secrets.Data
+	encode := func(values ...[]byte) string {
+		var data []byte
+		for _, v := range values {
+			data = append(data, v...)
+		}
+		return base64.StdEncoding.EncodeToString(data)
+	}
+
+	for _, tc := range []struct {
+		stage                      string
+		sharedSecret, cookieSecret string
+		signingKey                 string
+	}{
+		{"", encode(current["shared_secret"]), encode(current["cookie_secret"]), encode(current["signing_key"])},
+		{
+			model.RotationPublish,
+			encode(current["shared_secret"]), encode(current["cookie_secret"]),
+			encode(current["signing_key"], next["signing_key"]),
+		},
+		{
+			model.RotationRetire,
+			encode(next["shared_secret"]), encode(next["cookie_secret"]),
+			encode(next["signing_key"], current["signing_key"]),
+		},
+	} {
+		secrets.Annotations = map[string]string{model.BootstrapSecretsRotationAnnotation: tc.stage}
+
+		var opts config.Options
+		require.NoError(t, applySecrets(context.Background(), &opts, &model.Config{Secrets: secrets}), tc.stage)
+		assert.Equal(t, tc.sharedSecret, opts.SharedKey, tc.stage)
+		assert.Equal(t, tc.cookieSecret, opts.CookieSecret, tc.stage)
+		assert.Equal(t, tc.signingKey, opts.SigningKey, tc.stage)
+	}
+
+	secrets.Annotations = map[string]string{model.BootstrapSecretsRotationAnnotation: "unknown"}
+	assert.Error(t, applySecrets(context.Background(), new(config.Options), &model.Config{Secrets: secrets}))
+}
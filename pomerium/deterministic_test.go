@@ -106,14 +106,14 @@ func TestEnsureDeterministicConfigOrder(t *testing.T) {
 			t.Parallel()
 
 			cfg := proto.Clone(tc.cfg).(*configpb.Config)
-			ensureDeterministicConfigOrder(cfg)
+			ensureDeterministicConfigOrder(cfg, nil)
 
 			if diff := cmp.Diff(tc.want, cfg, protocmp.Transform()); diff != "" {
 				t.Fatalf("unexpected config (-want +got):\n%s", diff)
 			}
 
 			again := proto.Clone(cfg).(*configpb.Config)
-			ensureDeterministicConfigOrder(again)
+			ensureDeterministicConfigOrder(again, nil)
 
 			if diff := cmp.Diff(cfg, again, protocmp.Transform()); diff != "" {
 				t.Fatalf("ensureDeterministicConfigOrder not idempotent (-first +second):\n%s", diff)
@@ -6,6 +6,7 @@ import (
 	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	icgv1alpha1 "github.com/pomerium/ingress-controller/apis/gateway/v1alpha1"
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/internal/policy"
 	"github.com/pomerium/ingress-controller/model"
 	pb "github.com/pomerium/pomerium/pkg/grpc/config"
@@ -112,15 +113,36 @@ type PolicyFilter struct {
 
 // NewPolicyFilter parses a PolicyFilter CRD object, returning an error if the object is not valid.
 func NewPolicyFilter(obj *icgv1alpha1.PolicyFilter) (*PolicyFilter, error) {
+	ppl := obj.Spec.PPL
+	if mtls := obj.Spec.DownstreamMTLS; mtls != nil {
+		var err error
+		if ppl, err = policy.WithDownstreamMTLSEnforcement(ppl, mtls.Enforcement); err != nil {
+			return nil, fmt.Errorf("downstream mtls: %w", err)
+		}
+	}
+
 	var err error
 	filter := &PolicyFilter{obj: obj}
-	filter.ppl, filter.rego, err = policy.Parse(obj.Spec.PPL)
+	filter.ppl, filter.rego, err = policy.Parse(ppl)
 	if err != nil {
 		return nil, err
 	}
 	return filter, nil
 }
 
+// CheckDownstreamMTLS returns an error if the downstream mTLS requirements of this filter
+// are not allowed by the global downstream mTLS settings.
+func (f *PolicyFilter) CheckDownstreamMTLS(global *icsv1.DownstreamMTLS) error {
+	mtls := f.obj.Spec.DownstreamMTLS
+	if mtls == nil {
+		return nil
+	}
+	if err := policy.CheckDownstreamMTLSEnforcement(mtls.Enforcement, global, false); err != nil {
+		return fmt.Errorf("downstream mtls: %w", err)
+	}
+	return nil
+}
+
 // ApplyToRoute applies this policy filter to a Pomerium route proto.
 func (f *PolicyFilter) ApplyToRoute(r *pb.Route) error {
 	if dt := f.obj.DeletionTimestamp; dt != nil {
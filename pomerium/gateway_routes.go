@@ -0,0 +1,92 @@
+package pomerium
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	pb "github.com/pomerium/pomerium/pkg/grpc/config"
+
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/pomerium/gateway"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+// gatewayRoute is a Pomerium route translated from an HTTPRoute
+type gatewayRoute struct {
+	*pb.Route
+	// owner is the HTTPRoute the route was translated from
+	owner *model.GatewayHTTPRouteConfig
+	// index of the route among the routes translated from its HTTPRoute
+	index int
+}
+
+// translateGatewayRoutes translates HTTPRoutes that are not being deleted or removed into Pomerium routes,
+// and returns them in the order they are served, honoring the route priority.
+// Routes of different HTTPRoutes that match the same requests with the same priority
+// are reported in the context as [model.GatewayRouteConflict].
+func translateGatewayRoutes(ctx context.Context, config *model.GatewayConfig) []gatewayRoute {
+	var routes routeList
+	owners := make(map[*pb.Route]gatewayRoute)
+	priorities := make(routePriorities)
+	for i := range config.Routes {
+		r := &config.Routes[i]
+		if r.IsDeleted() {
+			// Ignore any deleted or unwatched HTTPRoutes.
+			continue
+		}
+		translated := gateway.TranslateRoutes(ctx, config, r)
+		priorities.set(r.Priority, translated...)
+		for j, route := range translated {
+			owners[route] = gatewayRoute{Route: route, owner: r, index: j}
+		}
+		routes = append(routes, translated...)
+	}
+	routes.Sort(priorities)
+
+	out := make([]gatewayRoute, 0, len(routes))
+	for _, route := range routes {
+		out = append(out, owners[route])
+	}
+	if util.Enabled[model.GatewayRouteConflict](ctx) {
+		util.Add(ctx, gatewayRouteConflicts(out)...)
+	}
+	return out
+}
+
+// gatewayRouteConflicts returns conflicts between routes of different HTTPRoutes
+// that match exactly the same requests with the same priority.
+// routes are expected to be sorted, so the first route of a group is the one that is served.
+func gatewayRouteConflicts(routes []gatewayRoute) []model.GatewayRouteConflict {
+	type matchKey struct {
+		from, path, regex, prefix string
+		priority                  int32
+	}
+	groups := make(map[matchKey][]types.NamespacedName)
+	var keys []matchKey
+	for _, r := range routes {
+		k := matchKey{r.GetFrom(), r.GetPath(), r.GetRegex(), r.GetPrefix(), r.owner.Priority}
+		name := types.NamespacedName{Namespace: r.owner.Namespace, Name: r.owner.Name}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], name)
+	}
+
+	var out []model.GatewayRouteConflict
+	for _, k := range keys {
+		names := groups[k]
+		served := names[0]
+		for _, name := range names[1:] {
+			if name == served {
+				continue
+			}
+			match := k.path + k.regex + k.prefix
+			out = append(out,
+				model.GatewayRouteConflict{HTTPRoute: name, From: k.from, Match: match, Other: served, Shadowed: true},
+				model.GatewayRouteConflict{HTTPRoute: served, From: k.from, Match: match, Other: name},
+			)
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package pomerium
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+func newTestGatewayRoute(name, path string, priority int32) model.GatewayHTTPRouteConfig {
+	return model.GatewayHTTPRouteConfig{
+		HTTPRoute: &gateway_v1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec: gateway_v1.HTTPRouteSpec{
+				Rules: []gateway_v1.HTTPRouteRule{{
+					Matches: []gateway_v1.HTTPRouteMatch{{
+						Path: &gateway_v1.HTTPPathMatch{
+							Type:  new(gateway_v1.PathMatchPathPrefix),
+							Value: new(path),
+						},
+					}},
+					BackendRefs: []gateway_v1.HTTPBackendRef{{
+						BackendRef: gateway_v1.BackendRef{
+							BackendObjectReference: gateway_v1.BackendObjectReference{
+								Name: "example-svc",
+								Port: new(gateway_v1.PortNumber(8000)),
+							},
+						},
+					}},
+				}},
+			},
+		},
+		Hostnames:        []gateway_v1.Hostname{"a.localhost.pomerium.io"},
+		ValidBackendRefs: noopBackendRefChecker{},
+		Services: map[types.NamespacedName]*corev1.Service{
+			{Name: "example-svc", Namespace: "test"}: {},
+		},
+		Priority: priority,
+	}
+}
+
+func TestTranslateGatewayRoutes(t *testing.T) {
+	t.Parallel()
+
+	gc := &model.GatewayConfig{
+		Routes: []model.GatewayHTTPRouteConfig{
+			newTestGatewayRoute("route-c", "/api", 0),
+			newTestGatewayRoute("route-b", "/api", 0),
+			newTestGatewayRoute("route-a", "/", 10),
+			newTestGatewayRoute("route-d", "/api", 5),
+		},
+	}
+
+	ctx := util.WithBin[model.GatewayRouteConflict](t.Context())
+	routes := translateGatewayRoutes(ctx, gc)
+
+	var names []string
+	for _, r := range routes {
+		names = append(names, r.owner.Name)
+	}
+	assert.Equal(t, []string{"route-a", "route-d", "route-b", "route-c"}, names)
+
+	from := "https://a.localhost.pomerium.io"
+	routeB := types.NamespacedName{Namespace: "test", Name: "route-b"}
+	routeC := types.NamespacedName{Namespace: "test", Name: "route-c"}
+	assert.Equal(t, []model.GatewayRouteConflict{
+		{HTTPRoute: routeC, From: from, Match: "/api", Other: routeB, Shadowed: true},
+		{HTTPRoute: routeB, From: from, Match: "/api", Other: routeC},
+	}, util.Get[model.GatewayRouteConflict](ctx))
+}
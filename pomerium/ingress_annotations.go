@@ -13,6 +13,7 @@ import (
 
 	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
 
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/internal/policy"
 	"github.com/pomerium/ingress-controller/model"
 	"github.com/pomerium/ingress-controller/util"
@@ -100,10 +101,18 @@ var (
 		model.UDPUpstream,
 		model.UseServiceProxy,
 		model.SubtleAllowEmptyHost,
+		model.RoutePriority,
+		model.DownstreamMTLSEnforcement,
 	})
 	unsupported = map[string]string{
 		"allowed_groups": "https://docs.pomerium.com/docs/overview/upgrading#idp-directory-sync",
 	}
+	// globalOnly are downstream mTLS settings that Pomerium only supports globally,
+	// as client certificates are verified against them the same way for every route
+	globalOnly = boolMap([]string{
+		"downstream_mtls_match_subject_alt_names",
+		"downstream_mtls_max_verify_depth",
+	})
 )
 
 func boolMap(keys []string) map[string]bool {
@@ -140,6 +149,9 @@ func removeKeyPrefix(src map[string]string, prefix string) (*keys, error) {
 		if help, ok := unsupported[k]; ok {
 			return nil, fmt.Errorf("%s%s no longer supported, see %s", prefix, k, help)
 		}
+		if globalOnly[k] {
+			return nil, fmt.Errorf("%s%s is not supported per route, it may only be set in the global downstreamMtls settings", prefix, k)
+		}
 
 		known := false
 		for _, m := range []struct {
@@ -193,6 +205,9 @@ func applyAnnotations(
 	if err = applyUpstreamAnnotations(r, kv.UpstreamTunnel); err != nil {
 		return err
 	}
+	if err = applyDownstreamMTLSAnnotations(kv, ic.DownstreamMTLS); err != nil {
+		return err
+	}
 	p := new(configpb.Policy)
 	r.Policies = []*configpb.Policy{p}
 	if err := unmarshalPolicyAnnotations(p, kv.Policy); err != nil {
@@ -201,6 +216,36 @@ func applyAnnotations(
 	return nil
 }
 
+// applyDownstreamMTLSAnnotations validates the downstream mTLS enforcement override against the global settings,
+// and adds the rules it requires to the policy annotation
+func applyDownstreamMTLSAnnotations(kv *keys, global *icsv1.DownstreamMTLS) error {
+	mode, ok := kv.Etc[model.DownstreamMTLSEnforcement]
+	if !ok {
+		return nil
+	}
+	_, routeCA := kv.TLS[model.TLSDownstreamClientCASecret]
+	if err := policy.CheckDownstreamMTLSEnforcement(mode, global, routeCA); err != nil {
+		return fmt.Errorf("%s: %w", model.DownstreamMTLSEnforcement, err)
+	}
+	return requireDownstreamMTLSEnforcement(kv)
+}
+
+// requireDownstreamMTLSEnforcement adds the rules the downstream mTLS enforcement override requires to the policy annotation
+func requireDownstreamMTLSEnforcement(kv *keys) error {
+	mode, ok := kv.Etc[model.DownstreamMTLSEnforcement]
+	if !ok {
+		return nil
+	}
+	ppl, err := policy.WithDownstreamMTLSEnforcement(kv.Policy["policy"], mode)
+	if err != nil {
+		return fmt.Errorf("%s: %w", model.DownstreamMTLSEnforcement, err)
+	}
+	if ppl != "" {
+		kv.Policy["policy"] = ppl
+	}
+	return nil
+}
+
 func unmarshalPolicyAnnotations(p *configpb.Policy, kvs map[string]string) error {
 	ppl, hasPPL := kvs["policy"]
 	if hasPPL {
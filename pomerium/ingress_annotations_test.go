@@ -23,6 +23,7 @@ import (
 
 	pb "github.com/pomerium/pomerium/pkg/grpc/config"
 
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/model"
 )
 
@@ -785,3 +786,49 @@ func TestNameAnnotation(t *testing.T) {
 		assert.Equal(t, "", r.GetName())
 	})
 }
+
+func TestDownstreamMTLSEnforcementAnnotation(t *testing.T) {
+	global := &icsv1.DownstreamMTLS{CA: []byte("ca"), Enforcement: proto.String("policy")}
+	ingressConfig := func(annotations map[string]string) *model.IngressConfig {
+		return &model.IngressConfig{
+			AnnotationPrefix: "a",
+			Ingress: &networkingv1.Ingress{
+				ObjectMeta: v1.ObjectMeta{Namespace: "test", Annotations: annotations},
+			},
+			DownstreamMTLS: global,
+		}
+	}
+
+	r := new(pb.Route)
+	require.NoError(t, applyAnnotations(r, ingressConfig(map[string]string{
+		"a/downstream_mtls_enforcement": "policy_with_default_deny",
+		"a/policy":                      testPPL1,
+	})))
+	require.Len(t, r.Policies, 1)
+	assert.JSONEq(t, `[
+		{"allow":{"or":[{"domain":{"is":"pomerium.com"}}]}},
+		{"deny":{"or":[{"invalid_client_certificate":true}]}}
+	]`, r.Policies[0].GetSourcePpl())
+	assert.NotEmpty(t, r.Policies[0].Rego)
+
+	r = new(pb.Route)
+	require.NoError(t, applyAnnotations(r, ingressConfig(map[string]string{
+		"a/downstream_mtls_enforcement": "policy",
+	})))
+	require.Len(t, r.Policies, 1)
+	assert.Nil(t, r.Policies[0].SourcePpl, "the global enforcement mode should not need any rules")
+
+	global.Enforcement = nil
+	assert.Error(t, applyAnnotations(new(pb.Route), ingressConfig(map[string]string{
+		"a/downstream_mtls_enforcement": "policy",
+	})), "should not relax the global enforcement")
+	assert.Error(t, applyAnnotations(new(pb.Route), ingressConfig(map[string]string{
+		"a/downstream_mtls_enforcement": "reject_connection",
+	})), "reject_connection may only be set globally")
+
+	for _, key := range []string{"a/downstream_mtls_match_subject_alt_names", "a/downstream_mtls_max_verify_depth"} {
+		assert.ErrorContains(t, applyAnnotations(new(pb.Route), ingressConfig(map[string]string{
+			key: "1",
+		})), "may only be set in the global downstreamMtls settings", key)
+	}
+}
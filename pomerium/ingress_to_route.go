@@ -18,6 +18,7 @@ import (
 	"github.com/pomerium/pomerium/config"
 	pb "github.com/pomerium/pomerium/pkg/grpc/config"
 
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	"github.com/pomerium/ingress-controller/model"
 )
 
@@ -50,6 +51,10 @@ func ingressToRoutes(ctx context.Context, ic *model.IngressConfig) (routeList, e
 	} else if err := applyAnnotations(tmpl, ic); err != nil {
 		return nil, fmt.Errorf("annotations: %w", err)
 	}
+	// the priority orders the routes rather than being part of them, but is validated along with them
+	if _, err := ic.GetRoutePriority(); err != nil {
+		return nil, fmt.Errorf("priority: %w", err)
+	}
 
 	routes := make(routeList, 0, len(ic.Ingress.Spec.Rules)+1)
 	if ic.Ingress.Spec.DefaultBackend != nil {
@@ -147,6 +152,13 @@ func pathToRoute(r *pb.Route, host string, p networkingv1.HTTPIngressPath, ic *m
 		return fmt.Errorf("name: %w", err)
 	}
 
+	if p.Backend.Service == nil && p.Backend.Resource != nil {
+		if err := setResourceBackend(r, p.Backend.Resource, ic); err != nil {
+			return fmt.Errorf("backend resource: %w", err)
+		}
+		return nil
+	}
+
 	if err := setServiceURLs(r, p, ic); err != nil {
 		return fmt.Errorf("backend: %w", err)
 	}
@@ -154,6 +166,64 @@ func pathToRoute(r *pb.Route, host string, p networkingv1.HTTPIngressPath, ic *m
 	return nil
 }
 
+// setResourceBackend configures the route to be handled by Pomerium itself,
+// according to the referenced Backend resource
+func setResourceBackend(r *pb.Route, ref *corev1.TypedLocalObjectReference, ic *model.IngressConfig) error {
+	if ic.IsSSHUpstream() || ic.IsTCPUpstream() || ic.IsUDPUpstream() {
+		return errors.New("resource backends are only supported for HTTP routes")
+	}
+
+	name := ic.GetNamespacedName(ref.Name)
+	backend, ok := ic.Backends[name]
+	if !ok {
+		return fmt.Errorf("%s %s was not fetched, this is a bug", ref.Kind, name.String())
+	}
+
+	spec := backend.Spec
+	switch {
+	case spec.DirectResponse != nil:
+		if spec.DirectResponse.Status < 200 || spec.DirectResponse.Status > 599 {
+			return fmt.Errorf("directResponse: invalid status %d", spec.DirectResponse.Status)
+		}
+		r.Response = &pb.RouteDirectResponse{
+			Status: uint32(spec.DirectResponse.Status), //nolint:gosec
+			Body:   spec.DirectResponse.Body,
+		}
+	case spec.Redirect != nil:
+		r.Redirect = backendRedirect(spec.Redirect)
+	case spec.ExternalURL != nil:
+		u, err := url.Parse(*spec.ExternalURL)
+		if err != nil {
+			return fmt.Errorf("externalURL: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("externalURL: expected an absolute http or https URL, got %q", *spec.ExternalURL)
+		}
+		r.To = []string{u.String()}
+	default:
+		return fmt.Errorf("%s %s: one of directResponse, redirect or externalURL must be set", ref.Kind, name.String())
+	}
+
+	return nil
+}
+
+func backendRedirect(src *icsv1.BackendRedirect) *pb.RouteRedirect {
+	dst := &pb.RouteRedirect{
+		SchemeRedirect: src.Scheme,
+		HostRedirect:   src.Host,
+		PathRedirect:   src.Path,
+		ResponseCode:   src.StatusCode,
+	}
+	if src.Port != nil {
+		port := uint32(*src.Port) //nolint:gosec
+		dst.PortRedirect = &port
+	}
+	if src.StripQuery {
+		dst.StripQuery = proto.Bool(true)
+	}
+	return dst
+}
+
 func setRoutePath(r *pb.Route, p networkingv1.HTTPIngressPath, ic *model.IngressConfig) error {
 	// https://kubernetes.io/docs/concepts/services-networking/ingress/#path-types
 	// Paths that do not include an explicit pathType will fail validation.
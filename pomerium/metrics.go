@@ -0,0 +1,159 @@
+package pomerium
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	pb "github.com/pomerium/pomerium/pkg/grpc/config"
+	"github.com/pomerium/pomerium/pkg/grpc/databroker"
+
+	"github.com/pomerium/ingress-controller/util/metrics"
+)
+
+const (
+	metricsBackendDataBroker = "databroker"
+	metricsBackendAPI        = "api"
+)
+
+// instrumentedDataBrokerClient records latency and failures of the databroker requests made by the reconciler
+type instrumentedDataBrokerClient struct {
+	databroker.DataBrokerServiceClient
+}
+
+func (c instrumentedDataBrokerClient) Get(ctx context.Context, in *databroker.GetRequest, opts ...grpc.CallOption) (*databroker.GetResponse, error) {
+	start := time.Now()
+	resp, err := c.DataBrokerServiceClient.Get(ctx, in, opts...)
+	failure := err
+	if status.Code(err) == codes.NotFound {
+		// the record was not created yet
+		failure = nil
+	}
+	metrics.ObserveBackendRequest(metricsBackendDataBroker, "Get", start, failure)
+	return resp, err
+}
+
+func (c instrumentedDataBrokerClient) Put(ctx context.Context, in *databroker.PutRequest, opts ...grpc.CallOption) (*databroker.PutResponse, error) {
+	start := time.Now()
+	resp, err := c.DataBrokerServiceClient.Put(ctx, in, opts...)
+	metrics.ObserveBackendRequest(metricsBackendDataBroker, "Put", start, err)
+	return resp, err
+}
+
+// SyncLatest is recorded once the stream ends
+func (c instrumentedDataBrokerClient) SyncLatest(
+	ctx context.Context, in *databroker.SyncLatestRequest, opts ...grpc.CallOption,
+) (databroker.DataBrokerService_SyncLatestClient, error) {
+	start := time.Now()
+	stream, err := c.DataBrokerServiceClient.SyncLatest(ctx, in, opts...)
+	if err != nil {
+		metrics.ObserveBackendRequest(metricsBackendDataBroker, "SyncLatest", start, err)
+		return nil, err
+	}
+	return &instrumentedSyncLatestClient{DataBrokerService_SyncLatestClient: stream, start: start}, nil
+}
+
+func (c instrumentedDataBrokerClient) AcquireLease(
+	ctx context.Context, in *databroker.AcquireLeaseRequest, opts ...grpc.CallOption,
+) (*databroker.AcquireLeaseResponse, error) {
+	start := time.Now()
+	resp, err := c.DataBrokerServiceClient.AcquireLease(ctx, in, opts...)
+	failure := err
+	if status.Code(err) == codes.AlreadyExists {
+		// the lease is held by another writer
+		failure = nil
+	}
+	metrics.ObserveBackendRequest(metricsBackendDataBroker, "AcquireLease", start, failure)
+	return resp, err
+}
+
+func (c instrumentedDataBrokerClient) RenewLease(
+	ctx context.Context, in *databroker.RenewLeaseRequest, opts ...grpc.CallOption,
+) (*emptypb.Empty, error) {
+	start := time.Now()
+	resp, err := c.DataBrokerServiceClient.RenewLease(ctx, in, opts...)
+	metrics.ObserveBackendRequest(metricsBackendDataBroker, "RenewLease", start, err)
+	return resp, err
+}
+
+func (c instrumentedDataBrokerClient) ReleaseLease(
+	ctx context.Context, in *databroker.ReleaseLeaseRequest, opts ...grpc.CallOption,
+) (*emptypb.Empty, error) {
+	start := time.Now()
+	resp, err := c.DataBrokerServiceClient.ReleaseLease(ctx, in, opts...)
+	metrics.ObserveBackendRequest(metricsBackendDataBroker, "ReleaseLease", start, err)
+	return resp, err
+}
+
+type instrumentedSyncLatestClient struct {
+	databroker.DataBrokerService_SyncLatestClient
+	start time.Time
+	done  bool
+}
+
+func (s *instrumentedSyncLatestClient) Recv() (*databroker.SyncLatestResponse, error) {
+	resp, err := s.DataBrokerService_SyncLatestClient.Recv()
+	if err != nil && !s.done {
+		s.done = true
+		failure := err
+		if errors.Is(err, io.EOF) {
+			failure = nil
+		}
+		metrics.ObserveBackendRequest(metricsBackendDataBroker, "SyncLatest", s.start, failure)
+	}
+	return resp, err
+}
+
+type configRecordStats struct {
+	routes       int
+	certificates int
+	size         int
+}
+
+// applied is called with the configuration record that is stored in the databroker,
+// and the previous one if it changed. A nil next means the record was deleted.
+func (r *DataBrokerReconciler) applied(ctx context.Context, recordID string, prev, next *pb.Config) {
+	r.Debugger.observe(ctx, recordID, prev, next)
+
+	if r.records == nil {
+		r.records = make(map[string]configRecordStats)
+	}
+	if next == nil {
+		delete(r.records, recordID)
+	} else {
+		r.records[recordID] = configRecordStats{
+			routes:       len(next.GetRoutes()),
+			certificates: len(next.GetSettings().GetCertificates()),
+			size:         proto.Size(next),
+		}
+	}
+
+	var total configRecordStats
+	for _, stats := range r.records {
+		total.routes += stats.routes
+		total.certificates += stats.certificates
+		total.size = max(total.size, stats.size)
+	}
+	metrics.SetManagedObjects(r.ConfigID, metrics.ObjectRoute, total.routes)
+	metrics.SetManagedObjects(r.ConfigID, metrics.ObjectCertificate, total.certificates)
+	metrics.SetConfigRecordSize(r.ConfigID, total.size)
+}
+
+// reportManagedObjects updates the number of unified API objects written by the reconciler
+func (r *APIReconciler) reportManagedObjects() {
+	if r.planning != nil {
+		// changes are only recorded, and are reported once applied
+		return
+	}
+	counts := r.synced.count()
+	metrics.SetManagedObjects(metricsBackendAPI, metrics.ObjectRoute, counts[apiObjectRoute])
+	metrics.SetManagedObjects(metricsBackendAPI, metrics.ObjectPolicy, counts[apiObjectPolicy])
+	metrics.SetManagedObjects(metricsBackendAPI, metrics.ObjectCertificate, counts[apiObjectKeyPair])
+}
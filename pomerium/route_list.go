@@ -8,6 +8,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	pb "github.com/pomerium/pomerium/pkg/grpc/config"
+
+	"github.com/pomerium/ingress-controller/model"
 )
 
 type routeID struct {
@@ -34,15 +36,60 @@ type (
 	routeMap  map[routeID]*pb.Route
 )
 
-func (routes routeList) Sort()         { sort.Sort(routes) }
+// routePriorities holds the priority of routes by route id, or by name for routes without an id (i.e. Gateway routes).
+// The priority is kept out of the route id, so that changing it does not change the route id,
+// and Pomerium configuration has no field for it. Instead, reconcilers keep the priorities of the routes they apply,
+// and rebuild them from Kubernetes objects on every full sync.
+// Routes without a recorded priority have the default priority of 0.
+type routePriorities map[string]int32
+
+func routePriorityKey(r *pb.Route) string {
+	if id := r.GetId(); id != "" {
+		return id
+	}
+	return r.GetName()
+}
+
+func (p routePriorities) get(r *pb.Route) int32 {
+	return p[routePriorityKey(r)]
+}
+
+// set records the priority of the routes, a nil routePriorities ignores it
+func (p routePriorities) set(priority int32, routes ...*pb.Route) {
+	if p == nil {
+		return
+	}
+	for _, r := range routes {
+		if priority == 0 {
+			delete(p, routePriorityKey(r))
+		} else {
+			p[routePriorityKey(r)] = priority
+		}
+	}
+}
+
+// Sort sorts routes, looking up route priorities just once
+func (routes routeList) Sort(priorities routePriorities) {
+	order := make([]int32, len(routes))
+	for i, r := range routes {
+		order[i] = priorities.get(r)
+	}
+	sort.Sort(prioritizedRouteList{routes, order})
+}
+
 func (routes routeList) Len() int      { return len(routes) }
 func (routes routeList) Swap(i, j int) { routes[i], routes[j] = routes[j], routes[i] }
 
 // Less reports whether the element with
 // index i should sort before the element with index j.
 // as envoy parses routes as presented, we should presents routes with longer paths first
-// exact Path always takes priority over Prefix matching
+// exact Path always takes priority over Prefix matching.
+// Route priorities are not known to the list, use Sort to honor them.
 func (routes routeList) Less(i, j int) bool {
+	return routes.less(i, j, 0, 0)
+}
+
+func (routes routeList) less(i, j int, iPriority, jPriority int32) bool {
 	// from ASC
 	iFrom, jFrom := routes[i].GetFrom(), routes[j].GetFrom()
 	switch {
@@ -52,6 +99,14 @@ func (routes routeList) Less(i, j int) bool {
 		return false
 	}
 
+	// priority DESC
+	switch {
+	case iPriority < jPriority:
+		return false
+	case iPriority > jPriority:
+		return true
+	}
+
 	// path DESC
 	iPath, jPath := routes[i].GetPath(), routes[j].GetPath()
 	switch {
@@ -79,7 +134,7 @@ func (routes routeList) Less(i, j int) bool {
 		return true
 	}
 
-	// finally, by id
+	// by id
 	iID, jID := routes[i].GetId(), routes[j].GetId()
 	switch {
 	case iID < jID:
@@ -88,7 +143,75 @@ func (routes routeList) Less(i, j int) bool {
 		return false
 	}
 
-	return false
+	// finally, by name, as Gateway routes without priority have no id
+	return routes[i].GetName() < routes[j].GetName()
+}
+
+type prioritizedRouteList struct {
+	routeList
+	priorities []int32
+}
+
+func (p prioritizedRouteList) Swap(i, j int) {
+	p.routeList.Swap(i, j)
+	p.priorities[i], p.priorities[j] = p.priorities[j], p.priorities[i]
+}
+
+func (p prioritizedRouteList) Less(i, j int) bool {
+	return p.routeList.less(i, j, p.priorities[i], p.priorities[j])
+}
+
+// conflicts returns routes of other ingresses that match exactly the same requests
+// as routes of the named ingress with the same priority.
+// routes are expected to be sorted, so the first route of a group is the one that is served.
+func (routes routeList) conflicts(name types.NamespacedName, priorities routePriorities) ([]model.RouteConflict, error) {
+	type matchKey struct {
+		from, path, regex, prefix string
+		priority                  int32
+	}
+	groups := make(map[matchKey][]routeID)
+	var keys []matchKey
+	for _, r := range routes {
+		var id routeID
+		if err := id.Unmarshal(r.GetId()); err != nil {
+			return nil, fmt.Errorf("cannot decode route id %s: %w", r.GetId(), err)
+		}
+		k := matchKey{r.GetFrom(), r.GetPath(), r.GetRegex(), r.GetPrefix(), priorities.get(r)}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], id)
+	}
+
+	var out []model.RouteConflict
+	for _, k := range keys {
+		ids := groups[k]
+		if len(ids) < 2 {
+			continue
+		}
+		match := k.path + k.regex + k.prefix
+		for i, id := range ids {
+			if id.Name != name.Name || id.Namespace != name.Namespace {
+				continue
+			}
+			for j, other := range ids {
+				if other.Name == name.Name && other.Namespace == name.Namespace {
+					continue
+				}
+				if i != 0 && j != 0 {
+					// neither is served, the conflict is reported against the winner
+					continue
+				}
+				out = append(out, model.RouteConflict{
+					From:     k.from,
+					Match:    match,
+					Ingress:  types.NamespacedName{Name: other.Name, Namespace: other.Namespace},
+					Shadowed: j == 0,
+				})
+			}
+		}
+	}
+	return out, nil
 }
 
 func (routes routeList) toMap() (routeMap, error) {
@@ -106,20 +229,22 @@ func (routes routeList) toMap() (routeMap, error) {
 	return m, nil
 }
 
-func (rm routeMap) removeName(name types.NamespacedName) {
-	for k := range rm {
+// removeName removes the routes of the named ingress along with their priorities
+func (rm routeMap) removeName(name types.NamespacedName, priorities routePriorities) {
+	for k, r := range rm {
 		if k.Name == name.Name && k.Namespace == name.Namespace {
+			delete(priorities, routePriorityKey(r))
 			delete(rm, k)
 		}
 	}
 }
 
-func (rm routeMap) toList() routeList {
+func (rm routeMap) toList(priorities routePriorities) routeList {
 	routes := make(routeList, 0, len(rm))
 	for _, r := range rm {
 		routes = append(routes, r)
 	}
-	sort.Sort(routes)
+	routes.Sort(priorities)
 	return routes
 }
 
@@ -7,10 +7,11 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
 	pb "github.com/pomerium/pomerium/pkg/grpc/config"
 )
 
-func mergeRoutes(dst *pb.Config, src routeList, name types.NamespacedName) error {
+func mergeRoutes(dst *pb.Config, priorities routePriorities, src routeList, name types.NamespacedName, priority int32) error {
 	srcMap, err := src.toMap()
 	if err != nil {
 		return fmt.Errorf("indexing new routes: %w", err)
@@ -20,27 +21,47 @@ func mergeRoutes(dst *pb.Config, src routeList, name types.NamespacedName) error
 		return fmt.Errorf("indexing current config routes: %w", err)
 	}
 	// remove any existing routes of the ingress we are merging
-	dstMap.removeName(name)
+	dstMap.removeName(name, priorities)
 	dstMap.merge(srcMap)
-	dst.Routes = dstMap.toList()
+	priorities.set(priority, src...)
+	dst.Routes = dstMap.toList(priorities)
 
 	return nil
 }
 
-func upsertRoutes(ctx context.Context, cfg *pb.Config, ic *model.IngressConfig) error {
+// upsertRoutes merges the routes of the ingress into the config, and records their priority
+func upsertRoutes(ctx context.Context, cfg *pb.Config, priorities routePriorities, ic *model.IngressConfig) error {
 	ingRoutes, err := ingressToRoutes(ctx, ic)
 	if err != nil {
 		return fmt.Errorf("parsing ingress: %w", err)
 	}
-	return mergeRoutes(cfg, ingRoutes, types.NamespacedName{Name: ic.Ingress.Name, Namespace: ic.Ingress.Namespace})
+	priority, err := ic.GetRoutePriority()
+	if err != nil {
+		return fmt.Errorf("parsing ingress: priority: %w", err)
+	}
+	name := types.NamespacedName{Name: ic.Ingress.Name, Namespace: ic.Ingress.Namespace}
+	if err = mergeRoutes(cfg, priorities, ingRoutes, name, priority); err != nil {
+		return err
+	}
+
+	// conflicts do not prevent the ingress from being reconciled, but are reported back
+	if !util.Enabled[model.RouteConflict](ctx) {
+		return nil
+	}
+	conflicts, err := routeList(cfg.Routes).conflicts(name, priorities)
+	if err != nil {
+		return fmt.Errorf("checking route conflicts: %w", err)
+	}
+	util.Add(ctx, conflicts...)
+	return nil
 }
 
-func deleteRoutes(cfg *pb.Config, namespacedName types.NamespacedName) error {
+func deleteRoutes(cfg *pb.Config, priorities routePriorities, namespacedName types.NamespacedName) error {
 	rm, err := routeList(cfg.Routes).toMap()
 	if err != nil {
 		return err
 	}
-	rm.removeName(namespacedName)
-	cfg.Routes = rm.toList()
+	rm.removeName(namespacedName, priorities)
+	cfg.Routes = rm.toList(priorities)
 	return nil
 }
@@ -25,8 +25,10 @@ import (
 	pb "github.com/pomerium/pomerium/pkg/grpc/config"
 	"github.com/pomerium/pomerium/pkg/identity"
 
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
 	_ "github.com/pomerium/ingress-controller/internal"
 	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
 )
 
 func TestHttp01Solver(t *testing.T) {
@@ -203,7 +205,7 @@ func TestUpsertIngress(t *testing.T) {
 	}
 
 	cfg := new(pb.Config)
-	require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+	require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 	routes, err := routeList(cfg.Routes).toMap()
 	require.NoError(t, err)
 	require.NotNil(t, routes[routeID{
@@ -225,14 +227,14 @@ func TestUpsertIngress(t *testing.T) {
 			},
 		},
 	})
-	require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+	require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 	routes, err = routeList(cfg.Routes).toMap()
 	require.NoError(t, err)
 	require.NotNil(t, routes[routeID{Name: "ingress", Namespace: "default", Path: "/a", Host: "service.localhost.pomerium.io"}])
 	require.NotNil(t, routes[routeID{Name: "ingress", Namespace: "default", Path: "/b", Host: "service.localhost.pomerium.io"}])
 
 	ic.Ingress.Spec.Rules[0].HTTP.Paths[0].Path = "/c"
-	require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+	require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 	routes, err = routeList(cfg.Routes).toMap()
 	require.NoError(t, err)
 	require.Nil(t, routes[routeID{Name: "ingress", Namespace: "default", Path: "/a", Host: "service.localhost.pomerium.io"}])
@@ -310,7 +312,7 @@ func TestSecureUpstream(t *testing.T) {
 	}
 
 	cfg := new(pb.Config)
-	require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+	require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 	routes, err := routeList(cfg.Routes).toMap()
 	require.NoError(t, err)
 	route := routes[routeID{
@@ -420,7 +422,7 @@ func TestCustomSecrets(t *testing.T) {
 	}
 
 	cfg := new(pb.Config)
-	require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+	require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 	routes, err := routeList(cfg.Routes).toMap()
 	require.NoError(t, err)
 	route := routes[routeID{
@@ -511,7 +513,7 @@ func TestKubernetesToken(t *testing.T) {
 
 	cfg := new(pb.Config)
 	ctx := context.Background()
-	require.NoError(t, upsertRoutes(ctx, cfg, ic))
+	require.NoError(t, upsertRoutes(ctx, cfg, nil, ic))
 	routes, err := routeList(cfg.Routes).toMap()
 	require.NoError(t, err)
 	route := routes[routeID{
@@ -672,7 +674,7 @@ func TestTCPUpstream(t *testing.T) {
 			ic.Spec.Rules[0].HTTP.Paths = tc.paths
 
 			cfg := new(pb.Config)
-			err := upsertRoutes(context.Background(), cfg, &ic)
+			err := upsertRoutes(context.Background(), cfg, nil, &ic)
 			if tc.expectError {
 				require.Error(t, err)
 				return
@@ -755,7 +757,7 @@ func TestExternalService(t *testing.T) {
 		}
 
 		cfg := new(pb.Config)
-		if err := upsertRoutes(context.Background(), cfg, ic); err != nil {
+		if err := upsertRoutes(context.Background(), cfg, nil, ic); err != nil {
 			return nil, fmt.Errorf("upsert routes: %w", err)
 		}
 		routes, err := routeList(cfg.Routes).toMap()
@@ -791,6 +793,101 @@ func TestExternalService(t *testing.T) {
 	}
 }
 
+func TestResourceBackend(t *testing.T) {
+	typePrefix := networkingv1.PathTypePrefix
+	apiGroup := icsv1.GroupVersion.Group
+	makeRoute := func(t *testing.T, spec icsv1.BackendSpec) (*pb.Route, error) {
+		t.Helper()
+
+		ic := &model.IngressConfig{
+			AnnotationPrefix: "p",
+			Ingress: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ingress",
+					Namespace: "default",
+				},
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{
+						Host: "service.localhost.pomerium.io",
+						IngressRuleValue: networkingv1.IngressRuleValue{
+							HTTP: &networkingv1.HTTPIngressRuleValue{
+								Paths: []networkingv1.HTTPIngressPath{{
+									Path:     "/a",
+									PathType: &typePrefix,
+									Backend: networkingv1.IngressBackend{
+										Resource: &corev1.TypedLocalObjectReference{
+											APIGroup: &apiGroup,
+											Kind:     icsv1.BackendKind,
+											Name:     "backend",
+										},
+									},
+								}},
+							},
+						},
+					}},
+				},
+			},
+			Backends: map[types.NamespacedName]*icsv1.Backend{
+				{Name: "backend", Namespace: "default"}: {
+					ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "default"},
+					Spec:       spec,
+				},
+			},
+		}
+
+		cfg := new(pb.Config)
+		if err := upsertRoutes(context.Background(), cfg, nil, ic); err != nil {
+			return nil, fmt.Errorf("upsert routes: %w", err)
+		}
+		require.Len(t, cfg.Routes, 1)
+		return cfg.Routes[0], nil
+	}
+
+	t.Run("direct response", func(t *testing.T) {
+		route, err := makeRoute(t, icsv1.BackendSpec{
+			DirectResponse: &icsv1.BackendDirectResponse{Status: 418, Body: "teapot"},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, route.To)
+		assert.Empty(t, cmp.Diff(&pb.RouteDirectResponse{Status: 418, Body: "teapot"}, route.Response, protocmp.Transform()))
+	})
+	t.Run("redirect", func(t *testing.T) {
+		route, err := makeRoute(t, icsv1.BackendSpec{
+			Redirect: &icsv1.BackendRedirect{
+				Host:       proto.String("other.localhost.pomerium.io"),
+				Port:       proto.Int32(8443),
+				StatusCode: proto.Int32(302),
+				StripQuery: true,
+			},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, route.To)
+		assert.Empty(t, cmp.Diff(&pb.RouteRedirect{
+			HostRedirect: proto.String("other.localhost.pomerium.io"),
+			PortRedirect: proto.Uint32(8443),
+			ResponseCode: proto.Int32(302),
+			StripQuery:   proto.Bool(true),
+		}, route.Redirect, protocmp.Transform()))
+	})
+	t.Run("external url", func(t *testing.T) {
+		route, err := makeRoute(t, icsv1.BackendSpec{
+			ExternalURL: proto.String("https://example.com:8443"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com:8443"}, route.To)
+	})
+	t.Run("invalid external url", func(t *testing.T) {
+		_, err := makeRoute(t, icsv1.BackendSpec{
+			ExternalURL: proto.String("example.com"),
+		})
+		assert.Error(t, err)
+	})
+	t.Run("empty", func(t *testing.T) {
+		_, err := makeRoute(t, icsv1.BackendSpec{})
+		assert.Error(t, err)
+	})
+}
+
 func TestDefaultBackendService(t *testing.T) {
 	typePrefix := networkingv1.PathTypePrefix
 	typeExact := networkingv1.PathTypeExact
@@ -838,7 +935,7 @@ func TestDefaultBackendService(t *testing.T) {
 		ic := icTemplate()
 		cfg := new(pb.Config)
 		t.Log(protojson.Format(cfg))
-		require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+		require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 		require.Len(t, cfg.Routes, 1)
 		assert.Equal(t, "/", cfg.Routes[0].Prefix)
 	})
@@ -862,7 +959,7 @@ func TestDefaultBackendService(t *testing.T) {
 			},
 		}}
 		cfg := new(pb.Config)
-		require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+		require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 		sort.Sort(routeList(cfg.Routes))
 		require.Len(t, cfg.Routes, 3)
 		assert.Equal(t, "/", cfg.Routes[2].Prefix, protojson.Format(cfg))
@@ -927,7 +1024,7 @@ func TestRegexPath(t *testing.T) {
 	}
 
 	cfg := new(pb.Config)
-	require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+	require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 	routes, err := routeList(cfg.Routes).toMap()
 	require.NoError(t, err)
 	route := routes[routeID{
@@ -1012,7 +1109,7 @@ func TestUseServiceProxy(t *testing.T) {
 	}
 
 	cfg := new(pb.Config)
-	require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+	require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 	routes, err := routeList(cfg.Routes).toMap()
 	require.NoError(t, err)
 	route := routes[routeID{
@@ -1081,6 +1178,108 @@ func shuffleRoutes(random *rand.Rand, routes []*pb.Route) {
 	}
 }
 
+func TestRoutePriority(t *testing.T) {
+	typePrefix := networkingv1.PathTypePrefix
+	makeIngress := func(name, path, priority string) *model.IngressConfig {
+		ic := &model.IngressConfig{
+			AnnotationPrefix: "p",
+			Ingress: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: networkingv1.IngressSpec{
+					Rules: []networkingv1.IngressRule{{
+						Host: "service.localhost.pomerium.io",
+						IngressRuleValue: networkingv1.IngressRuleValue{
+							HTTP: &networkingv1.HTTPIngressRuleValue{
+								Paths: []networkingv1.HTTPIngressPath{{
+									Path:     path,
+									PathType: &typePrefix,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: "service",
+											Port: networkingv1.ServiceBackendPort{Number: 80},
+										},
+									},
+								}},
+							},
+						},
+					}},
+				},
+			},
+			Services: map[types.NamespacedName]*corev1.Service{
+				{Name: "service", Namespace: "default"}: {
+					ObjectMeta: metav1.ObjectMeta{Name: "service", Namespace: "default"},
+				},
+			},
+		}
+		if priority != "" {
+			ic.Ingress.Annotations = map[string]string{fmt.Sprintf("p/%s", model.RoutePriority): priority}
+		}
+		return ic
+	}
+	prefixes := func(cfg *pb.Config) []string {
+		var out []string
+		for _, r := range cfg.Routes {
+			out = append(out, r.GetPrefix())
+		}
+		return out
+	}
+
+	t.Run("default order", func(t *testing.T) {
+		cfg, priorities := new(pb.Config), make(routePriorities)
+		require.NoError(t, upsertRoutes(context.Background(), cfg, priorities, makeIngress("api", "/api", "")))
+		require.NoError(t, upsertRoutes(context.Background(), cfg, priorities, makeIngress("api-v2", "/api/v2", "")))
+		assert.Equal(t, []string{"/api/v2", "/api"}, prefixes(cfg))
+	})
+	t.Run("priority", func(t *testing.T) {
+		cfg, priorities := new(pb.Config), make(routePriorities)
+		require.NoError(t, upsertRoutes(context.Background(), cfg, priorities, makeIngress("api", "/api", "10")))
+		require.NoError(t, upsertRoutes(context.Background(), cfg, priorities, makeIngress("api-v2", "/api/v2", "")))
+		assert.Equal(t, []string{"/api", "/api/v2"}, prefixes(cfg))
+
+		cfg.Routes[0], cfg.Routes[1] = cfg.Routes[1], cfg.Routes[0]
+		ensureDeterministicConfigOrder(cfg, priorities)
+		assert.Equal(t, []string{"/api", "/api/v2"}, prefixes(cfg))
+
+		require.NoError(t, deleteRoutes(cfg, priorities, types.NamespacedName{Name: "api", Namespace: "default"}))
+		assert.Empty(t, priorities, "priorities of removed routes should be dropped")
+	})
+	t.Run("priority keeps route id", func(t *testing.T) {
+		cfg, priorities := new(pb.Config), make(routePriorities)
+		require.NoError(t, upsertRoutes(context.Background(), cfg, priorities, makeIngress("api", "/api", "")))
+		require.Len(t, cfg.Routes, 1)
+		id := cfg.Routes[0].GetId()
+
+		require.NoError(t, upsertRoutes(context.Background(), cfg, priorities, makeIngress("api", "/api", "10")))
+		require.Len(t, cfg.Routes, 1)
+		assert.Equal(t, id, cfg.Routes[0].GetId())
+		assert.Equal(t, int32(10), priorities.get(cfg.Routes[0]))
+	})
+	t.Run("invalid priority", func(t *testing.T) {
+		cfg := new(pb.Config)
+		assert.Error(t, upsertRoutes(context.Background(), cfg, make(routePriorities), makeIngress("api", "/api", "high")))
+	})
+	t.Run("conflicts", func(t *testing.T) {
+		cfg, priorities := new(pb.Config), make(routePriorities)
+		ctx := util.WithBin[model.RouteConflict](context.Background())
+		require.NoError(t, upsertRoutes(ctx, cfg, priorities, makeIngress("a", "/api", "")))
+		assert.Empty(t, util.Get[model.RouteConflict](ctx))
+
+		ctx = util.WithBin[model.RouteConflict](context.Background())
+		require.NoError(t, upsertRoutes(ctx, cfg, priorities, makeIngress("b", "/api", "")))
+		assert.Equal(t, []model.RouteConflict{{
+			From:     "https://service.localhost.pomerium.io",
+			Match:    "/api",
+			Ingress:  types.NamespacedName{Name: "a", Namespace: "default"},
+			Shadowed: true,
+		}}, util.Get[model.RouteConflict](ctx))
+
+		ctx = util.WithBin[model.RouteConflict](context.Background())
+		require.NoError(t, upsertRoutes(ctx, cfg, priorities, makeIngress("b", "/api", "1")))
+		assert.Empty(t, util.Get[model.RouteConflict](ctx))
+		assert.Len(t, cfg.Routes, 2)
+	})
+}
+
 // TestServicePortsAndEndpoints checks that only correct Endpoints would be selected for a Service
 // https://github.com/pomerium/ingress-controller/issues/157
 // - if there's just one port defined for the service, it may be defined in numerical form
@@ -1264,7 +1463,7 @@ func TestServicePortsAndEndpoints(t *testing.T) {
 			}
 
 			cfg := new(pb.Config)
-			require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+			require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 			routes, err := routeList(cfg.Routes).toMap()
 			require.NoError(t, err)
 			route := routes[routeID{
@@ -1415,7 +1614,7 @@ func TestEndpointsHTTPS(t *testing.T) {
 			}
 
 			cfg := new(pb.Config)
-			require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+			require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 			routes, err := routeList(cfg.Routes).toMap()
 			require.NoError(t, err)
 			route := routes[routeID{
@@ -1504,7 +1703,7 @@ func TestEmptyHostRoute(t *testing.T) {
 	}
 
 	var config pb.Config
-	require.NoError(t, upsertRoutes(context.Background(), &config, ic))
+	require.NoError(t, upsertRoutes(context.Background(), &config, nil, ic))
 	routes, err := routeList(config.Routes).toMap()
 	require.NoError(t, err)
 	route := routes[routeID{
@@ -1518,7 +1717,7 @@ func TestEmptyHostRoute(t *testing.T) {
 
 	clear(ic.Annotations)
 
-	require.ErrorContains(t, upsertRoutes(context.Background(), &config, ic),
+	require.ErrorContains(t, upsertRoutes(context.Background(), &config, nil, ic),
 		"ingress rule has empty host")
 }
 
@@ -1590,7 +1789,7 @@ func TestCustomRouteName(t *testing.T) {
 	}
 
 	cfg := new(pb.Config)
-	require.NoError(t, upsertRoutes(context.Background(), cfg, ic))
+	require.NoError(t, upsertRoutes(context.Background(), cfg, nil, ic))
 	routes, err := routeList(cfg.Routes).toMap()
 	require.NoError(t, err)
 
@@ -12,6 +12,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"connectrpc.com/connect"
 	"github.com/google/go-cmp/cmp"
@@ -26,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/pomerium/pomerium/config"
 	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
@@ -34,18 +36,21 @@ import (
 	"github.com/pomerium/ingress-controller/model"
 	"github.com/pomerium/ingress-controller/pomerium/gateway"
 	"github.com/pomerium/ingress-controller/util"
+	"github.com/pomerium/ingress-controller/util/metrics"
 )
 
 // NewAPIReconciler initializes a reconciler that syncs using the unified API,
 // for the given API url and API token.
 func NewAPIReconciler(
 	apiURL, namespaceID, apiToken string, baseOptions *config.Options, dialAddressOverride string,
+	options ...APIReconcilerOption,
 ) (Reconciler, error) {
 	opts := []sdk.ClientOption{
 		sdk.WithURL(apiURL),
 		sdk.WithAPIToken(apiToken),
 	}
 
+	transport := http.DefaultTransport.(*http.Transport).Clone()
 	if dialAddressOverride != "" {
 		u, err := url.Parse(apiURL)
 		if err != nil {
@@ -56,14 +61,13 @@ func NewAPIReconciler(
 				ServerName: u.Hostname(),
 			},
 		}
-		transport := http.DefaultTransport.(*http.Transport).Clone()
 		transport.DialTLSContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
 			return dialer.DialContext(ctx, network, dialAddressOverride)
 		}
-		opts = append(opts, sdk.WithHTTPClient(&http.Client{
-			Transport: transport,
-		}))
 	}
+	opts = append(opts, sdk.WithHTTPClient(&http.Client{
+		Transport: metrics.InstrumentRoundTripper(metricsBackendAPI, transport),
+	}))
 	ar := &APIReconciler{
 		apiClient:   sdk.NewClient(opts...),
 		baseOptions: baseOptions,
@@ -72,6 +76,9 @@ func NewAPIReconciler(
 	if namespaceID != "" {
 		ar.namespaceID = &namespaceID
 	}
+	for _, opt := range options {
+		opt(ar)
+	}
 	return ar, nil
 }
 
@@ -89,6 +96,20 @@ type APIReconciler struct {
 	baseOptions *config.Options
 	namespaceID *string
 	secretsMap  *model.TLSSecretsMap
+	// synced is the last state of API objects written by the reconciler
+	synced syncedAPIObjects
+	// fullySynced is set once all Ingresses were synced since startup, so that synced holds their desired state
+	fullySynced atomic.Bool
+	// strictOwnership refuses to modify API objects not created by the ingress controller, unless adopted
+	strictOwnership bool
+	// clusterID is stamped on the API objects synced from this cluster, see WithClusterID
+	clusterID string
+	// dryRun only logs the planned changes
+	dryRun bool
+	// planning is set on the copy of the reconciler that records changes into a plan
+	planning *apiPlan
+	// debugger if set, keeps the synced routes and settings and recent changes for inspection
+	debugger *ConfigDebugger
 }
 
 const (
@@ -107,6 +128,13 @@ func (r *APIReconciler) SetK8sClient(client client.Client) {
 
 // Upsert should update or create the pomerium routes corresponding to this ingress
 func (r *APIReconciler) Upsert(ctx context.Context, ic *model.IngressConfig) (bool, error) {
+	defer r.reportManagedObjects()
+	ctx = withConfigTrigger(ctx, "Ingress %s", ic.GetIngressNamespacedName())
+
+	if r.dryRun {
+		return r.dryRunPlan(ctx, func(p *APIReconciler) (bool, error) { return p.Upsert(ctx, ic) })
+	}
+
 	var anyChanges bool
 
 	// Sync any referenced TLS secrets to API keypairs.
@@ -116,7 +144,7 @@ func (r *APIReconciler) Upsert(ctx context.Context, ic *model.IngressConfig) (bo
 			tlsSecrets = append(tlsSecrets, s)
 		}
 	}
-	changed, err := r.syncSecrets(ctx, tlsSecrets)
+	changed, err := r.syncSecrets(ctx, tlsSecrets, shouldAdopt(ic.Ingress))
 	if err != nil {
 		return anyChanges, err
 	}
@@ -139,9 +167,30 @@ func (r *APIReconciler) Upsert(ctx context.Context, ic *model.IngressConfig) (bo
 	return anyChanges, nil
 }
 
-// Set configuration to match provided ingresses and shared config settings
+// Set configuration to match provided ingresses and shared config settings.
+// All changes are planned first and then applied in dependency order:
+// key pairs, policies and routes, with deletions in reverse.
+// If applying fails, the changes already made are rolled back.
 func (r *APIReconciler) Set(ctx context.Context, ics []*model.IngressConfig) (bool, error) {
+	defer r.reportManagedObjects()
+	ctx = withConfigTrigger(ctx, "sync of %d Ingresses", len(ics))
+
+	plan, changes, err := r.planChanges(ctx, func(p *APIReconciler) (bool, error) { return p.set(ctx, ics) })
+	if r.dryRun {
+		return changes, err
+	}
+	if applyErr := r.applyPlan(ctx, plan); applyErr != nil {
+		return false, errors.Join(applyErr, err)
+	}
+	if err == nil {
+		r.fullySynced.Store(true)
+	}
+	return changes, err
+}
+
+func (r *APIReconciler) set(ctx context.Context, ics []*model.IngressConfig) (bool, error) {
 	tlsSecrets := make(map[types.NamespacedName]*corev1.Secret)
+	adoptSecrets := make(map[types.NamespacedName]bool)
 	for _, ic := range ics {
 		// Collect all the referenced TLS secrets. These need to be synced
 		// before the routes, so that a route can reference a keypair ID.
@@ -149,13 +198,18 @@ func (r *APIReconciler) Set(ctx context.Context, ics []*model.IngressConfig) (bo
 			if s.Type == corev1.SecretTypeTLS {
 				r.secretsMap.Add(model.KeyForObject(ic), n)
 				tlsSecrets[n] = s
+				adoptSecrets[n] = adoptSecrets[n] || shouldAdopt(ic.Ingress)
 			}
 		}
 	}
 
-	anyChanges, err := r.syncSecrets(ctx, slices.Collect(maps.Values(tlsSecrets)))
-	if err != nil {
-		return anyChanges, err
+	var anyChanges bool
+	for n, secret := range tlsSecrets {
+		changed, err := r.syncOneSecret(ctx, secret, adoptSecrets[n])
+		if err != nil {
+			return anyChanges, err
+		}
+		anyChanges = anyChanges || changed
 	}
 
 	// TODO: should we do an initial scan here for any Secrets that were deleted
@@ -163,9 +217,13 @@ func (r *APIReconciler) Set(ctx context.Context, ics []*model.IngressConfig) (bo
 
 	var errs []error
 	for _, ic := range ics {
+		// an invalid ingress should not prevent others from being applied
+		mark := r.planning.mark()
 		changed, err := r.upsertOneIngress(ctx, ic)
 		if err != nil {
 			errs = append(errs, err)
+			r.planning.reset(mark)
+			continue
 		}
 		anyChanges = anyChanges || changed
 	}
@@ -197,6 +255,10 @@ func (r *APIReconciler) upsertOneIngress(
 	if err != nil {
 		return changed, err
 	}
+	// the override was validated while converting the ingress to routes
+	if err := requireDownstreamMTLSEnforcement(kv); err != nil {
+		return changed, err
+	}
 
 	existingPolicyID := ic.Annotations[apiPolicyIDAnnotation]
 
@@ -262,7 +324,7 @@ func (r *APIReconciler) upsertOneIngress(
 		// Clear the route StatName as it can't currently be set in Pomerium Zero.
 		route.StatName = nil
 
-		changedRoute, err := r.upsertOneRoute(ctx, route)
+		changedRoute, err := r.upsertOneRoute(ctx, route, ic.Ingress)
 		if err != nil {
 			return changed, err
 		}
@@ -307,10 +369,11 @@ func (r *APIReconciler) upsertOneIngress(
 func (r *APIReconciler) syncSecrets(
 	ctx context.Context,
 	secrets []*corev1.Secret,
+	adopt bool,
 ) (bool, error) {
 	var anyChanges bool
 	for _, secret := range secrets {
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, adopt)
 		if err != nil {
 			return anyChanges, err
 		}
@@ -323,9 +386,12 @@ func keyPairName(n types.NamespacedName) string {
 	return slug.Make(fmt.Sprintf("%s %s", n.Namespace, n.Name))
 }
 
+// syncOneSecret creates or updates the key pair for the secret. An existing key pair
+// not created by the ingress controller is adopted if either adopt is set, or the secret allows it.
 func (r *APIReconciler) syncOneSecret(
 	ctx context.Context,
 	secret *corev1.Secret,
+	adopt bool,
 ) (bool, error) {
 	cert, hasTLSCert := secret.Data[corev1.TLSCertKey]
 	if !hasTLSCert {
@@ -338,14 +404,14 @@ func (r *APIReconciler) syncOneSecret(
 		NamespaceId:  r.namespaceID,
 		Certificate:  cert,
 		Key:          secret.Data[corev1.TLSPrivateKeyKey],
-		OriginatorId: &originatorID,
+		OriginatorId: new(r.ownerOriginator(secret)),
 	}
 	if id := secret.Annotations[apiKeyPairIDAnnotation]; id != "" {
 		keyPair.Id = &id
 	}
 
 	originalSecret := secret.DeepCopy()
-	changed, err := r.upsertKeyPair(ctx, keyPair)
+	changed, err := r.upsertKeyPair(ctx, keyPair, adopt || shouldAdopt(secret))
 	if err != nil {
 		return false, err
 	} else if changed {
@@ -358,6 +424,13 @@ func (r *APIReconciler) syncOneSecret(
 
 // SetConfig updates just the shared config settings
 func (r *APIReconciler) SetConfig(ctx context.Context, cfg *model.Config) (changes bool, err error) {
+	defer r.reportManagedObjects()
+	ctx = withConfigTrigger(ctx, "Pomerium %s", cfg.Name)
+
+	if r.dryRun {
+		return r.dryRunPlan(ctx, func(p *APIReconciler) (bool, error) { return p.SetConfig(ctx, cfg) })
+	}
+
 	// Remove keypairs corresponding to any newly-unreferenced TLS secrets.
 	unreferencedSecrets := r.secretsMap.UpdateConfig(cfg)
 	anyDeletes, err := r.deleteKeyPairsForSecrets(ctx, unreferencedSecrets...)
@@ -370,7 +443,7 @@ func (r *APIReconciler) SetConfig(ctx context.Context, cfg *model.Config) (chang
 	allCertSecrets := make([]*corev1.Secret, 0, len(cfg.CASecrets)+len(cfg.Certs))
 	allCertSecrets = append(allCertSecrets, cfg.CASecrets...)
 	allCertSecrets = append(allCertSecrets, slices.Collect(maps.Values(cfg.Certs))...)
-	changedKeyPair, err := r.syncSecrets(ctx, allCertSecrets)
+	changedKeyPair, err := r.syncSecrets(ctx, allCertSecrets, false)
 	if err != nil {
 		return changes, err
 	}
@@ -418,6 +491,7 @@ func (r *APIReconciler) SetConfig(ctx context.Context, cfg *model.Config) (chang
 
 	if proto.Equal(existing, settings) {
 		// No changes needed.
+		r.observeSettings(ctx, existing, settings)
 		return changes, nil
 	}
 
@@ -427,12 +501,22 @@ func (r *APIReconciler) SetConfig(ctx context.Context, cfg *model.Config) (chang
 	_, err = r.apiClient.UpdateSettings(ctx, connect.NewRequest(&configpb.UpdateSettingsRequest{
 		Settings: settings,
 	}))
+	if err == nil {
+		r.observeSettings(ctx, existing, settings)
+	}
 	changes = changes || (err == nil)
 	return changes, err
 }
 
 // Delete removes pomerium routes corresponding to this ingress.
 func (r *APIReconciler) Delete(ctx context.Context, name types.NamespacedName) (changed bool, err error) {
+	defer r.reportManagedObjects()
+	ctx = withConfigTrigger(ctx, "Ingress %s deleted", name)
+
+	if r.dryRun {
+		return r.dryRunPlan(ctx, func(p *APIReconciler) (bool, error) { return p.Delete(ctx, name) })
+	}
+
 	ingress := new(networkingv1.Ingress)
 	err = r.k8sClient.Get(ctx, name, ingress)
 	if apierrors.IsNotFound(err) {
@@ -485,6 +569,16 @@ func (r *APIReconciler) SetGatewayConfig(
 	ctx context.Context,
 	gatewayConfig *model.GatewayConfig,
 ) (changes bool, err error) {
+	defer r.reportManagedObjects()
+	ctx = withConfigTrigger(ctx, "Gateway config with %d HTTPRoutes", len(gatewayConfig.Routes))
+	if r.debugger != nil {
+		ctx = util.WithBin[routeSource](ctx)
+	}
+
+	if r.dryRun {
+		return r.dryRunPlan(ctx, func(p *APIReconciler) (bool, error) { return p.SetGatewayConfig(ctx, gatewayConfig) })
+	}
+
 	// Sync keypairs.
 	unreferencedSecrets := r.secretsMap.UpdateGatewayConfig(gatewayConfig)
 	anyDeletes, err := r.deleteKeyPairsForSecrets(ctx, unreferencedSecrets...)
@@ -493,7 +587,7 @@ func (r *APIReconciler) SetGatewayConfig(
 	}
 	changes = changes || anyDeletes
 
-	changedKeyPair, err := r.syncSecrets(ctx, gatewayConfig.Certificates)
+	changedKeyPair, err := r.syncSecrets(ctx, gatewayConfig.Certificates, false)
 	if err != nil {
 		return changes, err
 	}
@@ -506,52 +600,88 @@ func (r *APIReconciler) SetGatewayConfig(
 	}
 	changes = changes || changedPolicy
 
+	changedRoutes, err := r.syncGatewayRoutes(ctx, gatewayConfig, policyIDs)
+	changes = changes || changedRoutes
+	if err != nil {
+		return changes, err
+	}
+
+	removed, err := r.removeDeletedGatewayPolicies(ctx, gatewayConfig)
+	if err != nil {
+		return changes, err
+	}
+	changes = changes || removed
+
+	return changes, nil
+}
+
+// syncGatewayRoutes upserts routes of all HTTPRoutes in the order they are served,
+// the same order the databroker reconciler uses, and deletes routes of deleted or unwatched HTTPRoutes.
+// Route IDs are recorded in HTTPRoute annotations even if syncing fails part way.
+// An HTTPRoute split by route claims has several route configs, the route IDs of all of them
+// are recorded on the same HTTPRoute object, keyed by split and route index.
+func (r *APIReconciler) syncGatewayRoutes(
+	ctx context.Context, gatewayConfig *model.GatewayConfig, policyIDs map[string]string,
+) (changes bool, err error) {
+	var objects []*gateway_v1.HTTPRoute
+	byName := make(map[types.NamespacedName]*gateway_v1.HTTPRoute)
+	deleted := make(map[*gateway_v1.HTTPRoute]bool)
 	for i := range gatewayConfig.Routes {
 		gr := &gatewayConfig.Routes[i]
-		originalRoute := gr.HTTPRoute.DeepCopy()
-
-		if gr.DeletionTimestamp == nil {
-			routes := gateway.TranslateRoutes(ctx, gatewayConfig, gr)
-			for i, route := range routes {
-				// Replace any inline policy with a policy ID reference.
-				if err := replaceInlinePolicies(route, policyIDs); err != nil {
-					return changes, err
-				}
-
-				k := routeIDAnnotationForIndex(i)
-				route.Id = emptyToNil(gr.Annotations[k])
-				routeChanged, err := r.upsertOneRoute(ctx, route)
-				if err != nil {
-					return changes, err
-				}
-				changes = changes || routeChanged
-				if gr.Annotations[k] != *route.Id {
-					util.SetAnnotation(gr, k, *route.Id)
-				}
-			}
-			controllerutil.AddFinalizer(gr, apiFinalizer)
-		} else {
-			// This HTTPRoute was deleted, so delete any synced Pomerium routes.
-			anyDeletes, err := r.deleteRoutes(ctx, gr, allRouteIDAnnotations(gr.Annotations))
-			if err != nil {
-				return changes, err
-			}
-			changes = changes || anyDeletes
-
-			controllerutil.RemoveFinalizer(gr, apiFinalizer)
+		if gr.Removed && !controllerutil.ContainsFinalizer(gr, apiFinalizer) {
+			// An unwatched HTTPRoute that was never synced has nothing to clean up.
+			continue
 		}
+		name := client.ObjectKeyFromObject(gr)
+		if _, ok := byName[name]; !ok {
+			byName[name] = gr.HTTPRoute
+			objects = append(objects, gr.HTTPRoute)
+		}
+		deleted[gr.HTTPRoute] = gr.IsDeleted()
+	}
+	originalObjects := make([]*gateway_v1.HTTPRoute, len(objects))
+	for i, obj := range objects {
+		originalObjects[i] = obj.DeepCopy()
+	}
+	defer func() {
+		for i, obj := range objects {
+			err = errors.Join(err, r.k8sClient.Patch(ctx, obj, client.MergeFrom(originalObjects[i])))
+		}
+	}()
 
-		if err := r.k8sClient.Patch(ctx, gr.HTTPRoute, client.MergeFrom(originalRoute)); err != nil {
+	for _, obj := range objects {
+		if !deleted[obj] {
+			controllerutil.AddFinalizer(obj, apiFinalizer)
+			continue
+		}
+		// This HTTPRoute was deleted or is no longer watched, so delete any synced Pomerium routes.
+		anyDeletes, err := r.deleteRoutes(ctx, obj, allRouteIDAnnotations(obj.Annotations))
+		if err != nil {
 			return changes, err
 		}
-	}
+		changes = changes || anyDeletes
 
-	removed, err := r.removeDeletedGatewayPolicies(ctx, gatewayConfig)
-	if err != nil {
-		return changes, err
+		controllerutil.RemoveFinalizer(obj, apiFinalizer)
 	}
-	changes = changes || removed
 
+	for _, route := range translateGatewayRoutes(ctx, gatewayConfig) {
+		obj := byName[client.ObjectKeyFromObject(route.owner.HTTPRoute)]
+		// Replace any inline policy with a policy ID reference.
+		if err := replaceInlinePolicies(route.Route, policyIDs); err != nil {
+			return changes, err
+		}
+
+		k := routeIDAnnotationForSplit(route.owner.Split, route.index)
+		route.Id = emptyToNil(obj.Annotations[k])
+		routeChanged, err := r.upsertOneRoute(ctx, route.Route, obj)
+		if err != nil {
+			return changes, err
+		}
+		changes = changes || routeChanged
+		if obj.Annotations[k] != *route.Id {
+			util.SetAnnotation(obj, k, *route.Id)
+		}
+	}
 	return changes, nil
 }
 
@@ -581,7 +711,7 @@ func (r *APIReconciler) syncGatewayPolicies(
 		if err != nil {
 			return changes, nil, err
 		}
-		policy.OriginatorId = &originatorID
+		policy.OriginatorId = new(r.ownerOriginator(obj))
 		policy.Rego = nil
 		policyName := slug.Make(fmt.Sprintf("%s %s", obj.Namespace, obj.Name))
 		policy.Name = &policyName
@@ -590,7 +720,7 @@ func (r *APIReconciler) syncGatewayPolicies(
 			policy.Id = &id
 		}
 
-		changedPolicy, err := r.upsertPolicy(ctx, policy)
+		changedPolicy, err := r.upsertPolicy(ctx, policy, shouldAdopt(obj))
 		if err != nil {
 			return changes, nil, err
 		} else if changedPolicy {
@@ -655,7 +785,9 @@ func replaceInlinePolicies(route *configpb.Route, policyIDs map[string]string) e
 	return nil
 }
 
-func (r *APIReconciler) upsertOneRoute(ctx context.Context, route *configpb.Route) (bool, error) {
+// upsertOneRoute creates or updates the route synced from the owner. If the owner allows adopting,
+// an existing route that was not synced from it may be taken over.
+func (r *APIReconciler) upsertOneRoute(ctx context.Context, route *configpb.Route, owner client.Object) (bool, error) {
 	logger := log.FromContext(ctx).WithName("APIReconciler.upsertOneRoute")
 
 	apiRoute, err := convertProto[*configpb.Route](route)
@@ -663,7 +795,8 @@ func (r *APIReconciler) upsertOneRoute(ctx context.Context, route *configpb.Rout
 		return false, err
 	}
 	apiRoute.NamespaceId = r.namespaceID
-	apiRoute.OriginatorId = &originatorID
+	apiRoute.OriginatorId = new(r.ownerOriginator(owner))
+	adopt := shouldAdopt(owner)
 
 	var existing *configpb.Route
 	if id := route.GetId(); id != "" {
@@ -674,6 +807,9 @@ func (r *APIReconciler) upsertOneRoute(ctx context.Context, route *configpb.Rout
 			return false, err
 		} else if err == nil {
 			existing = resp.Msg.Route
+			if err := r.checkOwnership(ctx, apiObjectRoute, id, existing.GetOriginatorId(), apiRoute.GetOriginatorId(), adopt); err != nil {
+				return false, err
+			}
 		}
 	}
 
@@ -696,6 +832,9 @@ func (r *APIReconciler) upsertOneRoute(ctx context.Context, route *configpb.Rout
 		}))
 		if err == nil {
 			route.Id = resp.Msg.Route.Id
+			apiRoute.Id = route.Id
+			r.observeRoute(ctx, apiRoute.GetId(), apiRoute)
+			r.synced.set(apiObjectRoute, apiRoute.GetId(), apiRoute)
 			return true, nil
 		}
 
@@ -709,25 +848,28 @@ func (r *APIReconciler) upsertOneRoute(ctx context.Context, route *configpb.Rout
 			return false, err
 		}
 
-		// Attempt to look up the route by name.
-		existing, err = r.findRouteByName(ctx, route.GetName())
-		if err != nil {
+		// Attempt to look up the route by name. The route may have been created
+		// outside of the ingress controller, in which case it is only taken over if explicitly allowed.
+		var findErr error
+		existing, findErr = r.findRouteByName(ctx, route.GetName(), apiRoute.GetOriginatorId())
+		if connect.CodeOf(findErr) == connect.CodeNotFound {
+			return false, err
+		} else if findErr != nil {
+			return false, findErr
+		}
+		if err := checkFoundByName(ctx, apiObjectRoute, existing.GetId(), existing.GetOriginatorId(), apiRoute.GetOriginatorId(), adopt); err != nil {
 			return false, err
 		}
 		apiRoute.Id = existing.Id
 		route.Id = existing.Id
 	}
 
-	// Clear the fields that should be ignored when looking for changes.
-	if r.namespaceID == nil {
-		existing.NamespaceId = nil
-	}
-	existing.CreatedAt = nil
-	existing.ModifiedAt = nil
-	existing.AssignedPolicies = nil
-	existing.EnforcedPolicies = nil
-	existing.StatName = nil
+	// The desired state is recorded before the update,
+	// so that a concurrent drift scan never restores the previous state.
+	r.observeRoute(ctx, apiRoute.GetId(), apiRoute)
+	r.synced.set(apiObjectRoute, apiRoute.GetId(), apiRoute)
 
+	r.normalizeRoute(existing)
 	if proto.Equal(existing, apiRoute) {
 		// No changes needed.
 		return false, nil
@@ -743,13 +885,11 @@ func (r *APIReconciler) upsertOneRoute(ctx context.Context, route *configpb.Rout
 	return err == nil, err
 }
 
+// findRouteByName looks up the route by name, preferring the one with the ownership marker
 func (r *APIReconciler) findRouteByName(
-	ctx context.Context, name string,
+	ctx context.Context, name, marker string,
 ) (existing *configpb.Route, err error) {
-	filter, err := structpb.NewStruct(map[string]any{
-		"originator_id": originatorID,
-		"name":          name,
-	})
+	filter, err := nameFilter(name)
 	if err != nil {
 		return nil, fmt.Errorf("internal error - couldn't create ListRoutes filter: %w", err)
 	}
@@ -759,9 +899,9 @@ func (r *APIReconciler) findRouteByName(
 	if err != nil {
 		return nil, err
 	} else if len(resp.Msg.Routes) == 0 {
-		return nil, fmt.Errorf("could not find route by name")
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("could not find route by name"))
 	}
-	return resp.Msg.Routes[0], nil
+	return pickByOriginator(resp.Msg.Routes, marker), nil
 }
 
 // deleteRoutes deletes routes corresponding to the keys in annotationKeys and
@@ -783,6 +923,11 @@ func (r *APIReconciler) deleteRoutes(
 }
 
 func (r *APIReconciler) deleteRoute(ctx context.Context, id string) error {
+	r.observeRoute(ctx, id, nil)
+	r.synced.delete(apiObjectRoute, id)
+	if ok, err := r.checkCanDelete(ctx, apiObjectRoute, id); !ok {
+		return err
+	}
 	_, err := r.apiClient.DeleteRoute(ctx, connect.NewRequest(&configpb.DeleteRouteRequest{
 		Id: id,
 	}))
@@ -812,13 +957,13 @@ func (r *APIReconciler) syncIngressPolicy(
 		return false, "", fmt.Errorf("internal error: %w", err)
 	}
 	apiPolicy.NamespaceId = r.namespaceID
-	apiPolicy.OriginatorId = &originatorID
+	apiPolicy.OriginatorId = new(r.ownerOriginator(ingress))
 	if existingPolicyID != "" {
 		apiPolicy.Id = &existingPolicyID
 	}
 
 	// Create or update the Pomerium policy as needed.
-	changed, err = r.upsertPolicy(ctx, apiPolicy)
+	changed, err = r.upsertPolicy(ctx, apiPolicy, shouldAdopt(ingress))
 	if err != nil {
 		return false, "", fmt.Errorf("couldn't update ingress policy: %w", err)
 	}
@@ -828,8 +973,9 @@ func (r *APIReconciler) syncIngressPolicy(
 }
 
 // upsertPolicy will create or update a Pomerium policy. If a new ID is
-// assigned, policy.Id will be updated.
-func (r *APIReconciler) upsertPolicy(ctx context.Context, policy *configpb.Policy) (changed bool, err error) {
+// assigned, policy.Id will be updated. If adopt is set, an existing policy
+// that was not created by the ingress controller may be taken over.
+func (r *APIReconciler) upsertPolicy(ctx context.Context, policy *configpb.Policy, adopt bool) (changed bool, err error) {
 	var existing *configpb.Policy
 	if id := policy.GetId(); id != "" {
 		resp, err := r.apiClient.GetPolicy(ctx, connect.NewRequest(&configpb.GetPolicyRequest{
@@ -837,6 +983,9 @@ func (r *APIReconciler) upsertPolicy(ctx context.Context, policy *configpb.Polic
 		}))
 		if err == nil {
 			existing = resp.Msg.Policy
+			if err := r.checkOwnership(ctx, apiObjectPolicy, id, existing.GetOriginatorId(), policy.GetOriginatorId(), adopt); err != nil {
+				return false, err
+			}
 		} else if connect.CodeOf(err) != connect.CodeNotFound {
 			return false, err
 		}
@@ -859,6 +1008,7 @@ func (r *APIReconciler) upsertPolicy(ctx context.Context, policy *configpb.Polic
 		}))
 		if err == nil {
 			policy.Id = resp.Msg.Policy.Id
+			r.synced.set(apiObjectPolicy, policy.GetId(), policy)
 			return true, nil
 		} else if connect.CodeOf(err) != connect.CodeAlreadyExists {
 			return false, err
@@ -866,23 +1016,20 @@ func (r *APIReconciler) upsertPolicy(ctx context.Context, policy *configpb.Polic
 
 		// If we already created a policy, but failed to save the ID annotation,
 		// attempt to look up the policy by name.
-		existing, err = r.findPolicyByName(ctx, policy.GetName())
+		existing, err = r.findPolicyByName(ctx, policy.GetName(), policy.GetOriginatorId())
 		if err != nil {
 			return false, err
 		}
+		if err := checkFoundByName(ctx, apiObjectPolicy, existing.GetId(), existing.GetOriginatorId(), policy.GetOriginatorId(), adopt); err != nil {
+			return false, err
+		}
 		policy.Id = existing.Id
 		changed = true
 	}
 
-	// Zero out fields that should be ignored when looking for changes.
-	if r.namespaceID == nil {
-		existing.NamespaceId = nil
-	}
-	existing.CreatedAt = nil
-	existing.ModifiedAt = nil
-	existing.AssignedRoutes = nil
-	existing.Enforced = falseToNil(existing.Enforced)
+	r.synced.set(apiObjectPolicy, policy.GetId(), policy)
 
+	r.normalizePolicy(existing)
 	if proto.Equal(existing, policy) {
 		// No changes needed.
 		return changed, nil
@@ -900,13 +1047,11 @@ func (r *APIReconciler) upsertPolicy(ctx context.Context, policy *configpb.Polic
 	return true, nil
 }
 
+// findPolicyByName looks up the policy by name, preferring the one with the ownership marker
 func (r *APIReconciler) findPolicyByName(
-	ctx context.Context, name string,
+	ctx context.Context, name, marker string,
 ) (existing *configpb.Policy, err error) {
-	filter, err := structpb.NewStruct(map[string]any{
-		"originator_id": originatorID,
-		"name":          name,
-	})
+	filter, err := nameFilter(name)
 	if err != nil {
 		return nil, fmt.Errorf("internal error - couldn't create ListPolicies filter: %w", err)
 	}
@@ -916,9 +1061,9 @@ func (r *APIReconciler) findPolicyByName(
 	if err != nil {
 		return nil, err
 	} else if len(resp.Msg.Policies) == 0 {
-		return nil, fmt.Errorf("could not find policy by name")
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("could not find policy by name"))
 	}
-	return resp.Msg.Policies[0], nil
+	return pickByOriginator(resp.Msg.Policies, marker), nil
 }
 
 // deletePolicyForObject deletes the policy for obj and clears its policy ID
@@ -940,6 +1085,10 @@ func (r *APIReconciler) deletePolicyForObject(
 }
 
 func (r *APIReconciler) deletePolicy(ctx context.Context, id string) (err error) {
+	r.synced.delete(apiObjectPolicy, id)
+	if ok, err := r.checkCanDelete(ctx, apiObjectPolicy, id); !ok {
+		return err
+	}
 	_, err = r.apiClient.DeletePolicy(ctx, connect.NewRequest(&configpb.DeletePolicyRequest{
 		Id: id,
 	}))
@@ -949,7 +1098,9 @@ func (r *APIReconciler) deletePolicy(ctx context.Context, id string) (err error)
 	return err
 }
 
-func (r *APIReconciler) upsertKeyPair(ctx context.Context, keyPair *configpb.KeyPair) (changed bool, err error) {
+// upsertKeyPair creates or updates the key pair. If adopt is set, an existing key pair
+// that was not created by the ingress controller may be taken over.
+func (r *APIReconciler) upsertKeyPair(ctx context.Context, keyPair *configpb.KeyPair, adopt bool) (changed bool, err error) {
 	var existing *configpb.KeyPair
 	if id := keyPair.GetId(); id != "" {
 		resp, err := r.apiClient.GetKeyPair(ctx, connect.NewRequest(&configpb.GetKeyPairRequest{
@@ -957,6 +1108,9 @@ func (r *APIReconciler) upsertKeyPair(ctx context.Context, keyPair *configpb.Key
 		}))
 		if err == nil {
 			existing = resp.Msg.KeyPair
+			if err := r.checkOwnership(ctx, apiObjectKeyPair, id, existing.GetOriginatorId(), keyPair.GetOriginatorId(), adopt); err != nil {
+				return false, err
+			}
 		} else if connect.CodeOf(err) != connect.CodeNotFound {
 			return false, err
 		}
@@ -981,6 +1135,7 @@ func (r *APIReconciler) upsertKeyPair(ctx context.Context, keyPair *configpb.Key
 		}))
 		if err == nil {
 			keyPair.Id = resp.Msg.KeyPair.Id
+			r.synced.set(apiObjectKeyPair, keyPair.GetId(), keyPair)
 			return true, nil
 		} else if connect.CodeOf(err) != connect.CodeAlreadyExists {
 			return false, err
@@ -988,24 +1143,20 @@ func (r *APIReconciler) upsertKeyPair(ctx context.Context, keyPair *configpb.Key
 
 		// If we already created a keypair, but failed to save the ID annotation,
 		// attempt to look up the keypair by name.
-		existing, err = r.findKeyPairByName(ctx, keyPair.GetName())
+		existing, err = r.findKeyPairByName(ctx, keyPair.GetName(), keyPair.GetOriginatorId())
 		if err != nil {
 			return false, err
 		}
+		if err := checkFoundByName(ctx, apiObjectKeyPair, existing.GetId(), existing.GetOriginatorId(), keyPair.GetOriginatorId(), adopt); err != nil {
+			return false, err
+		}
 		keyPair.Id = existing.Id
 		changed = true
 	}
 
-	// Zero out fields that should be ignored when looking for changes.
-	if r.namespaceID == nil {
-		existing.NamespaceId = nil
-	}
-	existing.CreatedAt = nil
-	existing.ModifiedAt = nil
-	existing.CertificateInfo = nil
-	existing.Origin = configpb.KeyPairOrigin_KEY_PAIR_ORIGIN_UNKNOWN
-	existing.Status = configpb.KeyPairStatus_KEY_PAIR_STATUS_UNKNOWN
+	r.synced.set(apiObjectKeyPair, keyPair.GetId(), keyPair)
 
+	r.normalizeKeyPair(existing)
 	if proto.Equal(existing, keyPair) {
 		// No changes needed.
 		return changed, nil
@@ -1025,13 +1176,11 @@ func (r *APIReconciler) upsertKeyPair(ctx context.Context, keyPair *configpb.Key
 	return true, nil
 }
 
+// findKeyPairByName looks up the keypair by name, preferring the one with the ownership marker
 func (r *APIReconciler) findKeyPairByName(
-	ctx context.Context, name string,
+	ctx context.Context, name, marker string,
 ) (existing *configpb.KeyPair, err error) {
-	filter, err := structpb.NewStruct(map[string]any{
-		"originator_id": originatorID,
-		"name":          name,
-	})
+	filter, err := nameFilter(name)
 	if err != nil {
 		return nil, fmt.Errorf("internal error - couldn't create ListKeyPairs filter: %w", err)
 	}
@@ -1043,7 +1192,7 @@ func (r *APIReconciler) findKeyPairByName(
 	} else if len(resp.Msg.KeyPairs) == 0 {
 		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("could not find keypair by name"))
 	}
-	return resp.Msg.KeyPairs[0], nil
+	return pickByOriginator(resp.Msg.KeyPairs, marker), nil
 }
 
 // deleteKeyPairsForSecrets deletes the keypairs corresponding to the given
@@ -1068,12 +1217,17 @@ func (r *APIReconciler) deleteKeyPairsForSecrets(
 			keyPairID = secret.Annotations[apiKeyPairIDAnnotation]
 		}
 
-		// If we don't have a keypair ID, try to look up the keypair by name.
+		// If we don't have a keypair ID, try to look up the keypair by name,
+		// among the ones synced from this cluster.
 		if keyPairID == "" {
-			keypair, err := r.findKeyPairByName(ctx, keyPairName(n))
+			marker := r.clusterOriginator()
+			if secret != nil {
+				marker = r.ownerOriginator(secret)
+			}
+			keypair, err := r.findKeyPairByName(ctx, keyPairName(n), marker)
 			if err != nil && connect.CodeOf(err) != connect.CodeNotFound {
 				return anyDeletes, err
-			} else if err == nil {
+			} else if err == nil && r.isSyncedFromCluster(keypair.GetOriginatorId()) {
 				keyPairID = keypair.GetId()
 			}
 		}
@@ -1098,6 +1252,10 @@ func (r *APIReconciler) deleteKeyPairsForSecrets(
 }
 
 func (r *APIReconciler) deleteKeyPair(ctx context.Context, id string) error {
+	r.synced.delete(apiObjectKeyPair, id)
+	if ok, err := r.checkCanDelete(ctx, apiObjectKeyPair, id); !ok {
+		return err
+	}
 	_, err := r.apiClient.DeleteKeyPair(ctx, connect.NewRequest(&configpb.DeleteKeyPairRequest{
 		Id: id,
 	}))
@@ -1111,6 +1269,15 @@ func routeIDAnnotationForIndex(i int) string {
 	return apiRouteIDAnnotationPrefix + strconv.Itoa(i)
 }
 
+// routeIDAnnotationForSplit returns the route ID annotation for a route of a split HTTPRoute config,
+// the config that was not split uses the same annotations as an HTTPRoute without lost route claims.
+func routeIDAnnotationForSplit(split, i int) string {
+	if split == 0 {
+		return routeIDAnnotationForIndex(i)
+	}
+	return apiRouteIDAnnotationPrefix + strconv.Itoa(split) + "-" + strconv.Itoa(i)
+}
+
 func allRouteIDAnnotations(annotations map[string]string) map[string]struct{} {
 	m := make(map[string]struct{})
 	for k := range annotations {
@@ -1121,6 +1288,41 @@ func allRouteIDAnnotations(annotations map[string]string) map[string]struct{} {
 	return m
 }
 
+// normalizeRoute clears the fields that should be ignored when looking for changes.
+func (r *APIReconciler) normalizeRoute(route *configpb.Route) {
+	if r.namespaceID == nil {
+		route.NamespaceId = nil
+	}
+	route.CreatedAt = nil
+	route.ModifiedAt = nil
+	route.AssignedPolicies = nil
+	route.EnforcedPolicies = nil
+	route.StatName = nil
+}
+
+// normalizePolicy zeroes out fields that should be ignored when looking for changes.
+func (r *APIReconciler) normalizePolicy(policy *configpb.Policy) {
+	if r.namespaceID == nil {
+		policy.NamespaceId = nil
+	}
+	policy.CreatedAt = nil
+	policy.ModifiedAt = nil
+	policy.AssignedRoutes = nil
+	policy.Enforced = falseToNil(policy.Enforced)
+}
+
+// normalizeKeyPair zeroes out fields that should be ignored when looking for changes.
+func (r *APIReconciler) normalizeKeyPair(keyPair *configpb.KeyPair) {
+	if r.namespaceID == nil {
+		keyPair.NamespaceId = nil
+	}
+	keyPair.CreatedAt = nil
+	keyPair.ModifiedAt = nil
+	keyPair.CertificateInfo = nil
+	keyPair.Origin = configpb.KeyPairOrigin_KEY_PAIR_ORIGIN_UNKNOWN
+	keyPair.Status = configpb.KeyPairStatus_KEY_PAIR_STATUS_UNKNOWN
+}
+
 func convertProto[Dst, Src proto.Message](msg Src) (Dst, error) {
 	// TODO: figure out a way to avoid this extra marshal/unmarshal step
 	var newMsg Dst
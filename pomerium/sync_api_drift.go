@@ -0,0 +1,374 @@
+package pomerium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+
+	icgv1alpha1 "github.com/pomerium/ingress-controller/apis/gateway/v1alpha1"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+// DriftScanner detects and corrects changes made to Pomerium configuration outside of Kubernetes
+type DriftScanner interface {
+	// ScanDrift compares Pomerium configuration with the desired state and restores it,
+	// returning all drifts it has corrected. If prune is set, objects no longer referenced
+	// by any Kubernetes object are deleted, as long as they were synced from this cluster.
+	ScanDrift(ctx context.Context, prune bool) ([]Drift, error)
+}
+
+var _ = DriftScanner((*APIReconciler)(nil))
+
+// DriftKind describes how an API object deviates from the desired state
+type DriftKind string
+
+const (
+	// DriftModified is an object that was changed outside of Kubernetes
+	DriftModified DriftKind = "modified"
+	// DriftMissing is an object that was deleted outside of Kubernetes
+	DriftMissing DriftKind = "missing"
+	// DriftOrphaned is an object created by the ingress controller that no Kubernetes object refers to
+	DriftOrphaned DriftKind = "orphaned"
+)
+
+const (
+	apiObjectRoute   = "route"
+	apiObjectPolicy  = "policy"
+	apiObjectKeyPair = "keypair"
+
+	// apiDriftPruneGracePeriod protects objects that were just created,
+	// but whose ID was not yet saved to the Kubernetes object, from being pruned
+	apiDriftPruneGracePeriod = 5 * time.Minute
+)
+
+// Drift is a single API object that deviated from the desired state
+type Drift struct {
+	Kind DriftKind
+	// Type of the API object: route, policy or keypair
+	Type string
+	ID   string
+	Name string
+	// Owner is the Kubernetes object the API object is synced from, nil for orphaned objects
+	Owner client.Object
+}
+
+func (d Drift) String() string {
+	switch d.Kind {
+	case DriftModified:
+		return fmt.Sprintf("%s %s (%s) was modified outside of Kubernetes and has been restored", d.Type, d.Name, d.ID)
+	case DriftMissing:
+		return fmt.Sprintf("%s %s was deleted outside of Kubernetes and will be recreated", d.Type, d.ID)
+	default:
+		return fmt.Sprintf("%s %s (%s) is no longer referenced by any Kubernetes object and has been deleted", d.Type, d.Name, d.ID)
+	}
+}
+
+// syncedAPIObjects keeps the last state of API objects written by the reconciler,
+// which a drift scan restores them to
+type syncedAPIObjects struct {
+	mu      sync.Mutex
+	objects map[string]proto.Message
+}
+
+func syncedAPIObjectKey(typ, id string) string {
+	return typ + "/" + id
+}
+
+func (s *syncedAPIObjects) set(typ, id string, msg proto.Message) {
+	if id == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.objects == nil {
+		s.objects = make(map[string]proto.Message)
+	}
+	s.objects[syncedAPIObjectKey(typ, id)] = proto.Clone(msg)
+}
+
+func (s *syncedAPIObjects) get(typ, id string) proto.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg, ok := s.objects[syncedAPIObjectKey(typ, id)]
+	if !ok {
+		return nil
+	}
+	return proto.Clone(msg)
+}
+
+// count returns the number of objects of each type
+func (s *syncedAPIObjects) count() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]int)
+	for key := range s.objects {
+		typ, _, _ := strings.Cut(key, "/")
+		out[typ]++
+	}
+	return out
+}
+
+func (s *syncedAPIObjects) delete(typ, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.objects, syncedAPIObjectKey(typ, id))
+}
+
+// apiObjectOwner is a Kubernetes object that references an API object by ID in one of its annotations
+type apiObjectOwner struct {
+	obj        client.Object
+	annotation string
+}
+
+// apiObject is an API object created by the ingress controller
+type apiObject struct {
+	typ       string
+	id        string
+	name      string
+	createdAt *timestamppb.Timestamp
+	msg       proto.Message
+	// prunable objects carry the ownership marker of this cluster
+	prunable bool
+}
+
+// ScanDrift compares routes, policies and key pairs created by the ingress controller
+// against the desired state, referenced by the ID annotations of Kubernetes objects:
+//   - objects modified outside of Kubernetes are restored to the last synced state;
+//   - objects deleted outside of Kubernetes have their ID annotation removed from the owner,
+//     which triggers its reconciliation and recreates them;
+//   - objects no Kubernetes object refers to are deleted, if prune is set and a cluster ID is configured,
+//     as objects synced from other clusters to the same namespace are otherwise indistinguishable.
+//
+// The desired state is the last state written by this process. The scan is skipped until all Ingresses
+// were synced since startup, as objects modified outside of Kubernetes before a restart would otherwise go unreported.
+// Objects of HTTPRoutes are checked once the Gateway configuration was synced.
+func (r *APIReconciler) ScanDrift(ctx context.Context, prune bool) ([]Drift, error) {
+	logger := log.FromContext(ctx).WithName("APIReconciler.ScanDrift")
+	if !r.fullySynced.Load() {
+		logger.V(1).Info("skipping drift scan until all Ingresses are synced")
+		return nil, nil
+	}
+	if prune && r.clusterID == "" {
+		logger.Info("not pruning orphaned objects, no cluster ID is set")
+		prune = false
+	}
+
+	owners, err := r.listAPIObjectOwners(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := r.listAPIObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []Drift
+	var errs []error
+	for _, obj := range objects {
+		key := syncedAPIObjectKey(obj.typ, obj.id)
+		owner, ok := owners[key]
+		if !ok {
+			if !prune || !obj.prunable || obj.createdAt == nil || time.Since(obj.createdAt.AsTime()) < apiDriftPruneGracePeriod {
+				continue
+			}
+			if err := r.deleteAPIObject(ctx, obj.typ, obj.id); err != nil {
+				errs = append(errs, fmt.Errorf("delete orphaned %s %s: %w", obj.typ, obj.id, err))
+				continue
+			}
+			drifts = append(drifts, Drift{Kind: DriftOrphaned, Type: obj.typ, ID: obj.id, Name: obj.name})
+			continue
+		}
+		delete(owners, key)
+
+		desired := r.synced.get(obj.typ, obj.id)
+		if desired == nil {
+			logger.V(1).Info("not synced since startup, skipping", "type", obj.typ, "id", obj.id)
+			continue
+		}
+		if proto.Equal(desired, obj.msg) {
+			continue
+		}
+		logger.V(1).Info("restoring modified object", "type", obj.typ, "id", obj.id)
+		if err := r.updateAPIObject(ctx, desired); err != nil {
+			errs = append(errs, fmt.Errorf("restore %s %s: %w", obj.typ, obj.id, err))
+			continue
+		}
+		drifts = append(drifts, Drift{Kind: DriftModified, Type: obj.typ, ID: obj.id, Name: obj.name, Owner: owner.obj})
+	}
+
+	// the remaining owners refer to objects that no longer exist
+	for _, key := range slices.Sorted(maps.Keys(owners)) {
+		owner := owners[key]
+		typ, id, _ := strings.Cut(key, "/")
+		original := owner.obj.DeepCopyObject().(client.Object)
+		annotations := owner.obj.GetAnnotations()
+		delete(annotations, owner.annotation)
+		owner.obj.SetAnnotations(annotations)
+		if err := r.k8sClient.Patch(ctx, owner.obj, client.MergeFrom(original)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: clear %s annotation: %w", util.GetNamespacedName(owner.obj), owner.annotation, err))
+			continue
+		}
+		r.synced.delete(typ, id)
+		drifts = append(drifts, Drift{Kind: DriftMissing, Type: typ, ID: id, Owner: owner.obj})
+	}
+
+	return drifts, errors.Join(errs...)
+}
+
+// listAPIObjectOwners returns Kubernetes objects that hold API object IDs, keyed by the API object type and ID
+func (r *APIReconciler) listAPIObjectOwners(ctx context.Context) (map[string]apiObjectOwner, error) {
+	owners := make(map[string]apiObjectOwner)
+	for _, list := range []client.ObjectList{
+		new(networkingv1.IngressList),
+		new(corev1.SecretList),
+		new(gateway_v1.HTTPRouteList),
+		new(icgv1alpha1.PolicyFilterList),
+	} {
+		if err := r.k8sClient.List(ctx, list); meta.IsNoMatchError(err) {
+			// Gateway API is not installed
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("list %T: %w", list, err)
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, fmt.Errorf("extract %T: %w", list, err)
+		}
+		for _, item := range items {
+			obj, ok := item.(client.Object)
+			if !ok {
+				continue
+			}
+			for k, id := range obj.GetAnnotations() {
+				var typ string
+				switch {
+				case strings.HasPrefix(k, apiRouteIDAnnotationPrefix):
+					typ = apiObjectRoute
+				case k == apiPolicyIDAnnotation:
+					typ = apiObjectPolicy
+				case k == apiKeyPairIDAnnotation:
+					typ = apiObjectKeyPair
+				default:
+					continue
+				}
+				if id != "" {
+					owners[syncedAPIObjectKey(typ, id)] = apiObjectOwner{obj: obj, annotation: k}
+				}
+			}
+		}
+	}
+	return owners, nil
+}
+
+// listAPIObjects returns all routes, policies and key pairs synced from this cluster,
+// normalized to be compared with the desired state. Ownership markers differ for every owner,
+// so all objects are listed and filtered by their originator ID.
+func (r *APIReconciler) listAPIObjects(ctx context.Context) ([]apiObject, error) {
+	var objects []apiObject
+	routes, err := r.apiClient.ListRoutes(ctx, connect.NewRequest(&configpb.ListRoutesRequest{}))
+	if err != nil {
+		return nil, fmt.Errorf("list routes: %w", err)
+	}
+	for _, route := range routes.Msg.Routes {
+		if !r.inNamespace(route.NamespaceId) || !r.isSyncedFromCluster(route.GetOriginatorId()) {
+			continue
+		}
+		obj := apiObject{
+			typ: apiObjectRoute, id: route.GetId(), name: route.GetName(), createdAt: route.CreatedAt, msg: route,
+			prunable: r.isClusterOriginator(route.GetOriginatorId()),
+		}
+		r.normalizeRoute(route)
+		objects = append(objects, obj)
+	}
+
+	policies, err := r.apiClient.ListPolicies(ctx, connect.NewRequest(&configpb.ListPoliciesRequest{}))
+	if err != nil {
+		return nil, fmt.Errorf("list policies: %w", err)
+	}
+	for _, policy := range policies.Msg.Policies {
+		if !r.inNamespace(policy.NamespaceId) || !r.isSyncedFromCluster(policy.GetOriginatorId()) {
+			continue
+		}
+		obj := apiObject{
+			typ: apiObjectPolicy, id: policy.GetId(), name: policy.GetName(), createdAt: policy.CreatedAt, msg: policy,
+			prunable: r.isClusterOriginator(policy.GetOriginatorId()),
+		}
+		r.normalizePolicy(policy)
+		objects = append(objects, obj)
+	}
+
+	keyPairs, err := r.apiClient.ListKeyPairs(ctx, connect.NewRequest(&configpb.ListKeyPairsRequest{}))
+	if err != nil {
+		return nil, fmt.Errorf("list key pairs: %w", err)
+	}
+	for _, keyPair := range keyPairs.Msg.KeyPairs {
+		if !r.inNamespace(keyPair.NamespaceId) || !r.isSyncedFromCluster(keyPair.GetOriginatorId()) {
+			continue
+		}
+		obj := apiObject{
+			typ: apiObjectKeyPair, id: keyPair.GetId(), name: keyPair.GetName(), createdAt: keyPair.CreatedAt, msg: keyPair,
+			prunable: r.isClusterOriginator(keyPair.GetOriginatorId()),
+		}
+		r.normalizeKeyPair(keyPair)
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// inNamespace checks whether an API object belongs to the namespace this reconciler syncs to
+func (r *APIReconciler) inNamespace(namespaceID *string) bool {
+	return r.namespaceID == nil || (namespaceID != nil && *namespaceID == *r.namespaceID)
+}
+
+func (r *APIReconciler) updateAPIObject(ctx context.Context, desired proto.Message) error {
+	var err error
+	switch msg := desired.(type) {
+	case *configpb.Route:
+		_, err = r.apiClient.UpdateRoute(ctx, connect.NewRequest(&configpb.UpdateRouteRequest{Route: msg}))
+	case *configpb.Policy:
+		_, err = r.apiClient.UpdatePolicy(ctx, connect.NewRequest(&configpb.UpdatePolicyRequest{Policy: msg}))
+	case *configpb.KeyPair:
+		_, err = r.apiClient.UpdateKeyPair(ctx, connect.NewRequest(&configpb.UpdateKeyPairRequest{KeyPair: msg}))
+	case *configpb.Settings:
+		_, err = r.apiClient.UpdateSettings(ctx, connect.NewRequest(&configpb.UpdateSettingsRequest{Settings: msg}))
+	default:
+		err = fmt.Errorf("internal error - unexpected object type %T", desired)
+	}
+	return err
+}
+
+func (r *APIReconciler) deleteAPIObject(ctx context.Context, typ, id string) error {
+	switch typ {
+	case apiObjectRoute:
+		return r.deleteRoute(ctx, id)
+	case apiObjectPolicy:
+		return r.deletePolicy(ctx, id)
+	case apiObjectKeyPair:
+		return r.deleteKeyPair(ctx, id)
+	default:
+		return fmt.Errorf("internal error - unexpected object type %s", typ)
+	}
+}
@@ -0,0 +1,159 @@
+package pomerium
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gateway_v1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+
+	icgv1alpha1 "github.com/pomerium/ingress-controller/apis/gateway/v1alpha1"
+)
+
+func TestAPIReconciler_ScanDrift(t *testing.T) {
+	apiClient, k8sClient, r := setupReconciler(t)
+	WithClusterID("test")(r)
+	r.fullySynced.Store(true)
+	ctx := t.Context()
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-ingress",
+			Namespace: "test",
+			Annotations: map[string]string{
+				"api.pomerium.io/route-id-0": "route-id-1",
+				"api.pomerium.io/policy-id":  "policy-id-1",
+			},
+		},
+	}
+	desired := &configpb.Route{
+		Id:           new("route-id-1"),
+		OriginatorId: new("ingress-controller/test"),
+		Name:         new("test-my-ingress-a-localhost-pomerium-io"),
+		From:         "https://a.localhost.pomerium.io",
+		To:           []string{"http://example-svc.test.svc.cluster.local:8080"},
+		Prefix:       "/",
+	}
+	r.synced.set(apiObjectRoute, "route-id-1", desired)
+
+	k8sClient.EXPECT().List(ctx, gomock.AssignableToTypeOf((*networkingv1.IngressList)(nil))).DoAndReturn(
+		func(_ context.Context, list *networkingv1.IngressList, _ ...client.ListOption) error {
+			list.Items = []networkingv1.Ingress{*ingress}
+			return nil
+		})
+	k8sClient.EXPECT().List(ctx, gomock.AssignableToTypeOf((*corev1.SecretList)(nil))).Return(nil)
+	k8sClient.EXPECT().List(ctx, gomock.AssignableToTypeOf((*gateway_v1.HTTPRouteList)(nil))).Return(nil)
+	k8sClient.EXPECT().List(ctx, gomock.AssignableToTypeOf((*icgv1alpha1.PolicyFilterList)(nil))).Return(nil)
+
+	// the route was edited in the console, and a stale route was left behind,
+	// next to routes of another cluster and of the console that must not be pruned
+	modified := &configpb.Route{
+		Id:           new("route-id-1"),
+		OriginatorId: new("ingress-controller/test"),
+		Name:         new("test-my-ingress-a-localhost-pomerium-io"),
+		From:         "https://a.localhost.pomerium.io",
+		To:           []string{"http://example-svc.test.svc.cluster.local:8080"},
+		Prefix:       "/edited",
+		CreatedAt:    timestamppb.New(time.Now().Add(-time.Hour)),
+		ModifiedAt:   timestamppb.Now(),
+	}
+	apiClient.EXPECT().ListRoutes(ctx, RequestEq(&configpb.ListRoutesRequest{})).
+		Return(connect.NewResponse(&configpb.ListRoutesResponse{
+			Routes: []*configpb.Route{modified, {
+				Id:           new("route-id-orphaned"),
+				OriginatorId: new("ingress-controller/test/deleted-ingress-uid"),
+				Name:         new("orphaned"),
+				CreatedAt:    timestamppb.New(time.Now().Add(-time.Hour)),
+			}, {
+				Id:           new("route-id-other-cluster"),
+				OriginatorId: new("ingress-controller/other/ingress-uid"),
+				Name:         new("other-cluster"),
+				CreatedAt:    timestamppb.New(time.Now().Add(-time.Hour)),
+			}, {
+				Id:           new("route-id-console"),
+				OriginatorId: new("console"),
+				Name:         new("console"),
+				CreatedAt:    timestamppb.New(time.Now().Add(-time.Hour)),
+			}, {
+				Id:           new("route-id-just-created"),
+				OriginatorId: new("ingress-controller/test"),
+				Name:         new("just-created"),
+				CreatedAt:    timestamppb.Now(),
+			}},
+		}), nil)
+	// the policy was deleted in the console
+	apiClient.EXPECT().ListPolicies(ctx, RequestEq(&configpb.ListPoliciesRequest{})).
+		Return(connect.NewResponse(&configpb.ListPoliciesResponse{}), nil)
+	apiClient.EXPECT().ListKeyPairs(ctx, RequestEq(&configpb.ListKeyPairsRequest{})).
+		Return(connect.NewResponse(&configpb.ListKeyPairsResponse{}), nil)
+
+	apiClient.EXPECT().UpdateRoute(ctx, RequestEq(&configpb.UpdateRouteRequest{Route: desired})).
+		Return(connect.NewResponse(&configpb.UpdateRouteResponse{}), nil)
+	apiClient.EXPECT().DeleteRoute(ctx, RequestEq(&configpb.DeleteRouteRequest{Id: "route-id-orphaned"})).
+		Return(connect.NewResponse(&configpb.DeleteRouteResponse{}), nil)
+	k8sClient.EXPECT().Patch(ctx, gomock.AssignableToTypeOf((*networkingv1.Ingress)(nil)), gomock.Any()).DoAndReturn(
+		func(_ context.Context, obj client.Object, _ client.Patch, _ ...client.PatchOption) error {
+			assert.NotContains(t, obj.GetAnnotations(), "api.pomerium.io/policy-id")
+			assert.Contains(t, obj.GetAnnotations(), "api.pomerium.io/route-id-0")
+			return nil
+		})
+
+	drifts, err := r.ScanDrift(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, drifts, 3)
+	assert.Equal(t, DriftModified, drifts[0].Kind)
+	assert.Equal(t, "route-id-1", drifts[0].ID)
+	assert.Equal(t, DriftOrphaned, drifts[1].Kind)
+	assert.Equal(t, "route-id-orphaned", drifts[1].ID)
+	assert.Equal(t, DriftMissing, drifts[2].Kind)
+	assert.Equal(t, apiObjectPolicy, drifts[2].Type)
+	assert.Equal(t, "policy-id-1", drifts[2].ID)
+}
+
+func TestAPIReconciler_ScanDriftWithoutClusterID(t *testing.T) {
+	apiClient, k8sClient, r := setupReconciler(t)
+	r.fullySynced.Store(true)
+	ctx := t.Context()
+
+	k8sClient.EXPECT().List(ctx, gomock.Any()).Return(nil).Times(4)
+
+	// the route may have been synced from another cluster to the same namespace
+	apiClient.EXPECT().ListRoutes(ctx, RequestEq(&configpb.ListRoutesRequest{})).
+		Return(connect.NewResponse(&configpb.ListRoutesResponse{
+			Routes: []*configpb.Route{{
+				Id:           new("route-id-orphaned"),
+				OriginatorId: new("ingress-controller"),
+				Name:         new("orphaned"),
+				CreatedAt:    timestamppb.New(time.Now().Add(-time.Hour)),
+			}},
+		}), nil)
+	apiClient.EXPECT().ListPolicies(ctx, RequestEq(&configpb.ListPoliciesRequest{})).
+		Return(connect.NewResponse(&configpb.ListPoliciesResponse{}), nil)
+	apiClient.EXPECT().ListKeyPairs(ctx, RequestEq(&configpb.ListKeyPairsRequest{})).
+		Return(connect.NewResponse(&configpb.ListKeyPairsResponse{}), nil)
+
+	drifts, err := r.ScanDrift(ctx, true)
+	require.NoError(t, err)
+	assert.Empty(t, drifts, "objects should not be pruned without a cluster ID")
+}
+
+func TestAPIReconciler_ScanDriftBeforeFullSync(t *testing.T) {
+	// no API or Kubernetes calls are expected, as the desired state is not known yet after a restart
+	_, _, r := setupReconciler(t)
+	WithClusterID("test")(r)
+
+	drifts, err := r.ScanDrift(t.Context(), true)
+	require.NoError(t, err)
+	assert.Empty(t, drifts)
+}
@@ -0,0 +1,173 @@
+package pomerium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/structpb"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// apiAdoptAnnotation on an Ingress, HTTPRoute, PolicyFilter or Secret allows the reconciler
+// to take over existing API objects that were not created by the ingress controller,
+// i.e. routes created in the console with the same name.
+const apiAdoptAnnotation = "api.pomerium.io/adopt"
+
+// ErrNotOwned is returned when an API object that was not synced from a Kubernetes object
+// would have to be modified for it, and the Kubernetes object does not allow adopting it.
+var ErrNotOwned = errors.New("not synced from this object")
+
+// APIReconcilerOption customizes the unified API reconciler
+type APIReconcilerOption func(r *APIReconciler)
+
+// WithStrictOwnership makes the reconciler refuse to update or delete API objects
+// that were not created by the ingress controller, unless their owner has the adopt annotation.
+// Without it, such objects referenced by ID annotations are taken over.
+func WithStrictOwnership() APIReconcilerOption {
+	return func(r *APIReconciler) {
+		r.strictOwnership = true
+	}
+}
+
+// WithClusterID stamps the API objects synced from this cluster with its ID, in addition to the
+// ingress controller originator ID, so that clusters syncing to the same API namespace can tell
+// their objects apart. Pruning orphaned objects requires it, as objects of other clusters
+// are never referenced by local Kubernetes objects.
+func WithClusterID(id string) APIReconcilerOption {
+	return func(r *APIReconciler) {
+		r.clusterID = id
+	}
+}
+
+// clusterOriginator is the originator ID of the API objects synced from this cluster
+func (r *APIReconciler) clusterOriginator() string {
+	if r.clusterID == "" {
+		return originatorID
+	}
+	return originatorID + "/" + r.clusterID
+}
+
+// ownerOriginator returns the ownership marker stamped on the API objects synced from the Kubernetes object,
+// the cluster originator ID followed by the object UID, i.e. ingress-controller/<cluster ID>/<UID>.
+// Objects without a UID, which the API server always assigns, are only marked with the cluster originator ID.
+func (r *APIReconciler) ownerOriginator(owner client.Object) string {
+	if owner == nil || owner.GetUID() == "" {
+		return r.clusterOriginator()
+	}
+	return r.clusterOriginator() + "/" + string(owner.GetUID())
+}
+
+// isClusterOriginator checks whether an API object was synced from any Kubernetes object of this cluster
+func (r *APIReconciler) isClusterOriginator(originator string) bool {
+	uid, ok := strings.CutPrefix(originator, r.clusterOriginator()+"/")
+	return originator == r.clusterOriginator() || (ok && uid != "" && !strings.Contains(uid, "/"))
+}
+
+// isSyncedFromCluster checks whether an API object was synced from this cluster,
+// or by an ingress controller before ownership markers were introduced
+func (r *APIReconciler) isSyncedFromCluster(originator string) bool {
+	return r.isClusterOriginator(originator) || originator == originatorID
+}
+
+func shouldAdopt(obj client.Object) bool {
+	return obj != nil && obj.GetAnnotations()[apiAdoptAnnotation] == "true"
+}
+
+// isOwned checks whether an API object carries the ownership marker of the Kubernetes object it is synced from.
+// Objects created before ownership markers were introduced carry just the ingress controller originator ID,
+// they are stamped with the marker on their next update.
+func isOwned(originator, marker string) bool {
+	return originator == marker || originator == originatorID
+}
+
+func notOwnedError(typ, id string) error {
+	return fmt.Errorf("%s %s: %w, set %s annotation to adopt it", typ, id, ErrNotOwned, apiAdoptAnnotation)
+}
+
+func ignoreNotFound(err error) error {
+	if connect.CodeOf(err) == connect.CodeNotFound {
+		return nil
+	}
+	return err
+}
+
+// checkOwnership decides whether an existing API object, referenced by the ID annotation of a Kubernetes object
+// with the given ownership marker, may be updated by the reconciler. Objects synced from that Kubernetes object
+// can always be updated, others only if adopted or if strict ownership is not enforced.
+// An adopted object is stamped with the ownership marker on update.
+func (r *APIReconciler) checkOwnership(ctx context.Context, typ, id, originator, marker string, adopt bool) error {
+	if isOwned(originator, marker) {
+		return nil
+	}
+
+	logger := log.FromContext(ctx).WithName("APIReconciler.checkOwnership")
+	if adopt {
+		logger.Info("adopting object", "type", typ, "id", id, "originator", originator)
+		return nil
+	}
+	if r.strictOwnership {
+		return notOwnedError(typ, id)
+	}
+	logger.Info("taking over object", "type", typ, "id", id, "originator", originator)
+	return nil
+}
+
+// checkFoundByName decides whether an existing API object found by name may be updated by the reconciler.
+// Unlike objects referenced by ID annotations, objects not synced from the same Kubernetes object
+// are only taken over if adopted, even if strict ownership is not enforced.
+func checkFoundByName(ctx context.Context, typ, id, originator, marker string, adopt bool) error {
+	if isOwned(originator, marker) {
+		return nil
+	}
+	if !adopt {
+		return notOwnedError(typ, id)
+	}
+	log.FromContext(ctx).WithName("APIReconciler.checkFoundByName").
+		Info("adopting object", "type", typ, "id", id, "originator", originator)
+	return nil
+}
+
+// checkCanDelete verifies, if strict ownership is enforced, that the object was synced from this cluster.
+// It returns false if the object should not be deleted.
+func (r *APIReconciler) checkCanDelete(ctx context.Context, typ, id string) (bool, error) {
+	if !r.strictOwnership {
+		return true, nil
+	}
+
+	obj, err := r.getAPIObject(ctx, typ, id)
+	if err != nil {
+		return false, ignoreNotFound(err)
+	}
+	var originator string
+	if msg, ok := obj.(interface{ GetOriginatorId() string }); ok {
+		originator = msg.GetOriginatorId()
+	}
+
+	if !r.isSyncedFromCluster(originator) {
+		log.FromContext(ctx).Info("not deleting object that was not synced from this cluster",
+			"type", typ, "id", id, "originator", originator)
+		return false, nil
+	}
+	return true, nil
+}
+
+// nameFilter returns a List filter matching objects by name. The originator is not filtered on,
+// as it differs for every owner, and objects of other owners are reported as not owned.
+func nameFilter(name string) (*structpb.Struct, error) {
+	return structpb.NewStruct(map[string]any{"name": name})
+}
+
+// pickByOriginator returns the object carrying the ownership marker among objects of the same name,
+// or the first one if there is none
+func pickByOriginator[T interface{ GetOriginatorId() string }](objs []T, marker string) T {
+	for _, obj := range objs {
+		if obj.GetOriginatorId() == marker {
+			return obj
+		}
+	}
+	return objs[0]
+}
@@ -0,0 +1,206 @@
+package pomerium
+
+import (
+	"fmt"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+)
+
+func TestAPIReconciler_Ownership(t *testing.T) {
+	consolePolicy := func() *configpb.Policy {
+		return &configpb.Policy{
+			Id:           new("console-policy-id"),
+			OriginatorId: new("console"),
+			Name:         new("policy-name"),
+		}
+	}
+	desiredPolicy := func(id *string) *configpb.Policy {
+		return &configpb.Policy{
+			Id:           id,
+			OriginatorId: new("ingress-controller"),
+			Name:         new("policy-name"),
+			SourcePpl:    new(`[{"allow":{"or":[{"accept":true}]}}]`),
+		}
+	}
+	t.Run("strict mode refuses to update", func(t *testing.T) {
+		apiClient, _, r := setupReconciler(t)
+		WithStrictOwnership()(r)
+		ctx := t.Context()
+
+		apiClient.EXPECT().GetPolicy(ctx, RequestEq(&configpb.GetPolicyRequest{Id: "console-policy-id"})).
+			Return(connect.NewResponse(&configpb.GetPolicyResponse{Policy: consolePolicy()}), nil)
+
+		changed, err := r.upsertPolicy(ctx, desiredPolicy(new("console-policy-id")), false)
+		assert.False(t, changed)
+		assert.ErrorIs(t, err, ErrNotOwned)
+	})
+
+	t.Run("strict mode updates adopted", func(t *testing.T) {
+		apiClient, _, r := setupReconciler(t)
+		WithStrictOwnership()(r)
+		ctx := t.Context()
+
+		apiClient.EXPECT().GetPolicy(ctx, RequestEq(&configpb.GetPolicyRequest{Id: "console-policy-id"})).
+			Return(connect.NewResponse(&configpb.GetPolicyResponse{Policy: consolePolicy()}), nil)
+		apiClient.EXPECT().UpdatePolicy(ctx, RequestEq(&configpb.UpdatePolicyRequest{
+			Policy: desiredPolicy(new("console-policy-id")),
+		})).Return(connect.NewResponse(&configpb.UpdatePolicyResponse{}), nil)
+
+		changed, err := r.upsertPolicy(ctx, desiredPolicy(new("console-policy-id")), true)
+		assert.True(t, changed)
+		assert.NoError(t, err)
+	})
+
+	t.Run("name collision", func(t *testing.T) {
+		for _, adopt := range []bool{false, true} {
+			t.Run(fmt.Sprintf("adopt=%v", adopt), func(t *testing.T) {
+				apiClient, _, r := setupReconciler(t)
+				ctx := t.Context()
+
+				apiClient.EXPECT().CreatePolicy(ctx, RequestEq(&configpb.CreatePolicyRequest{
+					Policy: desiredPolicy(nil),
+				})).Return(nil, connect.NewError(connect.CodeAlreadyExists, fmt.Errorf("already exists")))
+				apiClient.EXPECT().ListPolicies(ctx, RequestEq(&configpb.ListPoliciesRequest{
+					Filter: filterByName(t, "policy-name"),
+				})).Return(connect.NewResponse(&configpb.ListPoliciesResponse{
+					Policies: []*configpb.Policy{consolePolicy()},
+				}), nil)
+				if adopt {
+					apiClient.EXPECT().UpdatePolicy(ctx, RequestEq(&configpb.UpdatePolicyRequest{
+						Policy: desiredPolicy(new("console-policy-id")),
+					})).Return(connect.NewResponse(&configpb.UpdatePolicyResponse{}), nil)
+				}
+
+				// even without strict mode, a console-managed object is only taken over by name if adopted
+				policy := desiredPolicy(nil)
+				_, err := r.upsertPolicy(ctx, policy, adopt)
+				if adopt {
+					assert.NoError(t, err)
+					assert.Equal(t, "console-policy-id", policy.GetId())
+				} else {
+					assert.ErrorIs(t, err, ErrNotOwned)
+				}
+			})
+		}
+	})
+
+	t.Run("strict mode does not delete", func(t *testing.T) {
+		apiClient, _, r := setupReconciler(t)
+		WithStrictOwnership()(r)
+		ctx := t.Context()
+
+		apiClient.EXPECT().GetPolicy(ctx, RequestEq(&configpb.GetPolicyRequest{Id: "console-policy-id"})).
+			Return(connect.NewResponse(&configpb.GetPolicyResponse{Policy: consolePolicy()}), nil)
+
+		assert.NoError(t, r.deletePolicy(ctx, "console-policy-id"))
+	})
+	t.Run("name lookup error", func(t *testing.T) {
+		apiClient, _, r := setupReconciler(t)
+		ctx := t.Context()
+
+		apiClient.EXPECT().CreatePolicy(ctx, RequestEq(&configpb.CreatePolicyRequest{
+			Policy: desiredPolicy(nil),
+		})).Return(nil, connect.NewError(connect.CodeAlreadyExists, fmt.Errorf("already exists")))
+		apiClient.EXPECT().ListPolicies(ctx, RequestEq(&configpb.ListPoliciesRequest{
+			Filter: filterByName(t, "policy-name"),
+		})).Return(nil, connect.NewError(connect.CodeUnavailable, fmt.Errorf("unavailable")))
+
+		_, err := r.upsertPolicy(ctx, desiredPolicy(nil), false)
+		assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+		assert.NotErrorIs(t, err, ErrNotOwned)
+	})
+}
+
+func TestAPIReconciler_OwnershipMarkers(t *testing.T) {
+	ingress := func(uid types.UID) *networkingv1.Ingress {
+		return &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ingress", UID: uid},
+		}
+	}
+	desiredRoute := func(id *string) *configpb.Route {
+		return &configpb.Route{
+			Id:           id,
+			OriginatorId: new("ingress-controller/cluster-a/ingress-uid"),
+			Name:         new("route-name"),
+			From:         "https://a.localhost.pomerium.io",
+		}
+	}
+	existingRoute := func(originator string) *configpb.Route {
+		route := desiredRoute(new("route-id"))
+		route.OriginatorId = new(originator)
+		route.Prefix = "/edited"
+		return route
+	}
+
+	t.Run("stamps the owner", func(t *testing.T) {
+		apiClient, _, r := setupReconciler(t)
+		WithClusterID("cluster-a")(r)
+		ctx := t.Context()
+
+		apiClient.EXPECT().CreateRoute(ctx, RequestEq(&configpb.CreateRouteRequest{Route: desiredRoute(nil)})).
+			Return(connect.NewResponse(&configpb.CreateRouteResponse{Route: desiredRoute(new("route-id"))}), nil)
+
+		changed, err := r.upsertOneRoute(ctx, desiredRoute(nil), ingress("ingress-uid"))
+		assert.True(t, changed)
+		assert.NoError(t, err)
+	})
+
+	for _, tc := range []struct {
+		name       string
+		originator string
+		owned      bool
+	}{
+		{"same owner", "ingress-controller/cluster-a/ingress-uid", true},
+		{"before ownership markers", "ingress-controller", true},
+		{"other owner", "ingress-controller/cluster-a/other-uid", false},
+		{"other cluster", "ingress-controller/cluster-b/ingress-uid", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			apiClient, _, r := setupReconciler(t)
+			WithClusterID("cluster-a")(r)
+			WithStrictOwnership()(r)
+			ctx := t.Context()
+
+			apiClient.EXPECT().GetRoute(ctx, RequestEq(&configpb.GetRouteRequest{Id: "route-id"})).
+				Return(connect.NewResponse(&configpb.GetRouteResponse{Route: existingRoute(tc.originator)}), nil)
+			if tc.owned {
+				apiClient.EXPECT().UpdateRoute(ctx, RequestEq(&configpb.UpdateRouteRequest{Route: desiredRoute(new("route-id"))})).
+					Return(connect.NewResponse(&configpb.UpdateRouteResponse{}), nil)
+			}
+
+			_, err := r.upsertOneRoute(ctx, desiredRoute(new("route-id")), ingress("ingress-uid"))
+			if tc.owned {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrNotOwned)
+			}
+		})
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		apiClient, _, r := setupReconciler(t)
+		WithClusterID("cluster-a")(r)
+		WithStrictOwnership()(r)
+		ctx := t.Context()
+
+		for id, originator := range map[string]string{
+			"this-cluster":  "ingress-controller/cluster-a/other-uid",
+			"other-cluster": "ingress-controller/cluster-b/ingress-uid",
+		} {
+			apiClient.EXPECT().GetRoute(ctx, RequestEq(&configpb.GetRouteRequest{Id: id})).
+				Return(connect.NewResponse(&configpb.GetRouteResponse{Route: existingRoute(originator)}), nil)
+		}
+		apiClient.EXPECT().DeleteRoute(ctx, RequestEq(&configpb.DeleteRouteRequest{Id: "this-cluster"})).
+			Return(connect.NewResponse(&configpb.DeleteRouteResponse{}), nil)
+
+		assert.NoError(t, r.deleteRoute(ctx, "this-cluster"))
+		assert.NoError(t, r.deleteRoute(ctx, "other-cluster"))
+	})
+}
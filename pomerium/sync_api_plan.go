@@ -0,0 +1,636 @@
+package pomerium
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+	"github.com/pomerium/sdk-go"
+
+	"github.com/pomerium/ingress-controller/util"
+)
+
+// apiPlanOp is the kind of change a plan step makes to an API object
+type apiPlanOp string
+
+const (
+	apiPlanCreate apiPlanOp = "create"
+	apiPlanUpdate apiPlanOp = "update"
+	apiPlanDelete apiPlanOp = "delete"
+
+	apiObjectSettings = "settings"
+
+	// apiPlannedIDPrefix marks the IDs of objects a plan creates, until it is applied
+	apiPlannedIDPrefix = "planned-"
+)
+
+// apiPlanDependencies lists API object types so that an object may only refer to the types before it
+var apiPlanDependencies = []string{apiObjectSettings, apiObjectKeyPair, apiObjectPolicy, apiObjectRoute}
+
+// WithDryRun makes the reconciler only log the changes it would make to the unified API and Kubernetes objects
+func WithDryRun() APIReconcilerOption {
+	return func(r *APIReconciler) {
+		r.dryRun = true
+	}
+}
+
+// apiPlanStep is a single change to an API object
+type apiPlanStep struct {
+	op  apiPlanOp
+	typ string
+	id  string
+	// obj is the desired state, for create and update
+	obj proto.Message
+	// prev is the state before the change, used to roll it back
+	prev proto.Message
+}
+
+func (s apiPlanStep) String() string {
+	msg := s.obj
+	if msg == nil {
+		msg = s.prev
+	}
+	if named, ok := msg.(interface{ GetName() string }); ok && named.GetName() != "" {
+		return fmt.Sprintf("%s %s %s (%s)", s.op, s.typ, named.GetName(), s.id)
+	}
+	return fmt.Sprintf("%s %s %s", s.op, s.typ, s.id)
+}
+
+// rank orders steps so that objects are created before, and deleted after, the objects that refer to them
+func (s apiPlanStep) rank() int {
+	i := slices.Index(apiPlanDependencies, s.typ)
+	if s.op == apiPlanDelete {
+		return 2*len(apiPlanDependencies) - i
+	}
+	return i
+}
+
+// apiPatch is a metadata update of a Kubernetes object
+type apiPatch struct {
+	obj       client.Object
+	patchType types.PatchType
+	data      []byte
+}
+
+// apiPlan is the set of changes to API objects and Kubernetes objects,
+// computed by running a reconciliation against a client that records writes instead of sending them
+type apiPlan struct {
+	steps   []apiPlanStep
+	patches []apiPatch
+	// read holds API objects as they were before the plan
+	read    map[string]proto.Message
+	created int
+	// synced is the desired state recorded while planning, see syncedAPIObjects
+	synced map[string]proto.Message
+}
+
+func newAPIPlan() *apiPlan {
+	return &apiPlan{read: make(map[string]proto.Message)}
+}
+
+// describe lists the plan steps, in the order they are applied
+func (p *apiPlan) describe() []string {
+	out := make([]string, 0, len(p.steps)+len(p.patches))
+	for _, s := range p.ordered() {
+		out = append(out, s.String())
+	}
+	for _, patch := range p.patches {
+		out = append(out, fmt.Sprintf("patch %T %s", patch.obj, util.GetNamespacedName(patch.obj)))
+	}
+	return out
+}
+
+func (p *apiPlan) ordered() []apiPlanStep {
+	steps := slices.Clone(p.steps)
+	slices.SortStableFunc(steps, func(a, b apiPlanStep) int {
+		return cmp.Compare(a.rank(), b.rank())
+	})
+	return steps
+}
+
+// apiPlanMark is a position in the plan to roll back to
+type apiPlanMark struct{ steps, patches int }
+
+func (p *apiPlan) mark() apiPlanMark {
+	return apiPlanMark{len(p.steps), len(p.patches)}
+}
+
+// reset drops the changes planned after the mark
+func (p *apiPlan) reset(m apiPlanMark) {
+	p.steps, p.patches = p.steps[:m.steps], p.patches[:m.patches]
+}
+
+func (p *apiPlan) recordRead(typ, id string, msg proto.Message) {
+	key := syncedAPIObjectKey(typ, id)
+	if _, ok := p.read[key]; !ok {
+		p.read[key] = proto.Clone(msg)
+	}
+}
+
+// planned returns the desired state of an object created by the plan
+func (p *apiPlan) planned(typ, id string) proto.Message {
+	if !strings.HasPrefix(id, apiPlannedIDPrefix) {
+		return nil
+	}
+	for _, s := range p.steps {
+		if s.typ == typ && s.id == id && s.op == apiPlanCreate {
+			return proto.Clone(s.obj)
+		}
+	}
+	return nil
+}
+
+func (p *apiPlan) create(typ string, obj proto.Message) string {
+	p.created++
+	id := apiPlannedIDPrefix + typ + "-" + strconv.Itoa(p.created)
+	setAPIObjectID(obj, &id)
+	p.steps = append(p.steps, apiPlanStep{op: apiPlanCreate, typ: typ, id: id, obj: proto.Clone(obj)})
+	return id
+}
+
+func (p *apiPlan) update(typ, id string, obj proto.Message) {
+	for i, s := range p.steps {
+		if s.typ == typ && s.id == id && s.op == apiPlanCreate {
+			p.steps[i].obj = proto.Clone(obj)
+			return
+		}
+	}
+	p.steps = append(p.steps, apiPlanStep{
+		op: apiPlanUpdate, typ: typ, id: id, obj: proto.Clone(obj), prev: p.read[syncedAPIObjectKey(typ, id)],
+	})
+}
+
+func (p *apiPlan) delete(typ, id string) {
+	if strings.HasPrefix(id, apiPlannedIDPrefix) {
+		p.steps = slices.DeleteFunc(p.steps, func(s apiPlanStep) bool {
+			return s.typ == typ && s.id == id
+		})
+		return
+	}
+	p.steps = append(p.steps, apiPlanStep{
+		op: apiPlanDelete, typ: typ, id: id, prev: p.read[syncedAPIObjectKey(typ, id)],
+	})
+}
+
+// planChanges runs fn against a copy of the reconciler that records changes into a plan instead of making them
+func (r *APIReconciler) planChanges(ctx context.Context, fn func(p *APIReconciler) (bool, error)) (*apiPlan, bool, error) {
+	plan := newAPIPlan()
+	planner := &APIReconciler{
+		apiClient:       &planningAPIClient{Client: r.apiClient, plan: plan},
+		k8sClient:       &planningK8sClient{Client: r.k8sClient, plan: plan},
+		baseOptions:     r.baseOptions,
+		namespaceID:     r.namespaceID,
+		secretsMap:      r.secretsMap,
+		strictOwnership: r.strictOwnership,
+		clusterID:       r.clusterID,
+		planning:        plan,
+	}
+	changes, err := fn(planner)
+	plan.synced = planner.synced.objects
+
+	logger := log.FromContext(ctx).WithName("APIReconciler.plan")
+	if r.dryRun {
+		logger.Info("dry run, not applying changes", "steps", plan.describe())
+	} else if len(plan.steps) > 0 {
+		logger.V(1).Info("planned changes", "steps", plan.describe())
+	}
+	return plan, changes, err
+}
+
+// dryRunPlan only logs the changes fn would make
+func (r *APIReconciler) dryRunPlan(ctx context.Context, fn func(p *APIReconciler) (bool, error)) (bool, error) {
+	_, changes, err := r.planChanges(ctx, fn)
+	return changes, err
+}
+
+// applyPlan applies the plan steps in dependency order, and then updates the Kubernetes objects.
+// If a step fails, the steps already applied are rolled back.
+// Failing to update Kubernetes objects does not roll back the API changes,
+// as the objects are found by name on the next reconciliation.
+func (r *APIReconciler) applyPlan(ctx context.Context, plan *apiPlan) error {
+	logger := log.FromContext(ctx).WithName("APIReconciler.applyPlan")
+
+	ids := make(map[string]string)
+	var applied []apiPlanStep
+	for _, step := range plan.ordered() {
+		step.obj = resolvePlannedIDs(step.obj, ids)
+		if id, ok := ids[step.id]; ok {
+			step.id = id
+		}
+		done, err := r.applyPlanStep(ctx, step)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", step, err)
+			logger.Error(err, "rolling back", "applied", len(applied))
+			return errors.Join(err, r.rollbackPlan(ctx, applied))
+		}
+		if step.op == apiPlanCreate {
+			ids[step.id] = done.id
+		}
+		applied = append(applied, done)
+	}
+
+	for _, step := range applied {
+		if step.op == apiPlanDelete && step.typ == apiObjectRoute {
+			r.observeRoute(ctx, step.id, nil)
+		}
+	}
+	for key, msg := range plan.synced {
+		typ, id, _ := strings.Cut(key, "/")
+		if resolved, ok := ids[id]; ok {
+			id = resolved
+		}
+		msg = resolvePlannedIDs(msg, ids)
+		if route, ok := msg.(*configpb.Route); ok && typ == apiObjectRoute {
+			r.observeRoute(ctx, id, route)
+		}
+		r.synced.set(typ, id, msg)
+	}
+
+	var errs []error
+	for _, patch := range plan.patches {
+		annotations := patch.obj.GetAnnotations()
+		for k, v := range annotations {
+			if id, ok := ids[v]; ok {
+				annotations[k] = id
+			}
+		}
+		patch.obj.SetAnnotations(annotations)
+
+		data := patch.data
+		for planned, id := range ids {
+			from, _ := json.Marshal(planned)
+			to, _ := json.Marshal(id)
+			data = bytes.ReplaceAll(data, from, to)
+		}
+		if err := r.k8sClient.Patch(ctx, patch.obj, client.RawPatch(patch.patchType, data)); err != nil {
+			errs = append(errs, fmt.Errorf("patch %s: %w", util.GetNamespacedName(patch.obj), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyPlanStep makes a single change, and returns the step as applied, to be rolled back
+func (r *APIReconciler) applyPlanStep(ctx context.Context, step apiPlanStep) (apiPlanStep, error) {
+	switch step.op {
+	case apiPlanCreate:
+		id, err := r.createAPIObject(ctx, step.obj)
+		if code := connect.CodeOf(err); code != connect.CodeAlreadyExists && code != connect.CodeFailedPrecondition {
+			step.id = id
+			return step, err
+		}
+		// The object was created before, but its ID annotation was not saved.
+		existing, findErr := r.findAPIObjectByName(ctx, step.typ, step.obj)
+		if connect.CodeOf(findErr) == connect.CodeNotFound {
+			return step, err
+		} else if findErr != nil {
+			return step, findErr
+		}
+		step.op, step.id, step.prev = apiPlanUpdate, apiObjectID(existing), existing
+		setAPIObjectID(step.obj, new(step.id))
+		return step, r.updateAPIObject(ctx, step.obj)
+	case apiPlanUpdate:
+		return step, r.updateAPIObject(ctx, step.obj)
+	case apiPlanDelete:
+		if step.prev == nil {
+			prev, err := r.getAPIObject(ctx, step.typ, step.id)
+			if connect.CodeOf(err) == connect.CodeNotFound {
+				return step, nil
+			} else if err != nil {
+				return step, err
+			}
+			step.prev = prev
+		}
+		return step, r.deleteAPIObject(ctx, step.typ, step.id)
+	default:
+		return step, fmt.Errorf("internal error - unexpected plan step %s", step.op)
+	}
+}
+
+// rollbackPlan reverts the applied steps in reverse order
+func (r *APIReconciler) rollbackPlan(ctx context.Context, applied []apiPlanStep) error {
+	var errs []error
+	for _, step := range slices.Backward(applied) {
+		var err error
+		switch {
+		case step.op == apiPlanCreate:
+			err = r.deleteAPIObject(ctx, step.typ, step.id)
+		case step.prev == nil:
+			err = fmt.Errorf("previous state unknown")
+		case step.op == apiPlanUpdate:
+			prev := proto.Clone(step.prev)
+			r.normalizeAPIObject(prev)
+			err = r.updateAPIObject(ctx, prev)
+		case step.op == apiPlanDelete:
+			// the object is recreated with a new ID, and the reference to it is fixed on the next reconciliation
+			prev := proto.Clone(step.prev)
+			r.normalizeAPIObject(prev)
+			_, err = r.createAPIObject(ctx, prev)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("roll back %s: %w", step, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *APIReconciler) getAPIObject(ctx context.Context, typ, id string) (proto.Message, error) {
+	switch typ {
+	case apiObjectRoute:
+		resp, err := r.apiClient.GetRoute(ctx, connect.NewRequest(&configpb.GetRouteRequest{Id: id}))
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg.GetRoute(), nil
+	case apiObjectPolicy:
+		resp, err := r.apiClient.GetPolicy(ctx, connect.NewRequest(&configpb.GetPolicyRequest{Id: id}))
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg.GetPolicy(), nil
+	case apiObjectKeyPair:
+		resp, err := r.apiClient.GetKeyPair(ctx, connect.NewRequest(&configpb.GetKeyPairRequest{Id: id}))
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg.GetKeyPair(), nil
+	default:
+		return nil, fmt.Errorf("internal error - unexpected object type %s", typ)
+	}
+}
+
+// createAPIObject creates an object with a new ID, and returns that ID
+func (r *APIReconciler) createAPIObject(ctx context.Context, obj proto.Message) (string, error) {
+	obj = proto.Clone(obj)
+	setAPIObjectID(obj, nil)
+	switch msg := obj.(type) {
+	case *configpb.Route:
+		resp, err := r.apiClient.CreateRoute(ctx, connect.NewRequest(&configpb.CreateRouteRequest{Route: msg}))
+		if err != nil {
+			return "", err
+		}
+		return resp.Msg.GetRoute().GetId(), nil
+	case *configpb.Policy:
+		resp, err := r.apiClient.CreatePolicy(ctx, connect.NewRequest(&configpb.CreatePolicyRequest{Policy: msg}))
+		if err != nil {
+			return "", err
+		}
+		return resp.Msg.GetPolicy().GetId(), nil
+	case *configpb.KeyPair:
+		resp, err := r.apiClient.CreateKeyPair(ctx, connect.NewRequest(&configpb.CreateKeyPairRequest{KeyPair: msg}))
+		if err != nil {
+			return "", err
+		}
+		return resp.Msg.GetKeyPair().GetId(), nil
+	default:
+		return "", fmt.Errorf("internal error - unexpected object type %T", obj)
+	}
+}
+
+// findAPIObjectByName looks up an existing object with the same name, which must have been synced
+// from the same Kubernetes object, as the plan was made without knowing about it
+func (r *APIReconciler) findAPIObjectByName(ctx context.Context, typ string, obj proto.Message) (proto.Message, error) {
+	named, ok := obj.(interface {
+		GetName() string
+		GetOriginatorId() string
+	})
+	if !ok {
+		return nil, fmt.Errorf("internal error - %T has no name", obj)
+	}
+	var existing interface {
+		proto.Message
+		GetId() string
+		GetOriginatorId() string
+	}
+	var err error
+	switch typ {
+	case apiObjectRoute:
+		existing, err = r.findRouteByName(ctx, named.GetName(), named.GetOriginatorId())
+	case apiObjectPolicy:
+		existing, err = r.findPolicyByName(ctx, named.GetName(), named.GetOriginatorId())
+	case apiObjectKeyPair:
+		existing, err = r.findKeyPairByName(ctx, named.GetName(), named.GetOriginatorId())
+	default:
+		return nil, fmt.Errorf("internal error - unexpected object type %s", typ)
+	}
+	if err != nil {
+		return nil, err
+	} else if !isOwned(existing.GetOriginatorId(), named.GetOriginatorId()) {
+		return nil, notOwnedError(typ, existing.GetId())
+	}
+	return existing, nil
+}
+
+func (r *APIReconciler) normalizeAPIObject(obj proto.Message) {
+	switch msg := obj.(type) {
+	case *configpb.Route:
+		r.normalizeRoute(msg)
+	case *configpb.Policy:
+		r.normalizePolicy(msg)
+	case *configpb.KeyPair:
+		r.normalizeKeyPair(msg)
+	}
+}
+
+func apiObjectID(obj proto.Message) string {
+	if msg, ok := obj.(interface{ GetId() string }); ok {
+		return msg.GetId()
+	}
+	return ""
+}
+
+func setAPIObjectID(obj proto.Message, id *string) {
+	switch msg := obj.(type) {
+	case *configpb.Route:
+		msg.Id = id
+	case *configpb.Policy:
+		msg.Id = id
+	case *configpb.KeyPair:
+		msg.Id = id
+	}
+}
+
+// resolvePlannedIDs returns a copy of obj with references to objects created by the plan replaced by their IDs
+func resolvePlannedIDs(obj proto.Message, ids map[string]string) proto.Message {
+	if obj == nil {
+		return nil
+	}
+	obj = proto.Clone(obj)
+	m := obj.ProtoReflect()
+	var resolved []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if fd.Kind() == protoreflect.StringKind && !fd.IsMap() {
+			resolved = append(resolved, fd)
+		}
+		return true
+	})
+	for _, fd := range resolved {
+		if fd.IsList() {
+			list := m.Mutable(fd).List()
+			for i := range list.Len() {
+				if id, ok := ids[list.Get(i).String()]; ok {
+					list.Set(i, protoreflect.ValueOfString(id))
+				}
+			}
+		} else if id, ok := ids[m.Get(fd).String()]; ok {
+			m.Set(fd, protoreflect.ValueOfString(id))
+		}
+	}
+	return obj
+}
+
+// planningAPIClient passes reads through to the unified API, and records writes into a plan
+type planningAPIClient struct {
+	sdk.Client
+	plan *apiPlan
+}
+
+func (c *planningAPIClient) GetRoute(
+	ctx context.Context, req *connect.Request[configpb.GetRouteRequest],
+) (*connect.Response[configpb.GetRouteResponse], error) {
+	if obj := c.plan.planned(apiObjectRoute, req.Msg.GetId()); obj != nil {
+		return connect.NewResponse(&configpb.GetRouteResponse{Route: obj.(*configpb.Route)}), nil
+	}
+	resp, err := c.Client.GetRoute(ctx, req)
+	if err == nil {
+		c.plan.recordRead(apiObjectRoute, req.Msg.GetId(), resp.Msg.GetRoute())
+	}
+	return resp, err
+}
+
+func (c *planningAPIClient) CreateRoute(
+	_ context.Context, req *connect.Request[configpb.CreateRouteRequest],
+) (*connect.Response[configpb.CreateRouteResponse], error) {
+	route := proto.CloneOf(req.Msg.GetRoute())
+	c.plan.create(apiObjectRoute, route)
+	return connect.NewResponse(&configpb.CreateRouteResponse{Route: route}), nil
+}
+
+func (c *planningAPIClient) UpdateRoute(
+	_ context.Context, req *connect.Request[configpb.UpdateRouteRequest],
+) (*connect.Response[configpb.UpdateRouteResponse], error) {
+	c.plan.update(apiObjectRoute, req.Msg.GetRoute().GetId(), req.Msg.GetRoute())
+	return connect.NewResponse(&configpb.UpdateRouteResponse{}), nil
+}
+
+func (c *planningAPIClient) DeleteRoute(
+	_ context.Context, req *connect.Request[configpb.DeleteRouteRequest],
+) (*connect.Response[configpb.DeleteRouteResponse], error) {
+	c.plan.delete(apiObjectRoute, req.Msg.GetId())
+	return connect.NewResponse(&configpb.DeleteRouteResponse{}), nil
+}
+
+func (c *planningAPIClient) GetPolicy(
+	ctx context.Context, req *connect.Request[configpb.GetPolicyRequest],
+) (*connect.Response[configpb.GetPolicyResponse], error) {
+	if obj := c.plan.planned(apiObjectPolicy, req.Msg.GetId()); obj != nil {
+		return connect.NewResponse(&configpb.GetPolicyResponse{Policy: obj.(*configpb.Policy)}), nil
+	}
+	resp, err := c.Client.GetPolicy(ctx, req)
+	if err == nil {
+		c.plan.recordRead(apiObjectPolicy, req.Msg.GetId(), resp.Msg.GetPolicy())
+	}
+	return resp, err
+}
+
+func (c *planningAPIClient) CreatePolicy(
+	_ context.Context, req *connect.Request[configpb.CreatePolicyRequest],
+) (*connect.Response[configpb.CreatePolicyResponse], error) {
+	policy := proto.CloneOf(req.Msg.GetPolicy())
+	c.plan.create(apiObjectPolicy, policy)
+	return connect.NewResponse(&configpb.CreatePolicyResponse{Policy: policy}), nil
+}
+
+func (c *planningAPIClient) UpdatePolicy(
+	_ context.Context, req *connect.Request[configpb.UpdatePolicyRequest],
+) (*connect.Response[configpb.UpdatePolicyResponse], error) {
+	c.plan.update(apiObjectPolicy, req.Msg.GetPolicy().GetId(), req.Msg.GetPolicy())
+	return connect.NewResponse(&configpb.UpdatePolicyResponse{}), nil
+}
+
+func (c *planningAPIClient) DeletePolicy(
+	_ context.Context, req *connect.Request[configpb.DeletePolicyRequest],
+) (*connect.Response[configpb.DeletePolicyResponse], error) {
+	c.plan.delete(apiObjectPolicy, req.Msg.GetId())
+	return connect.NewResponse(&configpb.DeletePolicyResponse{}), nil
+}
+
+func (c *planningAPIClient) GetKeyPair(
+	ctx context.Context, req *connect.Request[configpb.GetKeyPairRequest],
+) (*connect.Response[configpb.GetKeyPairResponse], error) {
+	if obj := c.plan.planned(apiObjectKeyPair, req.Msg.GetId()); obj != nil {
+		return connect.NewResponse(&configpb.GetKeyPairResponse{KeyPair: obj.(*configpb.KeyPair)}), nil
+	}
+	resp, err := c.Client.GetKeyPair(ctx, req)
+	if err == nil {
+		c.plan.recordRead(apiObjectKeyPair, req.Msg.GetId(), resp.Msg.GetKeyPair())
+	}
+	return resp, err
+}
+
+func (c *planningAPIClient) CreateKeyPair(
+	_ context.Context, req *connect.Request[configpb.CreateKeyPairRequest],
+) (*connect.Response[configpb.CreateKeyPairResponse], error) {
+	keyPair := proto.CloneOf(req.Msg.GetKeyPair())
+	c.plan.create(apiObjectKeyPair, keyPair)
+	return connect.NewResponse(&configpb.CreateKeyPairResponse{KeyPair: keyPair}), nil
+}
+
+func (c *planningAPIClient) UpdateKeyPair(
+	_ context.Context, req *connect.Request[configpb.UpdateKeyPairRequest],
+) (*connect.Response[configpb.UpdateKeyPairResponse], error) {
+	c.plan.update(apiObjectKeyPair, req.Msg.GetKeyPair().GetId(), req.Msg.GetKeyPair())
+	return connect.NewResponse(&configpb.UpdateKeyPairResponse{}), nil
+}
+
+func (c *planningAPIClient) DeleteKeyPair(
+	_ context.Context, req *connect.Request[configpb.DeleteKeyPairRequest],
+) (*connect.Response[configpb.DeleteKeyPairResponse], error) {
+	c.plan.delete(apiObjectKeyPair, req.Msg.GetId())
+	return connect.NewResponse(&configpb.DeleteKeyPairResponse{}), nil
+}
+
+func (c *planningAPIClient) GetSettings(
+	ctx context.Context, req *connect.Request[configpb.GetSettingsRequest],
+) (*connect.Response[configpb.GetSettingsResponse], error) {
+	resp, err := c.Client.GetSettings(ctx, req)
+	if err == nil {
+		c.plan.recordRead(apiObjectSettings, resp.Msg.GetSettings().GetId(), resp.Msg.GetSettings())
+	}
+	return resp, err
+}
+
+func (c *planningAPIClient) UpdateSettings(
+	_ context.Context, req *connect.Request[configpb.UpdateSettingsRequest],
+) (*connect.Response[configpb.UpdateSettingsResponse], error) {
+	c.plan.update(apiObjectSettings, req.Msg.GetSettings().GetId(), req.Msg.GetSettings())
+	return connect.NewResponse(&configpb.UpdateSettingsResponse{}), nil
+}
+
+// planningK8sClient passes reads through to Kubernetes, and records patches into a plan
+type planningK8sClient struct {
+	client.Client
+	plan *apiPlan
+}
+
+func (c *planningK8sClient) Patch(_ context.Context, obj client.Object, patch client.Patch, _ ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return fmt.Errorf("patch %s: %w", util.GetNamespacedName(obj), err)
+	}
+	c.plan.patches = append(c.plan.patches, apiPatch{obj: obj, patchType: patch.Type(), data: data})
+	return nil
+}
@@ -0,0 +1,118 @@
+package pomerium
+
+import (
+	"fmt"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	configpb "github.com/pomerium/pomerium/pkg/grpc/config"
+
+	"github.com/pomerium/ingress-controller/model"
+)
+
+func TestAPIReconciler_SetPlan(t *testing.T) {
+	newIngressConfig := func() *model.IngressConfig {
+		return &model.IngressConfig{
+			AnnotationPrefix: "a",
+			Ingress: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-ingress",
+					Namespace: "test",
+					Annotations: map[string]string{
+						"a/policy": `allow:
+  or:
+    - groups:
+        has: "engineering"`,
+					},
+				},
+				Spec: networkingv1.IngressSpec{
+					IngressClassName: new("pomerium"),
+					Rules: []networkingv1.IngressRule{{
+						Host:             "a.localhost.pomerium.io",
+						IngressRuleValue: exampleIngressRuleValue,
+					}},
+				},
+			},
+			Services: map[types.NamespacedName]*corev1.Service{
+				{Name: "example-svc", Namespace: "test"}: {},
+			},
+		}
+	}
+	createPolicy := &configpb.CreatePolicyRequest{
+		Policy: &configpb.Policy{
+			OriginatorId: new("ingress-controller"),
+			Name:         new("test-my-ingress-policy"),
+			SourcePpl:    new(`[{"allow":{"or":[{"groups":{"has":"engineering"}}]}}]`),
+		},
+	}
+	createRoute := &configpb.CreateRouteRequest{
+		Route: &configpb.Route{
+			OriginatorId: new("ingress-controller"),
+			Name:         new("test-my-ingress-a-localhost-pomerium-io"),
+			From:         "https://a.localhost.pomerium.io",
+			To:           []string{"http://example-svc.test.svc.cluster.local:8080"},
+			Prefix:       "/",
+			PolicyIds:    []string{"new-policy-id"},
+		},
+	}
+
+	t.Run("apply in dependency order", func(t *testing.T) {
+		apiClient, k8sClient, r := setupReconciler(t)
+		ctx := t.Context()
+		ic := newIngressConfig()
+
+		gomock.InOrder(
+			apiClient.EXPECT().CreatePolicy(ctx, RequestEq(createPolicy)).
+				Return(createPolicyResponseWithID("new-policy-id"), nil),
+			apiClient.EXPECT().CreateRoute(ctx, RequestEq(createRoute)).
+				Return(createRouteResponseWithID("new-route-id"), nil),
+			k8sClient.EXPECT().Patch(ctx, ic.Ingress, gomock.Any()).Return(nil),
+		)
+
+		changed, err := r.Set(ctx, []*model.IngressConfig{ic})
+		assert.True(t, changed)
+		require.NoError(t, err)
+		assert.Equal(t, "new-policy-id", ic.Annotations["api.pomerium.io/policy-id"])
+		assert.Equal(t, "new-route-id", ic.Annotations["api.pomerium.io/route-id-0"])
+		assert.NotNil(t, r.synced.get(apiObjectRoute, "new-route-id"))
+	})
+
+	t.Run("roll back on failure", func(t *testing.T) {
+		apiClient, _, r := setupReconciler(t)
+		ctx := t.Context()
+		ic := newIngressConfig()
+
+		createErr := connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid route"))
+		gomock.InOrder(
+			apiClient.EXPECT().CreatePolicy(ctx, RequestEq(createPolicy)).
+				Return(createPolicyResponseWithID("new-policy-id"), nil),
+			apiClient.EXPECT().CreateRoute(ctx, RequestEq(createRoute)).
+				Return(nil, createErr),
+			apiClient.EXPECT().DeletePolicy(ctx, RequestEq(&configpb.DeletePolicyRequest{Id: "new-policy-id"})).
+				Return(connect.NewResponse(&configpb.DeletePolicyResponse{}), nil),
+		)
+
+		changed, err := r.Set(ctx, []*model.IngressConfig{ic})
+		assert.False(t, changed)
+		assert.ErrorIs(t, err, createErr)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		_, _, r := setupReconciler(t)
+		WithDryRun()(r)
+		ctx := t.Context()
+
+		// no writes are expected by the mocks
+		changed, err := r.Set(ctx, []*model.IngressConfig{newIngressConfig()})
+		assert.True(t, changed)
+		require.NoError(t, err)
+	})
+}
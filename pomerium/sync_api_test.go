@@ -1133,6 +1133,119 @@ func TestAPIReconciler_SetGatewayConfig_routeRecreated(t *testing.T) {
 	assert.Equal(t, "recreated-route-id", httpRouteObject.Annotations["api.pomerium.io/route-id-0"])
 }
 
+func TestAPIReconciler_SetGatewayConfig_priority(t *testing.T) {
+	gc := &model.GatewayConfig{
+		Routes: []model.GatewayHTTPRouteConfig{
+			newTestGatewayRoute("route-api", "/api", 0),
+			newTestGatewayRoute("route-root", "/", 10),
+		},
+	}
+
+	apiClient, k8sClient, r := setupReconciler(t)
+	ctx := t.Context()
+
+	k8sClient.EXPECT().Patch(ctx, gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	// routes are synced in the order they are served, same as with the databroker
+	var names []string
+	apiClient.EXPECT().CreateRoute(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *connect.Request[configpb.CreateRouteRequest]) (*connect.Response[configpb.CreateRouteResponse], error) {
+			names = append(names, req.Msg.GetRoute().GetName())
+			assert.Nil(t, req.Msg.GetRoute().Id)
+			return createRouteResponseWithID(fmt.Sprintf("route-id-%d", len(names))), nil
+		}).Times(2)
+
+	changed, err := r.SetGatewayConfig(ctx, gc)
+	assert.True(t, changed)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"test-route-root-a-localhost-pomerium-io",
+		"test-route-api-a-localhost-pomerium-io",
+	}, names)
+	assert.Equal(t, "route-id-2", gc.Routes[0].Annotations["api.pomerium.io/route-id-0"])
+	assert.Equal(t, "route-id-1", gc.Routes[1].Annotations["api.pomerium.io/route-id-0"])
+}
+
+func TestAPIReconciler_SetGatewayConfig_splitRoute(t *testing.T) {
+	obj := newTestGatewayRoute("route-a", "/", 0).HTTPRoute
+	// The HTTPRoute is split the way route claims split it, b.localhost.pomerium.io lost a match,
+	// so both configs have a route with index 0.
+	splitConfig := func() *model.GatewayConfig {
+		kept := newTestGatewayRoute("route-a", "/", 0)
+		kept.HTTPRoute = obj
+		lost := newTestGatewayRoute("route-a", "/", 0)
+		lost.HTTPRoute = obj.DeepCopy()
+		lost.Hostnames = []gateway_v1.Hostname{"b.localhost.pomerium.io"}
+		lost.Split = 2
+		return &model.GatewayConfig{Routes: []model.GatewayHTTPRouteConfig{kept, lost}}
+	}
+
+	apiClient, k8sClient, r := setupReconciler(t)
+	ctx := t.Context()
+
+	created := make(map[string]*configpb.Route)
+	apiClient.EXPECT().CreateRoute(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *connect.Request[configpb.CreateRouteRequest]) (*connect.Response[configpb.CreateRouteResponse], error) {
+			route := proto.Clone(req.Msg.GetRoute()).(*configpb.Route)
+			route.Id = new(fmt.Sprintf("route-id-%d", len(created)+1))
+			created[route.GetId()] = route
+			return connect.NewResponse(&configpb.CreateRouteResponse{Route: route}), nil
+		}).Times(2)
+	apiClient.EXPECT().GetRoute(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *connect.Request[configpb.GetRouteRequest]) (*connect.Response[configpb.GetRouteResponse], error) {
+			route, ok := created[req.Msg.GetId()]
+			if !ok {
+				return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("not found"))
+			}
+			return connect.NewResponse(&configpb.GetRouteResponse{Route: route}), nil
+		}).Times(2)
+	// both configs are recorded on the one HTTPRoute object
+	k8sClient.EXPECT().Patch(ctx, obj, gomock.Any()).Return(nil).Times(2)
+
+	changed, err := r.SetGatewayConfig(ctx, splitConfig())
+	require.NoError(t, err)
+	assert.True(t, changed)
+	ids := map[string]string{
+		"api.pomerium.io/route-id-0":   obj.Annotations["api.pomerium.io/route-id-0"],
+		"api.pomerium.io/route-id-2-0": obj.Annotations["api.pomerium.io/route-id-2-0"],
+	}
+	assert.ElementsMatch(t, []string{"route-id-1", "route-id-2"}, []string{
+		ids["api.pomerium.io/route-id-0"], ids["api.pomerium.io/route-id-2-0"],
+	})
+
+	// Syncing again finds both routes by their IDs, nothing is recreated.
+	changed, err = r.SetGatewayConfig(ctx, splitConfig())
+	require.NoError(t, err)
+	assert.False(t, changed)
+	for k, id := range ids {
+		assert.Equal(t, id, obj.Annotations[k], k)
+	}
+}
+
+func TestAPIReconciler_SetGatewayConfig_unwatchedNamespace(t *testing.T) {
+	// The namespace label was removed, so the HTTPRoute no longer matches the namespace selector.
+	synced := newTestGatewayRoute("route-a", "/", 0)
+	synced.Annotations = map[string]string{"api.pomerium.io/route-id-0": "route-a-id"}
+	synced.Finalizers = []string{apiFinalizer}
+	synced.Removed = true
+	// A route that was never synced has nothing to clean up and is not patched.
+	neverSynced := newTestGatewayRoute("route-b", "/", 0)
+	neverSynced.Removed = true
+	gc := &model.GatewayConfig{Routes: []model.GatewayHTTPRouteConfig{synced, neverSynced}}
+
+	apiClient, k8sClient, r := setupReconciler(t)
+	ctx := t.Context()
+
+	apiClient.EXPECT().DeleteRoute(ctx, RequestEq(&configpb.DeleteRouteRequest{Id: "route-a-id"})).
+		Return(connect.NewResponse(&configpb.DeleteRouteResponse{}), nil)
+	k8sClient.EXPECT().Patch(ctx, synced.HTTPRoute, gomock.Any()).Return(nil)
+
+	changed, err := r.SetGatewayConfig(ctx, gc)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, synced.Annotations)
+	assert.Empty(t, synced.Finalizers, "finalizer should be removed so the namespace can be deleted")
+}
+
 func TestAPIReconciler_SetConfig(t *testing.T) {
 	cfg := &model.Config{
 		Pomerium: icsv1.Pomerium{
@@ -1307,7 +1420,7 @@ func TestAPIReconciler_syncOneSecret(t *testing.T) {
 		// newly-assigned ID in the keypair ID annotation (verified below).
 		k8sClient.EXPECT().Patch(ctx, secret, gomock.Any()).Return(nil)
 
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, false)
 		assert.True(t, changed)
 		require.NoError(t, err)
 		assert.Equal(t, "new-keypair-id", secret.Annotations[apiKeyPairIDAnnotation])
@@ -1335,7 +1448,7 @@ func TestAPIReconciler_syncOneSecret(t *testing.T) {
 
 		k8sClient.EXPECT().Patch(ctx, secret, gomock.Any()).Return(nil)
 
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, false)
 		assert.True(t, changed)
 		require.NoError(t, err)
 		assert.Equal(t, "new-keypair-id", secret.Annotations[apiKeyPairIDAnnotation])
@@ -1381,7 +1494,7 @@ func TestAPIReconciler_syncOneSecret(t *testing.T) {
 
 		k8sClient.EXPECT().Patch(ctx, secret, gomock.Any()).Return(nil)
 
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, false)
 		assert.True(t, changed)
 		assert.NoError(t, err)
 	})
@@ -1422,7 +1535,7 @@ func TestAPIReconciler_syncOneSecret(t *testing.T) {
 			},
 		})).Return(nil, connect.NewError(connect.CodeDeadlineExceeded, context.DeadlineExceeded))
 
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, false)
 		assert.False(t, changed)
 		assert.ErrorIs(t, err, context.DeadlineExceeded)
 	})
@@ -1460,7 +1573,7 @@ func TestAPIReconciler_syncOneSecret(t *testing.T) {
 			},
 		}, nil)
 
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, false)
 		assert.False(t, changed)
 		assert.NoError(t, err)
 	})
@@ -1505,7 +1618,7 @@ func TestAPIReconciler_syncOneSecret(t *testing.T) {
 
 		k8sClient.EXPECT().Patch(ctx, secret, gomock.Any()).Return(nil)
 
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, false)
 		assert.True(t, changed)
 		assert.NoError(t, err)
 		assert.Equal(t, "missing-keypair-id", secret.Annotations["api.pomerium.io/keypair-id"])
@@ -1545,7 +1658,7 @@ func TestAPIReconciler_syncOneSecret(t *testing.T) {
 
 		k8sClient.EXPECT().Patch(ctx, secret, gomock.Any()).Return(nil)
 
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, false)
 		assert.True(t, changed)
 		assert.NoError(t, err)
 		assert.Equal(t, "new-keypair-id", secret.Annotations[apiKeyPairIDAnnotation])
@@ -1604,7 +1717,7 @@ func TestAPIReconciler_syncOneSecret(t *testing.T) {
 
 		k8sClient.EXPECT().Patch(ctx, secret, gomock.Any()).Return(nil)
 
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, false)
 		assert.True(t, changed)
 		assert.NoError(t, err)
 		assert.Equal(t, "recreated-keypair-id", secret.Annotations[apiKeyPairIDAnnotation])
@@ -1636,7 +1749,7 @@ func TestAPIReconciler_syncOneSecret(t *testing.T) {
 		patchErr := fmt.Errorf("failed to patch")
 		k8sClient.EXPECT().Patch(ctx, secret, gomock.Any()).Return(patchErr)
 
-		changed, err := r.syncOneSecret(ctx, secret)
+		changed, err := r.syncOneSecret(ctx, secret, false)
 		assert.True(t, changed)
 		require.ErrorIs(t, err, patchErr)
 	})
@@ -1656,7 +1769,8 @@ func TestAPIReconciler_deleteKeyPairs(t *testing.T) {
 			Filter: filterByName(t, "test-my-secret"),
 		})).Return(connect.NewResponse(&configpb.ListKeyPairsResponse{
 			KeyPairs: []*configpb.KeyPair{{
-				Id: new("my-keypair-id"),
+				Id:           new("my-keypair-id"),
+				OriginatorId: new("ingress-controller"),
 			}},
 		}), nil)
 		apiClient.EXPECT().DeleteKeyPair(ctx, connect.NewRequest(&configpb.DeleteKeyPairRequest{
@@ -1704,7 +1818,7 @@ func TestAPIReconciler_upsertPolicy(t *testing.T) {
 			Policy: &configpb.Policy{},
 		})).Return(createPolicyResponseWithID("existing-policy-id"), nil)
 
-		changed, err := r.upsertPolicy(ctx, policy)
+		changed, err := r.upsertPolicy(ctx, policy, false)
 		assert.True(t, changed)
 		assert.NoError(t, err)
 	})
@@ -1719,7 +1833,7 @@ func TestAPIReconciler_upsertPolicy(t *testing.T) {
 			Id: "existing-policy-id",
 		})).Return(nil, apiError)
 
-		changed, err := r.upsertPolicy(ctx, policy)
+		changed, err := r.upsertPolicy(ctx, policy, false)
 		assert.False(t, changed)
 		assert.Equal(t, apiError, err)
 	})
@@ -1749,7 +1863,7 @@ func TestAPIReconciler_upsertPolicy(t *testing.T) {
 
 		// No UpdatePolicy() call expected.
 
-		changed, err := r.upsertPolicy(ctx, policy)
+		changed, err := r.upsertPolicy(ctx, policy, false)
 		assert.False(t, changed)
 		assert.NoError(t, err)
 	})
@@ -1788,7 +1902,7 @@ func TestAPIReconciler_upsertPolicy(t *testing.T) {
 			},
 		})).Return(createPolicyResponseWithID("recreated-policy-id"), nil)
 
-		changed, err := r.upsertPolicy(ctx, policy)
+		changed, err := r.upsertPolicy(ctx, policy, false)
 		assert.True(t, changed)
 		assert.NoError(t, err)
 		assert.Equal(t, "recreated-policy-id", policy.GetId())
@@ -1822,7 +1936,7 @@ func TestAPIReconciler_upsertPolicy(t *testing.T) {
 			}},
 		}), nil)
 
-		changed, err := r.upsertPolicy(ctx, policy)
+		changed, err := r.upsertPolicy(ctx, policy, false)
 		assert.True(t, changed)
 		assert.NoError(t, err)
 		assert.Equal(t, "missing-policy-id", policy.GetId())
@@ -1957,8 +2071,7 @@ func (noopBackendRefChecker) Valid(_ client.Object, _ *gateway_v1.BackendRef) bo
 
 func filterByName(t *testing.T, name string) *structpb.Struct {
 	f, err := structpb.NewStruct(map[string]any{
-		"originator_id": "ingress-controller",
-		"name":          name,
+		"name": name,
 	})
 	require.NoError(t, err)
 	return f
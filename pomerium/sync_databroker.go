@@ -4,6 +4,7 @@ package pomerium
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/sergi/go-diff/diffmatchpatch"
@@ -13,7 +14,6 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/types/known/timestamppb"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -22,37 +22,51 @@ import (
 	"github.com/pomerium/pomerium/pkg/protoutil"
 
 	"github.com/pomerium/ingress-controller/model"
-	"github.com/pomerium/ingress-controller/pomerium/gateway"
+	"github.com/pomerium/ingress-controller/util"
 )
 
 // NewDataBrokerReconciler returns a set of reconcilers that use the databroker API.
+// Ingress-defined routes are split by host across ingressConfigShards records, if more than one,
+// or into a record per host if set to ShardPerHost.
 func NewDataBrokerReconciler(
 	client databroker.DataBrokerServiceClient,
 	dumpConfigDiff bool,
+	ingressConfigShards int,
+	options ...DataBrokerReconcilerOption,
 ) Reconciler {
+	client = instrumentedDataBrokerClient{client}
+	ingress := &DataBrokerReconciler{
+		ConfigID:                IngressControllerConfigID,
+		DataBrokerServiceClient: client,
+		DebugDumpConfigDiff:     dumpConfigDiff,
+		RemoveUnreferencedCerts: true,
+		Shards:                  ingressConfigShards,
+	}
+	settings := &DataBrokerReconciler{
+		ConfigID:                SharedSettingsConfigID,
+		DataBrokerServiceClient: client,
+		DebugDumpConfigDiff:     dumpConfigDiff,
+		RemoveUnreferencedCerts: false,
+	}
+	gatewayReconciler := &DataBrokerReconciler{
+		ConfigID:                GatewayControllerConfigID,
+		DataBrokerServiceClient: client,
+		DebugDumpConfigDiff:     dumpConfigDiff,
+		RemoveUnreferencedCerts: false,
+	}
+	for _, opt := range options {
+		opt(ingress)
+		opt(settings)
+		opt(gatewayReconciler)
+	}
 	return struct {
 		IngressReconciler
 		ConfigReconciler
 		GatewayReconciler
 	}{
-		IngressReconciler: &DataBrokerReconciler{
-			ConfigID:                IngressControllerConfigID,
-			DataBrokerServiceClient: client,
-			DebugDumpConfigDiff:     dumpConfigDiff,
-			RemoveUnreferencedCerts: true,
-		},
-		ConfigReconciler: &DataBrokerReconciler{
-			ConfigID:                SharedSettingsConfigID,
-			DataBrokerServiceClient: client,
-			DebugDumpConfigDiff:     dumpConfigDiff,
-			RemoveUnreferencedCerts: false,
-		},
-		GatewayReconciler: &DataBrokerReconciler{
-			ConfigID:                GatewayControllerConfigID,
-			DataBrokerServiceClient: client,
-			DebugDumpConfigDiff:     dumpConfigDiff,
-			RemoveUnreferencedCerts: false,
-		},
+		IngressReconciler: ingress,
+		ConfigReconciler:  settings,
+		GatewayReconciler: gatewayReconciler,
 	}
 }
 
@@ -67,6 +81,7 @@ const (
 
 var (
 	_ = IngressReconciler((*DataBrokerReconciler)(nil))
+	_ = IngressBatchReconciler((*DataBrokerReconciler)(nil))
 	_ = GatewayReconciler((*DataBrokerReconciler)(nil))
 	_ = ConfigReconciler((*DataBrokerReconciler)(nil))
 )
@@ -81,38 +96,120 @@ type DataBrokerReconciler struct {
 	DebugDumpConfigDiff bool
 	// RemoveUnreferencedCerts would strip any certs not matched by any of the Routes SNI
 	RemoveUnreferencedCerts bool
+	// Shards splits Ingress-defined routes across several records by host,
+	// so that no single record approaches gRPC message limits. Zero or one keeps a single record,
+	// and ShardPerHost stores the routes of each host in their own record.
+	Shards int
+
+	// index tracks the shards holding routes of each Ingress, it is loaded on first use
+	index *shardIndex
+	// priorities of the routes, that are not part of the stored configuration.
+	// They are rebuilt on every full sync of Ingresses or Gateway configuration,
+	// that the controllers run before any incremental update.
+	priorities routePriorities
+
+	// Debugger if set, keeps the applied configuration and recent changes for inspection
+	Debugger *ConfigDebugger
+
+	// records keeps the size of the records last applied, for metrics
+	records map[string]configRecordStats
+}
+
+// DataBrokerReconcilerOption customizes the reconcilers created by NewDataBrokerReconciler
+type DataBrokerReconcilerOption func(*DataBrokerReconciler)
+
+// WithConfigDebugger records applied configuration and its changes in the debugger
+func WithConfigDebugger(debugger *ConfigDebugger) DataBrokerReconcilerOption {
+	return func(r *DataBrokerReconciler) {
+		r.Debugger = debugger
+	}
 }
 
 // Upsert should update or create the pomerium routes corresponding to this ingress
 func (r *DataBrokerReconciler) Upsert(ctx context.Context, ic *model.IngressConfig) (bool, error) {
-	prev, err := r.getConfig(ctx)
-	if err != nil {
-		return false, fmt.Errorf("get config: %w", err)
+	ctx = withConfigTrigger(ctx, "Ingress %s", ic.GetIngressNamespacedName())
+	upsert := func(next *pb.Config) error {
+		if err := upsertRoutes(ctx, next, r.routePriorities(), ic); err != nil {
+			return err
+		}
+		addCerts(next, ic.Secrets)
+		return nil
 	}
+	if r.sharded() {
+		return r.updateShards(ctx, []IngressChange{{Ctx: ctx, Upsert: ic}}, upsert)
+	}
+	return r.updateConfig(ctx, r.ConfigID, fmt.Sprintf("%s-%s", r.ConfigID, ic.Ingress.UID), upsert)
+}
 
-	next := proto.Clone(prev).(*pb.Config)
-	if err = upsertRoutes(ctx, next, ic); err != nil {
-		return false, err
+// Apply applies several ingress changes, writing each affected record once
+func (r *DataBrokerReconciler) Apply(ctx context.Context, changes []IngressChange) ([]bool, error) {
+	names := make([]string, 0, len(changes))
+	for _, change := range changes {
+		names = append(names, change.name().String())
+	}
+	ctx = withConfigTrigger(ctx, "batch of %d Ingress changes: %s", len(changes), strings.Join(names, ", "))
+	changed := make([]bool, len(changes))
+	apply := func(next *pb.Config) error {
+		prev, err := r.normalizedConfig(next)
+		if err != nil {
+			return err
+		}
+		for i, change := range changes {
+			if err := applyIngressChange(change.context(ctx), next, r.routePriorities(), change); err != nil {
+				return err
+			}
+			cur, err := r.normalizedConfig(next)
+			if err != nil {
+				return err
+			}
+			changed[i] = !proto.Equal(prev, cur)
+			prev = cur
+		}
+		return nil
 	}
-	addCerts(next, ic.Secrets)
 
-	return r.saveConfig(ctx, prev, next, fmt.Sprintf("%s-%s", r.ConfigID, ic.Ingress.UID))
+	var saved bool
+	var err error
+	if r.sharded() {
+		saved, err = r.updateShards(ctx, changes, apply)
+	} else {
+		saved, err = r.updateConfig(ctx, r.ConfigID, r.ConfigID, apply)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		// nothing was written, as later changes of the batch may have reverted earlier ones
+		clear(changed)
+	}
+	return changed, nil
+}
+
+func applyIngressChange(ctx context.Context, cfg *pb.Config, priorities routePriorities, change IngressChange) error {
+	if change.Upsert == nil {
+		if err := deleteRoutes(cfg, priorities, change.Delete); err != nil {
+			return fmt.Errorf("deleting pomerium config records %s: %w", change.Delete, err)
+		}
+		return nil
+	}
+	if err := upsertRoutes(ctx, cfg, priorities, change.Upsert); err != nil {
+		return fmt.Errorf("ingress %s: %w", change.name(), err)
+	}
+	addCerts(cfg, change.Upsert.Secrets)
+	return nil
 }
 
 // Set merges existing config with the one generated for ingress
 func (r *DataBrokerReconciler) Set(ctx context.Context, ics []*model.IngressConfig) (bool, error) {
+	ctx = withConfigTrigger(ctx, "sync of %d Ingresses", len(ics))
 	logger := log.FromContext(ctx)
 
-	prev, err := r.getConfig(ctx)
-	if err != nil {
-		return false, fmt.Errorf("get config: %w", err)
-	}
 	next := new(pb.Config)
-
+	r.priorities = make(routePriorities)
 	for _, ic := range ics {
 		cfg := proto.Clone(next).(*pb.Config)
 		if err := multierror.Append(
-			upsertRoutes(ctx, cfg, ic),
+			upsertRoutes(ctx, cfg, r.priorities, ic),
 			validate(ctx, cfg, string(ic.Ingress.UID)),
 		).ErrorOrNil(); err != nil {
 			logger.Error(err, "skip ingress", "ingress", fmt.Sprintf("%s/%s", ic.Namespace, ic.Name))
@@ -122,12 +219,33 @@ func (r *DataBrokerReconciler) Set(ctx context.Context, ics []*model.IngressConf
 		next = cfg
 	}
 
-	return r.saveConfig(ctx, prev, next, r.ConfigID)
+	shards := map[string]*pb.Config{r.ConfigID: next}
+	if r.sharded() {
+		shards = r.splitByHost(next)
+	}
+
+	prev, err := r.getConfigShards(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get config: %w", err)
+	}
+	if r.sharded() {
+		r.index = newShardIndex(prev)
+	}
+	return r.saveShards(ctx, prev, shards, nil)
+}
+
+// routePriorities returns the priorities of the routes, that are empty until the first full sync
+func (r *DataBrokerReconciler) routePriorities() routePriorities {
+	if r.priorities == nil {
+		r.priorities = make(routePriorities)
+	}
+	return r.priorities
 }
 
 // SetConfig updates just the shared config settings
 func (r *DataBrokerReconciler) SetConfig(ctx context.Context, cfg *model.Config) (changes bool, err error) {
-	prev, err := r.getConfig(ctx)
+	ctx = withConfigTrigger(ctx, "Pomerium %s", cfg.Name)
+	prev, err := r.getConfig(ctx, r.ConfigID)
 	if err != nil {
 		return false, fmt.Errorf("get config: %w", err)
 	}
@@ -137,20 +255,25 @@ func (r *DataBrokerReconciler) SetConfig(ctx context.Context, cfg *model.Config)
 		return false, fmt.Errorf("settings: %w", err)
 	}
 
-	return r.saveConfig(ctx, prev, next, r.ConfigID)
+	return r.saveConfig(ctx, r.ConfigID, prev, next, r.ConfigID)
 }
 
 // Delete should delete pomerium routes corresponding to this ingress name
 func (r *DataBrokerReconciler) Delete(ctx context.Context, namespacedName types.NamespacedName) (bool, error) {
-	prev, err := r.getConfig(ctx)
-	if err != nil {
-		return false, fmt.Errorf("get pomerium config: %w", err)
+	ctx = withConfigTrigger(ctx, "Ingress %s deleted", namespacedName)
+	del := func(cfg *pb.Config) error {
+		if err := deleteRoutes(cfg, r.routePriorities(), namespacedName); err != nil {
+			return fmt.Errorf("deleting pomerium config records %s: %w", namespacedName.String(), err)
+		}
+		return nil
 	}
-	cfg := proto.Clone(prev).(*pb.Config)
-	if err := deleteRoutes(cfg, namespacedName); err != nil {
-		return false, fmt.Errorf("deleting pomerium config records %s: %w", namespacedName.String(), err)
+	var changed bool
+	var err error
+	if r.sharded() {
+		changed, err = r.updateShards(ctx, []IngressChange{{Ctx: ctx, Delete: namespacedName}}, del)
+	} else {
+		changed, err = r.updateConfig(ctx, r.ConfigID, fmt.Sprintf("%s-%s", namespacedName.Namespace, namespacedName.Name), del)
 	}
-	changed, err := r.saveConfig(ctx, prev, cfg, fmt.Sprintf("%s-%s", namespacedName.Namespace, namespacedName.Name))
 	if err != nil {
 		return false, fmt.Errorf("updating pomerium config: %w", err)
 	}
@@ -162,80 +285,94 @@ func (r *DataBrokerReconciler) SetGatewayConfig(
 	ctx context.Context,
 	config *model.GatewayConfig,
 ) (changes bool, err error) {
-	prev, err := r.getConfig(ctx)
+	ctx = withConfigTrigger(ctx, "Gateway config with %d HTTPRoutes", len(config.Routes))
+	if r.Debugger != nil {
+		ctx = util.WithBin[routeSource](ctx)
+	}
+	prev, err := r.getConfig(ctx, r.ConfigID)
 	if err != nil {
 		return false, fmt.Errorf("get config: %w", err)
 	}
-	next := new(pb.Config)
+	next, priorities := gatewayToConfig(ctx, config)
+	r.priorities = priorities
 
-	for i := range config.Routes {
-		r := &config.Routes[i]
-		if r.DeletionTimestamp != nil {
-			// Ignore any deleted HTTPRoutes.
-			continue
+	return r.saveConfig(ctx, r.ConfigID, prev, next, r.ConfigID)
+}
+
+// gatewayToConfig converts Gateway-defined configuration into routes and certificates,
+// and returns the priorities the routes are ordered by
+func gatewayToConfig(ctx context.Context, config *model.GatewayConfig) (*pb.Config, routePriorities) {
+	routes := translateGatewayRoutes(ctx, config)
+	next := new(pb.Config)
+	priorities := make(routePriorities)
+	for _, r := range routes {
+		if util.Enabled[routeSource](ctx) {
+			util.Add(ctx, routeSource{route: r.GetName(), object: fmt.Sprintf("HTTPRoute %s/%s", r.owner.Namespace, r.owner.Name)})
 		}
-		next.Routes = append(next.Routes, gateway.TranslateRoutes(ctx, config, r)...)
+		priorities.set(r.owner.Priority, r.Route)
+		next.Routes = append(next.Routes, r.Route)
 	}
 	next.Settings = new(pb.Settings)
 	for _, cert := range config.Certificates {
 		addTLSCert(next.Settings, cert)
 	}
-
-	return r.saveConfig(ctx, prev, next, r.ConfigID)
+	return next, priorities
 }
 
 // DeleteAll cleans pomerium configuration entirely
 func (r *DataBrokerReconciler) DeleteAll(ctx context.Context) error {
-	data := protoutil.NewAny(&pb.Config{})
-	if _, err := r.Put(ctx, &databroker.PutRequest{
-		Records: []*databroker.Record{{
-			Type:      data.GetTypeUrl(),
-			Id:        IngressControllerConfigID,
-			Data:      data,
-			DeletedAt: timestamppb.Now(),
-		}},
-	}); err != nil {
+	prev, err := r.getConfigShards(ctx)
+	if err != nil {
+		return err
+	}
+	prev[IngressControllerConfigID] = new(pb.Config)
+
+	records := make([]*databroker.Record, 0, len(prev))
+	for id := range prev {
+		records = append(records, deletedConfigRecord(id))
+	}
+	if _, err := r.Put(ctx, &databroker.PutRequest{Records: records}); err != nil {
 		return err
 	}
+	r.index = nil
 	return nil
 }
 
-func (r *DataBrokerReconciler) getConfig(ctx context.Context) (*pb.Config, error) {
+func (r *DataBrokerReconciler) getConfig(ctx context.Context, recordID string) (*pb.Config, error) {
+	cfg, _, err := r.getConfigRecord(ctx, recordID)
+	return cfg, err
+}
+
+// getConfigRecord returns the config stored in the record and the record version, zero if there is no record
+func (r *DataBrokerReconciler) getConfigRecord(ctx context.Context, recordID string) (*pb.Config, uint64, error) {
 	cfg := new(pb.Config)
 	data := protoutil.NewAny(cfg)
 	var hdr metadata.MD
 	resp, err := r.Get(ctx, &databroker.GetRequest{
 		Type: data.GetTypeUrl(),
-		Id:   r.ConfigID,
+		Id:   recordID,
 	}, grpc.Header(&hdr))
 	if status.Code(err) == codes.NotFound {
-		return &pb.Config{}, nil
+		return &pb.Config{}, 0, nil
 	} else if err != nil {
-		return nil, fmt.Errorf("get pomerium config: %w", err)
+		return nil, 0, fmt.Errorf("get pomerium config: %w", err)
 	}
 
 	if err := resp.GetRecord().GetData().UnmarshalTo(cfg); err != nil {
-		return nil, fmt.Errorf("unmarshal current config: %w", err)
+		return nil, 0, fmt.Errorf("unmarshal current config: %w", err)
 	}
 
-	return cfg, nil
+	return cfg, resp.GetRecord().GetVersion(), nil
 }
 
-func (r *DataBrokerReconciler) saveConfig(ctx context.Context, prev, next *pb.Config, id string) (bool, error) {
-	if r.RemoveUnreferencedCerts {
-		if err := removeUnusedCerts(next); err != nil {
-			return false, fmt.Errorf("removing unused certs: %w", err)
-		}
-	}
-
-	ensureDeterministicConfigOrder(next)
-
-	if err := validate(ctx, next, id); err != nil {
-		return false, fmt.Errorf("config validation: %w", err)
+func (r *DataBrokerReconciler) saveConfig(ctx context.Context, recordID string, prev, next *pb.Config, id string) (bool, error) {
+	if err := r.prepareConfig(ctx, next, id); err != nil {
+		return false, err
 	}
 
 	logger := log.FromContext(ctx)
 	if proto.Equal(prev, next) {
+		r.applied(ctx, recordID, prev, next)
 		logger.V(1).Info("no changes in the config")
 		return false, nil
 	}
@@ -244,12 +381,13 @@ func (r *DataBrokerReconciler) saveConfig(ctx context.Context, prev, next *pb.Co
 	if _, err := r.Put(ctx, &databroker.PutRequest{
 		Records: []*databroker.Record{{
 			Type: data.GetTypeUrl(),
-			Id:   r.ConfigID,
+			Id:   recordID,
 			Data: data,
 		}},
 	}); err != nil {
 		return false, err
 	}
+	r.applied(ctx, recordID, prev, next)
 
 	if r.DebugDumpConfigDiff {
 		logger.Info("config diff", "diff", debugDumpConfigDiff(prev, next))
@@ -259,6 +397,35 @@ func (r *DataBrokerReconciler) saveConfig(ctx context.Context, prev, next *pb.Co
 	return true, nil
 }
 
+// normalizedConfig returns a copy of the config in the form it would be saved in,
+// so that the effect of a single change may be compared
+func (r *DataBrokerReconciler) normalizedConfig(cfg *pb.Config) (*pb.Config, error) {
+	out := proto.Clone(cfg).(*pb.Config)
+	if r.RemoveUnreferencedCerts {
+		if err := removeUnusedCerts(out); err != nil {
+			return nil, fmt.Errorf("removing unused certs: %w", err)
+		}
+	}
+	ensureDeterministicConfigOrder(out, r.priorities)
+	return out, nil
+}
+
+// prepareConfig normalizes and validates the config before it is saved
+func (r *DataBrokerReconciler) prepareConfig(ctx context.Context, next *pb.Config, id string) error {
+	if r.RemoveUnreferencedCerts {
+		if err := removeUnusedCerts(next); err != nil {
+			return fmt.Errorf("removing unused certs: %w", err)
+		}
+	}
+
+	ensureDeterministicConfigOrder(next, r.priorities)
+
+	if err := validate(ctx, next, id); err != nil {
+		return fmt.Errorf("config validation: %w", err)
+	}
+	return nil
+}
+
 func debugDumpConfigDiff(prev, next *pb.Config) []byte {
 	dmp := diffmatchpatch.New()
 	txt1 := protojson.Format(prev)
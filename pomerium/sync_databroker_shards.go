@@ -0,0 +1,359 @@
+package pomerium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	pb "github.com/pomerium/pomerium/pkg/grpc/config"
+	"github.com/pomerium/pomerium/pkg/grpc/databroker"
+	"github.com/pomerium/pomerium/pkg/protoutil"
+)
+
+// ShardPerHost keeps the routes of each host in their own config record,
+// so that an Ingress change only reads and writes the records of its hosts.
+const ShardPerHost = -1
+
+// maxConfigConflictRetries is how many times a shard update is retried when another writer changed its records
+const maxConfigConflictRetries = 5
+
+// errConfigConflict is returned when a config record was changed by another writer while being updated
+var errConfigConflict = errors.New("config record was changed by another writer")
+
+// sharded returns true if Ingress-defined routes are split across records by host
+func (r *DataBrokerReconciler) sharded() bool {
+	return r.Shards > 1 || r.Shards == ShardPerHost
+}
+
+// hostShardID returns the record id that holds routes with the given source URL.
+// All routes for a host share the record, so that they are ordered and checked for conflicts together,
+// as Pomerium does not preserve the order of routes across records.
+func (r *DataBrokerReconciler) hostShardID(from string) string {
+	host := from
+	if u, err := url.Parse(from); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	if r.Shards == ShardPerHost {
+		return fmt.Sprintf("%s-shard-%s", r.ConfigID, host)
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(host))
+	return configShardID(r.ConfigID, int(h.Sum32()%uint32(r.Shards)))
+}
+
+// splitByHost splits the config into host shards, certificates are copied to every shard
+// and those not referenced by its routes are removed when the shard is saved
+func (r *DataBrokerReconciler) splitByHost(cfg *pb.Config) map[string]*pb.Config {
+	out := make(map[string]*pb.Config)
+	for _, route := range cfg.GetRoutes() {
+		id := r.hostShardID(route.GetFrom())
+		shard, ok := out[id]
+		if !ok {
+			shard = &pb.Config{Settings: proto.Clone(cfg.GetSettings()).(*pb.Settings)}
+			out[id] = shard
+		}
+		shard.Routes = append(shard.Routes, route)
+	}
+	return out
+}
+
+// mergeConfigs combines Ingress-defined configuration spread across records
+func mergeConfigs(cfgs map[string]*pb.Config) *pb.Config {
+	out := &pb.Config{Settings: new(pb.Settings)}
+	for _, id := range slices.Sorted(maps.Keys(cfgs)) {
+		out.Routes = append(out.Routes, cfgs[id].GetRoutes()...)
+		out.Settings.Certificates = append(out.Settings.Certificates, cfgs[id].GetSettings().GetCertificates()...)
+	}
+	return out
+}
+
+// updateShards applies fn to the configuration merged from the shards that hold routes of the changed ingresses,
+// before or after the changes, and saves the result split by host again.
+// Other shards are neither read nor written, so an update costs in proportion to the routes of the hosts it touches.
+// If another writer changed one of the shards in the meantime, as detected by the record versions,
+// the update is applied again to the current content of the shards.
+func (r *DataBrokerReconciler) updateShards(
+	ctx context.Context,
+	changes []IngressChange,
+	fn func(*pb.Config) error,
+) (bool, error) {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = 50 * time.Millisecond
+	bo.MaxInterval = time.Second
+
+	var changed bool
+	err := backoff.Retry(func() error {
+		var err error
+		changed, err = r.tryUpdateShards(ctx, changes, fn)
+		if errors.Is(err, errConfigConflict) {
+			// the index may not reflect the changes of the other writer
+			r.index = nil
+			log.FromContext(ctx).V(1).Info("config record changed by another writer, retrying", "error", err)
+			return err
+		} else if err != nil {
+			return backoff.Permanent(err)
+		}
+		return nil
+	}, backoff.WithContext(backoff.WithMaxRetries(bo, maxConfigConflictRetries), ctx))
+	return changed, err
+}
+
+func (r *DataBrokerReconciler) tryUpdateShards(
+	ctx context.Context,
+	changes []IngressChange,
+	fn func(*pb.Config) error,
+) (bool, error) {
+	if r.index == nil {
+		shards, err := r.getConfigShards(ctx)
+		if err != nil {
+			return false, fmt.Errorf("get config: %w", err)
+		}
+		r.index = newShardIndex(shards)
+	}
+
+	ids := make(map[string]struct{})
+	for _, change := range changes {
+		for id := range r.index.shards[change.name()] {
+			ids[id] = struct{}{}
+		}
+		if change.Upsert == nil {
+			continue
+		}
+		routes, err := ingressToRoutes(change.context(ctx), change.Upsert)
+		if err != nil {
+			return false, fmt.Errorf("parsing ingress %s: %w", change.name(), err)
+		}
+		for _, route := range routes {
+			ids[r.hostShardID(route.GetFrom())] = struct{}{}
+		}
+	}
+
+	prev := make(map[string]*pb.Config, len(ids))
+	versions := make(map[string]uint64, len(ids))
+	for id := range ids {
+		cfg, version, err := r.getConfigRecord(ctx, id)
+		if err != nil {
+			return false, fmt.Errorf("get config: %w", err)
+		}
+		versions[id] = version
+		// shards without routes are not stored
+		if len(cfg.GetRoutes()) > 0 {
+			prev[id] = cfg
+		}
+	}
+
+	next := mergeConfigs(prev)
+	if err := fn(next); err != nil {
+		return false, err
+	}
+	return r.saveShards(ctx, prev, r.splitByHost(next), versions)
+}
+
+// checkVersions returns errConfigConflict if any of the records was changed since it was read at the given version.
+// The databroker has no conditional writes, so this narrows the window in which a concurrent write is lost
+// rather than closing it. Only one controller instance is expected to write, as it runs under leader election.
+func (r *DataBrokerReconciler) checkVersions(ctx context.Context, versions map[string]uint64) error {
+	for _, id := range slices.Sorted(maps.Keys(versions)) {
+		_, version, err := r.getConfigRecord(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get config: %w", err)
+		}
+		if version != versions[id] {
+			return fmt.Errorf("%w: %s", errConfigConflict, id)
+		}
+	}
+	return nil
+}
+
+// updateConfig applies fn to the current content of the record and saves the result
+func (r *DataBrokerReconciler) updateConfig(ctx context.Context, recordID, id string, fn func(*pb.Config) error) (bool, error) {
+	prev, err := r.getConfig(ctx, recordID)
+	if err != nil {
+		return false, fmt.Errorf("get config: %w", err)
+	}
+
+	next := proto.Clone(prev).(*pb.Config)
+	if err := fn(next); err != nil {
+		return false, err
+	}
+	return r.saveConfig(ctx, recordID, prev, next, id)
+}
+
+func configShardID(configID string, shard int) string {
+	return fmt.Sprintf("%s-shard-%d", configID, shard)
+}
+
+// isConfigRecordID checks whether the record holds configuration of this reconciler,
+// either as a single record or as one of the shards
+func (r *DataBrokerReconciler) isConfigRecordID(id string) bool {
+	return id == r.ConfigID || strings.HasPrefix(id, r.ConfigID+"-shard-")
+}
+
+// getConfigShards returns all existing records that hold configuration of this reconciler,
+// including the ones left over from a different shard count
+func (r *DataBrokerReconciler) getConfigShards(ctx context.Context) (map[string]*pb.Config, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := r.SyncLatest(ctx, &databroker.SyncLatestRequest{
+		Type: protoutil.NewAny(new(pb.Config)).GetTypeUrl(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sync latest config records: %w", err)
+	}
+
+	out := make(map[string]*pb.Config)
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("receive config record: %w", err)
+		}
+
+		record := msg.GetRecord()
+		if record == nil || record.GetDeletedAt() != nil || !r.isConfigRecordID(record.GetId()) {
+			continue
+		}
+		cfg := new(pb.Config)
+		if err := record.GetData().UnmarshalTo(cfg); err != nil {
+			return nil, fmt.Errorf("unmarshal config record %s: %w", record.GetId(), err)
+		}
+		out[record.GetId()] = cfg
+	}
+	return out, nil
+}
+
+// saveShards replaces the prev configuration records of this reconciler with the next ones.
+// Changed records are written and stale ones are deleted within a single request,
+// so that Pomerium never observes a mix of old and new shards, and a failed request changes nothing.
+// Only shards that changed are written, so the request stays small,
+// except when most shards change at once, as in the first sync after the shard count was changed.
+// If versions is set, the records must still be at the versions prev was read at, see checkVersions.
+func (r *DataBrokerReconciler) saveShards(ctx context.Context, prev, next map[string]*pb.Config, versions map[string]uint64) (bool, error) {
+	var records []*databroker.Record
+	for _, id := range slices.Sorted(maps.Keys(next)) {
+		cfg := next[id]
+		if err := r.prepareConfig(ctx, cfg, id); err != nil {
+			return false, err
+		}
+		old, ok := prev[id]
+		if !ok {
+			old = new(pb.Config)
+		}
+		if proto.Equal(old, cfg) {
+			r.applied(ctx, id, old, cfg)
+			continue
+		}
+		if r.DebugDumpConfigDiff {
+			log.FromContext(ctx).Info("config diff", "id", id, "diff", debugDumpConfigDiff(old, cfg))
+		}
+		data := protoutil.NewAny(cfg)
+		records = append(records, &databroker.Record{
+			Type: data.GetTypeUrl(),
+			Id:   id,
+			Data: data,
+		})
+	}
+	for _, id := range slices.Sorted(maps.Keys(prev)) {
+		if _, ok := next[id]; !ok {
+			records = append(records, deletedConfigRecord(id))
+		}
+	}
+
+	logger := log.FromContext(ctx)
+	if len(records) == 0 {
+		logger.V(1).Info("no changes in the config")
+		return false, nil
+	}
+
+	if err := r.checkVersions(ctx, versions); err != nil {
+		return false, err
+	}
+	if _, err := r.Put(ctx, &databroker.PutRequest{Records: records}); err != nil {
+		// the outcome is unknown, so the index is reloaded on the next update
+		r.index = nil
+		return false, err
+	}
+	for _, record := range records {
+		// a deleted record has no next config
+		r.applied(ctx, record.GetId(), prev[record.GetId()], next[record.GetId()])
+		if r.index != nil {
+			r.index.set(record.GetId(), next[record.GetId()])
+		}
+	}
+	logger.Info("new pomerium config applied", "records", len(records))
+	return true, nil
+}
+
+func deletedConfigRecord(id string) *databroker.Record {
+	data := protoutil.NewAny(&pb.Config{})
+	return &databroker.Record{
+		Type:      data.GetTypeUrl(),
+		Id:        id,
+		Data:      data,
+		DeletedAt: timestamppb.Now(),
+	}
+}
+
+// shardIndex tracks which shards hold routes of each Ingress,
+// so that an Ingress change does not have to read all shards to find its previous routes.
+// It is loaded from the databroker when first needed, and kept up to date as shards are saved.
+// It is reloaded if another writer changed the records, as detected by their versions.
+type shardIndex struct {
+	// shards holds the ids of shards with routes of each ingress
+	shards map[types.NamespacedName]map[string]struct{}
+	// ingresses holds the ingresses with routes in each shard
+	ingresses map[string]map[types.NamespacedName]struct{}
+}
+
+func newShardIndex(shards map[string]*pb.Config) *shardIndex {
+	idx := &shardIndex{
+		shards:    make(map[types.NamespacedName]map[string]struct{}),
+		ingresses: make(map[string]map[types.NamespacedName]struct{}),
+	}
+	for id, cfg := range shards {
+		idx.set(id, cfg)
+	}
+	return idx
+}
+
+// set records the ingresses with routes in the shard, a nil config removes the shard
+func (idx *shardIndex) set(id string, cfg *pb.Config) {
+	for name := range idx.ingresses[id] {
+		delete(idx.shards[name], id)
+		if len(idx.shards[name]) == 0 {
+			delete(idx.shards, name)
+		}
+	}
+	delete(idx.ingresses, id)
+
+	for _, route := range cfg.GetRoutes() {
+		var key routeID
+		if err := key.Unmarshal(route.GetId()); err != nil {
+			continue
+		}
+		name := types.NamespacedName{Namespace: key.Namespace, Name: key.Name}
+		if idx.shards[name] == nil {
+			idx.shards[name] = make(map[string]struct{})
+		}
+		idx.shards[name][id] = struct{}{}
+		if idx.ingresses[id] == nil {
+			idx.ingresses[id] = make(map[types.NamespacedName]struct{})
+		}
+		idx.ingresses[id][name] = struct{}{}
+	}
+}
@@ -0,0 +1,349 @@
+package pomerium
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	pb "github.com/pomerium/pomerium/pkg/grpc/config"
+	"github.com/pomerium/pomerium/pkg/grpc/databroker"
+
+	"github.com/pomerium/ingress-controller/internal/testutil"
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+func TestHostShardID(t *testing.T) {
+	r := &DataBrokerReconciler{ConfigID: IngressControllerConfigID, Shards: 4}
+	ids := make(map[string]bool)
+	for _, host := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		id := r.hostShardID("https://" + host + ".example.com")
+		assert.Equal(t, id, r.hostShardID("https://"+host+".example.com:8443"), "host should map to a single shard")
+		assert.True(t, r.isConfigRecordID(id))
+		ids[id] = true
+	}
+	assert.Greater(t, len(ids), 1)
+	assert.LessOrEqual(t, len(ids), 4)
+
+	assert.True(t, r.isConfigRecordID(IngressControllerConfigID))
+	assert.False(t, r.isConfigRecordID(GatewayControllerConfigID))
+
+	r.Shards = ShardPerHost
+	one := r.hostShardID("https://a.example.com")
+	assert.Equal(t, one, r.hostShardID("https://a.example.com:8443"))
+	assert.NotEqual(t, one, r.hostShardID("https://b.example.com"))
+	assert.True(t, r.isConfigRecordID(one))
+}
+
+// putCountingClient records the number of records written by each Put request and the ids of records read,
+// and fails Put requests while err is set
+type putCountingClient struct {
+	databroker.DataBrokerServiceClient
+	puts []int
+	gets []string
+	err  error
+}
+
+func (c *putCountingClient) Get(ctx context.Context, req *databroker.GetRequest, opts ...grpc.CallOption) (*databroker.GetResponse, error) {
+	c.gets = append(c.gets, req.GetId())
+	return c.DataBrokerServiceClient.Get(ctx, req, opts...)
+}
+
+func (c *putCountingClient) Put(ctx context.Context, req *databroker.PutRequest, opts ...grpc.CallOption) (*databroker.PutResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.puts = append(c.puts, len(req.GetRecords()))
+	return c.DataBrokerServiceClient.Put(ctx, req, opts...)
+}
+
+func newTestShardIngress(namespace, host, path string) *model.IngressConfig {
+	prefix := networkingv1.PathTypePrefix
+	return &model.IngressConfig{
+		AnnotationPrefix: "p",
+		Ingress: &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: namespace, UID: types.UID(namespace)},
+			Spec: networkingv1.IngressSpec{
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     path,
+								PathType: &prefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "service",
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		},
+		Services: map[types.NamespacedName]*corev1.Service{
+			{Name: "service", Namespace: namespace}: {
+				ObjectMeta: metav1.ObjectMeta{Name: "service", Namespace: namespace},
+			},
+		},
+	}
+}
+
+func TestHostShards(t *testing.T) {
+	ctx := t.Context()
+	client := &putCountingClient{DataBrokerServiceClient: testutil.NewInMemoryDataBroker(t)}
+	r := &DataBrokerReconciler{
+		ConfigID:                IngressControllerConfigID,
+		DataBrokerServiceClient: client,
+		RemoveUnreferencedCerts: true,
+		Shards:                  8,
+	}
+
+	// routes of the same host from different namespaces are kept in one record, longest path first
+	ics := []*model.IngressConfig{
+		newTestShardIngress("root", "app.localhost.pomerium.io", "/"),
+		newTestShardIngress("api", "app.localhost.pomerium.io", "/api"),
+		newTestShardIngress("other", "other.localhost.pomerium.io", "/"),
+	}
+	changed, err := r.Set(ctx, ics)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	shards, err := r.getConfigShards(ctx)
+	require.NoError(t, err)
+	appShard := shards[r.hostShardID("https://app.localhost.pomerium.io")]
+	require.NotNil(t, appShard)
+	var prefixes []string
+	for _, route := range appShard.GetRoutes() {
+		if route.GetFrom() == "https://app.localhost.pomerium.io" {
+			prefixes = append(prefixes, route.GetPrefix())
+		}
+	}
+	assert.Equal(t, []string{"/api", "/"}, prefixes)
+	assert.Equal(t, 3, countRoutes(shards))
+	assert.Equal(t, []int{len(shards)}, client.puts, "all shards should be written at once")
+
+	// an ingress moving to another host is removed from its previous shard
+	changed, err = r.Upsert(ctx, newTestShardIngress("api", "new.localhost.pomerium.io", "/api"))
+	require.NoError(t, err)
+	assert.True(t, changed)
+	shards, err = r.getConfigShards(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, countRoutes(shards))
+	for _, route := range shards[r.hostShardID("https://app.localhost.pomerium.io")].GetRoutes() {
+		assert.NotEqual(t, "/api", route.GetPrefix())
+	}
+
+	changed, err = r.Delete(ctx, types.NamespacedName{Namespace: "api", Name: "ingress"})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	shards, err = r.getConfigShards(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, countRoutes(shards))
+}
+
+func TestHostShardsCutOver(t *testing.T) {
+	ctx := t.Context()
+	client := &putCountingClient{DataBrokerServiceClient: testutil.NewInMemoryDataBroker(t)}
+	r := &DataBrokerReconciler{
+		ConfigID:                IngressControllerConfigID,
+		DataBrokerServiceClient: client,
+		RemoveUnreferencedCerts: true,
+		Shards:                  8,
+	}
+
+	var ics []*model.IngressConfig
+	for _, ns := range []string{"a", "b", "c", "d", "e", "f"} {
+		ics = append(ics, newTestShardIngress(ns, ns+".localhost.pomerium.io", "/"))
+	}
+	_, err := r.Set(ctx, ics)
+	require.NoError(t, err)
+	before, err := r.getConfigShards(ctx)
+	require.NoError(t, err)
+
+	// a failed cut-over to another shard count leaves the previous shards in place
+	r.Shards = 3
+	client.err = errors.New("unavailable")
+	_, err = r.Set(ctx, ics)
+	require.Error(t, err)
+	shards, err := r.getConfigShards(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, len(before), len(shards))
+	assert.Equal(t, len(ics), countRoutes(shards), "routes should be neither duplicated nor lost")
+
+	// new shards are written and stale ones removed in one request
+	client.err, client.puts = nil, nil
+	changed, err := r.Set(ctx, ics)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Len(t, client.puts, 1)
+	shards, err = r.getConfigShards(ctx)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(shards), 3)
+	assert.Equal(t, len(ics), countRoutes(shards))
+	for id := range shards {
+		assert.Contains(t, []string{configShardID(r.ConfigID, 0), configShardID(r.ConfigID, 1), configShardID(r.ConfigID, 2)}, id)
+	}
+
+	// back to a single record
+	r.Shards = 0
+	_, err = r.Set(ctx, ics)
+	require.NoError(t, err)
+	shards, err = r.getConfigShards(ctx)
+	require.NoError(t, err)
+	assert.Len(t, shards, 1)
+	assert.Len(t, shards[IngressControllerConfigID].GetRoutes(), len(ics))
+}
+
+func TestShardPerHost(t *testing.T) {
+	ctx := t.Context()
+	client := &putCountingClient{DataBrokerServiceClient: testutil.NewInMemoryDataBroker(t)}
+	r := &DataBrokerReconciler{
+		ConfigID:                IngressControllerConfigID,
+		DataBrokerServiceClient: client,
+		RemoveUnreferencedCerts: true,
+		Shards:                  ShardPerHost,
+	}
+
+	var ics []*model.IngressConfig
+	for _, ns := range []string{"a", "b", "c", "d"} {
+		ics = append(ics, newTestShardIngress(ns, ns+".localhost.pomerium.io", "/"))
+	}
+	_, err := r.Set(ctx, ics)
+	require.NoError(t, err)
+	shards, err := r.getConfigShards(ctx)
+	require.NoError(t, err)
+	assert.Len(t, shards, len(ics))
+
+	// an ingress sharing a host with another one is kept in the same record,
+	// so routes are ordered and conflicts are detected across both ingresses
+	client.gets = nil
+	ctx = util.WithBin[model.RouteConflict](ctx)
+	changed, err := r.Upsert(ctx, newTestShardIngress("api", "a.localhost.pomerium.io", "/"))
+	require.NoError(t, err)
+	assert.True(t, changed)
+	// the record is read, and its version checked again before it is saved
+	assert.Equal(t, []string{
+		r.hostShardID("https://a.localhost.pomerium.io"),
+		r.hostShardID("https://a.localhost.pomerium.io"),
+	}, client.gets, "only the record of the ingress host should be read")
+	assert.Len(t, util.Get[model.RouteConflict](ctx), 1)
+
+	shards, err = r.getConfigShards(ctx)
+	require.NoError(t, err)
+	assert.Len(t, shards, len(ics))
+	assert.Len(t, shards[r.hostShardID("https://a.localhost.pomerium.io")].GetRoutes(), 2)
+
+	// an ingress moving to another host is removed from the records of its previous host
+	client.gets = nil
+	_, err = r.Upsert(ctx, newTestShardIngress("api", "e.localhost.pomerium.io", "/"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		r.hostShardID("https://a.localhost.pomerium.io"),
+		r.hostShardID("https://e.localhost.pomerium.io"),
+		r.hostShardID("https://a.localhost.pomerium.io"),
+		r.hostShardID("https://e.localhost.pomerium.io"),
+	}, client.gets)
+	shards, err = r.getConfigShards(ctx)
+	require.NoError(t, err)
+	assert.Len(t, shards[r.hostShardID("https://a.localhost.pomerium.io")].GetRoutes(), 1)
+	assert.Len(t, shards[r.hostShardID("https://e.localhost.pomerium.io")].GetRoutes(), 1)
+
+	// the index is loaded from the databroker if the reconciler restarted
+	r = &DataBrokerReconciler{
+		ConfigID:                IngressControllerConfigID,
+		DataBrokerServiceClient: client,
+		RemoveUnreferencedCerts: true,
+		Shards:                  ShardPerHost,
+	}
+	changed, err = r.Delete(ctx, types.NamespacedName{Namespace: "api", Name: "ingress"})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	shards, err = r.getConfigShards(ctx)
+	require.NoError(t, err)
+	assert.Len(t, shards, len(ics), "a record without routes should be removed")
+	assert.NotContains(t, shards, r.hostShardID("https://e.localhost.pomerium.io"))
+}
+
+// conflictingClient lets another writer change a record right after it is first read
+type conflictingClient struct {
+	databroker.DataBrokerServiceClient
+	conflict func()
+}
+
+func (c *conflictingClient) Get(ctx context.Context, req *databroker.GetRequest, opts ...grpc.CallOption) (*databroker.GetResponse, error) {
+	resp, err := c.DataBrokerServiceClient.Get(ctx, req, opts...)
+	if c.conflict != nil {
+		conflict := c.conflict
+		c.conflict = nil
+		conflict()
+	}
+	return resp, err
+}
+
+func TestShardVersionConflict(t *testing.T) {
+	ctx := t.Context()
+	client := &conflictingClient{DataBrokerServiceClient: testutil.NewInMemoryDataBroker(t)}
+	r := &DataBrokerReconciler{
+		ConfigID:                IngressControllerConfigID,
+		DataBrokerServiceClient: client,
+		RemoveUnreferencedCerts: true,
+		Shards:                  ShardPerHost,
+	}
+	_, err := r.Set(ctx, []*model.IngressConfig{newTestShardIngress("a", "a.localhost.pomerium.io", "/")})
+	require.NoError(t, err)
+
+	// another writer adds a route to the record between the update reading and saving it
+	other := &DataBrokerReconciler{
+		ConfigID:                IngressControllerConfigID,
+		DataBrokerServiceClient: client.DataBrokerServiceClient,
+		RemoveUnreferencedCerts: true,
+		Shards:                  ShardPerHost,
+	}
+	client.conflict = func() {
+		_, err := other.Upsert(ctx, newTestShardIngress("other", "a.localhost.pomerium.io", "/other"))
+		assert.NoError(t, err)
+	}
+
+	changed, err := r.Upsert(ctx, newTestShardIngress("api", "a.localhost.pomerium.io", "/api"))
+	require.NoError(t, err)
+	assert.True(t, changed)
+	cfg, err := r.getConfig(ctx, r.hostShardID("https://a.localhost.pomerium.io"))
+	require.NoError(t, err)
+	assert.Len(t, cfg.GetRoutes(), 3, "the update should be applied again on top of the other writer's change")
+}
+
+func TestUpdateConfigSingleRecord(t *testing.T) {
+	ctx := t.Context()
+	client := &putCountingClient{DataBrokerServiceClient: testutil.NewInMemoryDataBroker(t)}
+	r := &DataBrokerReconciler{
+		ConfigID:                IngressControllerConfigID,
+		DataBrokerServiceClient: client,
+		RemoveUnreferencedCerts: true,
+	}
+
+	// the default mode reads and writes its record once, without leases or version checks
+	changed, err := r.Upsert(ctx, newTestShardIngress("a", "a.localhost.pomerium.io", "/"))
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{IngressControllerConfigID}, client.gets)
+	assert.Equal(t, []int{1}, client.puts)
+}
+
+func countRoutes(shards map[string]*pb.Config) int {
+	n := 0
+	for _, cfg := range shards {
+		n += len(cfg.GetRoutes())
+	}
+	return n
+}
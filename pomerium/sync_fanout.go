@@ -0,0 +1,320 @@
+package pomerium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+var (
+	_ = Reconciler((*FanOutReconciler)(nil))
+	_ = DriftScanner((*FanOutReconciler)(nil))
+)
+
+// errOutOfSync is reported for targets that are skipped while they are out of sync
+var errOutOfSync = errors.New("waiting for the target to be resynced")
+
+// FanOutTarget is a single Pomerium deployment the FanOutReconciler syncs configuration to
+type FanOutTarget struct {
+	// Name identifies the target in logs and status messages
+	Name string
+	// Timeout bounds each update of the target, if set,
+	// so that a slow target is marked out of sync instead of delaying the other targets
+	Timeout time.Duration
+	Reconciler
+}
+
+type fanOutTarget struct {
+	FanOutTarget
+	// outOfSync is set once an update to the target fails,
+	// and cleared once the complete desired state is applied to it again
+	outOfSync bool
+}
+
+// call calls fn with the target timeout
+func (t *fanOutTarget) call(ctx context.Context, fn func(context.Context, Reconciler) (bool, error)) (bool, error) {
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+	return fn(ctx, t.Reconciler)
+}
+
+// fanOutState is the desired state applied to the targets
+type fanOutState struct {
+	ingresses map[types.NamespacedName]*model.IngressConfig
+	gateway   *model.GatewayConfig
+	config    *model.Config
+}
+
+// FanOutReconciler applies the same configuration to several Pomerium targets,
+// i.e. a primary and a disaster recovery deployment.
+// A failure of some of the targets is not treated as an error:
+// it is reported as a model.TargetFailure in the request context,
+// and failed targets are resynced with the complete desired state independently of each other.
+// Changes are applied to the targets concurrently, while out of sync targets are skipped until they are resynced.
+// Unlike other reconcilers, it is safe for concurrent use, as failed targets are retried in the background.
+type FanOutReconciler struct {
+	targets       []*fanOutTarget
+	retryInterval time.Duration
+
+	mu sync.Mutex
+	// desired is the state that was applied to at least one target
+	desired fanOutState
+	// version is incremented on every change of the desired state
+	version uint64
+}
+
+// NewFanOutReconciler returns a reconciler that applies changes to all targets,
+// and retries failed targets every retryInterval once it is started.
+func NewFanOutReconciler(retryInterval time.Duration, targets ...FanOutTarget) *FanOutReconciler {
+	r := &FanOutReconciler{
+		retryInterval: retryInterval,
+		desired:       fanOutState{ingresses: make(map[types.NamespacedName]*model.IngressConfig)},
+	}
+	for _, t := range targets {
+		r.targets = append(r.targets, &fanOutTarget{FanOutTarget: t})
+	}
+	return r
+}
+
+// SetK8sClient sets the Kubernetes API client for the targets that need it
+func (r *FanOutReconciler) SetK8sClient(k8sClient client.Client) {
+	for _, t := range r.targets {
+		if ar, ok := t.Reconciler.(interface{ SetK8sClient(client client.Client) }); ok {
+			ar.SetK8sClient(k8sClient)
+		}
+	}
+}
+
+// Upsert should update or create the pomerium routes corresponding to this ingress
+func (r *FanOutReconciler) Upsert(ctx context.Context, ic *model.IngressConfig) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.apply(ctx, func(ctx context.Context, t Reconciler) (bool, error) {
+		return t.Upsert(ctx, ic)
+	}, func() {
+		r.desired.ingresses[ic.GetIngressNamespacedName()] = ic
+	})
+}
+
+// Set configuration to match provided ingresses
+func (r *FanOutReconciler) Set(ctx context.Context, ics []*model.IngressConfig) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.apply(ctx, func(ctx context.Context, t Reconciler) (bool, error) {
+		return t.Set(ctx, ics)
+	}, func() {
+		r.desired.ingresses = make(map[types.NamespacedName]*model.IngressConfig, len(ics))
+		for _, ic := range ics {
+			r.desired.ingresses[ic.GetIngressNamespacedName()] = ic
+		}
+	})
+}
+
+// Delete should delete pomerium routes corresponding to this ingress name
+func (r *FanOutReconciler) Delete(ctx context.Context, namespacedName types.NamespacedName) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.apply(ctx, func(ctx context.Context, t Reconciler) (bool, error) {
+		return t.Delete(ctx, namespacedName)
+	}, func() {
+		delete(r.desired.ingresses, namespacedName)
+	})
+}
+
+// SetGatewayConfig applies Gateway-defined configuration.
+func (r *FanOutReconciler) SetGatewayConfig(ctx context.Context, config *model.GatewayConfig) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.apply(ctx, func(ctx context.Context, t Reconciler) (bool, error) {
+		return t.SetGatewayConfig(ctx, config)
+	}, func() {
+		r.desired.gateway = config
+	})
+}
+
+// SetConfig updates just the shared config settings
+func (r *FanOutReconciler) SetConfig(ctx context.Context, cfg *model.Config) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.apply(ctx, func(ctx context.Context, t Reconciler) (bool, error) {
+		return t.SetConfig(ctx, cfg)
+	}, func() {
+		r.desired.config = cfg
+	})
+}
+
+// apply calls fn for every in sync target concurrently, and records the change in the desired state with update
+// if at least one target accepted it. An error is only returned if all targets failed.
+func (r *FanOutReconciler) apply(
+	ctx context.Context,
+	fn func(context.Context, Reconciler) (bool, error),
+	update func(),
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	changes := make([]bool, len(r.targets))
+	errs := make([]error, len(r.targets))
+	var wg sync.WaitGroup
+	for i, t := range r.targets {
+		if t.outOfSync {
+			// the change is applied along with the complete desired state once the target is resynced
+			errs[i] = errOutOfSync
+			continue
+		}
+		wg.Go(func() {
+			changes[i], errs[i] = t.call(ctx, fn)
+		})
+	}
+	wg.Wait()
+
+	var anyChanges bool
+	var failures []model.TargetFailure
+	for i, t := range r.targets {
+		if errs[i] != nil {
+			failures = append(failures, model.TargetFailure{Target: t.Name, Err: errs[i]})
+			if !t.outOfSync {
+				logger.Error(errs[i], "target is out of sync", "target", t.Name)
+			}
+			t.outOfSync = true
+			continue
+		}
+		anyChanges = anyChanges || changes[i]
+	}
+
+	if len(failures) == len(r.targets) {
+		joined := make([]error, 0, len(failures))
+		for _, f := range failures {
+			joined = append(joined, fmt.Errorf("%s: %w", f.Target, f.Err))
+		}
+		return anyChanges, errors.Join(joined...)
+	}
+
+	update()
+	r.version++
+	util.Add(ctx, failures...)
+	return anyChanges, nil
+}
+
+// ScanDrift scans every in sync target that supports drift detection concurrently
+func (r *FanOutReconciler) ScanDrift(ctx context.Context, prune bool) ([]Drift, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	drifts := make([][]Drift, len(r.targets))
+	errs := make([]error, len(r.targets))
+	var wg sync.WaitGroup
+	for i, t := range r.targets {
+		ds, ok := t.Reconciler.(DriftScanner)
+		if !ok || t.outOfSync {
+			// out of sync targets are resynced with the complete desired state anyway
+			continue
+		}
+		wg.Go(func() {
+			var err error
+			if drifts[i], err = ds.ScanDrift(ctx, prune); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", t.Name, err)
+			}
+		})
+	}
+	wg.Wait()
+	return slices.Concat(drifts...), errors.Join(errs...)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable,
+// as only the leader should modify Pomerium configuration
+func (r *FanOutReconciler) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable, and retries out of sync targets until the context is canceled
+func (r *FanOutReconciler) Start(ctx context.Context) error {
+	return (&util.Periodic{Interval: r.retryInterval, Run: r.retry}).Start(ctx)
+}
+
+// retry resyncs the out of sync targets concurrently, without blocking changes to the in sync targets.
+// A target stays out of sync if the desired state changed while it was resynced, and is resynced again on the next retry.
+func (r *FanOutReconciler) retry(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	r.mu.Lock()
+	var targets []*fanOutTarget
+	for _, t := range r.targets {
+		if t.outOfSync {
+			targets = append(targets, t)
+		}
+	}
+	desired := r.desired
+	desired.ingresses = maps.Clone(r.desired.ingresses)
+	version := r.version
+	r.mu.Unlock()
+
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Go(func() {
+			errs[i] = desired.resync(ctx, t)
+		})
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, t := range targets {
+		switch {
+		case errs[i] != nil:
+			logger.Error(errs[i], "retry out of sync target", "target", t.Name)
+		case r.version != version:
+			logger.Info("desired state changed while resyncing target, retrying", "target", t.Name)
+		default:
+			t.outOfSync = false
+			logger.Info("target is back in sync", "target", t.Name)
+		}
+	}
+}
+
+// resync applies the complete desired state to the target
+func (s *fanOutState) resync(ctx context.Context, t *fanOutTarget) error {
+	if s.config != nil {
+		if _, err := t.call(ctx, func(ctx context.Context, t Reconciler) (bool, error) {
+			return t.SetConfig(ctx, s.config)
+		}); err != nil {
+			return fmt.Errorf("settings: %w", err)
+		}
+	}
+	ics := make([]*model.IngressConfig, 0, len(s.ingresses))
+	for _, name := range slices.SortedFunc(maps.Keys(s.ingresses), compareNamespacedNames) {
+		ics = append(ics, s.ingresses[name])
+	}
+	if _, err := t.call(ctx, func(ctx context.Context, t Reconciler) (bool, error) {
+		return t.Set(ctx, ics)
+	}); err != nil {
+		return fmt.Errorf("ingresses: %w", err)
+	}
+	if s.gateway != nil {
+		if _, err := t.call(ctx, func(ctx context.Context, t Reconciler) (bool, error) {
+			return t.SetGatewayConfig(ctx, s.gateway)
+		}); err != nil {
+			return fmt.Errorf("gateway: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package pomerium
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+type fakeTargetReconciler struct {
+	err       error
+	ingresses map[types.NamespacedName]bool
+	config    *model.Config
+}
+
+func newFakeTargetReconciler() *fakeTargetReconciler {
+	return &fakeTargetReconciler{ingresses: make(map[types.NamespacedName]bool)}
+}
+
+func (r *fakeTargetReconciler) Upsert(_ context.Context, ic *model.IngressConfig) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	r.ingresses[ic.GetIngressNamespacedName()] = true
+	return true, nil
+}
+
+func (r *fakeTargetReconciler) Set(_ context.Context, ics []*model.IngressConfig) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	clear(r.ingresses)
+	for _, ic := range ics {
+		r.ingresses[ic.GetIngressNamespacedName()] = true
+	}
+	return true, nil
+}
+
+func (r *fakeTargetReconciler) Delete(_ context.Context, name types.NamespacedName) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	delete(r.ingresses, name)
+	return true, nil
+}
+
+func (r *fakeTargetReconciler) SetGatewayConfig(_ context.Context, _ *model.GatewayConfig) (bool, error) {
+	return false, r.err
+}
+
+func (r *fakeTargetReconciler) SetConfig(_ context.Context, cfg *model.Config) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	r.config = cfg
+	return true, nil
+}
+
+// slowTargetReconciler blocks every update until the context is done
+type slowTargetReconciler struct {
+	*fakeTargetReconciler
+}
+
+func (r slowTargetReconciler) Upsert(ctx context.Context, _ *model.IngressConfig) (bool, error) {
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func TestFanOutReconciler(t *testing.T) {
+	newIngressConfig := func(name string) *model.IngressConfig {
+		return &model.IngressConfig{Ingress: &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		}}
+	}
+
+	primary, dr := newFakeTargetReconciler(), newFakeTargetReconciler()
+	r := NewFanOutReconciler(0,
+		FanOutTarget{Name: "primary", Reconciler: primary},
+		FanOutTarget{Name: "dr", Reconciler: dr},
+	)
+
+	t.Run("partial failure", func(t *testing.T) {
+		dr.err = errors.New("unavailable")
+		ctx := util.WithBin[model.TargetFailure](t.Context())
+
+		changed, err := r.Upsert(ctx, newIngressConfig("a"))
+		require.NoError(t, err)
+		assert.True(t, changed)
+		assert.True(t, primary.ingresses[types.NamespacedName{Namespace: "default", Name: "a"}])
+		if failures := util.Get[model.TargetFailure](ctx); assert.Len(t, failures, 1) {
+			assert.Equal(t, "dr", failures[0].Target)
+			assert.ErrorIs(t, failures[0].Err, dr.err)
+		}
+
+		_, err = r.SetConfig(ctx, new(model.Config))
+		require.NoError(t, err)
+		if failures := util.Get[model.TargetFailure](ctx); assert.Len(t, failures, 2) {
+			assert.ErrorIs(t, failures[1].Err, errOutOfSync, "out of sync target should be skipped")
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		r.retry(t.Context())
+		assert.Empty(t, dr.ingresses, "target should be retried until it recovers")
+
+		dr.err = nil
+		r.retry(t.Context())
+		assert.Equal(t, primary.ingresses, dr.ingresses)
+		assert.Same(t, primary.config, dr.config)
+		assert.False(t, r.targets[1].outOfSync)
+	})
+
+	t.Run("all targets failed", func(t *testing.T) {
+		primary.err, dr.err = errors.New("primary unavailable"), errors.New("dr unavailable")
+		ctx := t.Context()
+
+		_, err := r.Delete(ctx, types.NamespacedName{Namespace: "default", Name: "a"})
+		assert.ErrorIs(t, err, primary.err)
+		assert.ErrorIs(t, err, dr.err)
+		assert.Len(t, r.desired.ingresses, 1, "desired state should not change if no target accepted the change")
+	})
+
+	t.Run("slow target", func(t *testing.T) {
+		primary, slow := newFakeTargetReconciler(), newFakeTargetReconciler()
+		r := NewFanOutReconciler(0,
+			FanOutTarget{Name: "primary", Reconciler: primary},
+			FanOutTarget{Name: "slow", Timeout: 10 * time.Millisecond, Reconciler: slowTargetReconciler{slow}},
+		)
+		ctx := util.WithBin[model.TargetFailure](t.Context())
+
+		_, err := r.Upsert(ctx, newIngressConfig("a"))
+		require.NoError(t, err)
+		assert.True(t, primary.ingresses[types.NamespacedName{Namespace: "default", Name: "a"}])
+		if failures := util.Get[model.TargetFailure](ctx); assert.Len(t, failures, 1) {
+			assert.ErrorIs(t, failures[0].Err, context.DeadlineExceeded)
+		}
+		assert.True(t, r.targets[1].outOfSync)
+
+		// the slow target is resynced with Set, that does not block
+		r.retry(t.Context())
+		assert.False(t, r.targets[1].outOfSync)
+		assert.Equal(t, primary.ingresses, slow.ingresses)
+	})
+}
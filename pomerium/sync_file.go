@@ -0,0 +1,324 @@
+package pomerium
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pomerium/pomerium/config"
+	pb "github.com/pomerium/pomerium/pkg/grpc/config"
+
+	"github.com/pomerium/ingress-controller/model"
+	"github.com/pomerium/ingress-controller/util"
+)
+
+// fileConfigRecordID identifies the written configuration in the config debugger
+const fileConfigRecordID = "config.yaml"
+
+var (
+	_ = IngressReconciler((*FileReconciler)(nil))
+	_ = GatewayReconciler((*FileReconciler)(nil))
+	_ = ConfigReconciler((*FileReconciler)(nil))
+)
+
+// FileReconciler renders Ingress, Gateway and settings configuration into a Pomerium config.yaml,
+// for Pomerium deployments that use file-based configuration instead of a databroker.
+// Unlike other reconcilers, it is safe for concurrent use,
+// as all controllers contribute to the same file. The file is first written once the settings are set.
+type FileReconciler struct {
+	writer      ConfigWriter
+	baseOptions *config.Options
+
+	mu        sync.Mutex
+	ingresses map[types.NamespacedName]*pb.Config
+	gateway   *pb.Config
+	settings  *pb.Config
+	// priorities of the Ingress and Gateway routes, by the config part they belong to
+	ingressPriorities map[types.NamespacedName]routePriorities
+	gatewayPriorities routePriorities
+	// last is the last configuration written
+	last []byte
+	// lastConfig is the merged configuration last written, before it was rendered
+	lastConfig *pb.Config
+	// debugger if set, keeps the written configuration and recent changes for inspection
+	debugger *ConfigDebugger
+}
+
+// FileReconcilerOption customizes the reconcilers created by NewFileReconciler
+type FileReconcilerOption func(*FileReconciler)
+
+// WithFileConfigDebugger records the written configuration and its changes in the debugger
+func WithFileConfigDebugger(debugger *ConfigDebugger) FileReconcilerOption {
+	return func(r *FileReconciler) {
+		r.debugger = debugger
+	}
+}
+
+// NewFileReconciler returns a reconciler that writes Pomerium configuration with the writer,
+// layering it on top of baseOptions.
+func NewFileReconciler(writer ConfigWriter, baseOptions *config.Options, opts ...FileReconcilerOption) *FileReconciler {
+	r := &FileReconciler{
+		writer:            writer,
+		baseOptions:       baseOptions,
+		ingresses:         make(map[types.NamespacedName]*pb.Config),
+		ingressPriorities: make(map[types.NamespacedName]routePriorities),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// SetK8sClient sets the Kubernetes API client, if the writer stores configuration in a Kubernetes object
+func (r *FileReconciler) SetK8sClient(k8sClient client.Client) {
+	if w, ok := r.writer.(interface{ SetK8sClient(client client.Client) }); ok {
+		w.SetK8sClient(k8sClient)
+	}
+}
+
+// Upsert should update or create the pomerium routes corresponding to this ingress
+func (r *FileReconciler) Upsert(ctx context.Context, ic *model.IngressConfig) (bool, error) {
+	ctx = withConfigTrigger(ctx, "Ingress %s", ic.GetIngressNamespacedName())
+	cfg, priorities, err := ingressToConfig(ctx, ic)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ingresses[ic.GetIngressNamespacedName()] = cfg
+	r.ingressPriorities[ic.GetIngressNamespacedName()] = priorities
+	return r.write(ctx)
+}
+
+// Set configuration to match provided ingresses
+func (r *FileReconciler) Set(ctx context.Context, ics []*model.IngressConfig) (bool, error) {
+	ctx = withConfigTrigger(ctx, "sync of %d Ingresses", len(ics))
+	logger := log.FromContext(ctx)
+
+	ingresses := make(map[types.NamespacedName]*pb.Config, len(ics))
+	ingressPriorities := make(map[types.NamespacedName]routePriorities, len(ics))
+	for _, ic := range ics {
+		cfg, priorities, err := ingressToConfig(ctx, ic)
+		if err != nil {
+			logger.Error(err, "skip ingress", "ingress", fmt.Sprintf("%s/%s", ic.Namespace, ic.Name))
+			continue
+		}
+		ingresses[ic.GetIngressNamespacedName()] = cfg
+		ingressPriorities[ic.GetIngressNamespacedName()] = priorities
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ingresses = ingresses
+	r.ingressPriorities = ingressPriorities
+	return r.write(ctx)
+}
+
+// Delete should delete pomerium routes corresponding to this ingress name
+func (r *FileReconciler) Delete(ctx context.Context, namespacedName types.NamespacedName) (bool, error) {
+	ctx = withConfigTrigger(ctx, "Ingress %s deleted", namespacedName)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.ingresses[namespacedName]; !ok {
+		return false, nil
+	}
+	delete(r.ingresses, namespacedName)
+	delete(r.ingressPriorities, namespacedName)
+	return r.write(ctx)
+}
+
+// SetGatewayConfig applies Gateway-defined configuration.
+func (r *FileReconciler) SetGatewayConfig(ctx context.Context, gatewayConfig *model.GatewayConfig) (bool, error) {
+	ctx = withConfigTrigger(ctx, "Gateway config with %d HTTPRoutes", len(gatewayConfig.Routes))
+	if r.debugger != nil {
+		ctx = util.WithBin[routeSource](ctx)
+	}
+	cfg, priorities := gatewayToConfig(ctx, gatewayConfig)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.gateway = cfg
+	r.gatewayPriorities = priorities
+	return r.write(ctx)
+}
+
+// SetConfig updates just the shared config settings
+func (r *FileReconciler) SetConfig(ctx context.Context, cfg *model.Config) (bool, error) {
+	ctx = withConfigTrigger(ctx, "Pomerium %s", cfg.Name)
+	next := new(pb.Config)
+	if err := ApplyConfig(ctx, next, cfg); err != nil {
+		return false, fmt.Errorf("settings: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settings = next
+	return r.write(ctx)
+}
+
+// write renders the merged configuration and writes it, if it changed.
+// Nothing is written until the settings were set, as Pomerium watching the file
+// would otherwise pick up routes without the identity provider and authenticate service settings.
+func (r *FileReconciler) write(ctx context.Context) (bool, error) {
+	if r.settings == nil {
+		log.FromContext(ctx).V(1).Info("not writing config before the settings are set")
+		return false, nil
+	}
+
+	merged, data, err := r.render(ctx)
+	if err != nil {
+		return false, fmt.Errorf("render config: %w", err)
+	}
+	if bytes.Equal(data, r.last) {
+		log.FromContext(ctx).V(1).Info("no changes in the config")
+		return false, nil
+	}
+	if err := r.writer.WriteConfig(ctx, data); err != nil {
+		return false, fmt.Errorf("write config: %w", err)
+	}
+	r.last = data
+	r.debugger.observe(ctx, fileConfigRecordID, r.lastConfig, merged)
+	r.lastConfig = merged
+	log.FromContext(ctx).Info("new pomerium config written")
+	return true, nil
+}
+
+// render merges the Ingress, Gateway and settings configuration into a Pomerium config.yaml,
+// and returns the merged configuration along with it
+func (r *FileReconciler) render(ctx context.Context) (*pb.Config, []byte, error) {
+	merged := &pb.Config{Settings: new(pb.Settings)}
+	if r.settings != nil {
+		proto.Merge(merged, r.settings)
+	}
+	parts := make([]*pb.Config, 0, len(r.ingresses)+1)
+	for _, name := range slices.SortedFunc(maps.Keys(r.ingresses), compareNamespacedNames) {
+		parts = append(parts, r.ingresses[name])
+	}
+	if r.gateway != nil {
+		parts = append(parts, r.gateway)
+	}
+	for _, part := range parts {
+		merged.Routes = append(merged.Routes, part.GetRoutes()...)
+		merged.Settings.Certificates = append(merged.Settings.Certificates, part.GetSettings().GetCertificates()...)
+	}
+	priorities := make(routePriorities)
+	maps.Copy(priorities, r.gatewayPriorities)
+	for _, p := range r.ingressPriorities {
+		maps.Copy(priorities, p)
+	}
+	ensureDeterministicConfigOrder(merged, priorities)
+
+	opts := *r.baseOptions
+	opts.ApplySettings(ctx, nil, merged.Settings)
+	opts.Routes = make([]config.Policy, 0, len(merged.Routes))
+	for _, route := range merged.Routes {
+		policy, err := config.NewPolicyFromProto(route)
+		if err != nil {
+			return nil, nil, fmt.Errorf("route %s: %w", route.GetName(), err)
+		}
+		opts.Routes = append(opts.Routes, *policy)
+	}
+
+	data, err := encodeOptions(&opts)
+	return merged, data, err
+}
+
+// encodeOptions encodes the options in the config file format Pomerium loads them from.
+// The options are encoded by their yaml field names, that match the config file keys,
+// except for durations, that yaml encodes as nanoseconds and are written as duration strings instead,
+// and the certificate data, that only exists in parsed form and is written
+// as base64 encoded certificate and key pairs under the certificates key instead.
+func encodeOptions(opts *config.Options) ([]byte, error) {
+	certs := opts.CertificateData
+	opts.CertificateData = nil
+	data, err := yaml.Marshal(opts)
+	opts.CertificateData = certs
+	if err != nil {
+		return nil, err
+	}
+
+	var file map[string]any
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	encodeDurations(file, reflect.ValueOf(opts).Elem())
+	if len(certs) > 0 {
+		pairs, _ := file["certificates"].([]any)
+		for _, c := range certs {
+			pairs = append(pairs, map[string]string{
+				"cert": base64.StdEncoding.EncodeToString(c.CertBytes),
+				"key":  base64.StdEncoding.EncodeToString(c.KeyBytes),
+			})
+		}
+		file["certificates"] = pairs
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(file); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeDurations replaces the durations of the struct value encoded into file with their string form
+func encodeDurations(file map[string]any, v reflect.Value) {
+	durationType := reflect.TypeFor[time.Duration]()
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if field.Type != durationType {
+			continue
+		}
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if _, ok := file[key]; !ok || key == "" || key == "-" {
+			continue
+		}
+		file[key] = time.Duration(v.Field(i).Int()).String()
+	}
+}
+
+// ingressToConfig converts a single Ingress into routes and certificates,
+// and returns the priorities the routes are ordered by
+func ingressToConfig(ctx context.Context, ic *model.IngressConfig) (*pb.Config, routePriorities, error) {
+	cfg := new(pb.Config)
+	priorities := make(routePriorities)
+	if err := upsertRoutes(ctx, cfg, priorities, ic); err != nil {
+		return nil, nil, err
+	}
+	addCerts(cfg, ic.Secrets)
+	if err := removeUnusedCerts(cfg); err != nil {
+		return nil, nil, fmt.Errorf("removing unused certs: %w", err)
+	}
+	if err := validate(ctx, cfg, string(ic.Ingress.UID)); err != nil {
+		return nil, nil, fmt.Errorf("config validation: %w", err)
+	}
+	return cfg, priorities, nil
+}
+
+func compareNamespacedNames(a, b types.NamespacedName) int {
+	return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+}
@@ -0,0 +1,217 @@
+package pomerium
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/pomerium/pomerium/config"
+
+	icsv1 "github.com/pomerium/ingress-controller/apis/ingress/v1"
+	"github.com/pomerium/ingress-controller/model"
+)
+
+type memConfigWriter struct {
+	writes [][]byte
+}
+
+func (w *memConfigWriter) WriteConfig(_ context.Context, data []byte) error {
+	w.writes = append(w.writes, data)
+	return nil
+}
+
+func TestFileReconciler(t *testing.T) {
+	ctx := t.Context()
+	w := new(memConfigWriter)
+	r := NewFileReconciler(w, config.NewDefaultOptions())
+
+	ic := &model.IngressConfig{
+		AnnotationPrefix: "a",
+		Ingress: &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-ingress", Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: new("pomerium"),
+				Rules: []networkingv1.IngressRule{{
+					Host:             "a.localhost.pomerium.io",
+					IngressRuleValue: exampleIngressRuleValue,
+				}},
+			},
+		},
+		Services: map[types.NamespacedName]*corev1.Service{
+			{Name: "example-svc", Namespace: "test"}: {},
+		},
+	}
+
+	changed, err := r.Set(ctx, []*model.IngressConfig{ic})
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Empty(t, w.writes, "config should not be written before the settings are set")
+
+	changed, err = r.SetConfig(ctx, &model.Config{})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.Len(t, w.writes, 1)
+	assert.Contains(t, string(w.writes[0]), "https://a.localhost.pomerium.io")
+
+	changed, err = r.Upsert(ctx, ic)
+	require.NoError(t, err)
+	assert.False(t, changed, "unchanged config should not be written again")
+	assert.Len(t, w.writes, 1)
+
+	changed, err = r.Delete(ctx, ic.GetIngressNamespacedName())
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.Len(t, w.writes, 2)
+	assert.NotContains(t, string(w.writes[1]), "https://a.localhost.pomerium.io")
+}
+
+func TestFileReconcilerRoundTrip(t *testing.T) {
+	ctx := t.Context()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "a.localhost.pomerium.io"},
+		DNSNames:     []string{"a.localhost.pomerium.io"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	secretName := types.NamespacedName{Name: "a-tls", Namespace: "test"}
+	ic := &model.IngressConfig{
+		AnnotationPrefix: "a",
+		Ingress: &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-ingress", Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: new("pomerium"),
+				TLS: []networkingv1.IngressTLS{{
+					Hosts:      []string{"a.localhost.pomerium.io"},
+					SecretName: secretName.Name,
+				}},
+				Rules: []networkingv1.IngressRule{{
+					Host:             "a.localhost.pomerium.io",
+					IngressRuleValue: exampleIngressRuleValue,
+				}},
+			},
+		},
+		Secrets: map[types.NamespacedName]*corev1.Secret{
+			secretName: {
+				ObjectMeta: metav1.ObjectMeta{Name: secretName.Name, Namespace: secretName.Namespace},
+				Type:       corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+					corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+				},
+			},
+		},
+		Services: map[types.NamespacedName]*corev1.Service{
+			{Name: "example-svc", Namespace: "test"}: {},
+		},
+	}
+
+	w := new(memConfigWriter)
+	r := NewFileReconciler(w, config.NewDefaultOptions())
+	_, err = r.Set(ctx, []*model.IngressConfig{ic})
+	require.NoError(t, err)
+	settings := &model.Config{}
+	settings.Spec.Authenticate = &icsv1.Authenticate{URL: "https://authenticate.localhost.pomerium.io"}
+	settings.Spec.Timeouts = &icsv1.Timeouts{
+		Read:  &metav1.Duration{Duration: 45 * time.Second},
+		Write: &metav1.Duration{Duration: 2 * time.Minute},
+	}
+	_, err = r.SetConfig(ctx, settings)
+	require.NoError(t, err)
+	require.Len(t, w.writes, 1)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, w.writes[0], 0o600))
+	loaded, err := config.NewOptionsFromConfig(path)
+	require.NoError(t, err, string(w.writes[0]))
+
+	assert.Equal(t, 45*time.Second, loaded.ReadTimeout)
+	assert.Equal(t, 2*time.Minute, loaded.WriteTimeout)
+	assert.Equal(t, "https://authenticate.localhost.pomerium.io", loaded.AuthenticateURLString)
+
+	r.mu.Lock()
+	expected := r.ingresses[ic.GetIngressNamespacedName()].GetRoutes()
+	r.mu.Unlock()
+	routes := loaded.ToProto().GetRoutes()
+	require.Len(t, routes, len(expected))
+	for i, route := range expected {
+		assert.Equal(t, route.GetFrom(), routes[i].GetFrom())
+		assert.Equal(t, route.GetTo(), routes[i].GetTo())
+		assert.Equal(t, route.GetPrefix(), routes[i].GetPrefix())
+	}
+
+	certs, err := loaded.GetCertificates()
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+	assert.Equal(t, der, certs[0].Certificate[0])
+}
+
+func TestConfigWriters(t *testing.T) {
+	ctx := t.Context()
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		w := NewFileConfigWriter(path)
+		for _, data := range []string{"a: 1\n", "a: 2\n"} {
+			require.NoError(t, w.WriteConfig(ctx, []byte(data)))
+			got, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, data, string(got))
+		}
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary files should be cleaned up")
+	})
+
+	name := types.NamespacedName{Namespace: "pomerium", Name: "pomerium-config"}
+	t.Run("configmap", func(t *testing.T) {
+		k8sClient := fake.NewClientBuilder().Build()
+		w := NewConfigMapWriter(name)
+		w.(interface{ SetK8sClient(c client.Client) }).SetK8sClient(k8sClient)
+		for _, data := range []string{"a: 1\n", "a: 2\n"} {
+			require.NoError(t, w.WriteConfig(ctx, []byte(data)))
+			cm := new(corev1.ConfigMap)
+			require.NoError(t, k8sClient.Get(ctx, name, cm))
+			assert.Equal(t, data, cm.Data[ConfigFileKey])
+		}
+	})
+
+	t.Run("secret", func(t *testing.T) {
+		k8sClient := fake.NewClientBuilder().Build()
+		w := NewSecretWriter(name)
+		w.(interface{ SetK8sClient(c client.Client) }).SetK8sClient(k8sClient)
+		for _, data := range []string{"a: 1\n", "a: 2\n"} {
+			require.NoError(t, w.WriteConfig(ctx, []byte(data)))
+			secret := new(corev1.Secret)
+			require.NoError(t, k8sClient.Get(ctx, name, secret))
+			assert.Equal(t, data, string(secret.Data[ConfigFileKey]))
+		}
+	})
+}
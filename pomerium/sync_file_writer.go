@@ -0,0 +1,120 @@
+package pomerium
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConfigFileKey is the ConfigMap or Secret key the Pomerium configuration is stored under
+const ConfigFileKey = "config.yaml"
+
+// ConfigWriter stores a rendered Pomerium configuration file
+type ConfigWriter interface {
+	WriteConfig(ctx context.Context, data []byte) error
+}
+
+type fileConfigWriter struct {
+	path string
+}
+
+// NewFileConfigWriter writes the configuration to a file, replacing it atomically
+// so that Pomerium watching the file never reads a partial configuration.
+func NewFileConfigWriter(path string) ConfigWriter {
+	return &fileConfigWriter{path: path}
+}
+
+func (w *fileConfigWriter) WriteConfig(_ context.Context, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(w.path), "."+filepath.Base(w.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), w.path)
+}
+
+type objectConfigWriter struct {
+	name   types.NamespacedName
+	secret bool
+	client client.Client
+}
+
+// NewConfigMapWriter stores the configuration under the config.yaml key of a ConfigMap,
+// creating it if it does not exist
+func NewConfigMapWriter(name types.NamespacedName) ConfigWriter {
+	return &objectConfigWriter{name: name}
+}
+
+// NewSecretWriter stores the configuration under the config.yaml key of a Secret,
+// creating it if it does not exist. A Secret should be preferred,
+// as the configuration contains IdP credentials and private keys.
+func NewSecretWriter(name types.NamespacedName) ConfigWriter {
+	return &objectConfigWriter{name: name, secret: true}
+}
+
+// SetK8sClient sets the Kubernetes API client
+func (w *objectConfigWriter) SetK8sClient(client client.Client) {
+	w.client = client
+}
+
+func (w *objectConfigWriter) WriteConfig(ctx context.Context, data []byte) error {
+	if w.client == nil {
+		return fmt.Errorf("internal error - kubernetes client not set")
+	}
+
+	if w.secret {
+		secret := new(corev1.Secret)
+		err := w.client.Get(ctx, w.name, secret)
+		if apierrors.IsNotFound(err) {
+			secret.Name, secret.Namespace = w.name.Name, w.name.Namespace
+			secret.Data = map[string][]byte{ConfigFileKey: data}
+			return w.client.Create(ctx, secret)
+		} else if err != nil {
+			return fmt.Errorf("get secret %s: %w", w.name, err)
+		}
+		if bytes.Equal(secret.Data[ConfigFileKey], data) {
+			return nil
+		}
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		secret.Data[ConfigFileKey] = data
+		return w.client.Update(ctx, secret)
+	}
+
+	cm := new(corev1.ConfigMap)
+	err := w.client.Get(ctx, w.name, cm)
+	if apierrors.IsNotFound(err) {
+		cm.Name, cm.Namespace = w.name.Name, w.name.Namespace
+		cm.Data = map[string]string{ConfigFileKey: string(data)}
+		return w.client.Create(ctx, cm)
+	} else if err != nil {
+		return fmt.Errorf("get configmap %s: %w", w.name, err)
+	}
+	if cm.Data[ConfigFileKey] == string(data) {
+		return nil
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[ConfigFileKey] = string(data)
+	return w.client.Update(ctx, cm)
+}
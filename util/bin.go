@@ -1,10 +1,15 @@
 package util
 
-import "context"
+import (
+	"context"
+	"slices"
+	"sync"
+)
 
 type key[T any] struct{}
 
 type bin[T any] struct {
+	mu      sync.Mutex
 	entries []T
 }
 
@@ -20,13 +25,15 @@ func WithBin[T any](ctx context.Context) context.Context {
 	return context.WithValue(ctx, k, new(bin[T]))
 }
 
-// Add attaches an entry to the collector
+// Add attaches an entry to the collector. It is safe for concurrent use.
 func Add[T any](ctx context.Context, entries ...T) {
 	collector, ok := ctx.Value(key[T]{}).(*bin[T])
 	if !ok {
 		return
 	}
+	collector.mu.Lock()
 	collector.entries = append(collector.entries, entries...)
+	collector.mu.Unlock()
 }
 
 // Get returns all entries attached to the collector
@@ -35,5 +42,14 @@ func Get[T any](ctx context.Context) []T {
 	if !ok {
 		return nil
 	}
-	return collector.entries
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	return slices.Clone(collector.entries)
+}
+
+// Enabled returns true if a collector for T is attached to the context,
+// so that callers may skip computing entries that would be discarded
+func Enabled[T any](ctx context.Context) bool {
+	_, ok := ctx.Value(key[T]{}).(*bin[T])
+	return ok
 }
@@ -16,3 +16,9 @@ func TestBin(t *testing.T) {
 	util.Add(ctx, testType("test"))
 	require.Equal(t, []testType{"test"}, util.Get[testType](ctx))
 }
+
+func TestBinEnabled(t *testing.T) {
+	ctx := context.Background()
+	require.False(t, util.Enabled[testType](ctx))
+	require.True(t, util.Enabled[testType](util.WithBin[testType](ctx)))
+}
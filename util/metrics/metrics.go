@@ -0,0 +1,176 @@
+// Package metrics defines Prometheus metrics of the ingress controller,
+// that are served along with the controller-runtime metrics
+package metrics
+
+import (
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const namespace = "pomerium_ingress_controller"
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "Time spent reconciling Kubernetes objects with Pomerium configuration",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"kind"})
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_errors_total",
+		Help:      "Number of reconciliations that failed",
+	}, []string{"kind"})
+	backendRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "backend_request_duration_seconds",
+		Help:      "Latency of requests to the databroker or the unified API",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"backend", "method"})
+	backendRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backend_request_errors_total",
+		Help:      "Number of requests to the databroker or the unified API that failed",
+	}, []string{"backend", "method"})
+	managedObjects = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "managed_objects",
+		Help:      "Number of routes, policies and certificates managed by the ingress controller",
+	}, []string{"source", "type"})
+	configRecordSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "config_record_size_bytes",
+		Help:      "Size of the largest databroker configuration record, that must stay within gRPC message limits",
+	}, []string{"config"})
+	ingressesNotReconciled = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ingresses_not_reconciled",
+		Help:      "Number of Ingresses that could not be reconciled with Pomerium",
+	})
+	certificateExpiryDays = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "certificate_expiry_days",
+		Help:      "Days until the certificate served for a hostname expires, negative once it has expired",
+	}, []string{"hostname"})
+	certificateProblems = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "certificate_problems",
+		Help:      "Hostnames whose served certificate has expired, expires soon or does not match the hostname",
+	}, []string{"hostname", "problem"})
+	configDriftCorrected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_drift_corrected_total",
+		Help:      "Number of Pomerium configuration objects restored or pruned after being changed outside of Kubernetes",
+	}, []string{"type", "drift"})
+	configDriftScanErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "config_drift_scan_errors_total",
+		Help:      "Number of Pomerium configuration drift scans that failed",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileDuration,
+		reconcileErrors,
+		backendRequestDuration,
+		backendRequestErrors,
+		managedObjects,
+		configRecordSize,
+		ingressesNotReconciled,
+		certificateExpiryDays,
+		certificateProblems,
+		configDriftCorrected,
+		configDriftScanErrors,
+	)
+}
+
+// Object types reported by SetManagedObjects
+const (
+	ObjectRoute       = "route"
+	ObjectPolicy      = "policy"
+	ObjectCertificate = "certificate"
+)
+
+// ObserveReconcile records a reconciliation of the kind of object that started at start
+func ObserveReconcile(kind string, start time.Time, err error) {
+	reconcileDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+	if err != nil {
+		reconcileErrors.WithLabelValues(kind).Inc()
+	}
+}
+
+// ObserveBackendRequest records a databroker or unified API request that started at start
+func ObserveBackendRequest(backend, method string, start time.Time, err error) {
+	observeBackendRequest(backend, method, start, err != nil)
+}
+
+func observeBackendRequest(backend, method string, start time.Time, failed bool) {
+	backendRequestDuration.WithLabelValues(backend, method).Observe(time.Since(start).Seconds())
+	if failed {
+		backendRequestErrors.WithLabelValues(backend, method).Inc()
+	}
+}
+
+// SetManagedObjects sets the number of objects of a type, i.e. ObjectRoute, managed by the source reconciler
+func SetManagedObjects(source, typ string, n int) {
+	managedObjects.WithLabelValues(source, typ).Set(float64(n))
+}
+
+// SetConfigRecordSize sets the size of the largest record of a databroker configuration
+func SetConfigRecordSize(config string, size int) {
+	configRecordSize.WithLabelValues(config).Set(float64(size))
+}
+
+// SetIngressesNotReconciled sets the number of Ingresses that currently fail to reconcile
+func SetIngressesNotReconciled(n int) {
+	ingressesNotReconciled.Set(float64(n))
+}
+
+// SetCertificateExpiry replaces the expiry of the certificates served per hostname
+func SetCertificateExpiry(notAfter map[string]time.Time) {
+	certificateExpiryDays.Reset()
+	for hostname, t := range notAfter {
+		certificateExpiryDays.WithLabelValues(hostname).Set(time.Until(t).Hours() / 24)
+	}
+}
+
+// SetCertificateProblems replaces the problems, i.e. expired, of the certificates served per hostname
+func SetCertificateProblems(problems map[string]string) {
+	certificateProblems.Reset()
+	for hostname, problem := range problems {
+		certificateProblems.WithLabelValues(hostname, problem).Set(1)
+	}
+}
+
+// ObserveConfigDrift records a drift of an object of a type, i.e. route, that was corrected
+func ObserveConfigDrift(typ, drift string) {
+	configDriftCorrected.WithLabelValues(typ, drift).Inc()
+}
+
+// ObserveConfigDriftScanError records a drift scan that failed
+func ObserveConfigDriftScanError() {
+	configDriftScanErrors.Inc()
+}
+
+type roundTripper struct {
+	backend string
+	next    http.RoundTripper
+}
+
+// InstrumentRoundTripper records requests made by an HTTP client of the backend,
+// using the last URL path segment as the method, that is the RPC name for Connect and gRPC-Web clients
+func InstrumentRoundTripper(backend string, next http.RoundTripper) http.RoundTripper {
+	return &roundTripper{backend: backend, next: next}
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	observeBackendRequest(rt.backend, path.Base(req.URL.Path), start, err != nil || resp.StatusCode >= http.StatusBadRequest)
+	return resp, err
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstrumentRoundTripper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pomerium.config.v1.ConfigService/CreateRoute" {
+			http.Error(w, "invalid route", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &http.Client{Transport: InstrumentRoundTripper("test", http.DefaultTransport)}
+	for _, method := range []string{"ListRoutes", "CreateRoute"} {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, srv.URL+"/pomerium.config.v1.ConfigService/"+method, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err, "error responses should be returned as is")
+		_ = resp.Body.Close()
+	}
+
+	assert.Equal(t, 2, testutil.CollectAndCount(backendRequestDuration), "one series per method")
+	assert.Equal(t, 0.0, testutil.ToFloat64(backendRequestErrors.WithLabelValues("test", "ListRoutes")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(backendRequestErrors.WithLabelValues("test", "CreateRoute")))
+}
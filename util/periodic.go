@@ -0,0 +1,55 @@
+package util
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var _ = manager.LeaderElectionRunnable((*Periodic)(nil))
+
+// Periodic is a manager.Runnable that runs a task every interval until the context is canceled.
+// It only runs on the leader, as periodic tasks update the cluster or Pomerium configuration.
+type Periodic struct {
+	// Interval between the runs
+	Interval time.Duration
+	// RunOnStart runs the task once started, rather than after the first interval
+	RunOnStart bool
+	// Trigger, if set, is called once started, and the task also runs whenever the returned channel receives
+	Trigger func(context.Context) (<-chan struct{}, error)
+	// Run is the task
+	Run func(context.Context)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (p *Periodic) NeedLeaderElection() bool {
+	return true
+}
+
+// Start implements manager.Runnable
+func (p *Periodic) Start(ctx context.Context) error {
+	var trigger <-chan struct{}
+	if p.Trigger != nil {
+		var err error
+		if trigger, err = p.Trigger(ctx); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	if p.RunOnStart {
+		p.Run(ctx)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-trigger:
+		}
+		p.Run(ctx)
+	}
+}
@@ -0,0 +1,34 @@
+package util_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomerium/ingress-controller/util"
+)
+
+func TestPeriodic(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	trigger := make(chan struct{})
+	runs := make(chan struct{})
+	p := &util.Periodic{
+		Interval:   time.Hour,
+		RunOnStart: true,
+		Trigger:    func(context.Context) (<-chan struct{}, error) { return trigger, nil },
+		Run:        func(context.Context) { runs <- struct{}{} },
+	}
+	done := make(chan error)
+	go func() { done <- p.Start(ctx) }()
+
+	<-runs
+	trigger <- struct{}{}
+	<-runs
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -1,8 +1,12 @@
 package util
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
 	"fmt"
 
+	"golang.org/x/crypto/ssh"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -12,6 +16,20 @@ import (
 
 // NewBootstrapSecrets generate secrets for pomerium bootstrap
 func NewBootstrapSecrets(name types.NamespacedName) (*corev1.Secret, error) {
+	data, err := NewBootstrapSecretsData()
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
+		Data:       data,
+		Type:       corev1.SecretTypeOpaque,
+	}, nil
+}
+
+// NewBootstrapSecretsData generates the shared secret, cookie secret and signing key for pomerium bootstrap
+func NewBootstrapSecretsData() (map[string][]byte, error) {
 	key, err := cryptutil.NewSigningKey()
 	if err != nil {
 		return nil, fmt.Errorf("gen key: %w", err)
@@ -21,13 +39,37 @@ func NewBootstrapSecrets(name types.NamespacedName) (*corev1.Secret, error) {
 		return nil, fmt.Errorf("pem: %w", err)
 	}
 
+	return map[string][]byte{
+		"shared_secret": cryptutil.NewKey(),
+		"cookie_secret": cryptutil.NewKey(),
+		"signing_key":   signingKey,
+	}, nil
+}
+
+// NewSSHPrivateKey generates an ed25519 ssh private key in the OpenSSH PEM format
+func NewSSHPrivateKey() ([]byte, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("gen key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// NewSSHKeySecret generates a secret holding an ssh host or user CA key
+func NewSSHKeySecret(name types.NamespacedName) (*corev1.Secret, error) {
+	key, err := NewSSHPrivateKey()
+	if err != nil {
+		return nil, err
+	}
 	return &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace},
 		Data: map[string][]byte{
-			"shared_secret": cryptutil.NewKey(),
-			"cookie_secret": cryptutil.NewKey(),
-			"signing_key":   signingKey,
+			corev1.SSHAuthPrivateKey: key,
 		},
-		Type: corev1.SecretTypeOpaque,
+		Type: corev1.SecretTypeSSHAuth,
 	}, nil
 }